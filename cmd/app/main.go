@@ -5,6 +5,7 @@ import (
 	"ProjectGolang/pkg/google"
 	"ProjectGolang/pkg/log"
 	"ProjectGolang/pkg/redis"
+	"ProjectGolang/pkg/scheduler"
 	"ProjectGolang/pkg/smtp"
 	websocketPkg "ProjectGolang/pkg/websocket"
 	"github.com/joho/godotenv"
@@ -29,6 +30,7 @@ func main() {
 	redisServer := redis.New()
 	smtpMailer := smtp.New()
 	websocket := websocketPkg.NewAIWebSocketClient()
+	jobScheduler := scheduler.New(logger)
 
 	server, err := config.NewServer(
 		config.WithFiber(fiberApp),
@@ -45,12 +47,16 @@ func main() {
 		config.WithGeminiClient(),
 		config.WithBcryptUtils(),
 		config.WithUtils(),
+		config.WithScheduler(jobScheduler),
+		config.WithDisbursementGateway(),
+		config.WithReceivingChannel(),
 	)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
 	server.RegisterHandler()
+	jobScheduler.Start()
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -65,4 +71,5 @@ func main() {
 
 	<-sigChan
 	logger.Info("Shutting down server...")
+	jobScheduler.Stop()
 }
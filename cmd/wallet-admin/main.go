@@ -0,0 +1,283 @@
+package main
+
+import (
+	"ProjectGolang/database/postgres"
+	authRepository "ProjectGolang/internal/api/auth/repository"
+	budgetRepository "ProjectGolang/internal/api/budget_manager/repository"
+	budgetService "ProjectGolang/internal/api/budget_manager/service"
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	sentrapayService "ProjectGolang/internal/api/sentra_pay/service"
+	"ProjectGolang/pkg/bcrypt"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/disbursement"
+	"ProjectGolang/pkg/events"
+	"ProjectGolang/pkg/gateway"
+	"ProjectGolang/pkg/log"
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/receiving"
+	"ProjectGolang/pkg/redis"
+	"ProjectGolang/pkg/risk"
+	"ProjectGolang/pkg/s3"
+	"ProjectGolang/pkg/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/joho/godotenv"
+	"os"
+	"time"
+)
+
+const usage = `usage: wallet-admin <command> [args]
+
+commands:
+  ledger-check        verify that every wallet balance equals the sum of its ledger entries
+  expire-topups       expire pending top-ups whose virtual account has passed its expiry
+  reconcile           check open top-ups against DOKU and credit missed payments
+  settle-withdrawals  settle or refund withdrawals still waiting for the gateway
+  recover-qris        settle or reverse QRIS payments left incomplete
+  status-history REF USER_ID
+                      show the recorded status transitions of a user's
+                      transaction
+  notifications [OUTCOME]
+                      list the latest stored payment notifications
+  replay-notification ID
+                      process a stored payment notification again
+  risk-decisions [OUTCOME]
+                      list the latest risk screenings of money movements
+  statement-jobs      build statement exports left pending or abandoned
+  payment-requests    expire overdue payment requests and send due reminders
+  simulate-qris-receive REF AMOUNT
+                      pay a QRIS receive code with a signed notification from
+                      the fake receiving channel (needs APP_ENV=development)
+`
+
+func main() {
+	logger := log.NewLogger()
+	if err := godotenv.Load(); err != nil {
+		logger.Warnf("Error loading .env file: %v", err)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	db, err := postgres.New()
+	if err != nil {
+		logger.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	walletRepo := sentrapayRepository.New(db, logger)
+	authRepo := authRepository.New(db, logger)
+	paymentGateways, err := gateway.New(logger)
+	if err != nil {
+		logger.Fatalf("Failed to create payment gateways: %v", err)
+	}
+	vaNumbering, err := gateway.NumberingFromEnv()
+	if err != nil {
+		logger.Fatalf("Invalid virtual account numbering: %v", err)
+	}
+	disbursementGateway, err := disbursement.New(logger)
+	if err != nil {
+		logger.Fatalf("Failed to create disbursement gateway: %v", err)
+	}
+	receivingChannel, err := receiving.New(logger)
+	if err != nil {
+		logger.Fatalf("Failed to create receiving channel: %v", err)
+	}
+
+	s3Client, err := s3.New()
+	if err != nil {
+		logger.Fatalf("Failed to create S3 client: %v", err)
+	}
+
+	redisServer := redis.New()
+	pinVerifier := sentrapayService.NewPINVerifier(logger, authRepo, redisServer, bcrypt.New())
+	riskEngine, err := risk.New()
+	if err != nil {
+		logger.Fatalf("Invalid risk rules: %v", err)
+	}
+	walletEvents := events.New(logger, redisServer)
+
+	budget := budgetService.NewBudgetService(logger, budgetRepository.New(db, logger), s3Client, utils.New())
+
+	service := sentrapayService.NewSentraPayService(logger, walletRepo, paymentGateways, vaNumbering, disbursementGateway, receivingChannel, authRepo, budget, pinVerifier, riskEngine, redisServer, walletEvents, s3Client, nil, utils.New())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	ctx = contextPkg.WithRequestID(ctx, fmt.Sprintf("wallet-admin-%d", time.Now().Unix()))
+
+	switch os.Args[1] {
+	case "ledger-check":
+		report, err := service.CheckLedgerConsistency(ctx)
+		if err != nil {
+			logger.Fatalf("Ledger check failed: %v", err)
+		}
+
+		printJSON(report)
+
+		if !report.Consistent {
+			os.Exit(1)
+		}
+	case "expire-topups":
+		expired, err := service.ExpirePendingTopUps(ctx)
+		if err != nil {
+			logger.Fatalf("Expiring top-ups failed: %v", err)
+		}
+
+		printJSON(map[string]int{"expired": expired})
+	case "settle-withdrawals":
+		settled, err := service.SettleProcessingWithdrawals(ctx)
+		if err != nil {
+			logger.Fatalf("Settling withdrawals failed: %v", err)
+		}
+
+		printJSON(map[string]int{"settled": settled})
+	case "recover-qris":
+		if err := paymentGateways.Init(); err != nil {
+			logger.Fatalf("Failed to initialize payment gateways: %v", err)
+		}
+
+		recovered, err := service.RecoverQRISPayments(ctx)
+		if err != nil {
+			logger.Fatalf("Recovering QRIS payments failed: %v", err)
+		}
+
+		printJSON(map[string]int{"recovered": recovered})
+	case "statement-jobs":
+		processed, err := service.ProcessStatementJobs(ctx)
+		if err != nil {
+			logger.Fatalf("Processing statement jobs failed: %v", err)
+		}
+
+		printJSON(map[string]int{"processed": processed})
+	case "payment-requests":
+		processed, err := service.ProcessPaymentRequests(ctx)
+		if err != nil {
+			logger.Fatalf("Processing payment requests failed: %v", err)
+		}
+
+		printJSON(map[string]int{"processed": processed})
+	case "status-history":
+		if len(os.Args) < 4 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+
+		history, err := service.GetTransactionStatusHistory(ctx, os.Args[3], os.Args[2])
+		if err != nil {
+			logger.Fatalf("Fetching status history failed: %v", err)
+		}
+
+		printJSON(history)
+	case "notifications":
+		var req sentrapay.ListPaymentNotificationsRequest
+		if len(os.Args) > 2 {
+			req.Outcome = os.Args[2]
+		}
+
+		notifications, err := service.ListPaymentNotifications(ctx, req)
+		if err != nil {
+			logger.Fatalf("Listing payment notifications failed: %v", err)
+		}
+
+		printJSON(notifications)
+	case "risk-decisions":
+		var req sentrapay.ListRiskDecisionsRequest
+		if len(os.Args) > 2 {
+			req.Outcome = os.Args[2]
+		}
+
+		decisions, err := service.ListRiskDecisions(ctx, req)
+		if err != nil {
+			logger.Fatalf("Listing risk decisions failed: %v", err)
+		}
+
+		printJSON(decisions)
+	case "replay-notification":
+		if len(os.Args) < 3 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+
+		notification, err := service.ReplayPaymentNotification(ctx, os.Args[2])
+		if err != nil {
+			logger.Fatalf("Replaying payment notification failed: %v", err)
+		}
+
+		printJSON(notification)
+
+		if notification.Outcome == sentrapay.NotificationFailed {
+			os.Exit(1)
+		}
+	case "simulate-qris-receive":
+		if len(os.Args) < 4 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+
+		if os.Getenv("APP_ENV") != "development" {
+			logger.Fatal("simulate-qris-receive is only available with APP_ENV=development")
+		}
+
+		simulator, ok := receivingChannel.(receiving.ISimulator)
+		if !ok {
+			logger.Fatal("The QRIS receiving channel cannot simulate payments")
+		}
+
+		amount, err := money.Parse(os.Args[3])
+		if err != nil {
+			logger.Fatalf("Invalid amount: %v", err)
+		}
+
+		notification, err := simulator.PayQRIS(ctx, os.Args[2], amount)
+		if err != nil {
+			logger.Fatalf("Simulating QRIS payment failed: %v", err)
+		}
+
+		_, err = service.HandlePaymentNotification(ctx, gateway.ProviderDOKU, sentrapay.PaymentNotification{
+			HTTPMethod:  notification.HTTPMethod,
+			EndpointURL: notification.EndpointURL,
+			Body:        notification.Body,
+			Headers:     notification.Headers,
+		})
+		if err != nil {
+			logger.Fatalf("Simulated QRIS payment failed: %v", err)
+		}
+
+		notifications, err := service.ListPaymentNotifications(ctx, sentrapay.ListPaymentNotificationsRequest{
+			ReferenceNo: os.Args[2],
+			Limit:       1,
+		})
+		if err != nil {
+			logger.Fatalf("Fetching the stored notification failed: %v", err)
+		}
+
+		printJSON(notifications)
+	case "reconcile":
+		if err := paymentGateways.Init(); err != nil {
+			logger.Fatalf("Failed to initialize payment gateways: %v", err)
+		}
+
+		report, err := service.ReconcileTopUps(ctx)
+		if report != nil {
+			printJSON(report)
+		}
+		if err != nil {
+			logger.Fatalf("Reconciliation failed: %v", err)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode output: %v\n", err)
+	}
+}
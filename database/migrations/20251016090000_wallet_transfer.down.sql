@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS wallet_transactions_user_id_created_at_idx;
+
+DROP INDEX IF EXISTS wallet_transactions_reference_no_user_id_key;
+
+ALTER TABLE wallet_transactions ADD CONSTRAINT wallet_transactions_reference_no_key UNIQUE (reference_no);
@@ -0,0 +1,7 @@
+ALTER TABLE wallet_transactions DROP CONSTRAINT IF EXISTS wallet_transactions_reference_no_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_transactions_reference_no_user_id_key
+    ON wallet_transactions (reference_no, user_id);
+
+CREATE INDEX IF NOT EXISTS wallet_transactions_user_id_created_at_idx
+    ON wallet_transactions (user_id, created_at DESC);
@@ -0,0 +1,8 @@
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_non_negative;
+
+DROP TRIGGER IF EXISTS wallet_ledger_entries_append_only ON wallet_ledger_entries;
+DROP TRIGGER IF EXISTS wallet_journals_append_only ON wallet_journals;
+DROP FUNCTION IF EXISTS wallet_ledger_forbid_mutation();
+
+DROP TABLE IF EXISTS wallet_ledger_entries;
+DROP TABLE IF EXISTS wallet_journals;
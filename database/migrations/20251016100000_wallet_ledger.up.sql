@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS wallet_journals (
+    id VARCHAR(50) PRIMARY KEY,
+    journal_key VARCHAR(150) NOT NULL UNIQUE,
+    reference_no VARCHAR(100) NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS wallet_ledger_entries (
+    id VARCHAR(50) PRIMARY KEY,
+    journal_id VARCHAR(50) NOT NULL REFERENCES wallet_journals (id),
+    account VARCHAR(100) NOT NULL,
+    user_id VARCHAR(50),
+    direction VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    balance_after DECIMAL(15, 2),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_ledger_entries_journal_id_idx ON wallet_ledger_entries (journal_id);
+CREATE INDEX IF NOT EXISTS wallet_ledger_entries_account_idx ON wallet_ledger_entries (account);
+CREATE INDEX IF NOT EXISTS wallet_ledger_entries_user_id_created_at_idx ON wallet_ledger_entries (user_id, created_at);
+
+CREATE OR REPLACE FUNCTION wallet_ledger_forbid_mutation() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'wallet ledger is append-only: % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallet_journals_append_only ON wallet_journals;
+CREATE TRIGGER wallet_journals_append_only
+    BEFORE UPDATE OR DELETE ON wallet_journals
+    FOR EACH ROW EXECUTE FUNCTION wallet_ledger_forbid_mutation();
+
+DROP TRIGGER IF EXISTS wallet_ledger_entries_append_only ON wallet_ledger_entries;
+CREATE TRIGGER wallet_ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON wallet_ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION wallet_ledger_forbid_mutation();
+
+ALTER TABLE wallets ADD CONSTRAINT wallets_balance_non_negative CHECK (balance >= 0) NOT VALID;
+
+-- Carry existing balances into the ledger so wallets.balance always equals the ledger sum.
+INSERT INTO wallet_journals (id, journal_key, reference_no, kind, description, created_at)
+SELECT 'OPEN' || user_id, 'opening:' || user_id, 'OPEN' || user_id, 'opening_balance', 'Opening balance', now()
+FROM wallets
+WHERE balance <> 0
+ON CONFLICT (journal_key) DO NOTHING;
+
+INSERT INTO wallet_ledger_entries (id, journal_id, account, user_id, direction, amount, balance_after, created_at)
+SELECT 'OPENW' || user_id, 'OPEN' || user_id, 'wallet:' || user_id, user_id,
+       CASE WHEN balance > 0 THEN 'credit' ELSE 'debit' END, ABS(balance), balance, now()
+FROM wallets
+WHERE balance <> 0
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO wallet_ledger_entries (id, journal_id, account, user_id, direction, amount, balance_after, created_at)
+SELECT 'OPENS' || user_id, 'OPEN' || user_id, 'system:opening_balance', NULL,
+       CASE WHEN balance > 0 THEN 'debit' ELSE 'credit' END, ABS(balance), NULL, now()
+FROM wallets
+WHERE balance <> 0
+ON CONFLICT (id) DO NOTHING;
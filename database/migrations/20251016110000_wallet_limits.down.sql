@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_user_limits;
+DROP TABLE IF EXISTS wallet_limit_profiles;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS wallet_limit_profiles (
+    code VARCHAR(30) PRIMARY KEY,
+    daily_limit DECIMAL(15, 2) NOT NULL CHECK (daily_limit > 0),
+    per_transaction_limit DECIMAL(15, 2) NOT NULL CHECK (per_transaction_limit > 0),
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- "unverified" is always applied on top of the user's profile while users.is_verified is false.
+INSERT INTO wallet_limit_profiles (code, daily_limit, per_transaction_limit, description)
+VALUES ('default', 20000000, 10000000, 'Verified wallet'),
+       ('unverified', 2000000, 1000000, 'Wallet without completed KYC')
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS wallet_user_limits (
+    user_id VARCHAR(50) PRIMARY KEY,
+    profile_code VARCHAR(30) REFERENCES wallet_limit_profiles (code),
+    daily_limit DECIMAL(15, 2) CHECK (daily_limit > 0),
+    per_transaction_limit DECIMAL(15, 2) CHECK (per_transaction_limit > 0),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
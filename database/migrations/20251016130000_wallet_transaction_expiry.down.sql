@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS wallet_transactions_pending_expiry_idx;
+
+UPDATE wallet_transactions SET status = 'failed' WHERE status = 'expired';
+
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,12 @@
+ALTER TABLE wallet_transactions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE wallet_transactions ADD COLUMN IF NOT EXISTS status_reason TEXT;
+
+-- Top-ups created before this migration used a fixed 24h virtual account lifetime.
+UPDATE wallet_transactions
+SET expires_at = created_at + INTERVAL '24 hours'
+WHERE type = 'topup'
+  AND expires_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS wallet_transactions_pending_expiry_idx
+    ON wallet_transactions (expires_at)
+    WHERE status = 'pending';
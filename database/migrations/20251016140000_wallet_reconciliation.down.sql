@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS wallet_transactions_open_topups_idx;
+DROP TABLE IF EXISTS wallet_reconciliation_items;
+DROP TABLE IF EXISTS wallet_reconciliation_runs;
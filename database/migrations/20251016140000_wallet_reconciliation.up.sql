@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS wallet_reconciliation_runs (
+    id VARCHAR(50) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('running', 'completed', 'failed')),
+    checked_count INT NOT NULL DEFAULT 0,
+    credited_count INT NOT NULL DEFAULT 0,
+    mismatch_count INT NOT NULL DEFAULT 0,
+    error_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS wallet_reconciliation_items (
+    id VARCHAR(50) PRIMARY KEY,
+    run_id VARCHAR(50) NOT NULL REFERENCES wallet_reconciliation_runs (id),
+    transaction_id VARCHAR(50) NOT NULL,
+    reference_no VARCHAR(100) NOT NULL,
+    user_id VARCHAR(50) NOT NULL,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('missed_payment_credited', 'status_corrected', 'amount_mismatch', 'gateway_error')),
+    local_status VARCHAR(20) NOT NULL,
+    expected_amount DECIMAL(15, 2) NOT NULL,
+    gateway_amount DECIMAL(15, 2),
+    difference DECIMAL(15, 2),
+    gateway_response_code VARCHAR(20),
+    note TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_reconciliation_items_run_id_idx ON wallet_reconciliation_items (run_id);
+CREATE INDEX IF NOT EXISTS wallet_reconciliation_items_reference_no_idx ON wallet_reconciliation_items (reference_no);
+
+CREATE INDEX IF NOT EXISTS wallet_transactions_open_topups_idx
+    ON wallet_transactions (id)
+    WHERE type = 'topup' AND status IN ('pending', 'processing');
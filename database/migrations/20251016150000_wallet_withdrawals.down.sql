@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wallet_withdrawals;
+DROP TABLE IF EXISTS wallet_bank_accounts;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS wallet_bank_accounts (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL,
+    bank_code VARCHAR(20) NOT NULL,
+    account_number VARCHAR(34) NOT NULL,
+    account_name VARCHAR(255),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'verified', 'failed')),
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, bank_code, account_number)
+);
+
+CREATE TABLE IF NOT EXISTS wallet_withdrawals (
+    id VARCHAR(50) PRIMARY KEY,
+    reference_no VARCHAR(100) NOT NULL UNIQUE,
+    user_id VARCHAR(50) NOT NULL,
+    bank_account_id VARCHAR(50) NOT NULL REFERENCES wallet_bank_accounts (id),
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('processing', 'success', 'failed')),
+    gateway_reference VARCHAR(100),
+    failure_reason TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_withdrawals_user_id_created_at_idx
+    ON wallet_withdrawals (user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS wallet_withdrawals_processing_idx
+    ON wallet_withdrawals (created_at)
+    WHERE status = 'processing';
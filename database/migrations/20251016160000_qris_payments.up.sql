@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS qris_payments (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL,
+    reference_no VARCHAR(100) NOT NULL UNIQUE,
+    gateway_reference_no VARCHAR(100),
+    merchant_name VARCHAR(255),
+    amount DECIMAL(15, 2) NOT NULL,
+    fee_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(15, 2) NOT NULL CHECK (total_amount > 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('initiated', 'gateway_confirmed', 'settled', 'reversed')),
+    failure_reason TEXT,
+    transaction_date VARCHAR(50),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS qris_payments_incomplete_idx
+    ON qris_payments (updated_at)
+    WHERE status IN ('initiated', 'gateway_confirmed');
@@ -0,0 +1,2 @@
+ALTER TABLE wallet_transactions DROP CONSTRAINT IF EXISTS wallet_transactions_status_check;
+DROP TABLE IF EXISTS wallet_transaction_status_history;
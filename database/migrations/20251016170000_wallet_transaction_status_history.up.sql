@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS wallet_transaction_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id VARCHAR(50) NOT NULL,
+    reference_no VARCHAR(100) NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    actor VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_transaction_status_history_transaction_id_idx
+    ON wallet_transaction_status_history (transaction_id, created_at);
+
+-- NOT VALID keeps legacy rows out of the check while enforcing it for new writes.
+ALTER TABLE wallet_transactions DROP CONSTRAINT IF EXISTS wallet_transactions_status_check;
+ALTER TABLE wallet_transactions ADD CONSTRAINT wallet_transactions_status_check
+    CHECK (status IN ('pending', 'processing', 'success', 'failed', 'expired', 'reversed', 'refunded')) NOT VALID;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS wallet_transactions_user_id_created_at_idx;
+DROP TABLE IF EXISTS wallet_statement_jobs;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS wallet_statement_jobs (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL,
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('pdf', 'csv')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    file_url TEXT,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS wallet_statement_jobs_user_id_idx ON wallet_statement_jobs (user_id, created_at);
+CREATE INDEX IF NOT EXISTS wallet_statement_jobs_open_idx ON wallet_statement_jobs (updated_at)
+    WHERE status IN ('pending', 'processing');
+
+CREATE INDEX IF NOT EXISTS wallet_transactions_user_id_created_at_idx ON wallet_transactions (user_id, created_at);
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS wallet_transactions_user_id_created_at_idx ON wallet_transactions (user_id, created_at);
+DROP INDEX IF EXISTS wallet_transactions_user_id_created_at_id_idx;
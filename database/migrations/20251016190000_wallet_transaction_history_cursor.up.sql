@@ -0,0 +1,3 @@
+-- History pages seek on (created_at, id), so the index needs id as a tiebreaker.
+CREATE INDEX IF NOT EXISTS wallet_transactions_user_id_created_at_id_idx ON wallet_transactions (user_id, created_at, id);
+DROP INDEX IF EXISTS wallet_transactions_user_id_created_at_idx;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS budget_wallet_sync_settings;
+DROP INDEX IF EXISTS budget_transactions_wallet_transaction_id_idx;
+ALTER TABLE budget_transactions DROP COLUMN IF EXISTS wallet_transaction_id;
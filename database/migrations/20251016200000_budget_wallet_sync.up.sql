@@ -0,0 +1,12 @@
+ALTER TABLE budget_transactions ADD COLUMN IF NOT EXISTS wallet_transaction_id VARCHAR(50);
+
+CREATE UNIQUE INDEX IF NOT EXISTS budget_transactions_wallet_transaction_id_idx
+    ON budget_transactions (wallet_transaction_id)
+    WHERE wallet_transaction_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS budget_wallet_sync_settings (
+    user_id VARCHAR(50) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id VARCHAR(50) PRIMARY KEY,
+    requester_id VARCHAR(50) NOT NULL,
+    requester_name VARCHAR(255) NOT NULL,
+    note TEXT,
+    qris_reference_no VARCHAR(100),
+    total_amount DECIMAL(15, 2) NOT NULL CHECK (total_amount > 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('open', 'completed', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payment_requests_requester_idx
+    ON payment_requests (requester_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS payment_requests_open_idx
+    ON payment_requests (expires_at)
+    WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS payment_request_participants (
+    id VARCHAR(50) PRIMARY KEY,
+    request_id VARCHAR(50) NOT NULL REFERENCES payment_requests (id) ON DELETE CASCADE,
+    user_id VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    phone_number VARCHAR(20) NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'paying', 'paid', 'declined', 'expired')),
+    transfer_reference_no VARCHAR(100),
+    reminder_count INT NOT NULL DEFAULT 0,
+    last_reminded_at TIMESTAMP,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (request_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS payment_request_participants_user_idx
+    ON payment_request_participants (user_id, created_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE qris_payments DROP COLUMN IF EXISTS gateway;
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS gateway;
@@ -0,0 +1,7 @@
+-- The provider that issued a top-up or charged a QRIS payment. Rows from
+-- before providers were pluggable are DOKU's.
+ALTER TABLE wallet_transactions ADD COLUMN IF NOT EXISTS gateway VARCHAR(32);
+ALTER TABLE qris_payments ADD COLUMN IF NOT EXISTS gateway VARCHAR(32);
+
+UPDATE wallet_transactions SET gateway = 'doku' WHERE type = 'topup' AND gateway IS NULL;
+UPDATE qris_payments SET gateway = 'doku' WHERE gateway IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS wallet_transactions_pending_va_idx;
+DROP TABLE IF EXISTS user_virtual_accounts;
+DROP SEQUENCE IF EXISTS user_virtual_account_customer_seq;
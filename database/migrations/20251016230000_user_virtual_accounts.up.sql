@@ -0,0 +1,24 @@
+-- Customer numbers are allocated once per user and channel and never reused.
+CREATE SEQUENCE IF NOT EXISTS user_virtual_account_customer_seq;
+
+CREATE TABLE IF NOT EXISTS user_virtual_accounts (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL,
+    channel VARCHAR(50) NOT NULL,
+    gateway VARCHAR(32) NOT NULL,
+    partner_service_id VARCHAR(8) NOT NULL,
+    customer_no VARCHAR(20) NOT NULL,
+    virtual_account_no VARCHAR(28) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, channel)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_virtual_accounts_number_idx
+    ON user_virtual_accounts (gateway, TRIM(virtual_account_no));
+
+-- Payments into a reusable virtual account are matched to the pending top-up
+-- by number and amount.
+CREATE INDEX IF NOT EXISTS wallet_transactions_pending_va_idx
+    ON wallet_transactions (TRIM(bank_account), amount)
+    WHERE type = 'topup' AND status = 'pending';
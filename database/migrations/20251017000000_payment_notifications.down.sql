@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS wallet_transactions_gateway_payment_idx;
+ALTER TABLE wallet_transactions DROP COLUMN IF EXISTS gateway_payment_id;
+DROP TABLE IF EXISTS payment_notifications;
@@ -0,0 +1,35 @@
+-- Every inbound payment notification, stored before it is processed, so a
+-- callback that fails halfway can be inspected and replayed.
+CREATE TABLE IF NOT EXISTS payment_notifications (
+    id VARCHAR(50) PRIMARY KEY,
+    provider VARCHAR(32) NOT NULL,
+    http_method VARCHAR(10) NOT NULL,
+    endpoint_url TEXT NOT NULL,
+    headers JSONB NOT NULL,
+    body BYTEA NOT NULL,
+    verification VARCHAR(20) NOT NULL CHECK (verification IN ('pending', 'verified', 'rejected')),
+    verification_error TEXT,
+    callback JSONB,
+    reference_no VARCHAR(100),
+    payment_id VARCHAR(100),
+    outcome VARCHAR(20) NOT NULL CHECK (outcome IN ('received', 'processed', 'acknowledged', 'failed')),
+    outcome_error TEXT,
+    replay_count INT NOT NULL DEFAULT 0,
+    received_at TIMESTAMP NOT NULL,
+    processed_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payment_notifications_received_idx
+    ON payment_notifications (received_at DESC);
+
+CREATE INDEX IF NOT EXISTS payment_notifications_reference_idx
+    ON payment_notifications (reference_no);
+
+-- The provider's ID of the payment that settled a transaction. A payment can
+-- settle one transaction only, however often it is notified or replayed.
+ALTER TABLE wallet_transactions ADD COLUMN IF NOT EXISTS gateway_payment_id VARCHAR(100);
+
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_transactions_gateway_payment_idx
+    ON wallet_transactions (gateway, gateway_payment_id)
+    WHERE gateway_payment_id IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE wallets DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE wallets DROP COLUMN IF EXISTS status;
@@ -0,0 +1,6 @@
+-- A frozen wallet can still receive money but cannot be spent from until the
+-- owner unfreezes it. A closed wallet cannot be spent from or reopened.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'frozen', 'closed'));
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS status_reason VARCHAR(255);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS risk_decisions;
+DROP TABLE IF EXISTS wallet_devices;
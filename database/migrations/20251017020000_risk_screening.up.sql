@@ -0,0 +1,30 @@
+-- Devices a user has moved money from, so a payment from any other device
+-- can be challenged.
+CREATE TABLE IF NOT EXISTS wallet_devices (
+    user_id VARCHAR(50) NOT NULL,
+    device_id VARCHAR(100) NOT NULL,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, device_id)
+);
+
+-- Every risk screening of a money movement, kept for review. Results lists
+-- the rules that fired; outcome records whether the movement went ahead.
+CREATE TABLE IF NOT EXISTS risk_decisions (
+    id VARCHAR(50) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL,
+    operation VARCHAR(30) NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    payee VARCHAR(255),
+    device_id VARCHAR(100),
+    action VARCHAR(20) NOT NULL CHECK (action IN ('allow', 'challenge', 'deny')),
+    outcome VARCHAR(20) NOT NULL CHECK (outcome IN ('allowed', 'challenged', 'challenge_passed', 'challenge_failed', 'denied')),
+    results JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS risk_decisions_created_idx
+    ON risk_decisions (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS risk_decisions_user_idx
+    ON risk_decisions (user_id, created_at DESC);
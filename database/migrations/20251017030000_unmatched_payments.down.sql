@@ -0,0 +1,6 @@
+DELETE FROM wallet_reconciliation_items WHERE kind = 'unmatched_payment';
+
+ALTER TABLE wallet_reconciliation_items DROP CONSTRAINT IF EXISTS wallet_reconciliation_items_kind_check;
+
+ALTER TABLE wallet_reconciliation_items ADD CONSTRAINT wallet_reconciliation_items_kind_check
+    CHECK (kind IN ('missed_payment_credited', 'status_corrected', 'amount_mismatch', 'gateway_error'));
@@ -1,41 +1,75 @@
 package budget_manager
 
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
 type CreateTransactionRequest struct {
-	UserID      string  `json:"user_id" validate:"required"`
-	Title       string  `json:"title" validate:"required"`
-	Description string  `json:"description"`
-	Nominal     float64 `json:"nominal" validate:"required,gt=0"`
-	Type        string  `json:"type" validate:"required,oneof=income expense"`
-	Category    string  `json:"category" validate:"required"`
+	UserID      string       `json:"user_id" validate:"required"`
+	Title       string       `json:"title" validate:"required"`
+	Description string       `json:"description"`
+	Nominal     money.Amount `json:"nominal" validate:"required,gt=0"`
+	Type        string       `json:"type" validate:"required,oneof=income expense"`
+	Category    string       `json:"category" validate:"required"`
 }
 
 type UpdateTransactionRequest struct {
-	ID          string  `json:"id" validate:"required"`
-	UserID      string  `json:"user_id" validate:"required"`
-	Title       string  `json:"title" validate:"required"`
-	Description string  `json:"description"`
-	Nominal     float64 `json:"nominal" validate:"required,gt=0"`
-	Type        string  `json:"type" validate:"required,oneof=income expense"`
-	Category    string  `json:"category" validate:"required"`
-	DeleteAudio bool    `json:"delete_audio"`
+	ID          string       `json:"id" validate:"required"`
+	UserID      string       `json:"user_id" validate:"required"`
+	Title       string       `json:"title" validate:"required"`
+	Description string       `json:"description"`
+	Nominal     money.Amount `json:"nominal" validate:"required,gt=0"`
+	Type        string       `json:"type" validate:"required,oneof=income expense transfer"`
+	Category    string       `json:"category" validate:"required"`
+	DeleteAudio bool         `json:"delete_audio"`
 }
 
 type TransactionResponse struct {
-	ID          string  `json:"id"`
-	UserID      string  `json:"user_id"`
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	Nominal     float64 `json:"nominal"`
-	Type        string  `json:"type"`
-	Category    string  `json:"category"`
-	AudioLink   string  `json:"audio_link,omitempty"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID                  string       `json:"id"`
+	UserID              string       `json:"user_id"`
+	Title               string       `json:"title"`
+	Description         string       `json:"description"`
+	Nominal             money.Amount `json:"nominal"`
+	Type                string       `json:"type"`
+	Category            string       `json:"category"`
+	AudioLink           string       `json:"audio_link,omitempty"`
+	WalletTransactionID string       `json:"wallet_transaction_id,omitempty"`
+	CreatedAt           string       `json:"created_at"`
+	UpdatedAt           string       `json:"updated_at"`
 }
 
 type TransactionListResponse struct {
 	Transactions []TransactionResponse `json:"transactions"`
-	TotalIncome  float64               `json:"total_income"`
-	TotalExpense float64               `json:"total_expense"`
-	Balance      float64               `json:"balance"`
+	TotalIncome  money.Amount          `json:"total_income"`
+	TotalExpense money.Amount          `json:"total_expense"`
+	Balance      money.Amount          `json:"balance"`
+}
+
+// WalletSyncSettings controls whether settled wallet movements are recorded
+// in the budget automatically. Users without settings have it disabled.
+type WalletSyncSettings struct {
+	UserID    string     `json:"user_id"`
+	Enabled   bool       `json:"enabled"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type UpdateWalletSyncRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+const (
+	WalletEntryTopUp       = "topup"
+	WalletEntryQRISPayment = "qris_payment"
+)
+
+// WalletEntry is a settled wallet movement offered to the budget.
+type WalletEntry struct {
+	WalletTransactionID string
+	UserID              string
+	Kind                string
+	ReferenceNo         string
+	Amount              money.Amount
+	MerchantName        string
+	OccurredAt          time.Time
 }
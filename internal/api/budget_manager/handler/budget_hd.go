@@ -6,6 +6,7 @@ import (
 	"ProjectGolang/pkg/handlerUtil"
 	jwtPkg "ProjectGolang/pkg/jwt"
 	"ProjectGolang/pkg/log"
+	"ProjectGolang/pkg/money"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/net/context"
@@ -80,16 +81,17 @@ func (h *BudgetHandler) GetTransactionByID(ctx *fiber.Ctx) error {
 	}
 
 	response := budget_manager.TransactionResponse{
-		ID:          transaction.ID,
-		UserID:      transaction.UserID,
-		Title:       transaction.Title,
-		Description: transaction.Description,
-		Nominal:     transaction.Nominal,
-		Type:        transaction.Type,
-		Category:    transaction.Category,
-		AudioLink:   transaction.AudioLink,
-		CreatedAt:   transaction.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   transaction.UpdatedAt.Format(time.RFC3339),
+		ID:                  transaction.ID,
+		UserID:              transaction.UserID,
+		Title:               transaction.Title,
+		Description:         transaction.Description,
+		Nominal:             transaction.Nominal,
+		Type:                transaction.Type,
+		Category:            transaction.Category,
+		AudioLink:           transaction.AudioLink,
+		WalletTransactionID: transaction.WalletTransactionID,
+		CreatedAt:           transaction.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:           transaction.UpdatedAt.Format(time.RFC3339),
 	}
 
 	select {
@@ -124,22 +126,23 @@ func (h *BudgetHandler) GetTransactionsByUserID(ctx *fiber.Ctx) error {
 
 	var (
 		transactionResponses []budget_manager.TransactionResponse
-		totalIncome          float64
-		totalExpense         float64
+		totalIncome          money.Amount
+		totalExpense         money.Amount
 	)
 
 	for _, transaction := range transactions {
 		transactionResponses = append(transactionResponses, budget_manager.TransactionResponse{
-			ID:          transaction.ID,
-			UserID:      transaction.UserID,
-			Title:       transaction.Title,
-			Description: transaction.Description,
-			Nominal:     transaction.Nominal,
-			Type:        transaction.Type,
-			Category:    transaction.Category,
-			AudioLink:   transaction.AudioLink,
-			CreatedAt:   transaction.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   transaction.UpdatedAt.Format(time.RFC3339),
+			ID:                  transaction.ID,
+			UserID:              transaction.UserID,
+			Title:               transaction.Title,
+			Description:         transaction.Description,
+			Nominal:             transaction.Nominal,
+			Type:                transaction.Type,
+			Category:            transaction.Category,
+			AudioLink:           transaction.AudioLink,
+			WalletTransactionID: transaction.WalletTransactionID,
+			CreatedAt:           transaction.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:           transaction.UpdatedAt.Format(time.RFC3339),
 		})
 
 		if transaction.Type == "income" {
@@ -195,22 +198,23 @@ func (h *BudgetHandler) GetTransactionsByPeriod(ctx *fiber.Ctx) error {
 
 	var (
 		transactionResponses []budget_manager.TransactionResponse
-		totalIncome          float64
-		totalExpense         float64
+		totalIncome          money.Amount
+		totalExpense         money.Amount
 	)
 
 	for _, transaction := range transactions {
 		transactionResponses = append(transactionResponses, budget_manager.TransactionResponse{
-			ID:          transaction.ID,
-			UserID:      transaction.UserID,
-			Title:       transaction.Title,
-			Description: transaction.Description,
-			Nominal:     transaction.Nominal,
-			Type:        transaction.Type,
-			Category:    transaction.Category,
-			AudioLink:   transaction.AudioLink,
-			CreatedAt:   transaction.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   transaction.UpdatedAt.Format(time.RFC3339),
+			ID:                  transaction.ID,
+			UserID:              transaction.UserID,
+			Title:               transaction.Title,
+			Description:         transaction.Description,
+			Nominal:             transaction.Nominal,
+			Type:                transaction.Type,
+			Category:            transaction.Category,
+			AudioLink:           transaction.AudioLink,
+			WalletTransactionID: transaction.WalletTransactionID,
+			CreatedAt:           transaction.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:           transaction.UpdatedAt.Format(time.RFC3339),
 		})
 
 		if transaction.Type == "income" {
@@ -347,20 +351,21 @@ func (h *BudgetHandler) GetTransactionsByTypeAndCategory(ctx *fiber.Ctx) error {
 	}
 
 	var transactionResponses []budget_manager.TransactionResponse
-	var total float64
+	var total money.Amount
 
 	for _, transaction := range transactions {
 		transactionResponses = append(transactionResponses, budget_manager.TransactionResponse{
-			ID:          transaction.ID,
-			UserID:      transaction.UserID,
-			Title:       transaction.Title,
-			Description: transaction.Description,
-			Nominal:     transaction.Nominal,
-			Type:        transaction.Type,
-			Category:    transaction.Category,
-			AudioLink:   transaction.AudioLink,
-			CreatedAt:   transaction.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   transaction.UpdatedAt.Format(time.RFC3339),
+			ID:                  transaction.ID,
+			UserID:              transaction.UserID,
+			Title:               transaction.Title,
+			Description:         transaction.Description,
+			Nominal:             transaction.Nominal,
+			Type:                transaction.Type,
+			Category:            transaction.Category,
+			AudioLink:           transaction.AudioLink,
+			WalletTransactionID: transaction.WalletTransactionID,
+			CreatedAt:           transaction.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:           transaction.UpdatedAt.Format(time.RFC3339),
 		})
 
 		total += transaction.Nominal
@@ -368,7 +373,7 @@ func (h *BudgetHandler) GetTransactionsByTypeAndCategory(ctx *fiber.Ctx) error {
 
 	response := struct {
 		Transactions []budget_manager.TransactionResponse `json:"transactions"`
-		Total        float64                              `json:"total"`
+		Total        money.Amount                         `json:"total"`
 		Type         string                               `json:"type"`
 		Category     string                               `json:"category"`
 	}{
@@ -39,4 +39,7 @@ func (h *BudgetHandler) Start(srv fiber.Router) {
 	budget.Get("/transactions/:id", h.GetTransactionByID)
 	budget.Put("/transactions", h.middleware.NewTokenMiddleware, h.UpdateTransaction)
 	budget.Delete("/transactions/:id", h.middleware.NewTokenMiddleware, h.DeleteTransaction)
+
+	budget.Get("/wallet-sync", h.middleware.NewTokenMiddleware, h.GetWalletSync)
+	budget.Put("/wallet-sync", h.middleware.NewTokenMiddleware, h.UpdateWalletSync)
 }
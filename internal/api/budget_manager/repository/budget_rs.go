@@ -4,6 +4,7 @@ import (
 	"ProjectGolang/internal/api/budget_manager"
 	"ProjectGolang/internal/entity"
 	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
 	"context"
 	"database/sql"
 	"errors"
@@ -13,16 +14,17 @@ import (
 )
 
 type BudgetTransactionDB struct {
-	ID          sql.NullString  `db:"id"`
-	UserID      sql.NullString  `db:"user_id"`
-	Title       sql.NullString  `db:"title"`
-	Description sql.NullString  `db:"description"`
-	Nominal     sql.NullFloat64 `db:"nominal"`
-	Type        sql.NullString  `db:"type"`
-	Category    sql.NullString  `db:"category"`
-	AudioLink   sql.NullString  `db:"audio_link"`
-	CreatedAt   time.Time       `db:"created_at"`
-	UpdatedAt   time.Time       `db:"updated_at"`
+	ID                  sql.NullString   `db:"id"`
+	UserID              sql.NullString   `db:"user_id"`
+	Title               sql.NullString   `db:"title"`
+	Description         sql.NullString   `db:"description"`
+	Nominal             money.NullAmount `db:"nominal"`
+	Type                sql.NullString   `db:"type"`
+	Category            sql.NullString   `db:"category"`
+	AudioLink           sql.NullString   `db:"audio_link"`
+	CreatedAt           time.Time        `db:"created_at"`
+	UpdatedAt           time.Time        `db:"updated_at"`
+	WalletTransactionID sql.NullString   `db:"wallet_transaction_id"`
 }
 
 func (r *budgetRepository) CreateTransaction(c context.Context, transaction entity.BudgetTransaction) error {
@@ -335,15 +337,16 @@ func (r *budgetRepository) GetTransactionsByPeriod(ctx context.Context, userID s
 
 func (r *budgetRepository) makeBudgetTransaction(transaction BudgetTransactionDB) entity.BudgetTransaction {
 	return entity.BudgetTransaction{
-		ID:          transaction.ID.String,
-		UserID:      transaction.UserID.String,
-		Title:       transaction.Title.String,
-		Description: transaction.Description.String,
-		Nominal:     transaction.Nominal.Float64,
-		Type:        transaction.Type.String,
-		Category:    transaction.Category.String,
-		AudioLink:   transaction.AudioLink.String,
-		CreatedAt:   transaction.CreatedAt,
-		UpdatedAt:   transaction.UpdatedAt,
+		ID:                  transaction.ID.String,
+		UserID:              transaction.UserID.String,
+		Title:               transaction.Title.String,
+		Description:         transaction.Description.String,
+		Nominal:             transaction.Nominal.Amount,
+		Type:                transaction.Type.String,
+		Category:            transaction.Category.String,
+		AudioLink:           transaction.AudioLink.String,
+		CreatedAt:           transaction.CreatedAt,
+		UpdatedAt:           transaction.UpdatedAt,
+		WalletTransactionID: transaction.WalletTransactionID.String,
 	}
 }
@@ -37,6 +37,7 @@ const (
 			type,
 			category,
 			audio_link,
+			wallet_transaction_id,
 			created_at,
 			updated_at
 		FROM budget_transactions
@@ -54,6 +55,7 @@ const (
 			type,
 			category,
 			audio_link,
+			wallet_transaction_id,
 			created_at,
 			updated_at
 		FROM budget_transactions
@@ -74,6 +76,7 @@ const (
 			type,
 			category,
 			audio_link,
+			wallet_transaction_id,
 			created_at,
 			updated_at
 		FROM budget_transactions
@@ -94,6 +97,7 @@ const (
 			type,
 			category,
 			audio_link,
+			wallet_transaction_id,
 			created_at,
 			updated_at
 		FROM budget_transactions
@@ -110,6 +114,7 @@ const (
 			type,
 			category,
 			audio_link,
+			wallet_transaction_id,
 			created_at,
 			updated_at
 		FROM budget_transactions
@@ -145,6 +150,7 @@ const (
 			type,
 			category,
 			audio_link,
+			wallet_transaction_id,
 			created_at,
 			updated_at
 		FROM budget_transactions
@@ -154,4 +160,74 @@ const (
 			AND category = :category
 		ORDER BY created_at DESC
 	`
+
+	queryCreateWalletBudgetTransaction = `
+		INSERT INTO budget_transactions (
+			id,
+			user_id,
+			title,
+			description,
+			nominal,
+			type,
+			category,
+			wallet_transaction_id,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:user_id,
+			:title,
+			:description,
+			:nominal,
+			:type,
+			:category,
+			:wallet_transaction_id,
+			:created_at,
+			:updated_at
+		)
+		ON CONFLICT (wallet_transaction_id) WHERE wallet_transaction_id IS NOT NULL DO NOTHING
+	`
+
+	queryGetLatestCategoryByTitle = `
+		SELECT category
+		FROM budget_transactions
+		WHERE
+			user_id = :user_id
+			AND type = :type
+			AND LOWER(title) = LOWER(:title)
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`
+
+	queryGetWalletSyncSettings = `
+		SELECT
+			user_id,
+			enabled,
+			created_at,
+			updated_at
+		FROM budget_wallet_sync_settings
+		WHERE user_id = :user_id
+	`
+
+	queryUpsertWalletSyncSettings = `
+		INSERT INTO budget_wallet_sync_settings (
+			user_id,
+			enabled,
+			created_at,
+			updated_at
+		) VALUES (
+			:user_id,
+			:enabled,
+			:now,
+			:now
+		)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			updated_at = EXCLUDED.updated_at
+		RETURNING
+			user_id,
+			enabled,
+			created_at,
+			updated_at
+	`
 )
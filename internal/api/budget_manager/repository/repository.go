@@ -1,6 +1,7 @@
 package budgetRepository
 
 import (
+	"ProjectGolang/internal/api/budget_manager"
 	"ProjectGolang/internal/entity"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
@@ -52,9 +53,10 @@ func (r *repository) NewClient(tx bool) (Client, error) {
 	}
 
 	return Client{
-		Budget:   &budgetRepository{q: sqlExecutor, log: r.log},
-		Commit:   commitFunc,
-		Rollback: rollbackFunc,
+		Budget:     &budgetRepository{q: sqlExecutor, log: r.log},
+		WalletSync: &walletSyncRepository{q: sqlExecutor, log: r.log},
+		Commit:     commitFunc,
+		Rollback:   rollbackFunc,
 	}, nil
 }
 
@@ -67,6 +69,13 @@ type Client struct {
 		UpdateTransaction(c context.Context, transaction entity.BudgetTransaction) error
 		DeleteTransaction(ctx context.Context, id string) error
 		GetTransactionsByTypeAndCategory(ctx context.Context, userID string, transactionType string, category string) ([]entity.BudgetTransaction, error)
+		CreateWalletTransaction(ctx context.Context, transaction entity.BudgetTransaction) (bool, error)
+		GetLatestCategoryByTitle(ctx context.Context, userID, transactionType, title string) (string, error)
+	}
+
+	WalletSync interface {
+		Get(ctx context.Context, userID string) (budget_manager.WalletSyncSettings, error)
+		Set(ctx context.Context, userID string, enabled bool) (budget_manager.WalletSyncSettings, error)
 	}
 
 	Commit   func() error
@@ -77,3 +86,8 @@ type budgetRepository struct {
 	q   SQLExecutor
 	log *logrus.Logger
 }
+
+type walletSyncRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
@@ -0,0 +1,183 @@
+package budgetRepository
+
+import (
+	"ProjectGolang/internal/api/budget_manager"
+	"ProjectGolang/internal/entity"
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type WalletSyncSettingsDB struct {
+	UserID    sql.NullString `db:"user_id"`
+	Enabled   bool           `db:"enabled"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+// CreateWalletTransaction inserts a budget entry copied from a wallet
+// transaction. It reports false when the wallet transaction already has an
+// entry, so the copy is made at most once.
+func (r *budgetRepository) CreateWalletTransaction(ctx context.Context, transaction entity.BudgetTransaction) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":                    transaction.ID,
+		"user_id":               transaction.UserID,
+		"title":                 transaction.Title,
+		"description":           transaction.Description,
+		"nominal":               transaction.Nominal,
+		"type":                  transaction.Type,
+		"category":              transaction.Category,
+		"wallet_transaction_id": transaction.WalletTransactionID,
+		"created_at":            transaction.CreatedAt,
+		"updated_at":            transaction.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateWalletBudgetTransaction, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateWalletTransaction named query preparation err")
+		return false, err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateWalletTransaction execution err")
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateWalletTransaction rows affected err")
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetLatestCategoryByTitle returns the category of the user's most recently
+// edited entry with the same title, or "" when there is none.
+func (r *budgetRepository) GetLatestCategoryByTitle(ctx context.Context, userID, transactionType, title string) (string, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var category string
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"type":    transactionType,
+		"title":   title,
+	}
+
+	query, args, err := sqlx.Named(queryGetLatestCategoryByTitle, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetLatestCategoryByTitle named query preparation err")
+		return "", err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).Scan(&category); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetLatestCategoryByTitle execution err")
+		return "", err
+	}
+
+	return category, nil
+}
+
+func (r *walletSyncRepository) Get(ctx context.Context, userID string) (budget_manager.WalletSyncSettings, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var settings WalletSyncSettingsDB
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	query, args, err := sqlx.Named(queryGetWalletSyncSettings, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWalletSyncSettings named query preparation err")
+		return budget_manager.WalletSyncSettings{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&settings); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return budget_manager.WalletSyncSettings{UserID: userID}, nil
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWalletSyncSettings execution err")
+		return budget_manager.WalletSyncSettings{}, err
+	}
+
+	return r.makeWalletSyncSettings(settings), nil
+}
+
+func (r *walletSyncRepository) Set(ctx context.Context, userID string, enabled bool) (budget_manager.WalletSyncSettings, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var settings WalletSyncSettingsDB
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"enabled": enabled,
+		"now":     time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryUpsertWalletSyncSettings, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SetWalletSyncSettings named query preparation err")
+		return budget_manager.WalletSyncSettings{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&settings); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SetWalletSyncSettings execution err")
+		return budget_manager.WalletSyncSettings{}, err
+	}
+
+	return r.makeWalletSyncSettings(settings), nil
+}
+
+func (r *walletSyncRepository) makeWalletSyncSettings(settings WalletSyncSettingsDB) budget_manager.WalletSyncSettings {
+	return budget_manager.WalletSyncSettings{
+		UserID:    settings.UserID.String,
+		Enabled:   settings.Enabled,
+		UpdatedAt: &settings.UpdatedAt,
+	}
+}
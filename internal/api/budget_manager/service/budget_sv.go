@@ -405,7 +405,7 @@ func (s *budgetService) GetTransactionsByTypeAndCategory(ctx context.Context, us
 		return nil, err
 	}
 
-	if transactionType != string(entity.TransactionTypeIncome) && transactionType != string(entity.TransactionTypeExpense) {
+	if transactionType != string(entity.TransactionTypeIncome) && transactionType != string(entity.TransactionTypeExpense) && transactionType != string(entity.TransactionTypeTransfer) {
 		s.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"type":       transactionType,
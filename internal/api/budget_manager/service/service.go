@@ -19,6 +19,10 @@ type IBudgetService interface {
 	UpdateTransaction(ctx context.Context, req budget_manager.UpdateTransactionRequest, audioFile *multipart.FileHeader) error
 	DeleteTransaction(ctx context.Context, id string, userID string) error
 	GetTransactionsByTypeAndCategory(ctx context.Context, userID string, transactionType string, category string) ([]entity.BudgetTransaction, error)
+
+	GetWalletSync(ctx context.Context, userID string) (*budget_manager.WalletSyncSettings, error)
+	UpdateWalletSync(ctx context.Context, userID string, req budget_manager.UpdateWalletSyncRequest) (*budget_manager.WalletSyncSettings, error)
+	RecordWalletTransaction(ctx context.Context, entry budget_manager.WalletEntry) error
 }
 
 type budgetService struct {
@@ -0,0 +1,214 @@
+package budgetService
+
+import (
+	"ProjectGolang/internal/api/budget_manager"
+	budgetRepository "ProjectGolang/internal/api/budget_manager/repository"
+	"ProjectGolang/internal/entity"
+	contextPkg "ProjectGolang/pkg/context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"strings"
+	"time"
+)
+
+// merchantCategoryKeywords guesses an expense category from a merchant name
+// when the user has no earlier entry for that merchant.
+var merchantCategoryKeywords = []struct {
+	category entity.ExpenseCategory
+	keywords []string
+}{
+	{entity.ExpenseCategoryFood, []string{"resto", "restaurant", "rumah makan", "warung", "cafe", "kafe", "kopi", "coffee", "bakso", "mie", "sate", "ayam", "bakery", "food", "kuliner", "mcd", "kfc", "pizza"}},
+	{entity.ExpenseCategoryDaily, []string{"indomaret", "alfamart", "alfamidi", "supermarket", "minimarket", "mart", "toko", "hypermart", "superindo", "laundry"}},
+	{entity.ExpenseCategoryTransportation, []string{"gojek", "grab", "maxim", "spbu", "pertamina", "shell", "parkir", "parking", "tol", "krl", "mrt", "lrt", "transjakarta", "kereta", "bus", "travel"}},
+	{entity.ExpenseCategoryHealth, []string{"apotek", "apotik", "pharmacy", "kimia farma", "klinik", "clinic", "rumah sakit", "hospital", "dokter", "lab"}},
+	{entity.ExpenseCategoryCommunication, []string{"telkomsel", "indosat", "xl", "smartfren", "pulsa", "internet", "indihome", "wifi"}},
+	{entity.ExpenseCategoryEntertainment, []string{"cinema", "bioskop", "xxi", "cgv", "cinepolis", "netflix", "spotify", "game", "karaoke"}},
+	{entity.ExpenseCategoryClothing, []string{"fashion", "butik", "boutique", "uniqlo", "zara", "h&m", "sepatu", "distro"}},
+	{entity.ExpenseCategoryAppearance, []string{"salon", "barber", "pangkas", "spa", "kosmetik", "beauty"}},
+	{entity.ExpenseCategoryEducation, []string{"gramedia", "buku", "kursus", "sekolah", "kampus", "universitas", "les"}},
+	{entity.ExpenseCategoryPet, []string{"petshop", "pet shop", "vet", "hewan"}},
+	{entity.ExpenseCategorySocial, []string{"masjid", "gereja", "donasi", "yayasan", "zakat", "infaq"}},
+}
+
+func (s *budgetService) GetWalletSync(ctx context.Context, userID string) (*budget_manager.WalletSyncSettings, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.budgetRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	settings, err := repo.WalletSync.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+func (s *budgetService) UpdateWalletSync(ctx context.Context, userID string, req budget_manager.UpdateWalletSyncRequest) (*budget_manager.WalletSyncSettings, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.budgetRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	settings, err := repo.WalletSync.Set(ctx, userID, *req.Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"user_id":    userID,
+		"enabled":    settings.Enabled,
+	}).Info("Updated budget wallet sync")
+
+	return &settings, nil
+}
+
+// RecordWalletTransaction copies a settled wallet movement into the user's
+// budget when they have opted in. QRIS payments become expenses and top-ups
+// become transfers. Each wallet transaction is recorded at most once.
+func (s *budgetService) RecordWalletTransaction(ctx context.Context, entry budget_manager.WalletEntry) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.budgetRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return err
+	}
+
+	settings, err := repo.WalletSync.Get(ctx, entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	transaction := entity.BudgetTransaction{
+		UserID:              entry.UserID,
+		Nominal:             entry.Amount.Abs(),
+		WalletTransactionID: entry.WalletTransactionID,
+		CreatedAt:           entry.OccurredAt,
+		UpdatedAt:           time.Now(),
+	}
+
+	switch entry.Kind {
+	case budget_manager.WalletEntryQRISPayment:
+		transaction.Type = string(entity.TransactionTypeExpense)
+		transaction.Title = strings.TrimSpace(entry.MerchantName)
+		if transaction.Title == "" {
+			transaction.Title = "Pembayaran QRIS"
+		}
+		transaction.Description = fmt.Sprintf("Pembayaran QRIS dari dompet (%s)", entry.ReferenceNo)
+
+		transaction.Category, err = s.categorizeMerchant(ctx, repo, entry.UserID, transaction.Title)
+		if err != nil {
+			return err
+		}
+	case budget_manager.WalletEntryTopUp:
+		transaction.Type = string(entity.TransactionTypeTransfer)
+		transaction.Title = "Top up dompet"
+		transaction.Description = fmt.Sprintf("Top up saldo dompet (%s)", entry.ReferenceNo)
+		transaction.Category = string(entity.TransferCategoryTopUp)
+	default:
+		return nil
+	}
+
+	transaction.ID, err = s.utils.NewULIDFromTimestamp(entry.OccurredAt)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate ULID")
+		return err
+	}
+
+	if err := transaction.Validate(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":            requestID,
+			"wallet_transaction_id": entry.WalletTransactionID,
+			"error":                 err.Error(),
+		}).Warn("Invalid budget entry from wallet")
+		return err
+	}
+
+	created, err := repo.Budget.CreateWalletTransaction(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		s.log.WithFields(logrus.Fields{
+			"request_id":            requestID,
+			"user_id":               entry.UserID,
+			"wallet_transaction_id": entry.WalletTransactionID,
+			"type":                  transaction.Type,
+			"category":              transaction.Category,
+		}).Info("Recorded wallet transaction in budget")
+	}
+
+	return nil
+}
+
+// categorizeMerchant prefers the category the user last gave this merchant,
+// then falls back to keywords in the merchant name.
+func (s *budgetService) categorizeMerchant(ctx context.Context, repo budgetRepository.Client, userID, merchantName string) (string, error) {
+	category, err := repo.Budget.GetLatestCategoryByTitle(ctx, userID, string(entity.TransactionTypeExpense), merchantName)
+	if err != nil {
+		return "", err
+	}
+
+	if entity.IsValidExpenseCategory(category) {
+		return category, nil
+	}
+
+	name := strings.ToLower(merchantName)
+	for _, rule := range merchantCategoryKeywords {
+		for _, keyword := range rule.keywords {
+			if containsWord(name, keyword) {
+				return string(rule.category), nil
+			}
+		}
+	}
+
+	return string(entity.ExpenseCategoryOther), nil
+}
+
+// containsWord reports whether keyword appears in name on word boundaries, so
+// short keywords like "xl" or "tol" do not match inside other words.
+func containsWord(name, keyword string) bool {
+	for start := 0; ; {
+		i := strings.Index(name[start:], keyword)
+		if i < 0 {
+			return false
+		}
+		i += start
+
+		end := i + len(keyword)
+		if (i == 0 || !isLetterOrDigit(name[i-1])) && (end == len(name) || !isLetterOrDigit(name[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isLetterOrDigit(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9')
+}
@@ -0,0 +1,43 @@
+package sentrapay
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// cursorTimeLayout keeps the wall clock of created_at, which is stored
+// without a zone, at the microsecond precision Postgres keeps.
+const cursorTimeLayout = "2006-01-02T15:04:05.000000"
+
+// TransactionCursor marks the last transaction of a page. Pages are ordered
+// by (created_at, id) descending, so rows inserted while a client scrolls
+// land before the cursor and never shift the pages after it.
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+func (c TransactionCursor) Encode() string {
+	raw := c.CreatedAt.Format(cursorTimeLayout) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseTransactionCursor(value string) (TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	t, err := time.Parse(cursorTimeLayout, createdAt)
+	if err != nil {
+		return TransactionCursor{}, ErrInvalidCursor
+	}
+
+	return TransactionCursor{CreatedAt: t, ID: id}, nil
+}
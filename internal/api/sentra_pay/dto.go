@@ -1,24 +1,26 @@
 package sentrapay
 
 import (
+	"ProjectGolang/pkg/money"
 	"time"
 )
 
 type TopUpRequest struct {
-	Amount float64 `json:"amount" validate:"required,gt=0"`
-	Bank   string  `json:"bank" validate:"required"`
+	Amount  money.Amount `json:"amount" validate:"required,gt=0"`
+	Bank    string       `json:"bank" validate:"required"`
+	OTPCode string       `json:"otp_code" validate:"omitempty,len=5,numeric"`
 }
 
 type TopUpResponse struct {
-	TransactionID   string    `json:"transaction_id"`
-	ReferenceNo     string    `json:"reference_no"`
-	VirtualAccount  string    `json:"virtual_account"`
-	Bank            string    `json:"bank"`
-	Amount          float64   `json:"amount"`
-	ExpiresAt       string    `json:"expires_at"`
-	PaymentGuideURL string    `json:"payment_guide_url"`
-	Status          string    `json:"status"`
-	CreatedAt       time.Time `json:"created_at"`
+	TransactionID   string       `json:"transaction_id"`
+	ReferenceNo     string       `json:"reference_no"`
+	VirtualAccount  string       `json:"virtual_account"`
+	Bank            string       `json:"bank"`
+	Amount          money.Amount `json:"amount"`
+	ExpiresAt       string       `json:"expires_at"`
+	PaymentGuideURL string       `json:"payment_guide_url"`
+	Status          string       `json:"status"`
+	CreatedAt       time.Time    `json:"created_at"`
 }
 
 type PaymentCallbackRequest struct {
@@ -34,6 +36,48 @@ type PaymentCallbackRequest struct {
 	TotalAmount         Amount         `json:"totalAmount"`
 	TrxDateTime         string         `json:"trxDateTime"`
 	AdditionalInfo      AdditionalInfo `json:"additionalInfo"`
+
+	// Provider is the gateway that sent the notification, empty when the
+	// callback did not come from a gateway.
+	Provider string `json:"-"`
+}
+
+// PaymentNotification is the raw, unauthenticated callback as received over
+// HTTP. Body is kept byte-for-byte because the signature covers it.
+type PaymentNotification struct {
+	HTTPMethod  string
+	EndpointURL string
+	Body        []byte
+	Headers     map[string]string
+}
+
+// SNAP response codes for the VA payment notification service (service code 25).
+const (
+	SNAPNotificationSuccess            = "2002500"
+	SNAPNotificationInvalidFieldFormat = "4002501"
+	SNAPNotificationMissingField       = "4002502"
+	SNAPNotificationUnauthorized       = "4012500"
+	SNAPNotificationBillNotFound       = "4042512"
+	SNAPNotificationInvalidAmount      = "4042513"
+	SNAPNotificationInvalidBill        = "4042519"
+	SNAPNotificationGeneralError       = "5002500"
+)
+
+type SNAPNotificationResponse struct {
+	ResponseCode       string                  `json:"responseCode"`
+	ResponseMessage    string                  `json:"responseMessage"`
+	VirtualAccountData *SNAPNotificationVAData `json:"virtualAccountData,omitempty"`
+}
+
+type SNAPNotificationVAData struct {
+	PartnerServiceId   string                 `json:"partnerServiceId"`
+	CustomerNo         string                 `json:"customerNo"`
+	VirtualAccountNo   string                 `json:"virtualAccountNo"`
+	VirtualAccountName string                 `json:"virtualAccountName"`
+	PaymentRequestId   string                 `json:"paymentRequestId"`
+	TrxId              string                 `json:"trxId"`
+	TrxDateTime        string                 `json:"trxDateTime"`
+	AdditionalInfo     map[string]interface{} `json:"additionalInfo,omitempty"`
 }
 
 type Amount struct {
@@ -50,27 +94,70 @@ type AdditionalInfo struct {
 }
 
 type WalletTransaction struct {
-	ID            string    `json:"id"`
-	UserID        string    `json:"user_id"`
-	Amount        float64   `json:"amount"`
-	Type          string    `json:"type"`
-	ReferenceNo   string    `json:"reference_no"`
-	PaymentMethod string    `json:"payment_method"`
-	Status        string    `json:"status"`
-	BankAccount   string    `json:"bank_account,omitempty"`
-	BankName      string    `json:"bank_name,omitempty"`
-	Description   string    `json:"description,omitempty"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID               string            `json:"id"`
+	UserID           string            `json:"user_id"`
+	Amount           money.Amount      `json:"amount"`
+	Type             string            `json:"type"`
+	ReferenceNo      string            `json:"reference_no"`
+	PaymentMethod    string            `json:"payment_method"`
+	Status           TransactionStatus `json:"status"`
+	BankAccount      string            `json:"bank_account,omitempty"`
+	BankName         string            `json:"bank_name,omitempty"`
+	Description      string            `json:"description,omitempty"`
+	ExpiresAt        *time.Time        `json:"expires_at,omitempty"`
+	StatusReason     string            `json:"status_reason,omitempty"`
+	Gateway          string            `json:"-"`
+	GatewayPaymentID string            `json:"-"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
 }
 
 type WalletBalance struct {
-	UserID      string    `json:"user_id"`
-	Balance     float64   `json:"balance"`
-	LastUpdated time.Time `json:"last_updated"`
+	UserID          string       `json:"user_id"`
+	Balance         money.Amount `json:"balance"`
+	Status          string       `json:"status"`
+	StatusReason    string       `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time   `json:"status_changed_at,omitempty"`
+	LastUpdated     time.Time    `json:"last_updated"`
+}
+
+// TransactionFilter narrows a transaction listing. Zero times leave that side
+// of the range open; To is exclusive. Empty strings match everything.
+type TransactionFilter struct {
+	From          time.Time
+	To            time.Time
+	Type          string
+	Status        string
+	PaymentMethod string
+	Search        string
+}
+
+// TransactionHistoryRequest filters the transaction history. Dates are
+// Jakarta calendar days, both inclusive; Search matches the description and
+// the QRIS merchant name.
+type TransactionHistoryRequest struct {
+	Cursor        string `query:"cursor"`
+	Limit         int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Type          string `query:"type" validate:"omitempty,max=50"`
+	Status        string `query:"status" validate:"omitempty,oneof=pending processing success failed expired reversed refunded"`
+	PaymentMethod string `query:"payment_method" validate:"omitempty,max=50"`
+	From          string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To            string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Search        string `query:"q" validate:"omitempty,max=100"`
+}
+
+// TransactionSummary totals every transaction matching a filter. Money in and
+// out only count transactions that moved the balance.
+type TransactionSummary struct {
+	Total    int          `json:"total"`
+	MoneyIn  money.Amount `json:"money_in"`
+	MoneyOut money.Amount `json:"money_out"`
 }
 
 type TransactionHistoryResponse struct {
 	Transactions []WalletTransaction `json:"transactions"`
 	Total        int                 `json:"total"`
+	MoneyIn      money.Amount        `json:"money_in"`
+	MoneyOut     money.Amount        `json:"money_out"`
+	NextCursor   string              `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,26 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+// Event types on the /wallet/events stream.
+const (
+	WalletEventBalance     = "balance"
+	WalletEventTransaction = "transaction"
+)
+
+// TransactionEvent is the committed state of one of the user's transactions.
+// Message is a short Indonesian sentence that clients can show or read out
+// as is.
+type TransactionEvent struct {
+	ReferenceNo   string            `json:"reference_no"`
+	Type          string            `json:"type"`
+	Status        TransactionStatus `json:"status"`
+	Amount        money.Amount      `json:"amount"`
+	PaymentMethod string            `json:"payment_method"`
+	StatusReason  string            `json:"status_reason,omitempty"`
+	Message       string            `json:"message,omitempty"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
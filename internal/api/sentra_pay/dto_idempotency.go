@@ -0,0 +1,24 @@
+package sentrapay
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+type IdempotencyKey struct {
+	UserID         string          `json:"user_id"`
+	Endpoint       string          `json:"endpoint"`
+	Key            string          `json:"idempotency_key"`
+	RequestHash    string          `json:"request_hash"`
+	Status         string          `json:"status"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	ResponseBody   json.RawMessage `json:"response_body,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+}
@@ -0,0 +1,98 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+type LedgerDirection string
+
+const (
+	LedgerDebit  LedgerDirection = "debit"
+	LedgerCredit LedgerDirection = "credit"
+)
+
+// Ledger accounts other than user wallets. Wallet accounts are named with
+// WalletLedgerAccount so their entries can be summed back to wallets.balance.
+const (
+	LedgerAccountTopUpClearing  = "clearing:topup"
+	LedgerAccountQRISClearing   = "clearing:qris"
+	LedgerAccountWithdrawalHold = "clearing:withdrawal"
+	LedgerAccountBankPayout     = "settlement:bank_payout"
+	LedgerAccountOpeningBalance = "system:opening_balance"
+)
+
+func WalletLedgerAccount(userID string) string {
+	return "wallet:" + userID
+}
+
+type LedgerLeg struct {
+	Account   string
+	UserID    string
+	Direction LedgerDirection
+	Amount    money.Amount
+}
+
+type LedgerPosting struct {
+	JournalKey  string
+	ReferenceNo string
+	Kind        string
+	Description string
+	Legs        []LedgerLeg
+}
+
+type LedgerJournal struct {
+	ID          string
+	JournalKey  string
+	ReferenceNo string
+	Kind        string
+	Description string
+	CreatedAt   time.Time
+}
+
+type LedgerEntry struct {
+	ID           string
+	JournalID    string
+	Account      string
+	UserID       string
+	Direction    LedgerDirection
+	Amount       money.Amount
+	BalanceAfter *money.Amount
+	CreatedAt    time.Time
+}
+
+// WalletLedgerEntry is one entry on a user's wallet account. Kind,
+// Description and Status come from the user's transaction under the same
+// reference, or from the journal when there is none.
+type WalletLedgerEntry struct {
+	ID           string
+	ReferenceNo  string
+	Kind         string
+	Description  string
+	Status       string
+	Direction    LedgerDirection
+	Amount       money.Amount
+	BalanceAfter money.Amount
+	PostedAt     time.Time
+}
+
+type LedgerBalanceMismatch struct {
+	UserID        string       `json:"user_id"`
+	WalletBalance money.Amount `json:"wallet_balance"`
+	LedgerBalance money.Amount `json:"ledger_balance"`
+	Difference    money.Amount `json:"difference"`
+}
+
+type UnbalancedJournal struct {
+	JournalID   string       `json:"journal_id"`
+	TotalDebit  money.Amount `json:"total_debit"`
+	TotalCredit money.Amount `json:"total_credit"`
+}
+
+type LedgerConsistencyReport struct {
+	CheckedAt          time.Time               `json:"checked_at"`
+	WalletsChecked     int                     `json:"wallets_checked"`
+	Consistent         bool                    `json:"consistent"`
+	BalanceMismatches  []LedgerBalanceMismatch `json:"balance_mismatches"`
+	UnbalancedJournals []UnbalancedJournal     `json:"unbalanced_journals"`
+}
@@ -0,0 +1,46 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+const (
+	LimitProfileDefault    = "default"
+	LimitProfileUnverified = "unverified"
+)
+
+type LimitProfile struct {
+	Code                string       `json:"code"`
+	DailyLimit          money.Amount `json:"daily_limit"`
+	PerTransactionLimit money.Amount `json:"per_transaction_limit"`
+	Description         string       `json:"description,omitempty"`
+}
+
+// UserLimit is a per-user override row. A nil limit follows the profile
+// ceiling; an empty ProfileCode means the default profile.
+type UserLimit struct {
+	UserID              string        `json:"user_id"`
+	ProfileCode         string        `json:"profile_code,omitempty"`
+	DailyLimit          *money.Amount `json:"daily_limit,omitempty"`
+	PerTransactionLimit *money.Amount `json:"per_transaction_limit,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+}
+
+type WalletLimits struct {
+	Profile                string       `json:"profile"`
+	IsVerified             bool         `json:"is_verified"`
+	DailyLimit             money.Amount `json:"daily_limit"`
+	PerTransactionLimit    money.Amount `json:"per_transaction_limit"`
+	MaxDailyLimit          money.Amount `json:"max_daily_limit"`
+	MaxPerTransactionLimit money.Amount `json:"max_per_transaction_limit"`
+	UsedToday              money.Amount `json:"used_today"`
+	RemainingToday         money.Amount `json:"remaining_today"`
+}
+
+type UpdateWalletLimitsRequest struct {
+	DailyLimit          *money.Amount `json:"daily_limit" validate:"omitempty,gt=0"`
+	PerTransactionLimit *money.Amount `json:"per_transaction_limit" validate:"omitempty,gt=0"`
+	OTPCode             string        `json:"otp_code" validate:"omitempty,len=5,numeric"`
+}
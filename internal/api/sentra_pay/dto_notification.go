@@ -0,0 +1,48 @@
+package sentrapay
+
+import "time"
+
+// Verification results of a stored payment notification.
+const (
+	NotificationVerificationPending = "pending"
+	NotificationVerified            = "verified"
+	NotificationRejected            = "rejected"
+)
+
+// Processing outcomes of a stored payment notification. Acknowledged
+// notifications were authentic but reported no completed payment.
+const (
+	NotificationReceived     = "received"
+	NotificationProcessed    = "processed"
+	NotificationAcknowledged = "acknowledged"
+	NotificationFailed       = "failed"
+)
+
+// PaymentNotificationRecord is an inbound payment notification as stored in
+// the inbox. Callback is the authenticated content and is what a replay
+// processes; it is empty unless the notification was verified.
+type PaymentNotificationRecord struct {
+	ID                string                  `json:"id"`
+	Provider          string                  `json:"provider"`
+	HTTPMethod        string                  `json:"http_method"`
+	EndpointURL       string                  `json:"endpoint_url"`
+	Headers           map[string]string       `json:"headers"`
+	Body              string                  `json:"body"`
+	Verification      string                  `json:"verification"`
+	VerificationError string                  `json:"verification_error,omitempty"`
+	Callback          *PaymentCallbackRequest `json:"callback,omitempty"`
+	ReferenceNo       string                  `json:"reference_no,omitempty"`
+	PaymentID         string                  `json:"payment_id,omitempty"`
+	Outcome           string                  `json:"outcome"`
+	OutcomeError      string                  `json:"outcome_error,omitempty"`
+	ReplayCount       int                     `json:"replay_count"`
+	ReceivedAt        time.Time               `json:"received_at"`
+	ProcessedAt       *time.Time              `json:"processed_at,omitempty"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+}
+
+type ListPaymentNotificationsRequest struct {
+	Outcome     string `query:"outcome" validate:"omitempty,oneof=received processed acknowledged failed"`
+	ReferenceNo string `query:"reference_no" validate:"omitempty,max=100"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,108 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+// A payment request stays open while any participant has yet to answer. It
+// completes once every participant has paid or declined, and expires with the
+// answers still missing.
+const (
+	PaymentRequestOpen      = "open"
+	PaymentRequestCompleted = "completed"
+	PaymentRequestExpired   = "expired"
+)
+
+// Participant states. Accepting now pays and answers in one database
+// transaction; paying is only left on rows from before that, which are still
+// treated as unanswered.
+const (
+	ParticipantPending  = "pending"
+	ParticipantPaying   = "paying"
+	ParticipantPaid     = "paid"
+	ParticipantDeclined = "declined"
+	ParticipantExpired  = "expired"
+)
+
+const (
+	SplitEven   = "even"
+	SplitCustom = "custom"
+)
+
+const (
+	PaymentRequestsSent     = "sent"
+	PaymentRequestsReceived = "received"
+)
+
+type PaymentRequestParticipantInput struct {
+	PhoneNumber string       `json:"phone_number" validate:"required,min=10,max=13"`
+	Amount      money.Amount `json:"amount" validate:"required,gt=0"`
+}
+
+// CreatePaymentRequest asks each participant for their own amount.
+// ExpiresInHours defaults to three days.
+type CreatePaymentRequest struct {
+	Participants   []PaymentRequestParticipantInput `json:"participants" validate:"required,min=1,max=20,dive"`
+	Note           string                           `json:"note" validate:"omitempty,max=255"`
+	ExpiresInHours int                              `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+}
+
+// SplitParticipantInput takes an amount only in custom mode.
+type SplitParticipantInput struct {
+	PhoneNumber string       `json:"phone_number" validate:"required,min=10,max=13"`
+	Amount      money.Amount `json:"amount" validate:"gte=0"`
+}
+
+// SplitQRISRequest splits a settled QRIS payment of the requester. In even
+// mode the total is shared equally with the requester, who keeps any sen that
+// do not divide evenly; in custom mode the shares may not exceed the total and
+// the requester covers the rest.
+type SplitQRISRequest struct {
+	QRISReferenceNo string                  `json:"qris_reference_no" validate:"required,max=100"`
+	Mode            string                  `json:"mode" validate:"required,oneof=even custom"`
+	Participants    []SplitParticipantInput `json:"participants" validate:"required,min=1,max=20,dive"`
+	Note            string                  `json:"note" validate:"omitempty,max=255"`
+	ExpiresInHours  int                     `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+}
+
+type ListPaymentRequestsRequest struct {
+	Role string `query:"role" validate:"omitempty,oneof=sent received"`
+}
+
+type RespondPaymentRequest struct {
+	PIN     string `json:"pin" validate:"required,min=6,max=6"`
+	OTPCode string `json:"otp_code" validate:"omitempty,len=5,numeric"`
+}
+
+type PaymentRequestParticipant struct {
+	ID                  string       `json:"id"`
+	RequestID           string       `json:"-"`
+	UserID              string       `json:"-"`
+	Name                string       `json:"name"`
+	PhoneNumber         string       `json:"phone_number"`
+	Amount              money.Amount `json:"amount"`
+	Status              string       `json:"status"`
+	TransferReferenceNo string       `json:"transfer_reference_no,omitempty"`
+	ReminderCount       int          `json:"reminder_count"`
+	LastRemindedAt      *time.Time   `json:"last_reminded_at,omitempty"`
+	RespondedAt         *time.Time   `json:"responded_at,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+}
+
+// PaymentRequest lists every participant to the requester, and only their
+// own entry to a participant.
+type PaymentRequest struct {
+	ID              string                      `json:"id"`
+	RequesterID     string                      `json:"-"`
+	RequesterName   string                      `json:"requester_name"`
+	Note            string                      `json:"note,omitempty"`
+	QRISReferenceNo string                      `json:"qris_reference_no,omitempty"`
+	TotalAmount     money.Amount                `json:"total_amount"`
+	Status          string                      `json:"status"`
+	ExpiresAt       time.Time                   `json:"expires_at"`
+	CreatedAt       time.Time                   `json:"created_at"`
+	UpdatedAt       time.Time                   `json:"updated_at"`
+	Participants    []PaymentRequestParticipant `json:"participants"`
+}
@@ -1,17 +1,57 @@
 package sentrapay
 
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+// QRIS payment stages. A payment is written as initiated, with the wallet
+// already debited, before DOKU is charged. gateway_confirmed means DOKU took
+// the payment and it must be settled, never reversed; reversed means DOKU did
+// not take it and the debit was returned to the wallet.
+const (
+	QRISPaymentInitiated        = "initiated"
+	QRISPaymentGatewayConfirmed = "gateway_confirmed"
+	QRISPaymentSettled          = "settled"
+	QRISPaymentReversed         = "reversed"
+)
+
+type QRISPayment struct {
+	ID                 string
+	UserID             string
+	ReferenceNo        string
+	GatewayReferenceNo string
+	MerchantName       string
+	Amount             money.Amount
+	FeeAmount          money.Amount
+	TotalAmount        money.Amount
+	Status             string
+	Gateway            string
+	FailureReason      string
+	TransactionDate    string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
 type QRISDecodeRequest struct {
 	QRContent string `json:"qr_content" validate:"required"`
 }
 
+// QRISDecodeResponse describes a QR code before payment. Offline is set when
+// DOKU could not be reached and the response is a preview read from the code
+// itself; such a code cannot be paid until DOKU is back.
 type QRISDecodeResponse struct {
-	ReferenceNo    string                   `json:"reference_no"`
-	MerchantName   string                   `json:"merchant_name"`
-	Amount         float64                  `json:"amount"`
-	FeeAmount      float64                  `json:"fee_amount"`
-	TotalAmount    float64                  `json:"total_amount"`
-	PaymentType    string                   `json:"payment_type"`
-	AdditionalInfo QRISDecodeAdditionalInfo `json:"additional_info"`
+	ReferenceNo          string                   `json:"reference_no"`
+	MerchantName         string                   `json:"merchant_name"`
+	MerchantCity         string                   `json:"merchant_city,omitempty"`
+	MerchantCategoryCode string                   `json:"merchant_category_code,omitempty"`
+	NMID                 string                   `json:"nmid,omitempty"`
+	Amount               money.Amount             `json:"amount"`
+	FeeAmount            money.Amount             `json:"fee_amount"`
+	TotalAmount          money.Amount             `json:"total_amount"`
+	PaymentType          string                   `json:"payment_type"`
+	Offline              bool                     `json:"offline,omitempty"`
+	AdditionalInfo       QRISDecodeAdditionalInfo `json:"additional_info"`
 }
 
 type QRISDecodeAdditionalInfo struct {
@@ -23,20 +63,23 @@ type QRISDecodeAdditionalInfo struct {
 
 type QRISPaymentRequest struct {
 	QRContent string `json:"qr_content" validate:"required"`
-	AuthCode  string `json:"auth_code""`
+	AuthCode  string `json:"auth_code"`
+	PIN       string `json:"pin" validate:"required,min=6,max=6"`
+	OTPCode   string `json:"otp_code" validate:"omitempty,len=5,numeric"`
 }
 
 type QRISPaymentResponse struct {
-	TransactionID   string                    `json:"transaction_id"`
-	ReferenceNo     string                    `json:"reference_no"`
-	Amount          float64                   `json:"amount"`
-	FeeAmount       float64                   `json:"fee_amount"`
-	TotalAmount     float64                   `json:"total_amount"`
-	MerchantName    string                    `json:"merchant_name"`
-	Status          string                    `json:"status"`
-	TransactionDate string                    `json:"transaction_date"`
-	PaymentMethod   string                    `json:"payment_method"`
-	AdditionalInfo  QRISPaymentAdditionalInfo `json:"additional_info"`
+	TransactionID      string                    `json:"transaction_id"`
+	ReferenceNo        string                    `json:"reference_no"`
+	GatewayReferenceNo string                    `json:"gateway_reference_no,omitempty"`
+	Amount             money.Amount              `json:"amount"`
+	FeeAmount          money.Amount              `json:"fee_amount"`
+	TotalAmount        money.Amount              `json:"total_amount"`
+	MerchantName       string                    `json:"merchant_name"`
+	Status             string                    `json:"status"`
+	TransactionDate    string                    `json:"transaction_date"`
+	PaymentMethod      string                    `json:"payment_method"`
+	AdditionalInfo     QRISPaymentAdditionalInfo `json:"additional_info"`
 }
 
 type QRISPaymentAdditionalInfo struct {
@@ -45,3 +88,26 @@ type QRISPaymentAdditionalInfo struct {
 	Acquirer                   string `json:"acquirer"`
 	AcquirerName               string `json:"acquirer_name"`
 }
+
+// QRISReceiveRequest asks for a code others can scan to pay into the wallet.
+// A zero amount lets the payer choose; ExpiresInMinutes defaults to 30.
+type QRISReceiveRequest struct {
+	Amount           money.Amount `json:"amount" validate:"gte=0"`
+	ExpiresInMinutes int          `json:"expires_in_minutes" validate:"omitempty,min=1,max=1440"`
+	IncludeImage     bool         `json:"include_image"`
+}
+
+// QRISReceiveResponse carries the QRIS payload; Image is a base64 PNG of it
+// when requested. The payment is tracked as the wallet transaction ReferenceNo.
+type QRISReceiveResponse struct {
+	TransactionID string       `json:"transaction_id"`
+	ReferenceNo   string       `json:"reference_no"`
+	Payload       string       `json:"qr_content"`
+	Image         string       `json:"qr_image,omitempty"`
+	Amount        money.Amount `json:"amount"`
+	FixedAmount   bool         `json:"fixed_amount"`
+	NMID          string       `json:"nmid"`
+	Status        string       `json:"status"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
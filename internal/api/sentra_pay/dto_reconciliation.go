@@ -0,0 +1,56 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+	ReconciliationFailed    = "failed"
+)
+
+// Kinds of reconciliation findings. Only missed_payment_credited and
+// status_corrected change wallet state; the others are left for finance to
+// review.
+const (
+	ReconciliationMissedPaymentCredited = "missed_payment_credited"
+	ReconciliationStatusCorrected       = "status_corrected"
+	ReconciliationAmountMismatch        = "amount_mismatch"
+	ReconciliationGatewayError          = "gateway_error"
+	ReconciliationUnmatchedPayment      = "unmatched_payment"
+)
+
+type ReconciliationRun struct {
+	ID            string     `json:"id"`
+	Status        string     `json:"status"`
+	CheckedCount  int        `json:"checked_count"`
+	CreditedCount int        `json:"credited_count"`
+	MismatchCount int        `json:"mismatch_count"`
+	ErrorCount    int        `json:"error_count"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+type ReconciliationItem struct {
+	ID                  string        `json:"id"`
+	RunID               string        `json:"run_id"`
+	TransactionID       string        `json:"transaction_id"`
+	ReferenceNo         string        `json:"reference_no"`
+	UserID              string        `json:"user_id"`
+	Kind                string        `json:"kind"`
+	LocalStatus         string        `json:"local_status"`
+	ExpectedAmount      money.Amount  `json:"expected_amount"`
+	GatewayAmount       *money.Amount `json:"gateway_amount,omitempty"`
+	Difference          *money.Amount `json:"difference,omitempty"`
+	GatewayResponseCode string        `json:"gateway_response_code,omitempty"`
+	Note                string        `json:"note,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+}
+
+type ReconciliationReport struct {
+	Run   ReconciliationRun    `json:"run"`
+	Items []ReconciliationItem `json:"items"`
+}
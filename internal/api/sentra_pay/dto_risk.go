@@ -0,0 +1,37 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/risk"
+	"time"
+)
+
+// Outcomes of a risk screening. A challenged movement waits for the user to
+// repeat the request with the OTP sent to their phone number.
+const (
+	RiskAllowed         = "allowed"
+	RiskChallenged      = "challenged"
+	RiskChallengePassed = "challenge_passed"
+	RiskChallengeFailed = "challenge_failed"
+	RiskDenied          = "denied"
+)
+
+// RiskDecision is one screening of a money movement as logged for review.
+type RiskDecision struct {
+	ID        string        `json:"id"`
+	UserID    string        `json:"user_id"`
+	Operation string        `json:"operation"`
+	Amount    money.Amount  `json:"amount"`
+	Payee     string        `json:"payee,omitempty"`
+	DeviceID  string        `json:"device_id,omitempty"`
+	Action    risk.Action   `json:"action"`
+	Outcome   string        `json:"outcome"`
+	Results   []risk.Result `json:"results"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type ListRiskDecisionsRequest struct {
+	Outcome string `query:"outcome" validate:"omitempty,oneof=allowed challenged challenge_passed challenge_failed denied"`
+	UserID  string `query:"user_id" validate:"omitempty,max=50"`
+	Limit   int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
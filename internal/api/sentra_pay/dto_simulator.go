@@ -0,0 +1,19 @@
+package sentrapay
+
+import "ProjectGolang/pkg/money"
+
+// SimulateVAPaymentRequest pays a top-up through the DOKU simulator. A zero
+// amount pays exactly what the virtual account bills.
+type SimulateVAPaymentRequest struct {
+	Amount money.Amount `json:"amount" validate:"gte=0"`
+}
+
+type SimulateVAPaymentResponse struct {
+	ReferenceNo       string       `json:"reference_no"`
+	VirtualAccount    string       `json:"virtual_account"`
+	PaidAmount        money.Amount `json:"paid_amount"`
+	CallbackStatus    int          `json:"callback_status"`
+	ResponseCode      string       `json:"response_code"`
+	ResponseMessage   string       `json:"response_message"`
+	TransactionStatus string       `json:"transaction_status"`
+}
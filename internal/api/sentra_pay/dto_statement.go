@@ -0,0 +1,73 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+const (
+	StatementFormatPDF = "pdf"
+	StatementFormatCSV = "csv"
+)
+
+const (
+	StatementJobPending    = "pending"
+	StatementJobProcessing = "processing"
+	StatementJobCompleted  = "completed"
+	StatementJobFailed     = "failed"
+)
+
+// StatementRequest dates are calendar days in Jakarta, both inclusive.
+type StatementRequest struct {
+	From   string `query:"from" validate:"required,datetime=2006-01-02"`
+	To     string `query:"to" validate:"required,datetime=2006-01-02"`
+	Format string `query:"format" validate:"omitempty,oneof=pdf csv"`
+}
+
+type StatementRow struct {
+	Date        time.Time    `json:"date"`
+	ReferenceNo string       `json:"reference_no"`
+	Type        string       `json:"type"`
+	Description string       `json:"description"`
+	Status      string       `json:"status"`
+	Credit      money.Amount `json:"credit"`
+	Debit       money.Amount `json:"debit"`
+	Balance     money.Amount `json:"balance"`
+}
+
+type Statement struct {
+	UserID         string         `json:"user_id"`
+	Name           string         `json:"name"`
+	PhoneNumber    string         `json:"phone_number"`
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	OpeningBalance money.Amount   `json:"opening_balance"`
+	ClosingBalance money.Amount   `json:"closing_balance"`
+	TotalCredit    money.Amount   `json:"total_credit"`
+	TotalDebit     money.Amount   `json:"total_debit"`
+	Rows           []StatementRow `json:"rows"`
+	GeneratedAt    time.Time      `json:"generated_at"`
+}
+
+type StatementFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// StatementJob is a statement too large to build within a request. Once it
+// completes, DownloadURL holds a short-lived presigned link to the file.
+type StatementJob struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	FileURL     string     `json:"-"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,25 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+type TransferRequest struct {
+	RecipientPhone string       `json:"recipient_phone" validate:"required,min=10,max=13"`
+	Amount         money.Amount `json:"amount" validate:"required,gt=0"`
+	PIN            string       `json:"pin" validate:"required,min=6,max=6"`
+	Note           string       `json:"note" validate:"omitempty,max=255"`
+	OTPCode        string       `json:"otp_code" validate:"omitempty,len=5,numeric"`
+}
+
+type TransferResponse struct {
+	TransactionID  string       `json:"transaction_id"`
+	ReferenceNo    string       `json:"reference_no"`
+	RecipientName  string       `json:"recipient_name"`
+	RecipientPhone string       `json:"recipient_phone"`
+	Amount         money.Amount `json:"amount"`
+	Note           string       `json:"note,omitempty"`
+	Status         string       `json:"status"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
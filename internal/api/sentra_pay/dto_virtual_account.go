@@ -0,0 +1,18 @@
+package sentrapay
+
+import "time"
+
+// UserVirtualAccount is the reusable virtual account a user tops up through
+// for one channel. The number never changes once issued.
+type UserVirtualAccount struct {
+	ID               string    `json:"-"`
+	UserID           string    `json:"-"`
+	Channel          string    `json:"channel"`
+	BankName         string    `json:"bank"`
+	Gateway          string    `json:"-"`
+	PartnerServiceID string    `json:"-"`
+	CustomerNo       string    `json:"-"`
+	VirtualAccountNo string    `json:"virtual_account"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"-"`
+}
@@ -0,0 +1,17 @@
+package sentrapay
+
+// Wallet statuses. A frozen wallet still receives money but every debit is
+// refused until the owner unfreezes it; a closed wallet cannot be reopened.
+const (
+	WalletActive = "active"
+	WalletFrozen = "frozen"
+	WalletClosed = "closed"
+)
+
+type FreezeWalletRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type UnfreezeWalletRequest struct {
+	OTPCode string `json:"otp_code" validate:"required,len=5,numeric"`
+}
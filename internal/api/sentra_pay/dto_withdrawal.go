@@ -0,0 +1,62 @@
+package sentrapay
+
+import (
+	"ProjectGolang/pkg/money"
+	"time"
+)
+
+const (
+	BankAccountPending  = "pending"
+	BankAccountVerified = "verified"
+	BankAccountFailed   = "failed"
+)
+
+const (
+	WithdrawalProcessing = "processing"
+	WithdrawalSuccess    = "success"
+	WithdrawalFailed     = "failed"
+)
+
+type BankAccount struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"-"`
+	BankCode      string     `json:"bank_code"`
+	AccountNumber string     `json:"account_number"`
+	AccountName   string     `json:"account_name,omitempty"`
+	Status        string     `json:"status"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type RegisterBankAccountRequest struct {
+	BankCode      string `json:"bank_code" validate:"required,max=20"`
+	AccountNumber string `json:"account_number" validate:"required,numeric,min=5,max=34"`
+}
+
+type WithdrawalRequest struct {
+	BankAccountID string       `json:"bank_account_id" validate:"required"`
+	Amount        money.Amount `json:"amount" validate:"required,gt=0"`
+	PIN           string       `json:"pin" validate:"required,min=6,max=6"`
+	OTPCode       string       `json:"otp_code" validate:"omitempty,len=5,numeric"`
+}
+
+type Withdrawal struct {
+	ID               string       `json:"id"`
+	ReferenceNo      string       `json:"reference_no"`
+	UserID           string       `json:"-"`
+	BankAccountID    string       `json:"bank_account_id"`
+	Amount           money.Amount `json:"amount"`
+	Status           string       `json:"status"`
+	GatewayReference string       `json:"gateway_reference,omitempty"`
+	FailureReason    string       `json:"failure_reason,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+type WithdrawalResponse struct {
+	Withdrawal
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+}
@@ -5,33 +5,91 @@ import (
 )
 
 var (
-	ErrInvalidQRISCode           = response.NewError(400, "invalid QRIS code")
-	ErrQRISExpired               = response.NewError(400, "QRIS has expired")
-	ErrQRISAlreadyUsed           = response.NewError(400, "QRIS has already been used")
-	ErrQRISNotFound              = response.NewError(404, "QRIS not found")
-	ErrInvalidMerchant           = response.NewError(400, "invalid merchant information")
-	ErrInvalidPIN                = response.NewError(401, "invalid PIN")
-	ErrPINAttemptExceeded        = response.NewError(429, "too many PIN attempts")
-	ErrMaxDailyLimitExceeded     = response.NewError(400, "daily transaction limit exceeded")
-	ErrMaxPerTransactionExceeded = response.NewError(400, "amount exceeds per transaction limit")
-	ErrFixedAmountMismatch       = response.NewError(400, "amount doesn't match QRIS fixed amount")
-	ErrQRISPaymentFailed         = response.NewError(500, "QRIS payment failed")
-	ErrQRISPaymentPending        = response.NewError(202, "QRIS payment is pending")
-	ErrDuplicateTransaction      = response.NewError(409, "duplicate transaction")
-	ErrMerchantOffline           = response.NewError(503, "merchant is currently offline")
-	ErrInsufficientFee           = response.NewError(400, "insufficient balance for fee")
-	ErrInsufficientForTotal      = response.NewError(400, "insufficient balance for total amount")
-	ErrQRISServiceUnavailable    = response.NewError(503, "QRIS service unavailable")
-	ErrTimeoutScanning           = response.NewError(504, "timeout while scanning QRIS")
-	ErrInsufficientBalance       = response.NewError(400, "insufficient balance")
-	ErrTransactionNotFound       = response.NewError(404, "transaction not found")
-	ErrCreateTransaction         = response.NewError(500, "failed to create transaction")
-	ErrUpdateTransaction         = response.NewError(500, "failed to update transaction")
-	ErrDeleteTransaction         = response.NewError(500, "failed to delete transaction")
-	ErrCreateVirtualAccount      = response.NewError(500, "failed to create virtual account")
-	ErrInvalidBank               = response.NewError(400, "invalid bank selection")
-	ErrInvalidAmount             = response.NewError(400, "invalid amount")
-	ErrWalletNotFound            = response.NewError(404, "wallet not found")
-	ErrInvalidCallback           = response.NewError(400, "invalid callback data")
-	ErrInvalidTransactionState   = response.NewError(400, "invalid transaction state")
+	ErrInvalidQRISCode               = response.NewError(400, "invalid QRIS code")
+	ErrQRISExpired                   = response.NewError(400, "QRIS has expired")
+	ErrQRISAlreadyUsed               = response.NewError(400, "QRIS has already been used")
+	ErrQRISNotFound                  = response.NewError(404, "QRIS not found")
+	ErrInvalidMerchant               = response.NewError(400, "invalid merchant information")
+	ErrInvalidPIN                    = response.NewError(401, "invalid PIN")
+	ErrPINAttemptExceeded            = response.NewError(429, "too many PIN attempts")
+	ErrMaxDailyLimitExceeded         = response.NewError(400, "daily transaction limit exceeded")
+	ErrMaxPerTransactionExceeded     = response.NewError(400, "amount exceeds per transaction limit")
+	ErrFixedAmountMismatch           = response.NewError(400, "amount doesn't match QRIS fixed amount")
+	ErrQRISPaymentFailed             = response.NewError(500, "QRIS payment failed")
+	ErrQRISPaymentPending            = response.NewError(202, "QRIS payment is pending")
+	ErrDuplicateTransaction          = response.NewError(409, "duplicate transaction")
+	ErrMerchantOffline               = response.NewError(503, "merchant is currently offline")
+	ErrInsufficientFee               = response.NewError(400, "insufficient balance for fee")
+	ErrInsufficientForTotal          = response.NewError(400, "insufficient balance for total amount")
+	ErrQRISServiceUnavailable        = response.NewError(503, "QRIS service unavailable")
+	ErrTimeoutScanning               = response.NewError(504, "timeout while scanning QRIS")
+	ErrInsufficientBalance           = response.NewError(400, "insufficient balance")
+	ErrTransactionNotFound           = response.NewError(404, "transaction not found")
+	ErrCreateTransaction             = response.NewError(500, "failed to create transaction")
+	ErrUpdateTransaction             = response.NewError(500, "failed to update transaction")
+	ErrDeleteTransaction             = response.NewError(500, "failed to delete transaction")
+	ErrCreateVirtualAccount          = response.NewError(500, "failed to create virtual account")
+	ErrInvalidBank                   = response.NewError(400, "invalid bank selection")
+	ErrInvalidAmount                 = response.NewError(400, "invalid amount")
+	ErrWalletNotFound                = response.NewError(404, "wallet not found")
+	ErrInvalidCallback               = response.NewError(400, "invalid callback data")
+	ErrInvalidTransactionState       = response.NewError(400, "invalid transaction state")
+	ErrRecipientNotFound             = response.NewError(404, "recipient not found")
+	ErrSelfTransfer                  = response.NewError(400, "cannot transfer to your own wallet")
+	ErrPINNotSet                     = response.NewError(403, "transaction PIN has not been set")
+	ErrUnbalancedPosting             = response.NewError(500, "ledger posting is not balanced")
+	ErrJournalAlreadyPosted          = response.NewError(409, "ledger journal already posted")
+	ErrLimitProfileNotFound          = response.NewError(500, "wallet limit profile not found")
+	ErrUserLimitNotFound             = response.NewError(404, "wallet limit override not found")
+	ErrLimitAboveMaximum             = response.NewError(400, "limit exceeds the maximum allowed for this account")
+	ErrInvalidLimit                  = response.NewError(400, "per transaction limit cannot exceed daily limit")
+	ErrNoLimitChange                 = response.NewError(400, "no limit value provided")
+	ErrLimitOTPRequired              = response.NewError(403, "OTP is required to raise a limit")
+	ErrInvalidOTP                    = response.NewError(401, "invalid or expired OTP")
+	ErrIdempotencyKeyNotFound        = response.NewError(404, "idempotency key not found")
+	ErrIdempotencyKeyInProgress      = response.NewError(409, "a request with this idempotency key is still being processed")
+	ErrTransactionExpired            = response.NewError(400, "transaction has expired")
+	ErrUnmatchedPayment              = response.NewError(400, "payment matches no open transaction")
+	ErrInvalidSignature              = response.NewError(401, "invalid notification signature")
+	ErrStaleNotification             = response.NewError(401, "notification timestamp outside allowed window")
+	ErrInvalidNotificationTimestamp  = response.NewError(400, "invalid notification timestamp")
+	ErrInvalidIdempotencyKey         = response.NewError(400, "idempotency key must be at most 255 characters")
+	ErrReconciliationInProgress      = response.NewError(409, "a reconciliation run is already in progress")
+	ErrBankAccountNotFound           = response.NewError(404, "bank account not found")
+	ErrBankAccountNotVerified        = response.NewError(400, "bank account has not been verified")
+	ErrBankAccountVerificationFailed = response.NewError(400, "bank account could not be verified")
+	ErrUnsupportedWithdrawalBank     = response.NewError(400, "bank is not supported for withdrawals")
+	ErrWithdrawalNotFound            = response.NewError(404, "withdrawal not found")
+	ErrWithdrawalNotProcessing       = response.NewError(409, "withdrawal has already been settled")
+	ErrQRISPaymentStateChanged       = response.NewError(409, "QRIS payment is no longer in the expected state")
+	ErrQRISPaymentDeclined           = response.NewError(400, "QRIS payment was declined")
+	ErrInvalidStatementRange         = response.NewError(400, "statement range is invalid")
+	ErrStatementRangeTooLarge        = response.NewError(400, "statement range cannot exceed one year")
+	ErrStatementJobNotFound          = response.NewError(404, "statement job not found")
+	ErrInvalidCursor                 = response.NewError(400, "pagination cursor is invalid")
+	ErrInvalidHistoryRange           = response.NewError(400, "transaction history range is invalid")
+	ErrQRISGatewayUnavailable        = response.NewError(503, "QRIS payments are temporarily unavailable")
+	ErrCreateQRISReceive             = response.NewError(500, "failed to create QRIS code")
+	ErrPaymentRequestNotFound        = response.NewError(404, "payment request not found")
+	ErrPaymentRequestClosed          = response.NewError(409, "payment request is no longer open")
+	ErrPaymentRequestAnswered        = response.NewError(409, "payment request has already been answered")
+	ErrDuplicateParticipant          = response.NewError(400, "each participant can only be asked once")
+	ErrSplitExceedsTotal             = response.NewError(400, "split shares exceed the payment total")
+	ErrSplitAmountRequired           = response.NewError(400, "custom split needs an amount for every participant")
+	ErrReminderTooSoon               = response.NewError(429, "participants were reminded recently")
+	ErrQRISPaymentNotFound           = response.NewError(404, "QRIS payment not found")
+	ErrQRISPaymentNotSettled         = response.NewError(409, "QRIS payment has not settled")
+	ErrSelfPaymentRequest            = response.NewError(400, "cannot request payment from yourself")
+	ErrSimulatorDisabled             = response.NewError(404, "payment simulator is not enabled")
+	ErrUnknownPaymentProvider        = response.NewError(404, "unknown payment provider")
+	ErrVirtualAccountNotFound        = response.NewError(404, "virtual account not found")
+	ErrPaymentNotificationNotFound   = response.NewError(404, "payment notification not found")
+	ErrNotificationNotReplayable     = response.NewError(409, "only verified payment notifications can be replayed")
+	ErrWalletEventsUnavailable       = response.NewError(503, "wallet events are not available")
+	ErrWalletFrozen                  = response.NewError(403, "wallet is frozen")
+	ErrWalletClosed                  = response.NewError(403, "wallet is closed")
+	ErrWalletNotFrozen               = response.NewError(409, "wallet is not frozen")
+	ErrRiskChallengeRequired         = response.NewError(428, "additional verification required, repeat the request with the OTP sent to your phone number")
+	ErrRiskDenied                    = response.NewError(403, "transaction was declined by risk checks")
+	ErrOTPAttemptExceeded            = response.NewError(429, "too many OTP attempts, request a new OTP")
 )
@@ -0,0 +1,143 @@
+package sentrapayHandler
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/gateway"
+	"ProjectGolang/pkg/log"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/net/context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PaymentCallback receives payment notifications from the provider named in
+// the path; the bare /wallet/callback route predates multiple providers and
+// stays DOKU's. DOKU is answered in SNAP format and keeps retrying anything
+// that is not 2002500, so notifications we have already applied are
+// acknowledged as success. Other providers only look at the HTTP status.
+func (h *SentraPayHandler) PaymentCallback(ctx *fiber.Ctx) error {
+	requestID := h.middleware.GetRequestID(ctx)
+	c, cancel := context.WithTimeout(contextPkg.FromFiberCtx(ctx), 10*time.Second)
+	defer cancel()
+
+	provider := strings.ToLower(ctx.Params("provider", gateway.ProviderDOKU))
+
+	h.log.WithFields(log.Fields{
+		"request_id": requestID,
+		"provider":   provider,
+		"path":       ctx.Path(),
+	}).Debug("Processing payment callback")
+
+	headers := make(map[string]string)
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		headers[http.CanonicalHeaderKey(string(key))] = string(value)
+	})
+
+	notification := sentrapay.PaymentNotification{
+		HTTPMethod:  ctx.Method(),
+		EndpointURL: ctx.OriginalURL(),
+		Body:        append([]byte(nil), ctx.Body()...),
+		Headers:     headers,
+	}
+
+	req, err := h.sentraPayService.HandlePaymentNotification(c, provider, notification)
+	if err == nil {
+		err = c.Err()
+	}
+
+	if provider != gateway.ProviderDOKU {
+		return h.providerCallbackResponse(ctx, requestID, err)
+	}
+
+	if err != nil {
+		return h.snapCallbackError(ctx, requestID, err)
+	}
+
+	h.log.WithFields(log.Fields{
+		"request_id":    requestID,
+		"channelID":     ctx.Get("CHANNEL-ID"),
+		"xExternalID":   ctx.Get("X-EXTERNAL-ID"),
+		"xTimestamp":    ctx.Get("X-TIMESTAMP"),
+		"trxId":         req.TrxId,
+		"paidAmount":    req.PaidAmount.Value,
+		"paymentMethod": req.AdditionalInfo.Channel,
+	}).Info("Received payment callback")
+
+	return ctx.Status(fiber.StatusOK).JSON(sentrapay.SNAPNotificationResponse{
+		ResponseCode:    sentrapay.SNAPNotificationSuccess,
+		ResponseMessage: "Successful",
+		VirtualAccountData: &sentrapay.SNAPNotificationVAData{
+			PartnerServiceId:   req.PartnerServiceId,
+			CustomerNo:         req.CustomerNo,
+			VirtualAccountNo:   req.VirtualAccountNo,
+			VirtualAccountName: req.VirtualAccountName,
+			PaymentRequestId:   req.PaymentRequestId,
+			TrxId:              req.TrxId,
+			TrxDateTime:        req.TrxDateTime,
+			AdditionalInfo: map[string]interface{}{
+				"channel": req.AdditionalInfo.Channel,
+			},
+		},
+	})
+}
+
+// providerCallbackResponse answers providers without a response format of
+// their own. Failures that a retry can fix get a 5xx so they are redelivered.
+func (h *SentraPayHandler) providerCallbackResponse(ctx *fiber.Ctx, requestID string, err error) error {
+	if err == nil {
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{"message": "OK"})
+	}
+
+	status, code, message := snapNotificationError(err)
+
+	h.log.WithFields(log.Fields{
+		"request_id":    requestID,
+		"path":          ctx.Path(),
+		"response_code": code,
+		"error":         err.Error(),
+	}).Warn("Payment callback rejected")
+
+	return ctx.Status(status).JSON(fiber.Map{"message": message})
+}
+
+func (h *SentraPayHandler) snapCallbackError(ctx *fiber.Ctx, requestID string, err error) error {
+	status, code, message := snapNotificationError(err)
+
+	h.log.WithFields(log.Fields{
+		"request_id":    requestID,
+		"path":          ctx.Path(),
+		"response_code": code,
+		"error":         err.Error(),
+	}).Warn("Payment callback rejected")
+
+	return ctx.Status(status).JSON(sentrapay.SNAPNotificationResponse{
+		ResponseCode:    code,
+		ResponseMessage: message,
+	})
+}
+
+func snapNotificationError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, sentrapay.ErrInvalidSignature), errors.Is(err, sentrapay.ErrStaleNotification):
+		return fiber.StatusUnauthorized, sentrapay.SNAPNotificationUnauthorized, "Unauthorized. " + err.Error()
+	case errors.Is(err, sentrapay.ErrInvalidNotificationTimestamp):
+		return fiber.StatusBadRequest, sentrapay.SNAPNotificationInvalidFieldFormat, "Invalid Field Format X-TIMESTAMP"
+	case errors.Is(err, sentrapay.ErrInvalidCallback):
+		return fiber.StatusBadRequest, sentrapay.SNAPNotificationMissingField, "Invalid Mandatory Field"
+	case errors.Is(err, sentrapay.ErrTransactionNotFound):
+		return fiber.StatusNotFound, sentrapay.SNAPNotificationBillNotFound, "Bill not found"
+	case errors.Is(err, sentrapay.ErrInvalidAmount):
+		return fiber.StatusNotFound, sentrapay.SNAPNotificationInvalidAmount, "Invalid Amount"
+	case errors.Is(err, sentrapay.ErrTransactionExpired):
+		return fiber.StatusNotFound, sentrapay.SNAPNotificationInvalidBill, "Invalid Bill/Virtual Account. Bill expired"
+	case errors.Is(err, sentrapay.ErrUnknownPaymentProvider):
+		return fiber.StatusNotFound, sentrapay.SNAPNotificationBillNotFound, "Unknown payment provider"
+	case errors.Is(err, sentrapay.ErrInvalidTransactionState), errors.Is(err, sentrapay.ErrUnmatchedPayment):
+		return fiber.StatusNotFound, sentrapay.SNAPNotificationInvalidBill, "Invalid Bill/Virtual Account"
+	default:
+		return fiber.StatusInternalServerError, sentrapay.SNAPNotificationGeneralError, "General Error"
+	}
+}
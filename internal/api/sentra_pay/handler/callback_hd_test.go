@@ -0,0 +1,306 @@
+package sentrapayHandler
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayService "ProjectGolang/internal/api/sentra_pay/service"
+	"ProjectGolang/internal/middleware"
+	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/gateway"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const callbackPath = "/api/v1/wallet/callback"
+
+const callbackBody = `{
+	"partnerServiceId": "   84923",
+	"customerNo": "000000000001",
+	"virtualAccountNo": "   84923000000000001",
+	"virtualAccountName": "Budi Santoso",
+	"trxId": "TOP-01J0000000000000000000000",
+	"paymentRequestId": "SIM1",
+	"paidAmount": {"value": "150000.00", "currency": "IDR"},
+	"trxDateTime": "2026-03-10T21:30:00+07:00",
+	"additionalInfo": {"channel": "VIRTUAL_ACCOUNT_BCA"}
+}`
+
+func newTestLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return log
+}
+
+// notificationService authenticates notifications with the DOKU signature
+// rules against a single key, so the handler can be exercised with requests
+// that are signed the way DOKU signs them. err replaces the result of a
+// notification that authenticated.
+type notificationService struct {
+	sentrapayService.ISentraPayService
+
+	publicKey *rsa.PublicKey
+	err       error
+
+	received sentrapay.PaymentNotification
+}
+
+func (s *notificationService) HandlePaymentNotification(ctx context.Context, provider string, notification sentrapay.PaymentNotification) (*sentrapay.PaymentCallbackRequest, error) {
+	s.received = notification
+	if provider != gateway.ProviderDOKU {
+		return nil, sentrapay.ErrUnknownPaymentProvider
+	}
+
+	headers := gateway.Notification{Headers: notification.Headers}
+	signed := doku.Notification{
+		HTTPMethod:  notification.HTTPMethod,
+		EndpointURL: notification.EndpointURL,
+		Body:        notification.Body,
+		Timestamp:   headers.Header("X-TIMESTAMP"),
+		Signature:   headers.Header("X-SIGNATURE"),
+	}
+
+	err := doku.CheckNotificationTimestamp(signed.Timestamp, time.Now(), doku.NotificationTimestampWindow)
+	switch {
+	case errors.Is(err, doku.ErrInvalidTimestamp):
+		return nil, sentrapay.ErrInvalidNotificationTimestamp
+	case errors.Is(err, doku.ErrStaleTimestamp):
+		return nil, sentrapay.ErrStaleNotification
+	}
+	if err := doku.VerifyNotificationSignature(s.publicKey, signed); err != nil {
+		return nil, sentrapay.ErrInvalidSignature
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var req sentrapay.PaymentCallbackRequest
+	if err := json.Unmarshal(notification.Body, &req); err != nil {
+		return nil, sentrapay.ErrInvalidCallback
+	}
+	return &req, nil
+}
+
+type callbackTest struct {
+	app     *fiber.App
+	key     *rsa.PrivateKey
+	service *notificationService
+}
+
+func newCallbackTest(t *testing.T) *callbackTest {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	log := newTestLogger()
+	service := &notificationService{publicKey: &key.PublicKey}
+	handler := New(log, nil, middleware.New(log), service)
+
+	app := fiber.New()
+	app.Post(callbackPath, handler.PaymentCallback)
+	app.Post(callbackPath+"/:provider", handler.PaymentCallback)
+
+	return &callbackTest{app: app, key: key, service: service}
+}
+
+// request builds a callback request for path signed with key at timestamp.
+func (c *callbackTest) request(t *testing.T, path string, key *rsa.PrivateKey, timestamp string) *http.Request {
+	t.Helper()
+
+	signature, err := doku.SignNotification(key, doku.Notification{
+		HTTPMethod:  http.MethodPost,
+		EndpointURL: path,
+		Body:        []byte(callbackBody),
+		Timestamp:   timestamp,
+	})
+	if err != nil {
+		t.Fatalf("sign notification: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(callbackBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-TIMESTAMP", timestamp)
+	req.Header.Set("X-SIGNATURE", signature)
+	req.Header.Set("X-PARTNER-ID", "BRN-0001-0000000000000")
+	return req
+}
+
+func (c *callbackTest) do(t *testing.T, req *http.Request) (int, sentrapay.SNAPNotificationResponse) {
+	t.Helper()
+
+	resp, err := c.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("callback request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body sentrapay.SNAPNotificationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode callback response: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestPaymentCallbackAcknowledgesInSNAPFormat(t *testing.T) {
+	for _, path := range []string{callbackPath, callbackPath + "/doku", callbackPath + "/DOKU"} {
+		t.Run(path, func(t *testing.T) {
+			c := newCallbackTest(t)
+
+			status, body := c.do(t, c.request(t, path, c.key, time.Now().Format(time.RFC3339)))
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d, want %d", status, fiber.StatusOK)
+			}
+			if body.ResponseCode != sentrapay.SNAPNotificationSuccess || body.ResponseMessage != "Successful" {
+				t.Fatalf("response = %s %q, want %s Successful", body.ResponseCode, body.ResponseMessage, sentrapay.SNAPNotificationSuccess)
+			}
+
+			data := body.VirtualAccountData
+			if data == nil {
+				t.Fatal("virtualAccountData missing from the success response")
+			}
+			if data.TrxId != "TOP-01J0000000000000000000000" ||
+				data.PaymentRequestId != "SIM1" ||
+				data.VirtualAccountNo != "   84923000000000001" ||
+				data.PartnerServiceId != "   84923" ||
+				data.CustomerNo != "000000000001" ||
+				data.VirtualAccountName != "Budi Santoso" ||
+				data.TrxDateTime != "2026-03-10T21:30:00+07:00" {
+				t.Fatalf("virtualAccountData = %+v does not echo the notification", data)
+			}
+			if data.AdditionalInfo["channel"] != "VIRTUAL_ACCOUNT_BCA" {
+				t.Fatalf("additionalInfo = %v, want channel VIRTUAL_ACCOUNT_BCA", data.AdditionalInfo)
+			}
+
+			received := c.service.received
+			if received.EndpointURL != path || received.HTTPMethod != http.MethodPost {
+				t.Fatalf("service received %s %s, want POST %s", received.HTTPMethod, received.EndpointURL, path)
+			}
+			if string(received.Body) != callbackBody {
+				t.Fatal("service did not receive the body byte for byte")
+			}
+		})
+	}
+}
+
+func TestPaymentCallbackRejections(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		key        *rsa.PrivateKey
+		timestamp  string
+		modify     func(*http.Request)
+		serviceErr error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "signed with another key",
+			key:        otherKey,
+			wantStatus: fiber.StatusUnauthorized,
+			wantCode:   sentrapay.SNAPNotificationUnauthorized,
+		},
+		{
+			name: "tampered body",
+			modify: func(req *http.Request) {
+				tampered := bytes.Replace([]byte(callbackBody), []byte("150000.00"), []byte("950000.00"), 1)
+				req.Body = io.NopCloser(bytes.NewReader(tampered))
+				req.ContentLength = int64(len(tampered))
+			},
+			wantStatus: fiber.StatusUnauthorized,
+			wantCode:   sentrapay.SNAPNotificationUnauthorized,
+		},
+		{
+			name: "missing signature",
+			modify: func(req *http.Request) {
+				req.Header.Del("X-SIGNATURE")
+			},
+			wantStatus: fiber.StatusUnauthorized,
+			wantCode:   sentrapay.SNAPNotificationUnauthorized,
+		},
+		{
+			name:       "stale timestamp",
+			timestamp:  time.Now().Add(-doku.NotificationTimestampWindow - time.Minute).Format(time.RFC3339),
+			wantStatus: fiber.StatusUnauthorized,
+			wantCode:   sentrapay.SNAPNotificationUnauthorized,
+		},
+		{
+			name:       "malformed timestamp",
+			timestamp:  time.Now().Format(time.RFC1123),
+			wantStatus: fiber.StatusBadRequest,
+			wantCode:   sentrapay.SNAPNotificationInvalidFieldFormat,
+		},
+		{
+			name:       "unknown bill",
+			serviceErr: sentrapay.ErrTransactionNotFound,
+			wantStatus: fiber.StatusNotFound,
+			wantCode:   sentrapay.SNAPNotificationBillNotFound,
+		},
+		{
+			name:       "amount does not match the bill",
+			serviceErr: sentrapay.ErrInvalidAmount,
+			wantStatus: fiber.StatusNotFound,
+			wantCode:   sentrapay.SNAPNotificationInvalidAmount,
+		},
+		{
+			name:       "expired bill",
+			serviceErr: sentrapay.ErrTransactionExpired,
+			wantStatus: fiber.StatusNotFound,
+			wantCode:   sentrapay.SNAPNotificationInvalidBill,
+		},
+		{
+			name:       "internal failure",
+			serviceErr: errors.New("database unavailable"),
+			wantStatus: fiber.StatusInternalServerError,
+			wantCode:   sentrapay.SNAPNotificationGeneralError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCallbackTest(t)
+			c.service.err = tt.serviceErr
+
+			key := c.key
+			if tt.key != nil {
+				key = tt.key
+			}
+			timestamp := tt.timestamp
+			if timestamp == "" {
+				timestamp = time.Now().Format(time.RFC3339)
+			}
+
+			req := c.request(t, callbackPath, key, timestamp)
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+
+			status, body := c.do(t, req)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if body.ResponseCode != tt.wantCode {
+				t.Fatalf("responseCode = %s (%s), want %s", body.ResponseCode, body.ResponseMessage, tt.wantCode)
+			}
+			if body.VirtualAccountData != nil {
+				t.Fatalf("rejection echoed virtualAccountData %+v", body.VirtualAccountData)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package sentrapayHandler
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/events"
+	"ProjectGolang/pkg/handlerUtil"
+	jwtPkg "ProjectGolang/pkg/jwt"
+	"ProjectGolang/pkg/log"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/net/context"
+	"time"
+)
+
+// walletEventsKeepAlive keeps idle streams open through proxies and notices
+// clients that have gone away.
+const walletEventsKeepAlive = 15 * time.Second
+
+// StreamWalletEvents streams the user's balance changes and transaction
+// status transitions as server-sent events. The first event is the current
+// balance.
+func (h *SentraPayHandler) StreamWalletEvents(ctx *fiber.Ctx) error {
+	requestID := h.middleware.GetRequestID(ctx)
+	c, cancel := context.WithTimeout(contextPkg.FromFiberCtx(ctx), 10*time.Second)
+	defer cancel()
+
+	errHandler := handlerUtil.New(h.log)
+
+	h.log.WithFields(log.Fields{
+		"request_id": requestID,
+		"path":       ctx.Path(),
+	}).Debug("Processing wallet events request")
+
+	userData, err := jwtPkg.GetUserLoginData(ctx)
+	if err != nil {
+		return errHandler.HandleUnauthorized(ctx, requestID, "Unauthorized")
+	}
+
+	subscription, balance, err := h.sentraPayService.SubscribeWalletEvents(c, userData.ID)
+	if err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "subscribe_wallet_events")
+	}
+
+	initial, err := json.Marshal(balance)
+	if err != nil {
+		subscription.Close()
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "subscribe_wallet_events")
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer subscription.Close()
+
+		keepAlive := time.NewTicker(walletEventsKeepAlive)
+		defer keepAlive.Stop()
+
+		if err := writeWalletEvent(w, events.Event{
+			Type: sentrapay.WalletEventBalance,
+			Data: initial,
+			At:   time.Now(),
+		}); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-subscription.C:
+				if !ok {
+					return
+				}
+				if err := writeWalletEvent(w, event); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := w.WriteString(": keep-alive\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeWalletEvent(w *bufio.Writer, event events.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,542 @@
+package sentrapayHandler
+
+import (
+	authRepository "ProjectGolang/internal/api/auth/repository"
+	"ProjectGolang/internal/api/budget_manager"
+	budgetService "ProjectGolang/internal/api/budget_manager/service"
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	"ProjectGolang/internal/entity"
+	"ProjectGolang/pkg/money"
+	"errors"
+	"golang.org/x/net/context"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errFakeNotImplemented = errors.New("not implemented by fake")
+
+// memoryStore is a wallet repository kept in memory. Writes apply
+// immediately and Rollback undoes nothing, so it only stands in for
+// database transactions that commit.
+type memoryStore struct {
+	mu sync.Mutex
+
+	wallets       map[string]sentrapay.WalletBalance
+	transactions  []sentrapay.WalletTransaction
+	history       []sentrapay.TransactionStatusHistory
+	journals      []sentrapay.LedgerJournal
+	entries       []sentrapay.LedgerEntry
+	accounts      []sentrapay.UserVirtualAccount
+	sequence      int64
+	notifications []sentrapay.PaymentNotificationRecord
+	decisions     []sentrapay.RiskDecision
+}
+
+var _ sentrapayRepository.Repository = (*memoryStore)(nil)
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{wallets: make(map[string]sentrapay.WalletBalance)}
+}
+
+func (s *memoryStore) NewClient(tx bool) (sentrapayRepository.Client, error) {
+	return sentrapayRepository.Client{
+		Wallet:              &memoryWallets{s},
+		Ledger:              &memoryLedger{s},
+		VirtualAccount:      &memoryVirtualAccounts{s},
+		PaymentNotification: &memoryNotifications{s},
+		Risk:                &memoryRisk{s},
+		Commit:              func() error { return nil },
+		Rollback:            func() error { return nil },
+	}, nil
+}
+
+type memoryWallets struct{ s *memoryStore }
+
+func (r *memoryWallets) CreateWallet(ctx context.Context, userID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.wallets[userID]; !ok {
+		r.s.wallets[userID] = sentrapay.WalletBalance{UserID: userID, Status: sentrapay.WalletActive, LastUpdated: time.Now()}
+	}
+	return nil
+}
+
+func (r *memoryWallets) GetWallet(ctx context.Context, userID string) (sentrapay.WalletBalance, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wallet, ok := r.s.wallets[userID]
+	if !ok {
+		return sentrapay.WalletBalance{}, sentrapay.ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+func (r *memoryWallets) GetWalletForUpdate(ctx context.Context, userID string) (sentrapay.WalletBalance, error) {
+	return r.GetWallet(ctx, userID)
+}
+
+func (r *memoryWallets) TransitionStatus(ctx context.Context, userID, from, to, reason string, at time.Time) (bool, error) {
+	return false, errFakeNotImplemented
+}
+
+func (r *memoryWallets) ApplyBalanceDelta(ctx context.Context, userID string, delta money.Amount) (money.Amount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	wallet, ok := r.s.wallets[userID]
+	if !ok {
+		return 0, sentrapay.ErrWalletNotFound
+	}
+	if wallet.Balance+delta < 0 {
+		return 0, sentrapay.ErrInsufficientBalance
+	}
+
+	wallet.Balance += delta
+	wallet.LastUpdated = time.Now()
+	r.s.wallets[userID] = wallet
+
+	return wallet.Balance, nil
+}
+
+func (r *memoryWallets) CreateTransaction(ctx context.Context, transaction sentrapay.WalletTransaction) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.transactions = append(r.s.transactions, transaction)
+	return nil
+}
+
+func (r *memoryWallets) find(match func(sentrapay.WalletTransaction) bool) (sentrapay.WalletTransaction, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, transaction := range r.s.transactions {
+		if match(transaction) {
+			return transaction, nil
+		}
+	}
+	return sentrapay.WalletTransaction{}, sentrapay.ErrTransactionNotFound
+}
+
+func (r *memoryWallets) GetTransactionByID(ctx context.Context, id string) (sentrapay.WalletTransaction, error) {
+	return r.find(func(t sentrapay.WalletTransaction) bool {
+		return t.ID == id
+	})
+}
+
+func (r *memoryWallets) GetTransactionByReferenceNo(ctx context.Context, userID, referenceNo string) (sentrapay.WalletTransaction, error) {
+	return r.find(func(t sentrapay.WalletTransaction) bool {
+		return t.UserID == userID && t.ReferenceNo == referenceNo
+	})
+}
+
+func (r *memoryWallets) GetTransactionsByReferenceNo(ctx context.Context, referenceNo string) ([]sentrapay.WalletTransaction, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var transactions []sentrapay.WalletTransaction
+	for _, transaction := range r.s.transactions {
+		if transaction.ReferenceNo == referenceNo {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (r *memoryWallets) GetPendingTopUpByVirtualAccount(ctx context.Context, gateway, virtualAccountNo string, amount money.Amount, now time.Time) (sentrapay.WalletTransaction, error) {
+	return r.find(func(t sentrapay.WalletTransaction) bool {
+		return t.Type == "topup" &&
+			t.Status == sentrapay.TransactionPending &&
+			t.PaymentMethod == "virtual_account" &&
+			strings.TrimSpace(t.BankAccount) == virtualAccountNo &&
+			t.Amount == amount &&
+			t.Gateway == gateway &&
+			(t.ExpiresAt == nil || t.ExpiresAt.After(now))
+	})
+}
+
+func (r *memoryWallets) GetTransactionByGatewayPayment(ctx context.Context, gateway, paymentID string) (sentrapay.WalletTransaction, error) {
+	return r.find(func(t sentrapay.WalletTransaction) bool {
+		return t.Gateway == gateway && t.GatewayPaymentID == paymentID
+	})
+}
+
+func (r *memoryWallets) update(referenceNo string, apply func(*sentrapay.WalletTransaction) error) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	found := false
+	for i := range r.s.transactions {
+		if r.s.transactions[i].ReferenceNo != referenceNo {
+			continue
+		}
+		if err := apply(&r.s.transactions[i]); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return sentrapay.ErrTransactionNotFound
+	}
+	return nil
+}
+
+func (r *memoryWallets) SetTransactionGatewayPayment(ctx context.Context, referenceNo, gateway, paymentID string) error {
+	return r.update(referenceNo, func(t *sentrapay.WalletTransaction) error {
+		t.Gateway = gateway
+		t.GatewayPaymentID = paymentID
+		return nil
+	})
+}
+
+func (r *memoryWallets) UpdateTransactionStatus(ctx context.Context, referenceNo string, to sentrapay.TransactionStatus, change sentrapay.StatusChange) error {
+	return r.update(referenceNo, func(t *sentrapay.WalletTransaction) error {
+		if !t.Status.CanTransitionTo(to) {
+			return sentrapay.ErrInvalidTransactionState
+		}
+
+		r.s.history = append(r.s.history, sentrapay.TransactionStatusHistory{
+			ID:            int64(len(r.s.history) + 1),
+			TransactionID: t.ID,
+			ReferenceNo:   t.ReferenceNo,
+			FromStatus:    t.Status,
+			ToStatus:      to,
+			Reason:        change.Reason,
+			Actor:         change.Actor,
+			CreatedAt:     time.Now(),
+		})
+
+		t.Status = to
+		t.StatusReason = change.Reason
+		t.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *memoryWallets) GetTransactionStatusHistory(ctx context.Context, userID, referenceNo string) ([]sentrapay.TransactionStatusHistory, error) {
+	transaction, err := r.GetTransactionByReferenceNo(ctx, userID, referenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var history []sentrapay.TransactionStatusHistory
+	for _, change := range r.s.history {
+		if change.TransactionID == transaction.ID {
+			history = append(history, change)
+		}
+	}
+	return history, nil
+}
+
+func (r *memoryWallets) GetTransactionsByUserID(ctx context.Context, userID string, filter sentrapay.TransactionFilter, after *sentrapay.TransactionCursor, limit int) ([]sentrapay.WalletTransaction, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *memoryWallets) SummarizeTransactions(ctx context.Context, userID string, filter sentrapay.TransactionFilter) (sentrapay.TransactionSummary, error) {
+	return sentrapay.TransactionSummary{}, errFakeNotImplemented
+}
+
+func (r *memoryWallets) LockSpending(ctx context.Context, userID string) error {
+	return errFakeNotImplemented
+}
+
+func (r *memoryWallets) SumDebitsSince(ctx context.Context, userID string, since time.Time) (money.Amount, error) {
+	return 0, errFakeNotImplemented
+}
+
+func (r *memoryWallets) ExpirePendingTopUps(ctx context.Context, now time.Time, change sentrapay.StatusChange, limit int) ([]string, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *memoryWallets) UpdateTransactionStatusReason(ctx context.Context, referenceNo string, reason string) error {
+	return errFakeNotImplemented
+}
+
+func (r *memoryWallets) SetOpenTransactionAmount(ctx context.Context, referenceNo string, amount money.Amount) error {
+	return errFakeNotImplemented
+}
+
+func (r *memoryWallets) GetOpenTopUps(ctx context.Context, afterID string, limit int) ([]sentrapay.WalletTransaction, error) {
+	return nil, errFakeNotImplemented
+}
+
+type memoryLedger struct{ s *memoryStore }
+
+func (r *memoryLedger) CreateJournal(ctx context.Context, journal sentrapay.LedgerJournal) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, posted := range r.s.journals {
+		if posted.JournalKey == journal.JournalKey {
+			return sentrapay.ErrJournalAlreadyPosted
+		}
+	}
+	r.s.journals = append(r.s.journals, journal)
+	return nil
+}
+
+func (r *memoryLedger) CreateEntry(ctx context.Context, entry sentrapay.LedgerEntry) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.entries = append(r.s.entries, entry)
+	return nil
+}
+
+func (r *memoryLedger) CountWallets(ctx context.Context) (int, error) {
+	return 0, errFakeNotImplemented
+}
+
+func (r *memoryLedger) GetBalanceMismatches(ctx context.Context) ([]sentrapay.LedgerBalanceMismatch, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *memoryLedger) GetUnbalancedJournals(ctx context.Context) ([]sentrapay.UnbalancedJournal, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *memoryLedger) GetWalletBalanceAt(ctx context.Context, userID string, at time.Time) (money.Amount, error) {
+	return 0, errFakeNotImplemented
+}
+
+func (r *memoryLedger) GetWalletEntries(ctx context.Context, userID string, from, to time.Time, after *sentrapay.TransactionCursor, limit int) ([]sentrapay.WalletLedgerEntry, error) {
+	return nil, errFakeNotImplemented
+}
+
+type memoryVirtualAccounts struct{ s *memoryStore }
+
+func (r *memoryVirtualAccounts) NextCustomerSequence(ctx context.Context) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.sequence++
+	return r.s.sequence, nil
+}
+
+func (r *memoryVirtualAccounts) Create(ctx context.Context, account sentrapay.UserVirtualAccount) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.accounts {
+		if existing.UserID == account.UserID && existing.Channel == account.Channel {
+			return false, nil
+		}
+	}
+	r.s.accounts = append(r.s.accounts, account)
+	return true, nil
+}
+
+func (r *memoryVirtualAccounts) Get(ctx context.Context, userID, channel string) (sentrapay.UserVirtualAccount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, account := range r.s.accounts {
+		if account.UserID == userID && account.Channel == channel {
+			return account, nil
+		}
+	}
+	return sentrapay.UserVirtualAccount{}, sentrapay.ErrVirtualAccountNotFound
+}
+
+func (r *memoryVirtualAccounts) GetByNumber(ctx context.Context, gateway, virtualAccountNo string) (sentrapay.UserVirtualAccount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, account := range r.s.accounts {
+		if account.Gateway == gateway && strings.TrimSpace(account.VirtualAccountNo) == strings.TrimSpace(virtualAccountNo) {
+			return account, nil
+		}
+	}
+	return sentrapay.UserVirtualAccount{}, sentrapay.ErrVirtualAccountNotFound
+}
+
+func (r *memoryVirtualAccounts) GetByUserID(ctx context.Context, userID string) ([]sentrapay.UserVirtualAccount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var accounts []sentrapay.UserVirtualAccount
+	for _, account := range r.s.accounts {
+		if account.UserID == userID {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+func (r *memoryVirtualAccounts) UpdateNumber(ctx context.Context, account sentrapay.UserVirtualAccount) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for i := range r.s.accounts {
+		if r.s.accounts[i].ID == account.ID {
+			r.s.accounts[i] = account
+			return nil
+		}
+	}
+	return sentrapay.ErrVirtualAccountNotFound
+}
+
+type memoryNotifications struct{ s *memoryStore }
+
+func (r *memoryNotifications) Create(ctx context.Context, notification sentrapay.PaymentNotificationRecord) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.notifications = append(r.s.notifications, notification)
+	return nil
+}
+
+func (r *memoryNotifications) Update(ctx context.Context, notification sentrapay.PaymentNotificationRecord) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for i := range r.s.notifications {
+		if r.s.notifications[i].ID == notification.ID {
+			r.s.notifications[i] = notification
+			return nil
+		}
+	}
+	return sentrapay.ErrPaymentNotificationNotFound
+}
+
+func (r *memoryNotifications) GetByID(ctx context.Context, id string) (sentrapay.PaymentNotificationRecord, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, notification := range r.s.notifications {
+		if notification.ID == id {
+			return notification, nil
+		}
+	}
+	return sentrapay.PaymentNotificationRecord{}, sentrapay.ErrPaymentNotificationNotFound
+}
+
+func (r *memoryNotifications) GetList(ctx context.Context, outcome, referenceNo string, limit int) ([]sentrapay.PaymentNotificationRecord, error) {
+	return nil, errFakeNotImplemented
+}
+
+// memoryRisk records risk decisions. History lookups are not supported, so
+// the service under test must run without rules.
+type memoryRisk struct{ s *memoryStore }
+
+func (r *memoryRisk) CountMovementsSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	return 0, errFakeNotImplemented
+}
+
+func (r *memoryRisk) MovementTimesSince(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *memoryRisk) HasPaidPayee(ctx context.Context, userID, kind, payee string) (bool, error) {
+	return false, errFakeNotImplemented
+}
+
+func (r *memoryRisk) KnownDevices(ctx context.Context, userID string) ([]string, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *memoryRisk) RememberDevice(ctx context.Context, userID, deviceID string, seenAt time.Time) error {
+	return errFakeNotImplemented
+}
+
+func (r *memoryRisk) CreateDecision(ctx context.Context, decision sentrapay.RiskDecision) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	r.s.decisions = append(r.s.decisions, decision)
+	return nil
+}
+
+func (r *memoryRisk) GetDecisions(ctx context.Context, outcome, userID string, limit int) ([]sentrapay.RiskDecision, error) {
+	return nil, errFakeNotImplemented
+}
+
+// fakeAuthRepository serves users from memory. Only lookups by ID are
+// supported.
+type fakeAuthRepository struct {
+	users map[string]entity.User
+}
+
+func (r *fakeAuthRepository) NewClient(tx bool) (authRepository.Client, error) {
+	return authRepository.Client{
+		Users:    &fakeUserRepository{users: r.users},
+		Commit:   func() error { return nil },
+		Rollback: func() error { return nil },
+	}, nil
+}
+
+type fakeUserRepository struct {
+	users map[string]entity.User
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id string) (entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return entity.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) CreateUser(ctx context.Context, user entity.User) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) GetByPhoneNumber(ctx context.Context, phoneNumber string) (entity.User, error) {
+	return entity.User{}, errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (entity.User, error) {
+	return entity.User{}, errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateUser(ctx context.Context, user entity.User) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateUserPIN(ctx context.Context, phoneNum string, pin string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateUserPassword(ctx context.Context, phoneNum string, password string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) DeleteUser(ctx context.Context, id string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) EnableTouchID(ctx context.Context, id string, hash string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateProfilePhoto(ctx context.Context, id string, photoURL string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateFacePhoto(ctx context.Context, id string, facePhotoURL string) error {
+	return errFakeNotImplemented
+}
+
+// fakeBudgetService records the wallet transactions offered to the budget
+// manager. Any other call panics.
+type fakeBudgetService struct {
+	budgetService.IBudgetService
+
+	mu      sync.Mutex
+	entries []budget_manager.WalletEntry
+}
+
+func (s *fakeBudgetService) RecordWalletTransaction(ctx context.Context, entry budget_manager.WalletEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entry)
+	return nil
+}
@@ -33,12 +33,44 @@ func (h *SentraPayHandler) Start(srv fiber.Router) {
 	wallet := srv.Group("/wallet")
 
 	wallet.Post("/topup", h.middleware.NewTokenMiddleware, h.CreateTopUp)
+	wallet.Get("/virtual-accounts", h.middleware.NewTokenMiddleware, h.GetVirtualAccounts)
 	wallet.Get("/balance", h.middleware.NewTokenMiddleware, h.GetWalletBalance)
+	wallet.Get("/events", h.middleware.NewTokenMiddleware, h.StreamWalletEvents)
 	wallet.Get("/transactions", h.middleware.NewTokenMiddleware, h.GetTransactionHistory)
 	wallet.Get("/transactions/status/:reference_no", h.middleware.NewTokenMiddleware, h.CheckTransactionStatus)
+	wallet.Post("/transfer", h.middleware.NewTokenMiddleware, h.TransferBalance)
+	wallet.Get("/limits", h.middleware.NewTokenMiddleware, h.GetWalletLimits)
+	wallet.Patch("/limits", h.middleware.NewTokenMiddleware, h.UpdateWalletLimits)
+	wallet.Post("/freeze", h.middleware.NewTokenMiddleware, h.FreezeWallet)
+	wallet.Post("/unfreeze", h.middleware.NewTokenMiddleware, h.UnfreezeWallet)
+	wallet.Get("/statement", h.middleware.NewTokenMiddleware, h.GetStatement)
+	wallet.Get("/statement/jobs/:id", h.middleware.NewTokenMiddleware, h.GetStatementJob)
+
+	wallet.Post("/bank-accounts", h.middleware.NewTokenMiddleware, h.RegisterBankAccount)
+	wallet.Get("/bank-accounts", h.middleware.NewTokenMiddleware, h.GetBankAccounts)
+	wallet.Post("/bank-accounts/:id/verify", h.middleware.NewTokenMiddleware, h.VerifyBankAccount)
+	wallet.Post("/withdrawals", h.middleware.NewTokenMiddleware, h.RequestWithdrawal)
+	wallet.Get("/withdrawals/:reference_no", h.middleware.NewTokenMiddleware, h.GetWithdrawal)
+
+	wallet.Post("/payment-requests", h.middleware.NewTokenMiddleware, h.CreatePaymentRequest)
+	wallet.Post("/payment-requests/split-qris", h.middleware.NewTokenMiddleware, h.SplitQRISPayment)
+	wallet.Get("/payment-requests", h.middleware.NewTokenMiddleware, h.GetPaymentRequests)
+	wallet.Get("/payment-requests/:id", h.middleware.NewTokenMiddleware, h.GetPaymentRequest)
+	wallet.Post("/payment-requests/:id/accept", h.middleware.NewTokenMiddleware, h.AcceptPaymentRequest)
+	wallet.Post("/payment-requests/:id/decline", h.middleware.NewTokenMiddleware, h.DeclinePaymentRequest)
+	wallet.Post("/payment-requests/:id/remind", h.middleware.NewTokenMiddleware, h.RemindPaymentRequest)
 
 	wallet.Post("/callback", h.PaymentCallback)
+	wallet.Post("/callback/:provider", h.PaymentCallback)
+	wallet.Post("/simulator/topup/:reference_no/pay", h.middleware.NewTokenMiddleware, h.SimulateVAPayment)
 
 	wallet.Post("/qris/decode", h.middleware.NewTokenMiddleware, h.DecodeQRIS)
 	wallet.Post("/qris/payment", h.middleware.NewTokenMiddleware, h.PaymentQRIS)
+	wallet.Post("/qris/receive", h.middleware.NewTokenMiddleware, h.CreateQRISReceive)
+
+	admin := wallet.Group("/admin", h.middleware.NewAdminKeyMiddleware)
+	admin.Get("/notifications", h.GetPaymentNotifications)
+	admin.Get("/notifications/:id", h.GetPaymentNotification)
+	admin.Post("/notifications/:id/replay", h.ReplayPaymentNotification)
+	admin.Get("/risk-decisions", h.GetRiskDecisions)
 }
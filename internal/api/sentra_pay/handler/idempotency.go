@@ -0,0 +1,163 @@
+package sentrapayHandler
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/handlerUtil"
+	"ProjectGolang/pkg/log"
+	"ProjectGolang/pkg/response"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/net/context"
+	"strings"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// rejectedBeforeProcessing are the errors a wallet operation returns from its
+// checks, before anything is written or sent to a gateway. Only these release
+// the Idempotency-Key, so the client can fix the request and retry with it.
+var rejectedBeforeProcessing = []error{
+	sentrapay.ErrInvalidAmount,
+	sentrapay.ErrInvalidBank,
+	sentrapay.ErrInvalidQRISCode,
+	sentrapay.ErrSelfTransfer,
+	sentrapay.ErrSelfPaymentRequest,
+	sentrapay.ErrDuplicateParticipant,
+	sentrapay.ErrSplitAmountRequired,
+	sentrapay.ErrSplitExceedsTotal,
+	sentrapay.ErrUnsupportedWithdrawalBank,
+	sentrapay.ErrInvalidPIN,
+	sentrapay.ErrPINNotSet,
+	sentrapay.ErrPINAttemptExceeded,
+	sentrapay.ErrRiskChallengeRequired,
+	sentrapay.ErrRiskDenied,
+	sentrapay.ErrInvalidOTP,
+	sentrapay.ErrOTPAttemptExceeded,
+	sentrapay.ErrMaxPerTransactionExceeded,
+	sentrapay.ErrMaxDailyLimitExceeded,
+	sentrapay.ErrWalletFrozen,
+	sentrapay.ErrWalletClosed,
+}
+
+// withIdempotency runs process at most once per Idempotency-Key. A retry with
+// the same key and body gets the stored response back. Requests without the
+// header are processed as before.
+//
+// A failed request releases its key only when it was rejected before
+// processing. Other client errors are stored and replayed like a success.
+// Server errors may come after money moved or a gateway call was made, so
+// the key stays in processing until it expires rather than allowing a
+// second attempt.
+func (h *SentraPayHandler) withIdempotency(
+	ctx *fiber.Ctx,
+	c context.Context,
+	userID string,
+	fingerprint interface{},
+	successStatus int,
+	operation string,
+	process func() (interface{}, error),
+) error {
+	requestID := h.middleware.GetRequestID(ctx)
+	errHandler := handlerUtil.New(h.log)
+
+	key := strings.TrimSpace(ctx.Get(idempotencyKeyHeader))
+	if key == "" {
+		response, err := process()
+		if err != nil {
+			return errHandler.Handle(ctx, requestID, err, ctx.Path(), operation)
+		}
+
+		select {
+		case <-c.Done():
+			return errHandler.HandleRequestTimeout(ctx)
+		default:
+			return errHandler.HandleSuccess(ctx, successStatus, response)
+		}
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		return errHandler.Handle(ctx, requestID, sentrapay.ErrInvalidIdempotencyKey, ctx.Path(), operation)
+	}
+
+	endpoint := ctx.Route().Path
+
+	stored, err := h.sentraPayService.BeginIdempotentRequest(c, userID, endpoint, key, fingerprint)
+	if err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "begin_idempotent_request")
+	}
+
+	if stored != nil {
+		ctx.Set(idempotentReplayedHeader, "true")
+		return errHandler.HandleSuccess(ctx, stored.ResponseStatus, stored.ResponseBody)
+	}
+
+	// The request context may already be past its deadline here, so the key
+	// bookkeeping uses the parent context.
+	bookkeepingCtx := contextPkg.FromFiberCtx(ctx)
+
+	response, err := process()
+	if err != nil {
+		h.settleFailedIdempotentRequest(bookkeepingCtx, requestID, userID, endpoint, key, err)
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), operation)
+	}
+
+	if err := h.sentraPayService.CompleteIdempotentRequest(bookkeepingCtx, userID, endpoint, key, successStatus, response); err != nil {
+		h.log.WithFields(log.Fields{
+			"request_id":      requestID,
+			"idempotency_key": key,
+			"error":           err.Error(),
+		}).Error("Failed to store idempotent response")
+	}
+
+	select {
+	case <-c.Done():
+		return errHandler.HandleRequestTimeout(ctx)
+	default:
+		return errHandler.HandleSuccess(ctx, successStatus, response)
+	}
+}
+
+func (h *SentraPayHandler) settleFailedIdempotentRequest(c context.Context, requestID, userID, endpoint, key string, err error) {
+	if isRejectedBeforeProcessing(err) {
+		if abortErr := h.sentraPayService.AbortIdempotentRequest(c, userID, endpoint, key); abortErr != nil {
+			h.log.WithFields(log.Fields{
+				"request_id":      requestID,
+				"idempotency_key": key,
+				"error":           abortErr.Error(),
+			}).Error("Failed to release idempotency key")
+		}
+		return
+	}
+
+	var respErr *response.Error
+	if errors.As(err, &respErr) && respErr.Code < fiber.StatusInternalServerError {
+		if completeErr := h.sentraPayService.CompleteIdempotentRequest(c, userID, endpoint, key, respErr.Code, fiber.Map{"error": err.Error()}); completeErr != nil {
+			h.log.WithFields(log.Fields{
+				"request_id":      requestID,
+				"idempotency_key": key,
+				"error":           completeErr.Error(),
+			}).Error("Failed to store idempotent error response")
+		}
+		return
+	}
+
+	h.log.WithFields(log.Fields{
+		"request_id":      requestID,
+		"idempotency_key": key,
+		"error":           err.Error(),
+	}).Warn("Idempotency key held after a failed request until it expires")
+}
+
+func isRejectedBeforeProcessing(err error) bool {
+	for _, rejection := range rejectedBeforeProcessing {
+		if errors.Is(err, rejection) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package sentrapayHandler
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayService "ProjectGolang/internal/api/sentra_pay/service"
+	"ProjectGolang/internal/middleware"
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// idempotencyService records what happens to the key of a processed request.
+type idempotencyService struct {
+	sentrapayService.ISentraPayService
+
+	aborted         bool
+	completedStatus int
+	completedBody   interface{}
+}
+
+func (s *idempotencyService) BeginIdempotentRequest(ctx context.Context, userID, endpoint, key string, request interface{}) (*sentrapay.IdempotencyKey, error) {
+	return nil, nil
+}
+
+func (s *idempotencyService) CompleteIdempotentRequest(ctx context.Context, userID, endpoint, key string, statusCode int, response interface{}) error {
+	s.completedStatus = statusCode
+	s.completedBody = response
+	return nil
+}
+
+func (s *idempotencyService) AbortIdempotentRequest(ctx context.Context, userID, endpoint, key string) error {
+	s.aborted = true
+	return nil
+}
+
+func TestWithIdempotencyFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantAborted   bool
+		wantCompleted int
+	}{
+		{
+			name:        "risk challenge releases the key",
+			err:         sentrapay.ErrRiskChallengeRequired,
+			wantStatus:  fiber.StatusPreconditionRequired,
+			wantAborted: true,
+		},
+		{
+			name:        "invalid PIN releases the key",
+			err:         sentrapay.ErrInvalidPIN,
+			wantStatus:  fiber.StatusUnauthorized,
+			wantAborted: true,
+		},
+		{
+			name:        "limit releases the key",
+			err:         sentrapay.ErrMaxDailyLimitExceeded,
+			wantStatus:  fiber.StatusBadRequest,
+			wantAborted: true,
+		},
+		{
+			name:          "declined payment is stored",
+			err:           sentrapay.ErrQRISPaymentDeclined,
+			wantStatus:    fiber.StatusBadRequest,
+			wantCompleted: fiber.StatusBadRequest,
+		},
+		{
+			name:       "failure after the gateway call holds the key",
+			err:        sentrapay.ErrCreateTransaction,
+			wantStatus: fiber.StatusInternalServerError,
+		},
+		{
+			name:       "unexpected error holds the key",
+			err:        errors.New("database unavailable"),
+			wantStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := newTestLogger()
+			service := &idempotencyService{}
+			handler := New(log, nil, middleware.New(log), service)
+
+			app := fiber.New()
+			app.Post("/pay", func(ctx *fiber.Ctx) error {
+				return handler.withIdempotency(ctx, contextPkg.FromFiberCtx(ctx), testUserID, nil, fiber.StatusCreated, "pay", func() (interface{}, error) {
+					return nil, tt.err
+				})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/pay", nil)
+			req.Header.Set(idempotencyKeyHeader, "key-1")
+
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if service.aborted != tt.wantAborted {
+				t.Fatalf("key released = %v, want %v", service.aborted, tt.wantAborted)
+			}
+			if service.completedStatus != tt.wantCompleted {
+				t.Fatalf("stored status = %d, want %d", service.completedStatus, tt.wantCompleted)
+			}
+
+			if tt.wantCompleted != 0 {
+				body, err := json.Marshal(service.completedBody)
+				if err != nil {
+					t.Fatalf("encode stored body: %v", err)
+				}
+				if string(body) != `{"error":"`+tt.err.Error()+`"}` {
+					t.Fatalf("stored body = %s, want the error response", body)
+				}
+			}
+		})
+	}
+}
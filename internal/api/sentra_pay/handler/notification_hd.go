@@ -0,0 +1,95 @@
+package sentrapayHandler
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/handlerUtil"
+	"ProjectGolang/pkg/log"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/net/context"
+	"time"
+)
+
+func (h *SentraPayHandler) GetPaymentNotifications(ctx *fiber.Ctx) error {
+	requestID := h.middleware.GetRequestID(ctx)
+	c, cancel := context.WithTimeout(contextPkg.FromFiberCtx(ctx), 10*time.Second)
+	defer cancel()
+
+	errHandler := handlerUtil.New(h.log)
+
+	h.log.WithFields(log.Fields{
+		"request_id": requestID,
+		"path":       ctx.Path(),
+	}).Debug("Processing list payment notifications request")
+
+	var req sentrapay.ListPaymentNotificationsRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "parse_query_params")
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return errHandler.HandleValidationError(ctx, requestID, err, ctx.Path())
+	}
+
+	notifications, err := h.sentraPayService.ListPaymentNotifications(c, req)
+	if err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "list_payment_notifications")
+	}
+
+	select {
+	case <-c.Done():
+		return errHandler.HandleRequestTimeout(ctx)
+	default:
+		return errHandler.HandleSuccess(ctx, fiber.StatusOK, notifications)
+	}
+}
+
+func (h *SentraPayHandler) GetPaymentNotification(ctx *fiber.Ctx) error {
+	requestID := h.middleware.GetRequestID(ctx)
+	c, cancel := context.WithTimeout(contextPkg.FromFiberCtx(ctx), 10*time.Second)
+	defer cancel()
+
+	errHandler := handlerUtil.New(h.log)
+
+	h.log.WithFields(log.Fields{
+		"request_id": requestID,
+		"path":       ctx.Path(),
+	}).Debug("Processing get payment notification request")
+
+	notification, err := h.sentraPayService.GetPaymentNotification(c, ctx.Params("id"))
+	if err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "get_payment_notification")
+	}
+
+	select {
+	case <-c.Done():
+		return errHandler.HandleRequestTimeout(ctx)
+	default:
+		return errHandler.HandleSuccess(ctx, fiber.StatusOK, notification)
+	}
+}
+
+func (h *SentraPayHandler) ReplayPaymentNotification(ctx *fiber.Ctx) error {
+	requestID := h.middleware.GetRequestID(ctx)
+	c, cancel := context.WithTimeout(contextPkg.FromFiberCtx(ctx), 30*time.Second)
+	defer cancel()
+
+	errHandler := handlerUtil.New(h.log)
+
+	h.log.WithFields(log.Fields{
+		"request_id": requestID,
+		"path":       ctx.Path(),
+	}).Debug("Processing replay payment notification request")
+
+	notification, err := h.sentraPayService.ReplayPaymentNotification(c, ctx.Params("id"))
+	if err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "replay_payment_notification")
+	}
+
+	select {
+	case <-c.Done():
+		return errHandler.HandleRequestTimeout(ctx)
+	default:
+		return errHandler.HandleSuccess(ctx, fiber.StatusOK, notification)
+	}
+}
@@ -71,15 +71,43 @@ func (h *SentraPayHandler) PaymentQRIS(ctx *fiber.Ctx) error {
 		return errHandler.HandleValidationError(ctx, requestID, err, ctx.Path())
 	}
 
-	response, err := h.sentraPayService.PaymentQRIS(c, userData.ID, req)
+	// The PIN is left out of the stored request hash.
+	fingerprint := req
+	fingerprint.PIN = ""
+	fingerprint.OTPCode = ""
+
+	return h.withIdempotency(ctx, c, userData.ID, fingerprint, fiber.StatusOK, "payment_qris", func() (interface{}, error) {
+		return h.sentraPayService.PaymentQRIS(c, userData.ID, req)
+	})
+}
+
+func (h *SentraPayHandler) CreateQRISReceive(ctx *fiber.Ctx) error {
+	requestID := h.middleware.GetRequestID(ctx)
+	c, cancel := context.WithTimeout(contextPkg.FromFiberCtx(ctx), 10*time.Second)
+	defer cancel()
+
+	errHandler := handlerUtil.New(h.log)
+
+	h.log.WithFields(log.Fields{
+		"request_id": requestID,
+		"path":       ctx.Path(),
+	}).Debug("Processing QRIS receive request")
+
+	var req sentrapay.QRISReceiveRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "parse_request_body")
+	}
+
+	userData, err := jwtPkg.GetUserLoginData(ctx)
 	if err != nil {
-		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "payment_qris")
+		return errHandler.HandleUnauthorized(ctx, requestID, "Unauthorized")
 	}
 
-	select {
-	case <-c.Done():
-		return errHandler.HandleRequestTimeout(ctx)
-	default:
-		return errHandler.HandleSuccess(ctx, fiber.StatusOK, response)
+	if err := h.validator.Struct(req); err != nil {
+		return errHandler.HandleValidationError(ctx, requestID, err, ctx.Path())
 	}
+
+	return h.withIdempotency(ctx, c, userData.ID, req, fiber.StatusCreated, "create_qris_receive", func() (interface{}, error) {
+		return h.sentraPayService.CreateQRISReceive(c, userData.ID, req)
+	})
 }
@@ -9,7 +9,6 @@ import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/net/context"
-	"strconv"
 	"time"
 )
 
@@ -39,20 +38,15 @@ func (h *SentraPayHandler) CreateTopUp(ctx *fiber.Ctx) error {
 		return errHandler.HandleValidationError(ctx, requestID, err, ctx.Path())
 	}
 
-	response, err := h.sentraPayService.CreateTopUpTransaction(c, userData.ID, req)
-	if err != nil {
-		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "create_topup_transaction")
-	}
+	fingerprint := req
+	fingerprint.OTPCode = ""
 
-	select {
-	case <-c.Done():
-		return errHandler.HandleRequestTimeout(ctx)
-	default:
-		return errHandler.HandleSuccess(ctx, fiber.StatusCreated, response)
-	}
+	return h.withIdempotency(ctx, c, userData.ID, fingerprint, fiber.StatusCreated, "create_topup_transaction", func() (interface{}, error) {
+		return h.sentraPayService.CreateTopUpTransaction(c, userData.ID, req)
+	})
 }
 
-func (h *SentraPayHandler) PaymentCallback(ctx *fiber.Ctx) error {
+func (h *SentraPayHandler) GetVirtualAccounts(ctx *fiber.Ctx) error {
 	requestID := h.middleware.GetRequestID(ctx)
 	c, cancel := context.WithTimeout(contextPkg.FromFiberCtx(ctx), 10*time.Second)
 	defer cancel()
@@ -62,53 +56,23 @@ func (h *SentraPayHandler) PaymentCallback(ctx *fiber.Ctx) error {
 	h.log.WithFields(log.Fields{
 		"request_id": requestID,
 		"path":       ctx.Path(),
-	}).Debug("Processing payment callback")
+	}).Debug("Processing get virtual accounts request")
 
-	var req sentrapay.PaymentCallbackRequest
-	if err := ctx.BodyParser(&req); err != nil {
-		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "parse_request_body")
+	userData, err := jwtPkg.GetUserLoginData(ctx)
+	if err != nil {
+		return errHandler.HandleUnauthorized(ctx, requestID, "Unauthorized")
 	}
 
-	channelID := ctx.Get("CHANNEL-ID")
-	xExternalID := ctx.Get("X-EXTERNAL-ID")
-	xTimestamp := ctx.Get("X-TIMESTAMP")
-	xPartnerID := ctx.Get("X-PARTNER-ID")
-
-	h.log.WithFields(log.Fields{
-		"request_id":    requestID,
-		"channelID":     channelID,
-		"xExternalID":   xExternalID,
-		"xTimestamp":    xTimestamp,
-		"xPartnerID":    xPartnerID,
-		"trxId":         req.TrxId,
-		"paidAmount":    req.PaidAmount.Value,
-		"paymentMethod": req.AdditionalInfo.Channel,
-	}).Info("Received payment callback")
-
-	if err := h.sentraPayService.ProcessPaymentCallback(c, req, channelID, xExternalID, xTimestamp, xPartnerID); err != nil {
-		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "process_payment_callback")
+	accounts, err := h.sentraPayService.GetVirtualAccounts(c, userData.ID)
+	if err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "get_virtual_accounts")
 	}
 
 	select {
 	case <-c.Done():
 		return errHandler.HandleRequestTimeout(ctx)
 	default:
-		return errHandler.HandleSuccess(ctx, fiber.StatusOK, fiber.Map{
-			"responseCode":    "2002500",
-			"responseMessage": "success",
-			"virtualAccountData": map[string]interface{}{
-				"partnerServiceId":   req.PartnerServiceId,
-				"customerNo":         req.CustomerNo,
-				"virtualAccountNo":   req.VirtualAccountNo,
-				"virtualAccountName": req.VirtualAccountName,
-				"paymentRequestId":   req.PaymentRequestId,
-				"trxId":              req.TrxId,
-				"trxDateTime":        req.TrxDateTime,
-				"additionalInfo": map[string]interface{}{
-					"channel": req.AdditionalInfo.Channel,
-				},
-			},
-		})
+		return errHandler.HandleSuccess(ctx, fiber.StatusOK, accounts)
 	}
 }
 
@@ -159,24 +123,16 @@ func (h *SentraPayHandler) GetTransactionHistory(ctx *fiber.Ctx) error {
 		return errHandler.HandleUnauthorized(ctx, requestID, "Unauthorized")
 	}
 
-	page, err := strconv.Atoi(ctx.Query("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
+	var req sentrapay.TransactionHistoryRequest
+	if err := ctx.QueryParser(&req); err != nil {
+		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "parse_query_params")
 	}
 
-	limit, err := strconv.Atoi(ctx.Query("limit", "10"))
-	if err != nil || limit < 1 || limit > 100 {
-		limit = 10
+	if err := h.validator.Struct(req); err != nil {
+		return errHandler.HandleValidationError(ctx, requestID, err, ctx.Path())
 	}
 
-	h.log.WithFields(log.Fields{
-		"request_id": requestID,
-		"user_id":    userData.ID,
-		"page":       page,
-		"limit":      limit,
-	}).Debug("Fetching transaction history")
-
-	history, err := h.sentraPayService.GetTransactionHistory(c, userData.ID, page, limit)
+	history, err := h.sentraPayService.GetTransactionHistory(c, userData.ID, req)
 	if err != nil {
 		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "get_transaction_history")
 	}
@@ -218,7 +174,7 @@ func (h *SentraPayHandler) CheckTransactionStatus(ctx *fiber.Ctx) error {
 		"reference_no": referenceNo,
 	}).Debug("Checking transaction status")
 
-	status, err := h.sentraPayService.CheckTransactionStatus(c, referenceNo)
+	status, err := h.sentraPayService.CheckTransactionStatus(c, userData.ID, referenceNo)
 	if err != nil {
 		return errHandler.Handle(ctx, requestID, err, ctx.Path(), "check_transaction_status")
 	}
@@ -0,0 +1,223 @@
+package sentrapayHandler
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayService "ProjectGolang/internal/api/sentra_pay/service"
+	"ProjectGolang/internal/entity"
+	"ProjectGolang/internal/middleware"
+	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/gateway"
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/risk"
+	"ProjectGolang/pkg/utils"
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"testing"
+)
+
+const (
+	testUserID    = "01J0000000000000000000USER"
+	testPartnerID = "BRN-0001-0000000000000"
+)
+
+// appTransport delivers HTTP requests to a fiber app without a listener.
+type appTransport struct {
+	app *fiber.App
+}
+
+func (t appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.app.Test(req, -1)
+}
+
+// topUpTest runs the wallet service against the DOKU simulator, which
+// delivers its notifications to the real callback route.
+type topUpTest struct {
+	store   *memoryStore
+	budget  *fakeBudgetService
+	service sentrapayService.ISentraPayService
+}
+
+func newTopUpTest(t *testing.T) *topUpTest {
+	t.Helper()
+
+	log := newTestLogger()
+	app := fiber.New()
+
+	simulator, err := doku.NewSimulator(log, doku.SimulatorConfig{
+		CallbackURL: "http://sentra.test/api/v1/wallet/callback",
+		PartnerID:   testPartnerID,
+		HTTPClient:  &http.Client{Transport: appTransport{app: app}},
+	})
+	if err != nil {
+		t.Fatalf("new simulator: %v", err)
+	}
+
+	router, err := gateway.NewRouter(gateway.ProviderDOKU, nil, gateway.NewDoku(simulator, testPartnerID))
+	if err != nil {
+		t.Fatalf("new gateway router: %v", err)
+	}
+
+	numbering, err := gateway.NewVirtualAccountNumbering("84923", nil, 12)
+	if err != nil {
+		t.Fatalf("new virtual account numbering: %v", err)
+	}
+
+	store := newMemoryStore()
+	budget := &fakeBudgetService{}
+	users := &fakeAuthRepository{users: map[string]entity.User{
+		testUserID: {ID: testUserID, Name: "Budi Santoso", Email: "budi@example.com", PhoneNumber: "6281234567890"},
+	}}
+
+	service := sentrapayService.NewSentraPayService(
+		log, store, router, numbering, nil, nil, users, budget, nil, risk.NewEngine(), nil, nil, nil, nil, utils.New(),
+	)
+
+	New(log, validator.New(), middleware.New(log), service).Start(app.Group("/api/v1"))
+
+	return &topUpTest{store: store, budget: budget, service: service}
+}
+
+func (tt *topUpTest) createTopUp(t *testing.T, amount money.Amount) *sentrapay.TopUpResponse {
+	t.Helper()
+
+	topUp, err := tt.service.CreateTopUpTransaction(context.Background(), testUserID, sentrapay.TopUpRequest{
+		Amount: amount,
+		Bank:   gateway.ChannelBCA,
+	})
+	if err != nil {
+		t.Fatalf("CreateTopUpTransaction: %v", err)
+	}
+	return topUp
+}
+
+func (tt *topUpTest) transaction(t *testing.T, referenceNo string) sentrapay.WalletTransaction {
+	t.Helper()
+
+	tt.store.mu.Lock()
+	defer tt.store.mu.Unlock()
+
+	for _, transaction := range tt.store.transactions {
+		if transaction.ReferenceNo == referenceNo {
+			return transaction
+		}
+	}
+	t.Fatalf("no transaction %s", referenceNo)
+	return sentrapay.WalletTransaction{}
+}
+
+func TestSimulatedTopUpCreditsWallet(t *testing.T) {
+	tt := newTopUpTest(t)
+	amount := money.FromRupiah(150_000)
+
+	topUp := tt.createTopUp(t, amount)
+	if topUp.VirtualAccount == "" {
+		t.Fatal("top-up has no virtual account number")
+	}
+	if got := tt.transaction(t, topUp.ReferenceNo).Status; got != sentrapay.TransactionPending {
+		t.Fatalf("status before payment = %s, want %s", got, sentrapay.TransactionPending)
+	}
+
+	result, err := tt.service.SimulateVAPayment(context.Background(), testUserID, topUp.ReferenceNo, sentrapay.SimulateVAPaymentRequest{})
+	if err != nil {
+		t.Fatalf("SimulateVAPayment: %v", err)
+	}
+	if result.CallbackStatus != fiber.StatusOK || result.ResponseCode != sentrapay.SNAPNotificationSuccess {
+		t.Fatalf("callback answered %d %s (%s), want %d %s",
+			result.CallbackStatus, result.ResponseCode, result.ResponseMessage, fiber.StatusOK, sentrapay.SNAPNotificationSuccess)
+	}
+	if result.TransactionStatus != string(sentrapay.TransactionSuccess) {
+		t.Fatalf("TransactionStatus = %s, want %s", result.TransactionStatus, sentrapay.TransactionSuccess)
+	}
+
+	transaction := tt.transaction(t, topUp.ReferenceNo)
+	if transaction.Status != sentrapay.TransactionSuccess {
+		t.Fatalf("stored status = %s, want %s", transaction.Status, sentrapay.TransactionSuccess)
+	}
+	if transaction.GatewayPaymentID == "" {
+		t.Fatal("gateway payment ID not recorded on the top-up")
+	}
+
+	wallet, err := tt.service.GetWalletBalance(context.Background(), testUserID)
+	if err != nil {
+		t.Fatalf("GetWalletBalance: %v", err)
+	}
+	if wallet.Balance != amount {
+		t.Fatalf("balance = %s, want %s", wallet.Balance, amount)
+	}
+
+	tt.store.mu.Lock()
+	journals, entries, notifications := tt.store.journals, tt.store.entries, tt.store.notifications
+	tt.store.mu.Unlock()
+
+	if len(journals) != 1 || journals[0].JournalKey != "topup:"+topUp.ReferenceNo {
+		t.Fatalf("journals = %+v, want one topup:%s", journals, topUp.ReferenceNo)
+	}
+
+	var credited, cleared bool
+	for _, entry := range entries {
+		if entry.JournalID != journals[0].ID || entry.Amount != amount {
+			t.Fatalf("unexpected ledger entry %+v", entry)
+		}
+		switch {
+		case entry.Account == sentrapay.WalletLedgerAccount(testUserID) && entry.Direction == sentrapay.LedgerCredit:
+			credited = entry.BalanceAfter != nil && *entry.BalanceAfter == amount
+		case entry.Account == sentrapay.LedgerAccountTopUpClearing && entry.Direction == sentrapay.LedgerDebit:
+			cleared = true
+		}
+	}
+	if len(entries) != 2 || !credited || !cleared {
+		t.Fatalf("ledger entries = %+v, want a wallet credit and a top-up clearing debit", entries)
+	}
+
+	if len(notifications) != 1 {
+		t.Fatalf("stored %d notifications, want 1", len(notifications))
+	}
+	if n := notifications[0]; n.Verification != sentrapay.NotificationVerified || n.Outcome != sentrapay.NotificationProcessed || n.ReferenceNo != topUp.ReferenceNo {
+		t.Fatalf("notification = %s/%s for %s, want verified/processed for %s", n.Verification, n.Outcome, n.ReferenceNo, topUp.ReferenceNo)
+	}
+
+	if len(tt.budget.entries) != 1 || tt.budget.entries[0].WalletTransactionID != transaction.ID {
+		t.Fatalf("budget entries = %+v, want the top-up", tt.budget.entries)
+	}
+}
+
+func TestSimulatedTopUpWithWrongAmountIsRejected(t *testing.T) {
+	tt := newTopUpTest(t)
+
+	topUp := tt.createTopUp(t, money.FromRupiah(150_000))
+
+	result, err := tt.service.SimulateVAPayment(context.Background(), testUserID, topUp.ReferenceNo, sentrapay.SimulateVAPaymentRequest{
+		Amount: money.FromRupiah(15_000),
+	})
+	if err != nil {
+		t.Fatalf("SimulateVAPayment: %v", err)
+	}
+	if result.CallbackStatus != fiber.StatusNotFound || result.ResponseCode != sentrapay.SNAPNotificationInvalidAmount {
+		t.Fatalf("callback answered %d %s (%s), want %d %s",
+			result.CallbackStatus, result.ResponseCode, result.ResponseMessage, fiber.StatusNotFound, sentrapay.SNAPNotificationInvalidAmount)
+	}
+
+	if got := tt.transaction(t, topUp.ReferenceNo).Status; got != sentrapay.TransactionPending {
+		t.Fatalf("status = %s, want %s", got, sentrapay.TransactionPending)
+	}
+
+	wallet, err := tt.service.GetWalletBalance(context.Background(), testUserID)
+	if err != nil {
+		t.Fatalf("GetWalletBalance: %v", err)
+	}
+	if !wallet.Balance.IsZero() {
+		t.Fatalf("balance = %s, want 0", wallet.Balance)
+	}
+
+	tt.store.mu.Lock()
+	defer tt.store.mu.Unlock()
+
+	if len(tt.store.journals) != 0 || len(tt.store.entries) != 0 {
+		t.Fatalf("ledger written for a rejected payment: %+v %+v", tt.store.journals, tt.store.entries)
+	}
+	if n := tt.store.notifications[0]; n.Outcome != sentrapay.NotificationFailed {
+		t.Fatalf("notification outcome = %s, want %s", n.Outcome, sentrapay.NotificationFailed)
+	}
+}
@@ -0,0 +1,186 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type IdempotencyKeyDB struct {
+	UserID         sql.NullString `db:"user_id"`
+	Endpoint       sql.NullString `db:"endpoint"`
+	Key            sql.NullString `db:"idempotency_key"`
+	RequestHash    sql.NullString `db:"request_hash"`
+	Status         sql.NullString `db:"status"`
+	ResponseStatus sql.NullInt64  `db:"response_status"`
+	ResponseBody   []byte         `db:"response_body"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+	ExpiresAt      time.Time      `db:"expires_at"`
+}
+
+// Reserve claims the key for a new request. It returns false when the key is
+// already held by an unexpired record.
+func (r *idempotencyRepository) Reserve(ctx context.Context, record sentrapay.IdempotencyKey) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"user_id":         record.UserID,
+		"endpoint":        record.Endpoint,
+		"idempotency_key": record.Key,
+		"request_hash":    record.RequestHash,
+		"status":          record.Status,
+		"created_at":      record.CreatedAt,
+		"updated_at":      record.UpdatedAt,
+		"expires_at":      record.ExpiresAt,
+	}
+
+	query, args, err := sqlx.Named(queryReserveIdempotencyKey, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to build SQL query for Reserve")
+		return false, err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Database error when reserving idempotency key")
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Reserve rows affected err")
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userID, endpoint, key string) (sentrapay.IdempotencyKey, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var record IdempotencyKeyDB
+
+	argsKV := map[string]interface{}{
+		"user_id":         userID,
+		"endpoint":        endpoint,
+		"idempotency_key": key,
+	}
+
+	query, args, err := sqlx.Named(queryGetIdempotencyKey, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Get idempotency key named query preparation err")
+		return sentrapay.IdempotencyKey{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&record); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.IdempotencyKey{}, sentrapay.ErrIdempotencyKeyNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Get idempotency key execution err")
+		return sentrapay.IdempotencyKey{}, err
+	}
+
+	return sentrapay.IdempotencyKey{
+		UserID:         record.UserID.String,
+		Endpoint:       record.Endpoint.String,
+		Key:            record.Key.String,
+		RequestHash:    record.RequestHash.String,
+		Status:         record.Status.String,
+		ResponseStatus: int(record.ResponseStatus.Int64),
+		ResponseBody:   record.ResponseBody,
+		CreatedAt:      record.CreatedAt,
+		UpdatedAt:      record.UpdatedAt,
+		ExpiresAt:      record.ExpiresAt,
+	}, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record sentrapay.IdempotencyKey) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"user_id":         record.UserID,
+		"endpoint":        record.Endpoint,
+		"idempotency_key": record.Key,
+		"status":          record.Status,
+		"response_status": record.ResponseStatus,
+		"response_body":   string(record.ResponseBody),
+		"updated_at":      record.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCompleteIdempotencyKey, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to build SQL query for Complete")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Database error when completing idempotency key")
+		return err
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, userID, endpoint, key string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"user_id":         userID,
+		"endpoint":        endpoint,
+		"idempotency_key": key,
+	}
+
+	query, args, err := sqlx.Named(queryDeleteIdempotencyKey, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to build SQL query for Delete")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Database error when deleting idempotency key")
+		return err
+	}
+
+	return nil
+}
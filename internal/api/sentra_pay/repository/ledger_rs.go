@@ -0,0 +1,278 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type LedgerBalanceMismatchDB struct {
+	UserID        sql.NullString   `db:"user_id"`
+	WalletBalance money.NullAmount `db:"wallet_balance"`
+	LedgerBalance money.NullAmount `db:"ledger_balance"`
+}
+
+type UnbalancedJournalDB struct {
+	JournalID   sql.NullString   `db:"journal_id"`
+	TotalDebit  money.NullAmount `db:"total_debit"`
+	TotalCredit money.NullAmount `db:"total_credit"`
+}
+
+type WalletLedgerEntryDB struct {
+	ID           sql.NullString   `db:"id"`
+	ReferenceNo  sql.NullString   `db:"reference_no"`
+	Kind         sql.NullString   `db:"kind"`
+	Description  sql.NullString   `db:"description"`
+	Status       sql.NullString   `db:"status"`
+	Direction    sql.NullString   `db:"direction"`
+	Amount       money.NullAmount `db:"amount"`
+	BalanceAfter money.NullAmount `db:"balance_after"`
+	CreatedAt    time.Time        `db:"created_at"`
+}
+
+func (r *ledgerRepository) CreateJournal(ctx context.Context, journal sentrapay.LedgerJournal) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":           journal.ID,
+		"journal_key":  journal.JournalKey,
+		"reference_no": journal.ReferenceNo,
+		"kind":         journal.Kind,
+		"description":  journal.Description,
+		"created_at":   journal.CreatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateLedgerJournal, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to build SQL query for CreateJournal")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Database error when creating ledger journal")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateJournal rows affected err")
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.log.WithFields(logrus.Fields{
+			"request_id":  requestID,
+			"journal_key": journal.JournalKey,
+		}).Warn("CreateJournal journal key already posted")
+		return sentrapay.ErrJournalAlreadyPosted
+	}
+
+	return nil
+}
+
+func (r *ledgerRepository) CreateEntry(ctx context.Context, entry sentrapay.LedgerEntry) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":            entry.ID,
+		"journal_id":    entry.JournalID,
+		"account":       entry.Account,
+		"user_id":       sql.NullString{String: entry.UserID, Valid: entry.UserID != ""},
+		"direction":     string(entry.Direction),
+		"amount":        entry.Amount,
+		"balance_after": entry.BalanceAfter,
+		"created_at":    entry.CreatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateLedgerEntry, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to build SQL query for CreateEntry")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Database error when creating ledger entry")
+		return err
+	}
+
+	return nil
+}
+
+func (r *ledgerRepository) CountWallets(ctx context.Context) (int, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var total int
+
+	if err := r.q.QueryRowxContext(ctx, queryCountWallets).Scan(&total); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CountWallets execution err")
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *ledgerRepository) GetBalanceMismatches(ctx context.Context) ([]sentrapay.LedgerBalanceMismatch, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var rows []LedgerBalanceMismatchDB
+
+	if err := r.q.SelectContext(ctx, &rows, queryGetLedgerBalanceMismatches); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetBalanceMismatches execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.LedgerBalanceMismatch, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, sentrapay.LedgerBalanceMismatch{
+			UserID:        row.UserID.String,
+			WalletBalance: row.WalletBalance.Amount,
+			LedgerBalance: row.LedgerBalance.Amount,
+			Difference:    row.WalletBalance.Amount - row.LedgerBalance.Amount,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *ledgerRepository) GetUnbalancedJournals(ctx context.Context) ([]sentrapay.UnbalancedJournal, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var rows []UnbalancedJournalDB
+
+	if err := r.q.SelectContext(ctx, &rows, queryGetUnbalancedJournals); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetUnbalancedJournals execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.UnbalancedJournal, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, sentrapay.UnbalancedJournal{
+			JournalID:   row.JournalID.String,
+			TotalDebit:  row.TotalDebit.Amount,
+			TotalCredit: row.TotalCredit.Amount,
+		})
+	}
+
+	return result, nil
+}
+
+// GetWalletBalanceAt returns the user's balance just before at, read from the
+// last ledger entry written before it.
+func (r *ledgerRepository) GetWalletBalanceAt(ctx context.Context, userID string, at time.Time) (money.Amount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var balance money.Amount
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"at":      at,
+	}
+
+	query, args, err := sqlx.Named(queryGetWalletBalanceAt, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWalletBalanceAt named query preparation err")
+		return 0, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).Scan(&balance); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWalletBalanceAt execution err")
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// GetWalletEntries returns the entries posted to the user's wallet account
+// from from up to but excluding to, oldest first, after the cursor if one is
+// given.
+func (r *ledgerRepository) GetWalletEntries(ctx context.Context, userID string, from, to time.Time, after *sentrapay.TransactionCursor, limit int) ([]sentrapay.WalletLedgerEntry, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var entries []WalletLedgerEntryDB
+
+	argsKV := map[string]interface{}{
+		"user_id":           userID,
+		"from":              from,
+		"to":                to,
+		"cursor_created_at": sql.NullTime{},
+		"cursor_id":         "",
+		"limit":             limit,
+	}
+	if after != nil {
+		argsKV["cursor_created_at"] = sql.NullTime{Time: after.CreatedAt, Valid: true}
+		argsKV["cursor_id"] = after.ID
+	}
+
+	query, args, err := sqlx.Named(queryGetWalletLedgerEntries, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWalletLedgerEntries named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &entries, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWalletLedgerEntries execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.WalletLedgerEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, sentrapay.WalletLedgerEntry{
+			ID:           entry.ID.String,
+			ReferenceNo:  entry.ReferenceNo.String,
+			Kind:         entry.Kind.String,
+			Description:  entry.Description.String,
+			Status:       entry.Status.String,
+			Direction:    sentrapay.LedgerDirection(entry.Direction.String),
+			Amount:       entry.Amount.Amount,
+			BalanceAfter: entry.BalanceAfter.Amount,
+			PostedAt:     entry.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
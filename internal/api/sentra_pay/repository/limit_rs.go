@@ -0,0 +1,155 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type LimitProfileDB struct {
+	Code                sql.NullString   `db:"code"`
+	DailyLimit          money.NullAmount `db:"daily_limit"`
+	PerTransactionLimit money.NullAmount `db:"per_transaction_limit"`
+	Description         sql.NullString   `db:"description"`
+}
+
+type UserLimitDB struct {
+	UserID              sql.NullString   `db:"user_id"`
+	ProfileCode         sql.NullString   `db:"profile_code"`
+	DailyLimit          money.NullAmount `db:"daily_limit"`
+	PerTransactionLimit money.NullAmount `db:"per_transaction_limit"`
+	CreatedAt           time.Time        `db:"created_at"`
+	UpdatedAt           time.Time        `db:"updated_at"`
+}
+
+func (r *limitRepository) GetProfile(ctx context.Context, code string) (sentrapay.LimitProfile, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var profile LimitProfileDB
+
+	argsKV := map[string]interface{}{
+		"code": code,
+	}
+
+	query, args, err := sqlx.Named(queryGetLimitProfile, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetProfile named query preparation err")
+		return sentrapay.LimitProfile{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&profile); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"code":       code,
+			}).Error("GetProfile limit profile not found")
+			return sentrapay.LimitProfile{}, sentrapay.ErrLimitProfileNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetProfile execution err")
+		return sentrapay.LimitProfile{}, err
+	}
+
+	return sentrapay.LimitProfile{
+		Code:                profile.Code.String,
+		DailyLimit:          profile.DailyLimit.Amount,
+		PerTransactionLimit: profile.PerTransactionLimit.Amount,
+		Description:         profile.Description.String,
+	}, nil
+}
+
+func (r *limitRepository) GetUserLimit(ctx context.Context, userID string) (sentrapay.UserLimit, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var limit UserLimitDB
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	query, args, err := sqlx.Named(queryGetUserLimit, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetUserLimit named query preparation err")
+		return sentrapay.UserLimit{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&limit); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.UserLimit{}, sentrapay.ErrUserLimitNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetUserLimit execution err")
+		return sentrapay.UserLimit{}, err
+	}
+
+	result := sentrapay.UserLimit{
+		UserID:      limit.UserID.String,
+		ProfileCode: limit.ProfileCode.String,
+		CreatedAt:   limit.CreatedAt,
+		UpdatedAt:   limit.UpdatedAt,
+	}
+
+	if limit.DailyLimit.Valid {
+		result.DailyLimit = &limit.DailyLimit.Amount
+	}
+
+	if limit.PerTransactionLimit.Valid {
+		result.PerTransactionLimit = &limit.PerTransactionLimit.Amount
+	}
+
+	return result, nil
+}
+
+func (r *limitRepository) UpsertUserLimit(ctx context.Context, limit sentrapay.UserLimit) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"user_id":               limit.UserID,
+		"profile_code":          sql.NullString{String: limit.ProfileCode, Valid: limit.ProfileCode != ""},
+		"daily_limit":           limit.DailyLimit,
+		"per_transaction_limit": limit.PerTransactionLimit,
+		"created_at":            limit.CreatedAt,
+		"updated_at":            limit.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryUpsertUserLimit, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to build SQL query for UpsertUserLimit")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Database error when saving user limit")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type PaymentNotificationDB struct {
+	ID                sql.NullString `db:"id"`
+	Provider          sql.NullString `db:"provider"`
+	HTTPMethod        sql.NullString `db:"http_method"`
+	EndpointURL       sql.NullString `db:"endpoint_url"`
+	Headers           []byte         `db:"headers"`
+	Body              []byte         `db:"body"`
+	Verification      sql.NullString `db:"verification"`
+	VerificationError sql.NullString `db:"verification_error"`
+	Callback          []byte         `db:"callback"`
+	ReferenceNo       sql.NullString `db:"reference_no"`
+	PaymentID         sql.NullString `db:"payment_id"`
+	Outcome           sql.NullString `db:"outcome"`
+	OutcomeError      sql.NullString `db:"outcome_error"`
+	ReplayCount       int            `db:"replay_count"`
+	ReceivedAt        time.Time      `db:"received_at"`
+	ProcessedAt       sql.NullTime   `db:"processed_at"`
+	UpdatedAt         time.Time      `db:"updated_at"`
+}
+
+func (r *paymentNotificationRepository) Create(ctx context.Context, notification sentrapay.PaymentNotificationRecord) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV, err := paymentNotificationArgs(notification)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreatePaymentNotification marshal err")
+		return err
+	}
+	argsKV["provider"] = notification.Provider
+	argsKV["http_method"] = notification.HTTPMethod
+	argsKV["endpoint_url"] = notification.EndpointURL
+	argsKV["body"] = []byte(notification.Body)
+	argsKV["received_at"] = notification.ReceivedAt
+
+	headers, err := json.Marshal(notification.Headers)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreatePaymentNotification marshal err")
+		return err
+	}
+	argsKV["headers"] = string(headers)
+
+	query, args, err := sqlx.Named(queryCreatePaymentNotification, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreatePaymentNotification named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreatePaymentNotification execution err")
+		return err
+	}
+
+	return nil
+}
+
+// Update records the verification result and processing outcome. The raw
+// request is never rewritten.
+func (r *paymentNotificationRepository) Update(ctx context.Context, notification sentrapay.PaymentNotificationRecord) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV, err := paymentNotificationArgs(notification)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdatePaymentNotification marshal err")
+		return err
+	}
+
+	query, args, err := sqlx.Named(queryUpdatePaymentNotification, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdatePaymentNotification named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdatePaymentNotification execution err")
+		return err
+	}
+
+	return nil
+}
+
+func paymentNotificationArgs(notification sentrapay.PaymentNotificationRecord) (map[string]interface{}, error) {
+	callback := sql.NullString{}
+	if notification.Callback != nil {
+		encoded, err := json.Marshal(notification.Callback)
+		if err != nil {
+			return nil, err
+		}
+		callback = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	return map[string]interface{}{
+		"id":                 notification.ID,
+		"verification":       notification.Verification,
+		"verification_error": sql.NullString{String: notification.VerificationError, Valid: notification.VerificationError != ""},
+		"callback":           callback,
+		"reference_no":       sql.NullString{String: notification.ReferenceNo, Valid: notification.ReferenceNo != ""},
+		"payment_id":         sql.NullString{String: notification.PaymentID, Valid: notification.PaymentID != ""},
+		"outcome":            notification.Outcome,
+		"outcome_error":      sql.NullString{String: notification.OutcomeError, Valid: notification.OutcomeError != ""},
+		"replay_count":       notification.ReplayCount,
+		"processed_at":       notification.ProcessedAt,
+		"updated_at":         notification.UpdatedAt,
+	}, nil
+}
+
+func (r *paymentNotificationRepository) GetByID(ctx context.Context, id string) (sentrapay.PaymentNotificationRecord, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var notification PaymentNotificationDB
+
+	argsKV := map[string]interface{}{
+		"id": id,
+	}
+
+	query, args, err := sqlx.Named(queryGetPaymentNotification, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentNotification named query preparation err")
+		return sentrapay.PaymentNotificationRecord{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&notification); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.PaymentNotificationRecord{}, sentrapay.ErrPaymentNotificationNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentNotification execution err")
+		return sentrapay.PaymentNotificationRecord{}, err
+	}
+
+	return r.makePaymentNotification(ctx, notification), nil
+}
+
+func (r *paymentNotificationRepository) GetList(ctx context.Context, outcome, referenceNo string, limit int) ([]sentrapay.PaymentNotificationRecord, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var notifications []PaymentNotificationDB
+
+	argsKV := map[string]interface{}{
+		"outcome":      outcome,
+		"reference_no": referenceNo,
+		"limit":        limit,
+	}
+
+	query, args, err := sqlx.Named(queryGetPaymentNotifications, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentNotifications named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &notifications, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentNotifications execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.PaymentNotificationRecord, 0, len(notifications))
+	for _, notification := range notifications {
+		result = append(result, r.makePaymentNotification(ctx, notification))
+	}
+
+	return result, nil
+}
+
+func (r *paymentNotificationRepository) makePaymentNotification(ctx context.Context, notification PaymentNotificationDB) sentrapay.PaymentNotificationRecord {
+	result := sentrapay.PaymentNotificationRecord{
+		ID:                notification.ID.String,
+		Provider:          notification.Provider.String,
+		HTTPMethod:        notification.HTTPMethod.String,
+		EndpointURL:       notification.EndpointURL.String,
+		Body:              string(notification.Body),
+		Verification:      notification.Verification.String,
+		VerificationError: notification.VerificationError.String,
+		ReferenceNo:       notification.ReferenceNo.String,
+		PaymentID:         notification.PaymentID.String,
+		Outcome:           notification.Outcome.String,
+		OutcomeError:      notification.OutcomeError.String,
+		ReplayCount:       notification.ReplayCount,
+		ReceivedAt:        notification.ReceivedAt,
+		UpdatedAt:         notification.UpdatedAt,
+	}
+
+	if notification.ProcessedAt.Valid {
+		result.ProcessedAt = &notification.ProcessedAt.Time
+	}
+
+	if err := json.Unmarshal(notification.Headers, &result.Headers); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id":      contextPkg.GetRequestID(ctx),
+			"notification_id": result.ID,
+			"error":           err.Error(),
+		}).Warn("Failed to decode stored notification headers")
+	}
+
+	if len(notification.Callback) > 0 {
+		var callback sentrapay.PaymentCallbackRequest
+		if err := json.Unmarshal(notification.Callback, &callback); err != nil {
+			r.log.WithFields(logrus.Fields{
+				"request_id":      contextPkg.GetRequestID(ctx),
+				"notification_id": result.ID,
+				"error":           err.Error(),
+			}).Warn("Failed to decode stored notification callback")
+		} else {
+			callback.Provider = result.Provider
+			result.Callback = &callback
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,486 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type PaymentRequestDB struct {
+	ID              sql.NullString   `db:"id"`
+	RequesterID     sql.NullString   `db:"requester_id"`
+	RequesterName   sql.NullString   `db:"requester_name"`
+	Note            sql.NullString   `db:"note"`
+	QRISReferenceNo sql.NullString   `db:"qris_reference_no"`
+	TotalAmount     money.NullAmount `db:"total_amount"`
+	Status          sql.NullString   `db:"status"`
+	ExpiresAt       time.Time        `db:"expires_at"`
+	CreatedAt       time.Time        `db:"created_at"`
+	UpdatedAt       time.Time        `db:"updated_at"`
+}
+
+type PaymentRequestParticipantDB struct {
+	ID                  sql.NullString   `db:"id"`
+	RequestID           sql.NullString   `db:"request_id"`
+	UserID              sql.NullString   `db:"user_id"`
+	Name                sql.NullString   `db:"name"`
+	PhoneNumber         sql.NullString   `db:"phone_number"`
+	Amount              money.NullAmount `db:"amount"`
+	Status              sql.NullString   `db:"status"`
+	TransferReferenceNo sql.NullString   `db:"transfer_reference_no"`
+	ReminderCount       int              `db:"reminder_count"`
+	LastRemindedAt      sql.NullTime     `db:"last_reminded_at"`
+	RespondedAt         sql.NullTime     `db:"responded_at"`
+	CreatedAt           time.Time        `db:"created_at"`
+	UpdatedAt           time.Time        `db:"updated_at"`
+}
+
+// Create stores the request and all of its participants.
+func (r *paymentRequestRepository) Create(ctx context.Context, request sentrapay.PaymentRequest) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":                request.ID,
+		"requester_id":      request.RequesterID,
+		"requester_name":    request.RequesterName,
+		"note":              sql.NullString{String: request.Note, Valid: request.Note != ""},
+		"qris_reference_no": sql.NullString{String: request.QRISReferenceNo, Valid: request.QRISReferenceNo != ""},
+		"total_amount":      request.TotalAmount,
+		"status":            request.Status,
+		"expires_at":        request.ExpiresAt,
+		"created_at":        request.CreatedAt,
+		"updated_at":        request.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreatePaymentRequest, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreatePaymentRequest named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreatePaymentRequest execution err")
+		return err
+	}
+
+	for _, participant := range request.Participants {
+		argsKV := map[string]interface{}{
+			"id":           participant.ID,
+			"request_id":   request.ID,
+			"user_id":      participant.UserID,
+			"name":         participant.Name,
+			"phone_number": participant.PhoneNumber,
+			"amount":       participant.Amount,
+			"status":       participant.Status,
+			"created_at":   participant.CreatedAt,
+			"updated_at":   participant.UpdatedAt,
+		}
+
+		query, args, err := sqlx.Named(queryCreatePaymentRequestParticipant, argsKV)
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("CreatePaymentRequestParticipant named query preparation err")
+			return err
+		}
+
+		query = r.q.Rebind(query)
+
+		if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+			r.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("CreatePaymentRequestParticipant execution err")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *paymentRequestRepository) GetByID(ctx context.Context, id string) (sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var request PaymentRequestDB
+
+	argsKV := map[string]interface{}{
+		"id": id,
+	}
+
+	query, args, err := sqlx.Named(queryGetPaymentRequestByID, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentRequestByID named query preparation err")
+		return sentrapay.PaymentRequest{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&request); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.PaymentRequest{}, sentrapay.ErrPaymentRequestNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentRequestByID execution err")
+		return sentrapay.PaymentRequest{}, err
+	}
+
+	result := makePaymentRequest(request)
+
+	result.Participants, err = r.getParticipants(ctx, id)
+	if err != nil {
+		return sentrapay.PaymentRequest{}, err
+	}
+
+	return result, nil
+}
+
+// GetByRequester returns the newest requests the user sent, each with all of
+// its participants.
+func (r *paymentRequestRepository) GetByRequester(ctx context.Context, userID string, limit int) ([]sentrapay.PaymentRequest, error) {
+	return r.getRequests(ctx, queryGetPaymentRequestsByRequester, userID, limit, "GetPaymentRequestsByRequester")
+}
+
+// GetByParticipant returns the newest requests the user was asked to pay,
+// each with all of its participants; callers trim them to the user's own.
+func (r *paymentRequestRepository) GetByParticipant(ctx context.Context, userID string, limit int) ([]sentrapay.PaymentRequest, error) {
+	return r.getRequests(ctx, queryGetPaymentRequestsByParticipant, userID, limit, "GetPaymentRequestsByParticipant")
+}
+
+func (r *paymentRequestRepository) getRequests(ctx context.Context, baseQuery, userID string, limit int, name string) ([]sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var requests []PaymentRequestDB
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"limit":   limit,
+	}
+
+	query, args, err := sqlx.Named(baseQuery, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error(name + " named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &requests, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error(name + " execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.PaymentRequest, 0, len(requests))
+	for _, request := range requests {
+		paymentRequest := makePaymentRequest(request)
+
+		paymentRequest.Participants, err = r.getParticipants(ctx, paymentRequest.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, paymentRequest)
+	}
+
+	return result, nil
+}
+
+func (r *paymentRequestRepository) getParticipants(ctx context.Context, paymentRequestID string) ([]sentrapay.PaymentRequestParticipant, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var participants []PaymentRequestParticipantDB
+
+	argsKV := map[string]interface{}{
+		"request_id": paymentRequestID,
+	}
+
+	query, args, err := sqlx.Named(queryGetPaymentRequestParticipants, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentRequestParticipants named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &participants, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPaymentRequestParticipants execution err")
+		return nil, err
+	}
+
+	return makePaymentRequestParticipants(participants), nil
+}
+
+// TransitionParticipant moves a participant from fromStatus to
+// participant.Status. Leaving pending also requires the request to be open and
+// unexpired. It returns ErrPaymentRequestAnswered when the participant is no
+// longer in fromStatus or the request has closed.
+func (r *paymentRequestRepository) TransitionParticipant(ctx context.Context, participant sentrapay.PaymentRequestParticipant, fromStatus string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	var respondedAt sql.NullTime
+	if participant.RespondedAt != nil {
+		respondedAt = sql.NullTime{Time: *participant.RespondedAt, Valid: true}
+	}
+
+	argsKV := map[string]interface{}{
+		"id":                    participant.ID,
+		"status":                participant.Status,
+		"from_status":           fromStatus,
+		"transfer_reference_no": sql.NullString{String: participant.TransferReferenceNo, Valid: participant.TransferReferenceNo != ""},
+		"responded_at":          respondedAt,
+		"updated_at":            time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryTransitionPaymentRequestParticipant, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("TransitionPaymentRequestParticipant named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("TransitionPaymentRequestParticipant execution err")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sentrapay.ErrPaymentRequestAnswered
+	}
+
+	return nil
+}
+
+// CompleteIfAnswered closes an open request once no participant is left to
+// answer, and reports whether it did.
+func (r *paymentRequestRepository) CompleteIfAnswered(ctx context.Context, id string) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":         id,
+		"updated_at": time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryCompletePaymentRequest, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CompletePaymentRequest named query preparation err")
+		return false, err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CompletePaymentRequest execution err")
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Expire closes at most limit open requests past their expiry, along with
+// their unanswered participants, and returns the request IDs.
+func (r *paymentRequestRepository) Expire(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var ids []string
+
+	argsKV := map[string]interface{}{
+		"now":   now,
+		"limit": limit,
+	}
+
+	query, args, err := sqlx.Named(queryExpirePaymentRequests, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ExpirePaymentRequests named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &ids, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ExpirePaymentRequests execution err")
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetDueReminders returns pending participants of open requests who were
+// last reminded, or asked, before remindedBefore and have had fewer than
+// maxReminders reminders.
+func (r *paymentRequestRepository) GetDueReminders(ctx context.Context, now, remindedBefore time.Time, maxReminders, limit int) ([]sentrapay.PaymentRequestParticipant, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var participants []PaymentRequestParticipantDB
+
+	argsKV := map[string]interface{}{
+		"now":             now,
+		"reminded_before": remindedBefore,
+		"max_reminders":   maxReminders,
+		"limit":           limit,
+	}
+
+	query, args, err := sqlx.Named(queryGetDuePaymentReminders, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetDuePaymentReminders named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &participants, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetDuePaymentReminders execution err")
+		return nil, err
+	}
+
+	return makePaymentRequestParticipants(participants), nil
+}
+
+// MarkReminded records a reminder to the participant unless another one was
+// recorded after remindedBefore, and reports whether this call recorded it.
+// Only the caller that records the reminder sends it.
+func (r *paymentRequestRepository) MarkReminded(ctx context.Context, participantID string, at, remindedBefore time.Time) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":              participantID,
+		"reminded_at":     at,
+		"reminded_before": remindedBefore,
+	}
+
+	query, args, err := sqlx.Named(queryMarkPaymentReminderSent, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("MarkPaymentReminderSent named query preparation err")
+		return false, err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("MarkPaymentReminderSent execution err")
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func makePaymentRequest(request PaymentRequestDB) sentrapay.PaymentRequest {
+	return sentrapay.PaymentRequest{
+		ID:              request.ID.String,
+		RequesterID:     request.RequesterID.String,
+		RequesterName:   request.RequesterName.String,
+		Note:            request.Note.String,
+		QRISReferenceNo: request.QRISReferenceNo.String,
+		TotalAmount:     request.TotalAmount.Amount,
+		Status:          request.Status.String,
+		ExpiresAt:       request.ExpiresAt,
+		CreatedAt:       request.CreatedAt,
+		UpdatedAt:       request.UpdatedAt,
+	}
+}
+
+func makePaymentRequestParticipants(participants []PaymentRequestParticipantDB) []sentrapay.PaymentRequestParticipant {
+	result := make([]sentrapay.PaymentRequestParticipant, 0, len(participants))
+	for _, participant := range participants {
+		var lastRemindedAt, respondedAt *time.Time
+		if participant.LastRemindedAt.Valid {
+			lastRemindedAt = &participant.LastRemindedAt.Time
+		}
+		if participant.RespondedAt.Valid {
+			respondedAt = &participant.RespondedAt.Time
+		}
+
+		result = append(result, sentrapay.PaymentRequestParticipant{
+			ID:                  participant.ID.String,
+			RequestID:           participant.RequestID.String,
+			UserID:              participant.UserID.String,
+			Name:                participant.Name.String,
+			PhoneNumber:         participant.PhoneNumber.String,
+			Amount:              participant.Amount.Amount,
+			Status:              participant.Status.String,
+			TransferReferenceNo: participant.TransferReferenceNo.String,
+			ReminderCount:       participant.ReminderCount,
+			LastRemindedAt:      lastRemindedAt,
+			RespondedAt:         respondedAt,
+			CreatedAt:           participant.CreatedAt,
+			UpdatedAt:           participant.UpdatedAt,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,212 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type QRISPaymentDB struct {
+	ID                 sql.NullString   `db:"id"`
+	UserID             sql.NullString   `db:"user_id"`
+	ReferenceNo        sql.NullString   `db:"reference_no"`
+	GatewayReferenceNo sql.NullString   `db:"gateway_reference_no"`
+	MerchantName       sql.NullString   `db:"merchant_name"`
+	Amount             money.NullAmount `db:"amount"`
+	FeeAmount          money.NullAmount `db:"fee_amount"`
+	TotalAmount        money.NullAmount `db:"total_amount"`
+	Status             sql.NullString   `db:"status"`
+	Gateway            sql.NullString   `db:"gateway"`
+	FailureReason      sql.NullString   `db:"failure_reason"`
+	TransactionDate    sql.NullString   `db:"transaction_date"`
+	CreatedAt          time.Time        `db:"created_at"`
+	UpdatedAt          time.Time        `db:"updated_at"`
+}
+
+func (r *qrisRepository) Create(ctx context.Context, payment sentrapay.QRISPayment) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":            payment.ID,
+		"user_id":       payment.UserID,
+		"reference_no":  payment.ReferenceNo,
+		"merchant_name": payment.MerchantName,
+		"amount":        payment.Amount,
+		"fee_amount":    payment.FeeAmount,
+		"total_amount":  payment.TotalAmount,
+		"status":        payment.Status,
+		"gateway":       sql.NullString{String: payment.Gateway, Valid: payment.Gateway != ""},
+		"created_at":    payment.CreatedAt,
+		"updated_at":    payment.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateQRISPayment, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateQRISPayment named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateQRISPayment execution err")
+		return err
+	}
+
+	return nil
+}
+
+// Transition moves a payment from fromStatus to payment.Status and records the
+// gateway fields that are set. It returns ErrQRISPaymentStateChanged when the
+// payment is no longer in fromStatus, so each stage is entered only once.
+func (r *qrisRepository) Transition(ctx context.Context, payment sentrapay.QRISPayment, fromStatus string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":                   payment.ID,
+		"status":               payment.Status,
+		"from_status":          fromStatus,
+		"gateway_reference_no": sql.NullString{String: payment.GatewayReferenceNo, Valid: payment.GatewayReferenceNo != ""},
+		"transaction_date":     sql.NullString{String: payment.TransactionDate, Valid: payment.TransactionDate != ""},
+		"failure_reason":       sql.NullString{String: payment.FailureReason, Valid: payment.FailureReason != ""},
+		"updated_at":           time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryTransitionQRISPayment, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("TransitionQRISPayment named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("TransitionQRISPayment execution err")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("TransitionQRISPayment rows affected err")
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sentrapay.ErrQRISPaymentStateChanged
+	}
+
+	return nil
+}
+
+// GetIncomplete returns payments that are still initiated or
+// gateway_confirmed and have not been touched since before.
+func (r *qrisRepository) GetIncomplete(ctx context.Context, before time.Time, limit int) ([]sentrapay.QRISPayment, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var payments []QRISPaymentDB
+
+	argsKV := map[string]interface{}{
+		"before": before,
+		"limit":  limit,
+	}
+
+	query, args, err := sqlx.Named(queryGetIncompleteQRISPayments, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetIncompleteQRISPayments named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &payments, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetIncompleteQRISPayments execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.QRISPayment, 0, len(payments))
+	for _, payment := range payments {
+		result = append(result, makeQRISPayment(payment))
+	}
+
+	return result, nil
+}
+
+func (r *qrisRepository) GetByReferenceNo(ctx context.Context, referenceNo string) (sentrapay.QRISPayment, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var payment QRISPaymentDB
+
+	argsKV := map[string]interface{}{
+		"reference_no": referenceNo,
+	}
+
+	query, args, err := sqlx.Named(queryGetQRISPaymentByReferenceNo, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetQRISPaymentByReferenceNo named query preparation err")
+		return sentrapay.QRISPayment{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&payment); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.QRISPayment{}, sentrapay.ErrQRISPaymentNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetQRISPaymentByReferenceNo execution err")
+		return sentrapay.QRISPayment{}, err
+	}
+
+	return makeQRISPayment(payment), nil
+}
+
+func makeQRISPayment(payment QRISPaymentDB) sentrapay.QRISPayment {
+	return sentrapay.QRISPayment{
+		ID:                 payment.ID.String,
+		UserID:             payment.UserID.String,
+		ReferenceNo:        payment.ReferenceNo.String,
+		GatewayReferenceNo: payment.GatewayReferenceNo.String,
+		MerchantName:       payment.MerchantName.String,
+		Amount:             payment.Amount.Amount,
+		FeeAmount:          payment.FeeAmount.Amount,
+		TotalAmount:        payment.TotalAmount.Amount,
+		Status:             payment.Status.String,
+		Gateway:            payment.Gateway.String,
+		FailureReason:      payment.FailureReason.String,
+		TransactionDate:    payment.TransactionDate.String,
+		CreatedAt:          payment.CreatedAt,
+		UpdatedAt:          payment.UpdatedAt,
+	}
+}
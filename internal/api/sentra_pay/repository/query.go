@@ -15,6 +15,7 @@ const (
 			:created_at,
 			:updated_at
 		)
+		ON CONFLICT (user_id) DO NOTHING
 	`
 
 	queryGetWallet = `
@@ -22,18 +23,38 @@ const (
 			id,
 			user_id,
 			balance,
+			status,
+			status_reason,
+			status_changed_at,
+			created_at,
+			updated_at
+		FROM wallets
+		WHERE user_id = :user_id
+	`
+
+	queryGetWalletForUpdate = `
+		SELECT
+			id,
+			user_id,
+			balance,
+			status,
+			status_reason,
+			status_changed_at,
 			created_at,
 			updated_at
 		FROM wallets
 		WHERE user_id = :user_id
+		FOR UPDATE
 	`
 
-	queryUpdateWalletBalance = `
+	queryApplyWalletBalanceDelta = `
 		UPDATE wallets
 		SET
-			balance = :balance,
+			balance = balance + :delta,
 			updated_at = :updated_at
 		WHERE user_id = :user_id
+		  AND balance + :delta >= 0
+		RETURNING balance
 	`
 
 	queryCreateTransaction = `
@@ -48,6 +69,9 @@ const (
 			bank_account,
 			bank_name,
 			description,
+			expires_at,
+			status_reason,
+			gateway,
 			created_at,
 			updated_at
 		) VALUES (
@@ -61,6 +85,9 @@ const (
 			:bank_account,
 			:bank_name,
 			:description,
+			:expires_at,
+			:status_reason,
+			:gateway,
 			:created_at,
 			:updated_at
 		)
@@ -78,6 +105,10 @@ const (
 			bank_account,
 			bank_name,
 			description,
+			expires_at,
+			status_reason,
+			gateway,
+			gateway_payment_id,
 			created_at,
 			updated_at
 		FROM wallet_transactions
@@ -96,44 +127,120 @@ const (
 			bank_account,
 			bank_name,
 			description,
+			expires_at,
+			status_reason,
+			gateway,
+			gateway_payment_id,
 			created_at,
 			updated_at
 		FROM wallet_transactions
 		WHERE reference_no = :reference_no
+		  AND user_id = :user_id
+	`
+
+	queryGetTransactionStatusesForUpdate = `
+		SELECT id, status
+		FROM wallet_transactions
+		WHERE reference_no = :reference_no
+		ORDER BY id
+		FOR UPDATE
 	`
 
-	queryUpdateTransactionStatus = `
+	queryTransitionTransactionStatus = `
 		UPDATE wallet_transactions
 		SET
-			status = :status,
+			status = :to_status,
+			status_reason = COALESCE(:status_reason, status_reason),
 			updated_at = :updated_at
-		WHERE reference_no = :reference_no
+		WHERE id = :id
+		  AND status = :from_status
+	`
+
+	queryCreateTransactionStatusHistory = `
+		INSERT INTO wallet_transaction_status_history (
+			transaction_id,
+			reference_no,
+			from_status,
+			to_status,
+			reason,
+			actor,
+			created_at
+		) VALUES (
+			:transaction_id,
+			:reference_no,
+			:from_status,
+			:to_status,
+			:reason,
+			:actor,
+			:created_at
+		)
+	`
+
+	queryGetTransactionStatusHistory = `
+		SELECT
+			h.id,
+			h.transaction_id,
+			h.reference_no,
+			h.from_status,
+			h.to_status,
+			h.reason,
+			h.actor,
+			h.created_at
+		FROM wallet_transaction_status_history h
+		JOIN wallet_transactions t ON t.id = h.transaction_id
+		WHERE h.reference_no = :reference_no
+		  AND t.user_id = :user_id
+		ORDER BY h.created_at, h.id
 	`
 
 	queryGetTransactionsByUserID = `
 		SELECT
-			id,
-			user_id,
-			amount,
-			type,
-			reference_no,
-			payment_method,
-			status,
-			bank_account,
-			bank_name,
-			description,
-			created_at,
-			updated_at
-		FROM wallet_transactions
-		WHERE user_id = :user_id
-		ORDER BY created_at DESC
-		LIMIT :limit OFFSET :offset
+			t.id,
+			t.user_id,
+			t.amount,
+			t.type,
+			t.reference_no,
+			t.payment_method,
+			t.status,
+			t.bank_account,
+			t.bank_name,
+			t.description,
+			t.expires_at,
+			t.status_reason,
+			t.created_at,
+			t.updated_at
+		FROM wallet_transactions t
+		LEFT JOIN qris_payments q ON q.reference_no = t.reference_no
+		WHERE t.user_id = :user_id
+		  AND (CAST(:from AS TIMESTAMP) IS NULL OR t.created_at >= :from)
+		  AND (CAST(:to AS TIMESTAMP) IS NULL OR t.created_at < :to)
+		  AND (CAST(:type AS TEXT) = '' OR t.type = :type)
+		  AND (CAST(:status AS TEXT) = '' OR t.status = :status)
+		  AND (CAST(:payment_method AS TEXT) = '' OR t.payment_method = :payment_method)
+		  AND (CAST(:search AS TEXT) = '' OR t.description ILIKE :search OR q.merchant_name ILIKE :search)
+		  AND (CAST(:cursor_created_at AS TIMESTAMP) IS NULL OR (t.created_at, t.id) < (:cursor_created_at, :cursor_id))
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT :limit
 	`
 
-	queryCountTransactionsByUserID = `
-		SELECT COUNT(*)
-		FROM wallet_transactions
-		WHERE user_id = :user_id
+	querySummarizeTransactionsByUserID = `
+		SELECT
+			COUNT(*) AS total,
+			COALESCE(SUM(t.amount) FILTER (
+				WHERE t.amount > 0 AND t.status IN ('success', 'reversed', 'refunded')
+			), 0) AS money_in,
+			COALESCE(-SUM(t.amount) FILTER (
+				WHERE t.amount < 0 AND t.status NOT IN ('pending', 'expired')
+			), 0) AS money_out
+		FROM wallet_transactions t
+		LEFT JOIN qris_payments q ON q.reference_no = t.reference_no
+		WHERE t.user_id = :user_id
+		  AND (CAST(:from AS TIMESTAMP) IS NULL OR t.created_at >= :from)
+		  AND (CAST(:to AS TIMESTAMP) IS NULL OR t.created_at < :to)
+		  AND (CAST(:type AS TEXT) = '' OR t.type = :type)
+		  AND (CAST(:status AS TEXT) = '' OR t.status = :status)
+		  AND (CAST(:payment_method AS TEXT) = '' OR t.payment_method = :payment_method)
+		  AND (CAST(:search AS TEXT) = '' OR t.description ILIKE :search OR q.merchant_name ILIKE :search)
 	`
 
 	queryCreateQRisTransaction = `
@@ -292,4 +399,1305 @@ const (
 		  AND created_at > :cutoff_time
 		LIMIT 1
 	`
+
+	queryCreateLedgerJournal = `
+		INSERT INTO wallet_journals (
+			id,
+			journal_key,
+			reference_no,
+			kind,
+			description,
+			created_at
+		) VALUES (
+			:id,
+			:journal_key,
+			:reference_no,
+			:kind,
+			:description,
+			:created_at
+		)
+		ON CONFLICT (journal_key) DO NOTHING
+	`
+
+	queryCreateLedgerEntry = `
+		INSERT INTO wallet_ledger_entries (
+			id,
+			journal_id,
+			account,
+			user_id,
+			direction,
+			amount,
+			balance_after,
+			created_at
+		) VALUES (
+			:id,
+			:journal_id,
+			:account,
+			:user_id,
+			:direction,
+			:amount,
+			:balance_after,
+			:created_at
+		)
+	`
+
+	queryCountWallets = `
+		SELECT COUNT(*)
+		FROM wallets
+	`
+
+	queryGetLedgerBalanceMismatches = `
+		SELECT
+			w.user_id,
+			w.balance AS wallet_balance,
+			COALESCE(l.ledger_balance, 0) AS ledger_balance
+		FROM wallets w
+		LEFT JOIN (
+			SELECT
+				user_id,
+				SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS ledger_balance
+			FROM wallet_ledger_entries
+			WHERE account LIKE 'wallet:%'
+			GROUP BY user_id
+		) l ON l.user_id = w.user_id
+		WHERE w.balance <> COALESCE(l.ledger_balance, 0)
+		ORDER BY w.user_id
+	`
+
+	queryGetUnbalancedJournals = `
+		SELECT
+			journal_id,
+			SUM(CASE WHEN direction = 'debit' THEN amount ELSE 0 END) AS total_debit,
+			SUM(CASE WHEN direction = 'credit' THEN amount ELSE 0 END) AS total_credit
+		FROM wallet_ledger_entries
+		GROUP BY journal_id
+		HAVING SUM(CASE WHEN direction = 'debit' THEN amount ELSE 0 END)
+		    <> SUM(CASE WHEN direction = 'credit' THEN amount ELSE 0 END)
+		ORDER BY journal_id
+	`
+
+	queryLockUserSpending = `
+		SELECT pg_advisory_xact_lock(hashtext('wallet_spending'), hashtext(:user_id))
+	`
+
+	querySumDebitsSince = `
+		SELECT COALESCE(SUM(-amount), 0)
+		FROM wallet_transactions
+		WHERE user_id = :user_id
+		  AND amount < 0
+		  AND status IN ('pending', 'processing', 'success')
+		  AND created_at >= :since
+	`
+
+	queryGetLimitProfile = `
+		SELECT
+			code,
+			daily_limit,
+			per_transaction_limit,
+			description
+		FROM wallet_limit_profiles
+		WHERE code = :code
+	`
+
+	queryGetUserLimit = `
+		SELECT
+			user_id,
+			profile_code,
+			daily_limit,
+			per_transaction_limit,
+			created_at,
+			updated_at
+		FROM wallet_user_limits
+		WHERE user_id = :user_id
+	`
+
+	queryUpsertUserLimit = `
+		INSERT INTO wallet_user_limits (
+			user_id,
+			profile_code,
+			daily_limit,
+			per_transaction_limit,
+			created_at,
+			updated_at
+		) VALUES (
+			:user_id,
+			:profile_code,
+			:daily_limit,
+			:per_transaction_limit,
+			:created_at,
+			:updated_at
+		)
+		ON CONFLICT (user_id) DO UPDATE SET
+			profile_code = EXCLUDED.profile_code,
+			daily_limit = EXCLUDED.daily_limit,
+			per_transaction_limit = EXCLUDED.per_transaction_limit,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	queryReserveIdempotencyKey = `
+		INSERT INTO idempotency_keys (
+			user_id,
+			endpoint,
+			idempotency_key,
+			request_hash,
+			status,
+			created_at,
+			updated_at,
+			expires_at
+		) VALUES (
+			:user_id,
+			:endpoint,
+			:idempotency_key,
+			:request_hash,
+			:status,
+			:created_at,
+			:updated_at,
+			:expires_at
+		)
+		ON CONFLICT (user_id, endpoint, idempotency_key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status = EXCLUDED.status,
+			response_status = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < EXCLUDED.created_at
+	`
+
+	queryGetIdempotencyKey = `
+		SELECT
+			user_id,
+			endpoint,
+			idempotency_key,
+			request_hash,
+			status,
+			response_status,
+			response_body,
+			created_at,
+			updated_at,
+			expires_at
+		FROM idempotency_keys
+		WHERE user_id = :user_id
+		  AND endpoint = :endpoint
+		  AND idempotency_key = :idempotency_key
+	`
+
+	queryCompleteIdempotencyKey = `
+		UPDATE idempotency_keys
+		SET status = :status,
+			response_status = :response_status,
+			response_body = :response_body,
+			updated_at = :updated_at
+		WHERE user_id = :user_id
+		  AND endpoint = :endpoint
+		  AND idempotency_key = :idempotency_key
+	`
+
+	queryDeleteIdempotencyKey = `
+		DELETE FROM idempotency_keys
+		WHERE user_id = :user_id
+		  AND endpoint = :endpoint
+		  AND idempotency_key = :idempotency_key
+		  AND status = 'processing'
+	`
+
+	queryExpirePendingTopUps = `
+		WITH expired AS (
+			UPDATE wallet_transactions
+			SET
+				status = 'expired',
+				status_reason = :status_reason,
+				updated_at = :updated_at
+			WHERE id IN (
+				SELECT id
+				FROM wallet_transactions
+				WHERE type IN ('topup', 'qris_receive')
+				  AND status = 'pending'
+				  AND expires_at IS NOT NULL
+				  AND expires_at <= :now
+				ORDER BY expires_at
+				LIMIT :limit
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, reference_no
+		), history AS (
+			INSERT INTO wallet_transaction_status_history (
+				transaction_id,
+				reference_no,
+				from_status,
+				to_status,
+				reason,
+				actor,
+				created_at
+			)
+			SELECT id, reference_no, 'pending', 'expired', :status_reason, :actor, :updated_at
+			FROM expired
+		)
+		SELECT reference_no FROM expired
+	`
+
+	queryUpdateTransactionStatusReason = `
+		UPDATE wallet_transactions
+		SET
+			status_reason = :status_reason,
+			updated_at = :updated_at
+		WHERE reference_no = :reference_no
+	`
+
+	queryGetOpenTopUps = `
+		SELECT
+			id,
+			user_id,
+			amount,
+			type,
+			reference_no,
+			payment_method,
+			status,
+			bank_account,
+			bank_name,
+			description,
+			expires_at,
+			status_reason,
+			gateway,
+			gateway_payment_id,
+			created_at,
+			updated_at
+		FROM wallet_transactions
+		WHERE type = 'topup'
+		  AND status IN ('pending', 'processing')
+		  AND id > :after_id
+		ORDER BY id
+		LIMIT :limit
+	`
+
+	queryCreateReconciliationRun = `
+		INSERT INTO wallet_reconciliation_runs (
+			id,
+			status,
+			started_at
+		) VALUES (
+			:id,
+			:status,
+			:started_at
+		)
+	`
+
+	queryFinishReconciliationRun = `
+		UPDATE wallet_reconciliation_runs
+		SET
+			status = :status,
+			checked_count = :checked_count,
+			credited_count = :credited_count,
+			mismatch_count = :mismatch_count,
+			error_count = :error_count,
+			error = :error,
+			finished_at = :finished_at
+		WHERE id = :id
+	`
+
+	queryCreateReconciliationItem = `
+		INSERT INTO wallet_reconciliation_items (
+			id,
+			run_id,
+			transaction_id,
+			reference_no,
+			user_id,
+			kind,
+			local_status,
+			expected_amount,
+			gateway_amount,
+			difference,
+			gateway_response_code,
+			note,
+			created_at
+		) VALUES (
+			:id,
+			:run_id,
+			:transaction_id,
+			:reference_no,
+			:user_id,
+			:kind,
+			:local_status,
+			:expected_amount,
+			:gateway_amount,
+			:difference,
+			:gateway_response_code,
+			:note,
+			:created_at
+		)
+	`
+
+	queryCreateBankAccount = `
+		INSERT INTO wallet_bank_accounts (
+			id,
+			user_id,
+			bank_code,
+			account_number,
+			account_name,
+			status,
+			verified_at,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:user_id,
+			:bank_code,
+			:account_number,
+			:account_name,
+			:status,
+			:verified_at,
+			:created_at,
+			:updated_at
+		)
+	`
+
+	queryGetBankAccountByID = `
+		SELECT
+			id,
+			user_id,
+			bank_code,
+			account_number,
+			account_name,
+			status,
+			verified_at,
+			created_at,
+			updated_at
+		FROM wallet_bank_accounts
+		WHERE id = :id
+		  AND user_id = :user_id
+	`
+
+	queryGetBankAccountByNumber = `
+		SELECT
+			id,
+			user_id,
+			bank_code,
+			account_number,
+			account_name,
+			status,
+			verified_at,
+			created_at,
+			updated_at
+		FROM wallet_bank_accounts
+		WHERE user_id = :user_id
+		  AND bank_code = :bank_code
+		  AND account_number = :account_number
+	`
+
+	queryGetBankAccountsByUserID = `
+		SELECT
+			id,
+			user_id,
+			bank_code,
+			account_number,
+			account_name,
+			status,
+			verified_at,
+			created_at,
+			updated_at
+		FROM wallet_bank_accounts
+		WHERE user_id = :user_id
+		ORDER BY created_at DESC
+	`
+
+	queryUpdateBankAccountVerification = `
+		UPDATE wallet_bank_accounts
+		SET
+			account_name = :account_name,
+			status = :status,
+			verified_at = :verified_at,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	queryCreateWithdrawal = `
+		INSERT INTO wallet_withdrawals (
+			id,
+			reference_no,
+			user_id,
+			bank_account_id,
+			amount,
+			status,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:reference_no,
+			:user_id,
+			:bank_account_id,
+			:amount,
+			:status,
+			:created_at,
+			:updated_at
+		)
+	`
+
+	queryGetWithdrawalByReferenceNo = `
+		SELECT
+			id,
+			reference_no,
+			user_id,
+			bank_account_id,
+			amount,
+			status,
+			gateway_reference,
+			failure_reason,
+			created_at,
+			updated_at
+		FROM wallet_withdrawals
+		WHERE reference_no = :reference_no
+	`
+
+	queryGetProcessingWithdrawals = `
+		SELECT
+			id,
+			reference_no,
+			user_id,
+			bank_account_id,
+			amount,
+			status,
+			gateway_reference,
+			failure_reason,
+			created_at,
+			updated_at
+		FROM wallet_withdrawals
+		WHERE status = 'processing'
+		  AND created_at <= :before
+		ORDER BY created_at
+		LIMIT :limit
+	`
+
+	queryUpdateWithdrawalStatus = `
+		UPDATE wallet_withdrawals
+		SET
+			status = :status,
+			gateway_reference = COALESCE(:gateway_reference, gateway_reference),
+			failure_reason = :failure_reason,
+			updated_at = :updated_at
+		WHERE reference_no = :reference_no
+		  AND status = 'processing'
+	`
+
+	queryCreateQRISPayment = `
+		INSERT INTO qris_payments (
+			id,
+			user_id,
+			reference_no,
+			merchant_name,
+			amount,
+			fee_amount,
+			total_amount,
+			status,
+			gateway,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:user_id,
+			:reference_no,
+			:merchant_name,
+			:amount,
+			:fee_amount,
+			:total_amount,
+			:status,
+			:gateway,
+			:created_at,
+			:updated_at
+		)
+	`
+
+	queryTransitionQRISPayment = `
+		UPDATE qris_payments
+		SET
+			status = :status,
+			gateway_reference_no = COALESCE(:gateway_reference_no, gateway_reference_no),
+			transaction_date = COALESCE(:transaction_date, transaction_date),
+			failure_reason = COALESCE(:failure_reason, failure_reason),
+			updated_at = :updated_at
+		WHERE id = :id
+		  AND status = :from_status
+	`
+
+	queryGetIncompleteQRISPayments = `
+		SELECT
+			id,
+			user_id,
+			reference_no,
+			gateway_reference_no,
+			merchant_name,
+			amount,
+			fee_amount,
+			total_amount,
+			status,
+			gateway,
+			failure_reason,
+			transaction_date,
+			created_at,
+			updated_at
+		FROM qris_payments
+		WHERE status IN ('initiated', 'gateway_confirmed')
+		  AND updated_at <= :before
+		ORDER BY updated_at
+		LIMIT :limit
+	`
+
+	queryCreateStatementJob = `
+		INSERT INTO wallet_statement_jobs (
+			id,
+			user_id,
+			from_date,
+			to_date,
+			format,
+			status,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:user_id,
+			:from_date,
+			:to_date,
+			:format,
+			:status,
+			:created_at,
+			:updated_at
+		)
+	`
+
+	queryGetStatementJobByID = `
+		SELECT
+			id,
+			user_id,
+			from_date,
+			to_date,
+			format,
+			status,
+			file_url,
+			error,
+			created_at,
+			updated_at,
+			completed_at
+		FROM wallet_statement_jobs
+		WHERE id = :id
+		  AND user_id = :user_id
+	`
+
+	queryClaimStatementJob = `
+		UPDATE wallet_statement_jobs
+		SET
+			status = 'processing',
+			updated_at = :updated_at
+		WHERE id = :id
+		  AND (status = 'pending' OR (status = 'processing' AND updated_at <= :stale_before))
+	`
+
+	queryFinishStatementJob = `
+		UPDATE wallet_statement_jobs
+		SET
+			status = :status,
+			file_url = :file_url,
+			error = :error,
+			updated_at = :updated_at,
+			completed_at = :completed_at
+		WHERE id = :id
+		  AND status = 'processing'
+	`
+
+	queryGetOpenStatementJobs = `
+		SELECT
+			id,
+			user_id,
+			from_date,
+			to_date,
+			format,
+			status,
+			file_url,
+			error,
+			created_at,
+			updated_at,
+			completed_at
+		FROM wallet_statement_jobs
+		WHERE status IN ('pending', 'processing')
+		  AND updated_at <= :before
+		ORDER BY updated_at
+		LIMIT :limit
+	`
+
+	queryGetWalletBalanceAt = `
+		SELECT COALESCE((
+			SELECT balance_after
+			FROM wallet_ledger_entries
+			WHERE user_id = :user_id
+			  AND balance_after IS NOT NULL
+			  AND created_at < :at
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		), 0)
+	`
+
+	querySetOpenTransactionAmount = `
+		UPDATE wallet_transactions
+		SET
+			amount = :amount,
+			updated_at = :updated_at
+		WHERE reference_no = :reference_no
+		  AND status = 'pending'
+		  AND amount = 0
+	`
+
+	queryGetQRISPaymentByReferenceNo = `
+		SELECT
+			id,
+			user_id,
+			reference_no,
+			gateway_reference_no,
+			merchant_name,
+			amount,
+			fee_amount,
+			total_amount,
+			status,
+			gateway,
+			failure_reason,
+			transaction_date,
+			created_at,
+			updated_at
+		FROM qris_payments
+		WHERE reference_no = :reference_no
+	`
+
+	queryCreatePaymentRequest = `
+		INSERT INTO payment_requests (
+			id,
+			requester_id,
+			requester_name,
+			note,
+			qris_reference_no,
+			total_amount,
+			status,
+			expires_at,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:requester_id,
+			:requester_name,
+			:note,
+			:qris_reference_no,
+			:total_amount,
+			:status,
+			:expires_at,
+			:created_at,
+			:updated_at
+		)
+	`
+
+	queryCreatePaymentRequestParticipant = `
+		INSERT INTO payment_request_participants (
+			id,
+			request_id,
+			user_id,
+			name,
+			phone_number,
+			amount,
+			status,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:request_id,
+			:user_id,
+			:name,
+			:phone_number,
+			:amount,
+			:status,
+			:created_at,
+			:updated_at
+		)
+	`
+
+	queryGetPaymentRequestByID = `
+		SELECT
+			id,
+			requester_id,
+			requester_name,
+			note,
+			qris_reference_no,
+			total_amount,
+			status,
+			expires_at,
+			created_at,
+			updated_at
+		FROM payment_requests
+		WHERE id = :id
+	`
+
+	queryGetPaymentRequestsByRequester = `
+		SELECT
+			id,
+			requester_id,
+			requester_name,
+			note,
+			qris_reference_no,
+			total_amount,
+			status,
+			expires_at,
+			created_at,
+			updated_at
+		FROM payment_requests
+		WHERE requester_id = :user_id
+		ORDER BY created_at DESC, id DESC
+		LIMIT :limit
+	`
+
+	queryGetPaymentRequestsByParticipant = `
+		SELECT
+			r.id,
+			r.requester_id,
+			r.requester_name,
+			r.note,
+			r.qris_reference_no,
+			r.total_amount,
+			r.status,
+			r.expires_at,
+			r.created_at,
+			r.updated_at
+		FROM payment_requests r
+		JOIN payment_request_participants p ON p.request_id = r.id
+		WHERE p.user_id = :user_id
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT :limit
+	`
+
+	queryGetPaymentRequestParticipants = `
+		SELECT
+			id,
+			request_id,
+			user_id,
+			name,
+			phone_number,
+			amount,
+			status,
+			transfer_reference_no,
+			reminder_count,
+			last_reminded_at,
+			responded_at,
+			created_at,
+			updated_at
+		FROM payment_request_participants
+		WHERE request_id = :request_id
+		ORDER BY created_at, id
+	`
+
+	queryTransitionPaymentRequestParticipant = `
+		UPDATE payment_request_participants p
+		SET
+			status = :status,
+			transfer_reference_no = COALESCE(:transfer_reference_no, p.transfer_reference_no),
+			responded_at = COALESCE(:responded_at, p.responded_at),
+			updated_at = :updated_at
+		WHERE p.id = :id
+		  AND p.status = :from_status
+		  AND (
+			:from_status <> 'pending'
+			OR EXISTS (
+				SELECT 1
+				FROM payment_requests r
+				WHERE r.id = p.request_id
+				  AND r.status = 'open'
+				  AND r.expires_at > :updated_at
+			)
+		  )
+	`
+
+	queryCompletePaymentRequest = `
+		UPDATE payment_requests r
+		SET
+			status = 'completed',
+			updated_at = :updated_at
+		WHERE r.id = :id
+		  AND r.status = 'open'
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM payment_request_participants p
+			WHERE p.request_id = r.id
+			  AND p.status IN ('pending', 'paying')
+		  )
+	`
+
+	queryExpirePaymentRequests = `
+		WITH expired AS (
+			UPDATE payment_requests
+			SET
+				status = 'expired',
+				updated_at = :now
+			WHERE id IN (
+				SELECT id
+				FROM payment_requests
+				WHERE status = 'open'
+				  AND expires_at <= :now
+				ORDER BY expires_at
+				LIMIT :limit
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id
+		), participants AS (
+			UPDATE payment_request_participants
+			SET
+				status = 'expired',
+				updated_at = :now
+			WHERE request_id IN (SELECT id FROM expired)
+			  AND status = 'pending'
+		)
+		SELECT id FROM expired
+	`
+
+	queryGetDuePaymentReminders = `
+		SELECT
+			p.id,
+			p.request_id,
+			p.user_id,
+			p.name,
+			p.phone_number,
+			p.amount,
+			p.status,
+			p.transfer_reference_no,
+			p.reminder_count,
+			p.last_reminded_at,
+			p.responded_at,
+			p.created_at,
+			p.updated_at
+		FROM payment_request_participants p
+		JOIN payment_requests r ON r.id = p.request_id
+		WHERE r.status = 'open'
+		  AND r.expires_at > :now
+		  AND p.status = 'pending'
+		  AND p.reminder_count < :max_reminders
+		  AND COALESCE(p.last_reminded_at, p.created_at) <= :reminded_before
+		ORDER BY p.created_at, p.id
+		LIMIT :limit
+	`
+
+	queryMarkPaymentReminderSent = `
+		UPDATE payment_request_participants
+		SET
+			reminder_count = reminder_count + 1,
+			last_reminded_at = :reminded_at,
+			updated_at = :reminded_at
+		WHERE id = :id
+		  AND COALESCE(last_reminded_at, created_at) <= :reminded_before
+	`
+
+	queryGetPendingTopUpByVirtualAccount = `
+		SELECT
+			id,
+			user_id,
+			amount,
+			type,
+			reference_no,
+			payment_method,
+			status,
+			bank_account,
+			bank_name,
+			description,
+			expires_at,
+			status_reason,
+			gateway,
+			gateway_payment_id,
+			created_at,
+			updated_at
+		FROM wallet_transactions
+		WHERE type = 'topup'
+		  AND status = 'pending'
+		  AND payment_method = 'virtual_account'
+		  AND TRIM(bank_account) = :virtual_account_no
+		  AND amount = :amount
+		  AND COALESCE(gateway, 'doku') = :gateway
+		  AND (expires_at IS NULL OR expires_at > :now)
+		ORDER BY created_at, id
+		LIMIT 1
+	`
+
+	queryNextVirtualAccountCustomerSequence = `
+		SELECT nextval('user_virtual_account_customer_seq')
+	`
+
+	queryCreateUserVirtualAccount = `
+		INSERT INTO user_virtual_accounts (
+			id,
+			user_id,
+			channel,
+			gateway,
+			partner_service_id,
+			customer_no,
+			virtual_account_no,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:user_id,
+			:channel,
+			:gateway,
+			:partner_service_id,
+			:customer_no,
+			:virtual_account_no,
+			:created_at,
+			:updated_at
+		)
+		ON CONFLICT (user_id, channel) DO NOTHING
+	`
+
+	queryGetUserVirtualAccount = `
+		SELECT
+			id,
+			user_id,
+			channel,
+			gateway,
+			partner_service_id,
+			customer_no,
+			virtual_account_no,
+			created_at,
+			updated_at
+		FROM user_virtual_accounts
+		WHERE user_id = :user_id
+		  AND channel = :channel
+	`
+
+	queryGetUserVirtualAccountByNumber = `
+		SELECT
+			id,
+			user_id,
+			channel,
+			gateway,
+			partner_service_id,
+			customer_no,
+			virtual_account_no,
+			created_at,
+			updated_at
+		FROM user_virtual_accounts
+		WHERE gateway = :gateway
+		  AND TRIM(virtual_account_no) = :virtual_account_no
+	`
+
+	queryGetUserVirtualAccountsByUserID = `
+		SELECT
+			id,
+			user_id,
+			channel,
+			gateway,
+			partner_service_id,
+			customer_no,
+			virtual_account_no,
+			created_at,
+			updated_at
+		FROM user_virtual_accounts
+		WHERE user_id = :user_id
+		ORDER BY channel
+	`
+
+	queryUpdateUserVirtualAccountNumber = `
+		UPDATE user_virtual_accounts
+		SET
+			virtual_account_no = :virtual_account_no,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	queryGetTransactionByGatewayPayment = `
+		SELECT
+			id,
+			user_id,
+			amount,
+			type,
+			reference_no,
+			payment_method,
+			status,
+			bank_account,
+			bank_name,
+			description,
+			expires_at,
+			status_reason,
+			gateway,
+			gateway_payment_id,
+			created_at,
+			updated_at
+		FROM wallet_transactions
+		WHERE gateway = :gateway
+		  AND gateway_payment_id = :gateway_payment_id
+	`
+
+	querySetTransactionGatewayPayment = `
+		UPDATE wallet_transactions
+		SET
+			gateway = COALESCE(gateway, :gateway),
+			gateway_payment_id = :gateway_payment_id,
+			updated_at = :updated_at
+		WHERE reference_no = :reference_no
+	`
+
+	queryCreatePaymentNotification = `
+		INSERT INTO payment_notifications (
+			id,
+			provider,
+			http_method,
+			endpoint_url,
+			headers,
+			body,
+			verification,
+			verification_error,
+			callback,
+			reference_no,
+			payment_id,
+			outcome,
+			outcome_error,
+			replay_count,
+			received_at,
+			processed_at,
+			updated_at
+		) VALUES (
+			:id,
+			:provider,
+			:http_method,
+			:endpoint_url,
+			:headers,
+			:body,
+			:verification,
+			:verification_error,
+			:callback,
+			:reference_no,
+			:payment_id,
+			:outcome,
+			:outcome_error,
+			:replay_count,
+			:received_at,
+			:processed_at,
+			:updated_at
+		)
+	`
+
+	queryUpdatePaymentNotification = `
+		UPDATE payment_notifications
+		SET
+			verification = :verification,
+			verification_error = :verification_error,
+			callback = :callback,
+			reference_no = :reference_no,
+			payment_id = :payment_id,
+			outcome = :outcome,
+			outcome_error = :outcome_error,
+			replay_count = :replay_count,
+			processed_at = :processed_at,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	queryGetPaymentNotification = `
+		SELECT
+			id,
+			provider,
+			http_method,
+			endpoint_url,
+			headers,
+			body,
+			verification,
+			verification_error,
+			callback,
+			reference_no,
+			payment_id,
+			outcome,
+			outcome_error,
+			replay_count,
+			received_at,
+			processed_at,
+			updated_at
+		FROM payment_notifications
+		WHERE id = :id
+	`
+
+	queryGetPaymentNotifications = `
+		SELECT
+			id,
+			provider,
+			http_method,
+			endpoint_url,
+			headers,
+			body,
+			verification,
+			verification_error,
+			callback,
+			reference_no,
+			payment_id,
+			outcome,
+			outcome_error,
+			replay_count,
+			received_at,
+			processed_at,
+			updated_at
+		FROM payment_notifications
+		WHERE (CAST(:outcome AS TEXT) = '' OR outcome = :outcome)
+		  AND (CAST(:reference_no AS TEXT) = '' OR reference_no = :reference_no)
+		ORDER BY received_at DESC, id DESC
+		LIMIT :limit
+	`
+
+	queryGetTransactionsByReferenceNo = `
+		SELECT
+			id,
+			user_id,
+			amount,
+			type,
+			reference_no,
+			payment_method,
+			status,
+			bank_account,
+			bank_name,
+			description,
+			expires_at,
+			status_reason,
+			gateway,
+			gateway_payment_id,
+			created_at,
+			updated_at
+		FROM wallet_transactions
+		WHERE reference_no = :reference_no
+		ORDER BY id
+	`
+
+	queryTransitionWalletStatus = `
+		UPDATE wallets
+		SET
+			status = :status,
+			status_reason = :status_reason,
+			status_changed_at = :changed_at,
+			updated_at = :changed_at
+		WHERE user_id = :user_id
+		  AND status = :from_status
+	`
+
+	queryCountRiskMovementsSince = `
+		SELECT COUNT(*)
+		FROM wallet_transactions
+		WHERE user_id = :user_id
+		  AND (amount < 0 OR type = 'topup')
+		  AND created_at >= :since
+	`
+
+	queryGetRiskMovementTimesSince = `
+		SELECT created_at
+		FROM wallet_transactions
+		WHERE user_id = :user_id
+		  AND (amount < 0 OR type = 'topup')
+		  AND status = 'success'
+		  AND created_at >= :since
+		ORDER BY created_at DESC
+		LIMIT :limit
+	`
+
+	queryHasPaidPayee = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM wallet_transactions
+			WHERE user_id = :user_id
+			  AND type = :type
+			  AND bank_account = :payee
+			  AND status = 'success'
+		)
+	`
+
+	queryGetKnownDevices = `
+		SELECT device_id
+		FROM wallet_devices
+		WHERE user_id = :user_id
+		ORDER BY last_seen_at DESC
+	`
+
+	queryRememberDevice = `
+		INSERT INTO wallet_devices (
+			user_id,
+			device_id,
+			first_seen_at,
+			last_seen_at
+		) VALUES (
+			:user_id,
+			:device_id,
+			:seen_at,
+			:seen_at
+		)
+		ON CONFLICT (user_id, device_id) DO UPDATE
+		SET last_seen_at = EXCLUDED.last_seen_at
+	`
+
+	queryCreateRiskDecision = `
+		INSERT INTO risk_decisions (
+			id,
+			user_id,
+			operation,
+			amount,
+			payee,
+			device_id,
+			action,
+			outcome,
+			results,
+			created_at
+		) VALUES (
+			:id,
+			:user_id,
+			:operation,
+			:amount,
+			:payee,
+			:device_id,
+			:action,
+			:outcome,
+			:results,
+			:created_at
+		)
+	`
+
+	queryGetRiskDecisions = `
+		SELECT
+			id,
+			user_id,
+			operation,
+			amount,
+			payee,
+			device_id,
+			action,
+			outcome,
+			results,
+			created_at
+		FROM risk_decisions
+		WHERE (CAST(:outcome AS TEXT) = '' OR outcome = :outcome)
+		  AND (CAST(:user_id AS TEXT) = '' OR user_id = :user_id)
+		ORDER BY created_at DESC, id DESC
+		LIMIT :limit
+	`
+
+	queryGetWalletLedgerEntries = `
+		SELECT
+			e.id,
+			e.direction,
+			e.amount,
+			e.balance_after,
+			e.created_at,
+			j.reference_no,
+			COALESCE(t.type, j.kind) AS kind,
+			COALESCE(t.description, j.description) AS description,
+			COALESCE(t.status, 'success') AS status
+		FROM wallet_ledger_entries e
+		JOIN wallet_journals j ON j.id = e.journal_id
+		LEFT JOIN LATERAL (
+			SELECT type, description, status
+			FROM wallet_transactions
+			WHERE reference_no = j.reference_no
+			  AND user_id = e.user_id
+			ORDER BY created_at, id
+			LIMIT 1
+		) t ON TRUE
+		WHERE e.user_id = :user_id
+		  AND e.balance_after IS NOT NULL
+		  AND e.created_at >= :from
+		  AND e.created_at < :to
+		  AND (CAST(:cursor_created_at AS TIMESTAMP) IS NULL OR (e.created_at, e.id) > (:cursor_created_at, :cursor_id))
+		ORDER BY e.created_at, e.id
+		LIMIT :limit
+	`
 )
@@ -0,0 +1,118 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+func (r *reconciliationRepository) CreateRun(ctx context.Context, run sentrapay.ReconciliationRun) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":         run.ID,
+		"status":     run.Status,
+		"started_at": run.StartedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateReconciliationRun, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateRun named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateRun execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *reconciliationRepository) FinishRun(ctx context.Context, run sentrapay.ReconciliationRun) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":             run.ID,
+		"status":         run.Status,
+		"checked_count":  run.CheckedCount,
+		"credited_count": run.CreditedCount,
+		"mismatch_count": run.MismatchCount,
+		"error_count":    run.ErrorCount,
+		"error":          sql.NullString{String: run.Error, Valid: run.Error != ""},
+		"finished_at":    run.FinishedAt,
+	}
+
+	query, args, err := sqlx.Named(queryFinishReconciliationRun, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("FinishRun named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("FinishRun execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *reconciliationRepository) CreateItem(ctx context.Context, item sentrapay.ReconciliationItem) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":                    item.ID,
+		"run_id":                item.RunID,
+		"transaction_id":        item.TransactionID,
+		"reference_no":          item.ReferenceNo,
+		"user_id":               item.UserID,
+		"kind":                  item.Kind,
+		"local_status":          item.LocalStatus,
+		"expected_amount":       item.ExpectedAmount,
+		"gateway_amount":        item.GatewayAmount,
+		"difference":            item.Difference,
+		"gateway_response_code": sql.NullString{String: item.GatewayResponseCode, Valid: item.GatewayResponseCode != ""},
+		"note":                  sql.NullString{String: item.Note, Valid: item.Note != ""},
+		"created_at":            item.CreatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateReconciliationItem, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateItem named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateItem execution err")
+		return err
+	}
+
+	return nil
+}
@@ -2,9 +2,11 @@ package sentrapayRepository
 
 import (
 	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	"ProjectGolang/pkg/money"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"time"
 )
 
 type SQLExecutor interface {
@@ -52,9 +54,21 @@ func (r *repository) NewClient(tx bool) (Client, error) {
 	}
 
 	return Client{
-		Wallet:   &walletRepository{q: sqlExecutor, log: r.log},
-		Commit:   commitFunc,
-		Rollback: rollbackFunc,
+		Wallet:              &walletRepository{q: sqlExecutor, log: r.log},
+		Ledger:              &ledgerRepository{q: sqlExecutor, log: r.log},
+		Limit:               &limitRepository{q: sqlExecutor, log: r.log},
+		Idempotency:         &idempotencyRepository{q: sqlExecutor, log: r.log},
+		Reconciliation:      &reconciliationRepository{q: sqlExecutor, log: r.log},
+		BankAccount:         &bankAccountRepository{q: sqlExecutor, log: r.log},
+		Withdrawal:          &withdrawalRepository{q: sqlExecutor, log: r.log},
+		QRIS:                &qrisRepository{q: sqlExecutor, log: r.log},
+		Statement:           &statementRepository{q: sqlExecutor, log: r.log},
+		PaymentRequest:      &paymentRequestRepository{q: sqlExecutor, log: r.log},
+		VirtualAccount:      &virtualAccountRepository{q: sqlExecutor, log: r.log},
+		PaymentNotification: &paymentNotificationRepository{q: sqlExecutor, log: r.log},
+		Risk:                &riskRepository{q: sqlExecutor, log: r.log},
+		Commit:              commitFunc,
+		Rollback:            rollbackFunc,
 	}, nil
 }
 
@@ -62,12 +76,123 @@ type Client struct {
 	Wallet interface {
 		CreateWallet(ctx context.Context, userID string) error
 		GetWallet(ctx context.Context, userID string) (sentrapay.WalletBalance, error)
-		UpdateWalletBalance(ctx context.Context, userID string, amount float64) error
+		GetWalletForUpdate(ctx context.Context, userID string) (sentrapay.WalletBalance, error)
+		TransitionStatus(ctx context.Context, userID, from, to, reason string, at time.Time) (bool, error)
+		ApplyBalanceDelta(ctx context.Context, userID string, delta money.Amount) (money.Amount, error)
 		CreateTransaction(ctx context.Context, transaction sentrapay.WalletTransaction) error
 		GetTransactionByID(ctx context.Context, id string) (sentrapay.WalletTransaction, error)
-		GetTransactionByReferenceNo(ctx context.Context, referenceNo string) (sentrapay.WalletTransaction, error)
-		UpdateTransactionStatus(ctx context.Context, referenceNo string, status string) error
-		GetTransactionsByUserID(ctx context.Context, userID string, limit, offset int) ([]sentrapay.WalletTransaction, int, error)
+		GetTransactionByReferenceNo(ctx context.Context, userID, referenceNo string) (sentrapay.WalletTransaction, error)
+		GetTransactionsByReferenceNo(ctx context.Context, referenceNo string) ([]sentrapay.WalletTransaction, error)
+		GetPendingTopUpByVirtualAccount(ctx context.Context, gateway, virtualAccountNo string, amount money.Amount, now time.Time) (sentrapay.WalletTransaction, error)
+		GetTransactionByGatewayPayment(ctx context.Context, gateway, paymentID string) (sentrapay.WalletTransaction, error)
+		SetTransactionGatewayPayment(ctx context.Context, referenceNo, gateway, paymentID string) error
+		UpdateTransactionStatus(ctx context.Context, referenceNo string, to sentrapay.TransactionStatus, change sentrapay.StatusChange) error
+		GetTransactionStatusHistory(ctx context.Context, userID, referenceNo string) ([]sentrapay.TransactionStatusHistory, error)
+		GetTransactionsByUserID(ctx context.Context, userID string, filter sentrapay.TransactionFilter, after *sentrapay.TransactionCursor, limit int) ([]sentrapay.WalletTransaction, error)
+		SummarizeTransactions(ctx context.Context, userID string, filter sentrapay.TransactionFilter) (sentrapay.TransactionSummary, error)
+		LockSpending(ctx context.Context, userID string) error
+		SumDebitsSince(ctx context.Context, userID string, since time.Time) (money.Amount, error)
+		ExpirePendingTopUps(ctx context.Context, now time.Time, change sentrapay.StatusChange, limit int) ([]string, error)
+		UpdateTransactionStatusReason(ctx context.Context, referenceNo string, reason string) error
+		SetOpenTransactionAmount(ctx context.Context, referenceNo string, amount money.Amount) error
+		GetOpenTopUps(ctx context.Context, afterID string, limit int) ([]sentrapay.WalletTransaction, error)
+	}
+
+	Ledger interface {
+		CreateJournal(ctx context.Context, journal sentrapay.LedgerJournal) error
+		CreateEntry(ctx context.Context, entry sentrapay.LedgerEntry) error
+		CountWallets(ctx context.Context) (int, error)
+		GetBalanceMismatches(ctx context.Context) ([]sentrapay.LedgerBalanceMismatch, error)
+		GetUnbalancedJournals(ctx context.Context) ([]sentrapay.UnbalancedJournal, error)
+		GetWalletBalanceAt(ctx context.Context, userID string, at time.Time) (money.Amount, error)
+		GetWalletEntries(ctx context.Context, userID string, from, to time.Time, after *sentrapay.TransactionCursor, limit int) ([]sentrapay.WalletLedgerEntry, error)
+	}
+
+	Limit interface {
+		GetProfile(ctx context.Context, code string) (sentrapay.LimitProfile, error)
+		GetUserLimit(ctx context.Context, userID string) (sentrapay.UserLimit, error)
+		UpsertUserLimit(ctx context.Context, limit sentrapay.UserLimit) error
+	}
+
+	Idempotency interface {
+		Reserve(ctx context.Context, record sentrapay.IdempotencyKey) (bool, error)
+		Get(ctx context.Context, userID, endpoint, key string) (sentrapay.IdempotencyKey, error)
+		Complete(ctx context.Context, record sentrapay.IdempotencyKey) error
+		Delete(ctx context.Context, userID, endpoint, key string) error
+	}
+
+	Reconciliation interface {
+		CreateRun(ctx context.Context, run sentrapay.ReconciliationRun) error
+		FinishRun(ctx context.Context, run sentrapay.ReconciliationRun) error
+		CreateItem(ctx context.Context, item sentrapay.ReconciliationItem) error
+	}
+
+	BankAccount interface {
+		Create(ctx context.Context, account sentrapay.BankAccount) error
+		GetByID(ctx context.Context, userID, id string) (sentrapay.BankAccount, error)
+		GetByNumber(ctx context.Context, userID, bankCode, accountNumber string) (sentrapay.BankAccount, error)
+		GetByUserID(ctx context.Context, userID string) ([]sentrapay.BankAccount, error)
+		UpdateVerification(ctx context.Context, account sentrapay.BankAccount) error
+	}
+
+	Withdrawal interface {
+		Create(ctx context.Context, withdrawal sentrapay.Withdrawal) error
+		GetByReferenceNo(ctx context.Context, referenceNo string) (sentrapay.Withdrawal, error)
+		GetProcessing(ctx context.Context, before time.Time, limit int) ([]sentrapay.Withdrawal, error)
+		UpdateStatus(ctx context.Context, referenceNo, status, gatewayReference, failureReason string) error
+	}
+
+	QRIS interface {
+		Create(ctx context.Context, payment sentrapay.QRISPayment) error
+		Transition(ctx context.Context, payment sentrapay.QRISPayment, fromStatus string) error
+		GetIncomplete(ctx context.Context, before time.Time, limit int) ([]sentrapay.QRISPayment, error)
+		GetByReferenceNo(ctx context.Context, referenceNo string) (sentrapay.QRISPayment, error)
+	}
+
+	Statement interface {
+		Create(ctx context.Context, job sentrapay.StatementJob) error
+		GetByID(ctx context.Context, userID, id string) (sentrapay.StatementJob, error)
+		Claim(ctx context.Context, id string, staleBefore time.Time) (bool, error)
+		Finish(ctx context.Context, job sentrapay.StatementJob) error
+		GetOpen(ctx context.Context, before time.Time, limit int) ([]sentrapay.StatementJob, error)
+	}
+
+	PaymentRequest interface {
+		Create(ctx context.Context, request sentrapay.PaymentRequest) error
+		GetByID(ctx context.Context, id string) (sentrapay.PaymentRequest, error)
+		GetByRequester(ctx context.Context, userID string, limit int) ([]sentrapay.PaymentRequest, error)
+		GetByParticipant(ctx context.Context, userID string, limit int) ([]sentrapay.PaymentRequest, error)
+		TransitionParticipant(ctx context.Context, participant sentrapay.PaymentRequestParticipant, fromStatus string) error
+		CompleteIfAnswered(ctx context.Context, id string) (bool, error)
+		Expire(ctx context.Context, now time.Time, limit int) ([]string, error)
+		GetDueReminders(ctx context.Context, now, remindedBefore time.Time, maxReminders, limit int) ([]sentrapay.PaymentRequestParticipant, error)
+		MarkReminded(ctx context.Context, participantID string, at, remindedBefore time.Time) (bool, error)
+	}
+
+	VirtualAccount interface {
+		NextCustomerSequence(ctx context.Context) (int64, error)
+		Create(ctx context.Context, account sentrapay.UserVirtualAccount) (bool, error)
+		Get(ctx context.Context, userID, channel string) (sentrapay.UserVirtualAccount, error)
+		GetByNumber(ctx context.Context, gateway, virtualAccountNo string) (sentrapay.UserVirtualAccount, error)
+		GetByUserID(ctx context.Context, userID string) ([]sentrapay.UserVirtualAccount, error)
+		UpdateNumber(ctx context.Context, account sentrapay.UserVirtualAccount) error
+	}
+
+	PaymentNotification interface {
+		Create(ctx context.Context, notification sentrapay.PaymentNotificationRecord) error
+		Update(ctx context.Context, notification sentrapay.PaymentNotificationRecord) error
+		GetByID(ctx context.Context, id string) (sentrapay.PaymentNotificationRecord, error)
+		GetList(ctx context.Context, outcome, referenceNo string, limit int) ([]sentrapay.PaymentNotificationRecord, error)
+	}
+
+	Risk interface {
+		CountMovementsSince(ctx context.Context, userID string, since time.Time) (int, error)
+		MovementTimesSince(ctx context.Context, userID string, since time.Time) ([]time.Time, error)
+		HasPaidPayee(ctx context.Context, userID, kind, payee string) (bool, error)
+		KnownDevices(ctx context.Context, userID string) ([]string, error)
+		RememberDevice(ctx context.Context, userID, deviceID string, seenAt time.Time) error
+		CreateDecision(ctx context.Context, decision sentrapay.RiskDecision) error
+		GetDecisions(ctx context.Context, outcome, userID string, limit int) ([]sentrapay.RiskDecision, error)
 	}
 
 	Commit   func() error
@@ -79,7 +204,62 @@ type walletRepository struct {
 	log *logrus.Logger
 }
 
+type ledgerRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type limitRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type idempotencyRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type reconciliationRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type bankAccountRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type withdrawalRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
 type qrisRepository struct {
 	q   SQLExecutor
 	log *logrus.Logger
 }
+
+type statementRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type paymentRequestRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type virtualAccountRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type paymentNotificationRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
+
+type riskRepository struct {
+	q   SQLExecutor
+	log *logrus.Logger
+}
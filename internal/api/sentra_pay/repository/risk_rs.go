@@ -0,0 +1,300 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/risk"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// riskHistoryLimit caps how many past movements the unusual hours rule
+// looks at; the most recent ones are enough to tell the user's pattern.
+const riskHistoryLimit = 500
+
+type RiskDecisionDB struct {
+	ID        sql.NullString `db:"id"`
+	UserID    sql.NullString `db:"user_id"`
+	Operation sql.NullString `db:"operation"`
+	Amount    money.Amount   `db:"amount"`
+	Payee     sql.NullString `db:"payee"`
+	DeviceID  sql.NullString `db:"device_id"`
+	Action    sql.NullString `db:"action"`
+	Outcome   sql.NullString `db:"outcome"`
+	Results   []byte         `db:"results"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func (r *riskRepository) CountMovementsSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var count int
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"since":   since,
+	}
+
+	query, args, err := sqlx.Named(queryCountRiskMovementsSince, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CountRiskMovementsSince named query preparation err")
+		return 0, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).Scan(&count); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CountRiskMovementsSince execution err")
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *riskRepository) MovementTimesSince(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var times []time.Time
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"since":   since,
+		"limit":   riskHistoryLimit,
+	}
+
+	query, args, err := sqlx.Named(queryGetRiskMovementTimesSince, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetRiskMovementTimesSince named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &times, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetRiskMovementTimesSince execution err")
+		return nil, err
+	}
+
+	return times, nil
+}
+
+// HasPaidPayee reports whether the user completed a transaction of type kind
+// to payee before. The payee is stored in bank_account: the merchant name of
+// a QRIS payment, the recipient's phone number of a transfer and the account
+// number of a withdrawal.
+func (r *riskRepository) HasPaidPayee(ctx context.Context, userID, kind, payee string) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var paid bool
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"type":    kind,
+		"payee":   payee,
+	}
+
+	query, args, err := sqlx.Named(queryHasPaidPayee, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("HasPaidPayee named query preparation err")
+		return false, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).Scan(&paid); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("HasPaidPayee execution err")
+		return false, err
+	}
+
+	return paid, nil
+}
+
+func (r *riskRepository) KnownDevices(ctx context.Context, userID string) ([]string, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var devices []string
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	query, args, err := sqlx.Named(queryGetKnownDevices, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetKnownDevices named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &devices, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetKnownDevices execution err")
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (r *riskRepository) RememberDevice(ctx context.Context, userID, deviceID string, seenAt time.Time) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"user_id":   userID,
+		"device_id": deviceID,
+		"seen_at":   seenAt,
+	}
+
+	query, args, err := sqlx.Named(queryRememberDevice, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("RememberDevice named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("RememberDevice execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *riskRepository) CreateDecision(ctx context.Context, decision sentrapay.RiskDecision) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	results, err := json.Marshal(decision.Results)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateRiskDecision marshal err")
+		return err
+	}
+
+	argsKV := map[string]interface{}{
+		"id":         decision.ID,
+		"user_id":    decision.UserID,
+		"operation":  decision.Operation,
+		"amount":     decision.Amount,
+		"payee":      sql.NullString{String: decision.Payee, Valid: decision.Payee != ""},
+		"device_id":  sql.NullString{String: decision.DeviceID, Valid: decision.DeviceID != ""},
+		"action":     string(decision.Action),
+		"outcome":    decision.Outcome,
+		"results":    string(results),
+		"created_at": decision.CreatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateRiskDecision, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateRiskDecision named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateRiskDecision execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *riskRepository) GetDecisions(ctx context.Context, outcome, userID string, limit int) ([]sentrapay.RiskDecision, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var decisions []RiskDecisionDB
+
+	argsKV := map[string]interface{}{
+		"outcome": outcome,
+		"user_id": userID,
+		"limit":   limit,
+	}
+
+	query, args, err := sqlx.Named(queryGetRiskDecisions, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetRiskDecisions named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &decisions, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetRiskDecisions execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.RiskDecision, 0, len(decisions))
+	for _, decision := range decisions {
+		result = append(result, r.makeRiskDecision(ctx, decision))
+	}
+
+	return result, nil
+}
+
+func (r *riskRepository) makeRiskDecision(ctx context.Context, decision RiskDecisionDB) sentrapay.RiskDecision {
+	result := sentrapay.RiskDecision{
+		ID:        decision.ID.String,
+		UserID:    decision.UserID.String,
+		Operation: decision.Operation.String,
+		Amount:    decision.Amount,
+		Payee:     decision.Payee.String,
+		DeviceID:  decision.DeviceID.String,
+		Action:    risk.Action(decision.Action.String),
+		Outcome:   decision.Outcome.String,
+		Results:   []risk.Result{},
+		CreatedAt: decision.CreatedAt,
+	}
+
+	if err := json.Unmarshal(decision.Results, &result.Results); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id":  contextPkg.GetRequestID(ctx),
+			"decision_id": result.ID,
+			"error":       err.Error(),
+		}).Warn("Failed to decode stored risk decision results")
+	}
+
+	return result
+}
@@ -0,0 +1,234 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type StatementJobDB struct {
+	ID          sql.NullString `db:"id"`
+	UserID      sql.NullString `db:"user_id"`
+	FromDate    sql.NullTime   `db:"from_date"`
+	ToDate      sql.NullTime   `db:"to_date"`
+	Format      sql.NullString `db:"format"`
+	Status      sql.NullString `db:"status"`
+	FileURL     sql.NullString `db:"file_url"`
+	Error       sql.NullString `db:"error"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+	CompletedAt sql.NullTime   `db:"completed_at"`
+}
+
+func (r *statementRepository) Create(ctx context.Context, job sentrapay.StatementJob) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":         job.ID,
+		"user_id":    job.UserID,
+		"from_date":  job.From.Format(time.DateOnly),
+		"to_date":    job.To.Format(time.DateOnly),
+		"format":     job.Format,
+		"status":     job.Status,
+		"created_at": job.CreatedAt,
+		"updated_at": job.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateStatementJob, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateStatementJob named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateStatementJob execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *statementRepository) GetByID(ctx context.Context, userID, id string) (sentrapay.StatementJob, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var job StatementJobDB
+
+	argsKV := map[string]interface{}{
+		"id":      id,
+		"user_id": userID,
+	}
+
+	query, args, err := sqlx.Named(queryGetStatementJobByID, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetStatementJobByID named query preparation err")
+		return sentrapay.StatementJob{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&job); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.StatementJob{}, sentrapay.ErrStatementJobNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetStatementJobByID execution err")
+		return sentrapay.StatementJob{}, err
+	}
+
+	return r.makeStatementJob(job), nil
+}
+
+// Claim marks a job as processing. It succeeds for pending jobs and for
+// processing jobs not touched since staleBefore, whose worker is presumed
+// dead; it reports false when another worker holds the job.
+func (r *statementRepository) Claim(ctx context.Context, id string, staleBefore time.Time) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":           id,
+		"stale_before": staleBefore,
+		"updated_at":   time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryClaimStatementJob, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ClaimStatementJob named query preparation err")
+		return false, err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ClaimStatementJob execution err")
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ClaimStatementJob rows affected err")
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Finish records the outcome of a processing job.
+func (r *statementRepository) Finish(ctx context.Context, job sentrapay.StatementJob) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":           job.ID,
+		"status":       job.Status,
+		"file_url":     sql.NullString{String: job.FileURL, Valid: job.FileURL != ""},
+		"error":        sql.NullString{String: job.Error, Valid: job.Error != ""},
+		"updated_at":   job.UpdatedAt,
+		"completed_at": job.CompletedAt,
+	}
+
+	query, args, err := sqlx.Named(queryFinishStatementJob, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("FinishStatementJob named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("FinishStatementJob execution err")
+		return err
+	}
+
+	return nil
+}
+
+// GetOpen returns pending or processing jobs not touched since before.
+func (r *statementRepository) GetOpen(ctx context.Context, before time.Time, limit int) ([]sentrapay.StatementJob, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var jobs []StatementJobDB
+
+	argsKV := map[string]interface{}{
+		"before": before,
+		"limit":  limit,
+	}
+
+	query, args, err := sqlx.Named(queryGetOpenStatementJobs, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetOpenStatementJobs named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &jobs, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetOpenStatementJobs execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.StatementJob, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, r.makeStatementJob(job))
+	}
+
+	return result, nil
+}
+
+func (r *statementRepository) makeStatementJob(job StatementJobDB) sentrapay.StatementJob {
+	result := sentrapay.StatementJob{
+		ID:        job.ID.String,
+		UserID:    job.UserID.String,
+		From:      job.FromDate.Time,
+		To:        job.ToDate.Time,
+		Format:    job.Format.String,
+		Status:    job.Status.String,
+		FileURL:   job.FileURL.String,
+		Error:     job.Error.String,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+
+	if job.CompletedAt.Valid {
+		result.CompletedAt = &job.CompletedAt.Time
+	}
+
+	return result
+}
@@ -0,0 +1,220 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type UserVirtualAccountDB struct {
+	ID               sql.NullString `db:"id"`
+	UserID           sql.NullString `db:"user_id"`
+	Channel          sql.NullString `db:"channel"`
+	Gateway          sql.NullString `db:"gateway"`
+	PartnerServiceID sql.NullString `db:"partner_service_id"`
+	CustomerNo       sql.NullString `db:"customer_no"`
+	VirtualAccountNo sql.NullString `db:"virtual_account_no"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at"`
+}
+
+func (r *virtualAccountRepository) NextCustomerSequence(ctx context.Context) (int64, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var sequence int64
+
+	if err := r.q.QueryRowxContext(ctx, queryNextVirtualAccountCustomerSequence).Scan(&sequence); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("NextVirtualAccountCustomerSequence execution err")
+		return 0, err
+	}
+
+	return sequence, nil
+}
+
+// Create stores a user's virtual account for a channel. It returns false when
+// the user already has one for that channel.
+func (r *virtualAccountRepository) Create(ctx context.Context, account sentrapay.UserVirtualAccount) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":                 account.ID,
+		"user_id":            account.UserID,
+		"channel":            account.Channel,
+		"gateway":            account.Gateway,
+		"partner_service_id": account.PartnerServiceID,
+		"customer_no":        account.CustomerNo,
+		"virtual_account_no": account.VirtualAccountNo,
+		"created_at":         account.CreatedAt,
+		"updated_at":         account.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateUserVirtualAccount, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateUserVirtualAccount named query preparation err")
+		return false, err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateUserVirtualAccount execution err")
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateUserVirtualAccount rows affected err")
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *virtualAccountRepository) Get(ctx context.Context, userID, channel string) (sentrapay.UserVirtualAccount, error) {
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"channel": channel,
+	}
+
+	return r.get(ctx, queryGetUserVirtualAccount, argsKV, "GetUserVirtualAccount")
+}
+
+// GetByNumber looks a virtual account up by its number, ignoring the space
+// padding of the partner service ID.
+func (r *virtualAccountRepository) GetByNumber(ctx context.Context, gateway, virtualAccountNo string) (sentrapay.UserVirtualAccount, error) {
+	argsKV := map[string]interface{}{
+		"gateway":            gateway,
+		"virtual_account_no": virtualAccountNo,
+	}
+
+	return r.get(ctx, queryGetUserVirtualAccountByNumber, argsKV, "GetUserVirtualAccountByNumber")
+}
+
+func (r *virtualAccountRepository) get(ctx context.Context, namedQuery string, argsKV map[string]interface{}, name string) (sentrapay.UserVirtualAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var account UserVirtualAccountDB
+
+	query, args, err := sqlx.Named(namedQuery, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error(name + " named query preparation err")
+		return sentrapay.UserVirtualAccount{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&account); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.UserVirtualAccount{}, sentrapay.ErrVirtualAccountNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error(name + " execution err")
+		return sentrapay.UserVirtualAccount{}, err
+	}
+
+	return makeUserVirtualAccount(account), nil
+}
+
+func (r *virtualAccountRepository) GetByUserID(ctx context.Context, userID string) ([]sentrapay.UserVirtualAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var accounts []UserVirtualAccountDB
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	query, args, err := sqlx.Named(queryGetUserVirtualAccountsByUserID, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetUserVirtualAccountsByUserID named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &accounts, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetUserVirtualAccountsByUserID execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.UserVirtualAccount, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, makeUserVirtualAccount(account))
+	}
+
+	return result, nil
+}
+
+// UpdateNumber records the number a provider actually issued when it differs
+// from the one derived from configuration.
+func (r *virtualAccountRepository) UpdateNumber(ctx context.Context, account sentrapay.UserVirtualAccount) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":                 account.ID,
+		"virtual_account_no": account.VirtualAccountNo,
+		"updated_at":         account.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryUpdateUserVirtualAccountNumber, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateUserVirtualAccountNumber named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateUserVirtualAccountNumber execution err")
+		return err
+	}
+
+	return nil
+}
+
+func makeUserVirtualAccount(account UserVirtualAccountDB) sentrapay.UserVirtualAccount {
+	return sentrapay.UserVirtualAccount{
+		ID:               account.ID.String,
+		UserID:           account.UserID.String,
+		Channel:          account.Channel.String,
+		Gateway:          account.Gateway.String,
+		PartnerServiceID: account.PartnerServiceID.String,
+		CustomerNo:       account.CustomerNo.String,
+		VirtualAccountNo: account.VirtualAccountNo.String,
+		CreatedAt:        account.CreatedAt,
+		UpdatedAt:        account.UpdatedAt,
+	}
+}
@@ -3,35 +3,66 @@ package sentrapayRepository
 import (
 	sentrapay "ProjectGolang/internal/api/sentra_pay"
 	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
 	"context"
 	"database/sql"
 	"errors"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
+	"strings"
 	"time"
 )
 
 type WalletDB struct {
-	ID        sql.NullString  `db:"id"`
-	UserID    sql.NullString  `db:"user_id"`
-	Balance   sql.NullFloat64 `db:"balance"`
-	CreatedAt time.Time       `db:"created_at"`
-	UpdatedAt time.Time       `db:"updated_at"`
+	ID              sql.NullString   `db:"id"`
+	UserID          sql.NullString   `db:"user_id"`
+	Balance         money.NullAmount `db:"balance"`
+	Status          sql.NullString   `db:"status"`
+	StatusReason    sql.NullString   `db:"status_reason"`
+	StatusChangedAt sql.NullTime     `db:"status_changed_at"`
+	CreatedAt       time.Time        `db:"created_at"`
+	UpdatedAt       time.Time        `db:"updated_at"`
 }
 
 type WalletTransactionDB struct {
-	ID            sql.NullString  `db:"id"`
-	UserID        sql.NullString  `db:"user_id"`
-	Amount        sql.NullFloat64 `db:"amount"`
-	Type          sql.NullString  `db:"type"`
-	ReferenceNo   sql.NullString  `db:"reference_no"`
-	PaymentMethod sql.NullString  `db:"payment_method"`
-	Status        sql.NullString  `db:"status"`
-	BankAccount   sql.NullString  `db:"bank_account"`
-	BankName      sql.NullString  `db:"bank_name"`
-	Description   sql.NullString  `db:"description"`
-	CreatedAt     time.Time       `db:"created_at"`
-	UpdatedAt     time.Time       `db:"updated_at"`
+	ID               sql.NullString   `db:"id"`
+	UserID           sql.NullString   `db:"user_id"`
+	Amount           money.NullAmount `db:"amount"`
+	Type             sql.NullString   `db:"type"`
+	ReferenceNo      sql.NullString   `db:"reference_no"`
+	PaymentMethod    sql.NullString   `db:"payment_method"`
+	Status           sql.NullString   `db:"status"`
+	BankAccount      sql.NullString   `db:"bank_account"`
+	BankName         sql.NullString   `db:"bank_name"`
+	Description      sql.NullString   `db:"description"`
+	ExpiresAt        sql.NullTime     `db:"expires_at"`
+	StatusReason     sql.NullString   `db:"status_reason"`
+	Gateway          sql.NullString   `db:"gateway"`
+	GatewayPaymentID sql.NullString   `db:"gateway_payment_id"`
+	CreatedAt        time.Time        `db:"created_at"`
+	UpdatedAt        time.Time        `db:"updated_at"`
+}
+
+type TransactionSummaryDB struct {
+	Total    int          `db:"total"`
+	MoneyIn  money.Amount `db:"money_in"`
+	MoneyOut money.Amount `db:"money_out"`
+}
+
+type TransactionStatusDB struct {
+	ID     string `db:"id"`
+	Status string `db:"status"`
+}
+
+type TransactionStatusHistoryDB struct {
+	ID            int64          `db:"id"`
+	TransactionID string         `db:"transaction_id"`
+	ReferenceNo   string         `db:"reference_no"`
+	FromStatus    string         `db:"from_status"`
+	ToStatus      string         `db:"to_status"`
+	Reason        sql.NullString `db:"reason"`
+	Actor         string         `db:"actor"`
+	CreatedAt     time.Time      `db:"created_at"`
 }
 
 func (r *walletRepository) CreateWallet(ctx context.Context, userID string) error {
@@ -104,29 +135,84 @@ func (r *walletRepository) GetWallet(ctx context.Context, userID string) (sentra
 		return sentrapay.WalletBalance{}, err
 	}
 
-	return sentrapay.WalletBalance{
-		UserID:      wallet.UserID.String,
-		Balance:     wallet.Balance.Float64,
-		LastUpdated: wallet.UpdatedAt,
-	}, nil
+	return makeWalletBalance(wallet), nil
 }
 
-func (r *walletRepository) UpdateWalletBalance(ctx context.Context, userID string, amount float64) error {
+func (r *walletRepository) GetWalletForUpdate(ctx context.Context, userID string) (sentrapay.WalletBalance, error) {
 	requestID := contextPkg.GetRequestID(ctx)
+	var wallet WalletDB
 
 	argsKV := map[string]interface{}{
-		"user_id":    userID,
-		"balance":    amount,
-		"updated_at": time.Now(),
+		"user_id": userID,
 	}
 
-	query, args, err := sqlx.Named(queryUpdateWalletBalance, argsKV)
+	query, args, err := sqlx.Named(queryGetWalletForUpdate, argsKV)
 	if err != nil {
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("UpdateWalletBalance named query preparation err")
-		return err
+		}).Error("GetWalletForUpdate named query preparation err")
+		return sentrapay.WalletBalance{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&wallet); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Warn("GetWalletForUpdate no rows found")
+			return sentrapay.WalletBalance{}, sentrapay.ErrWalletNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWalletForUpdate execution err")
+
+		return sentrapay.WalletBalance{}, err
+	}
+
+	return makeWalletBalance(wallet), nil
+}
+
+func makeWalletBalance(wallet WalletDB) sentrapay.WalletBalance {
+	result := sentrapay.WalletBalance{
+		UserID:       wallet.UserID.String,
+		Balance:      wallet.Balance.Amount,
+		Status:       wallet.Status.String,
+		StatusReason: wallet.StatusReason.String,
+		LastUpdated:  wallet.UpdatedAt,
+	}
+
+	if wallet.StatusChangedAt.Valid {
+		result.StatusChangedAt = &wallet.StatusChangedAt.Time
+	}
+
+	return result
+}
+
+// TransitionStatus moves the wallet from status from to status to. It returns
+// false when the wallet was not in status from.
+func (r *walletRepository) TransitionStatus(ctx context.Context, userID, from, to, reason string, at time.Time) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"user_id":       userID,
+		"from_status":   from,
+		"status":        to,
+		"status_reason": sql.NullString{String: reason, Valid: reason != ""},
+		"changed_at":    at,
+	}
+
+	query, args, err := sqlx.Named(queryTransitionWalletStatus, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("TransitionWalletStatus named query preparation err")
+		return false, err
 	}
 
 	query = r.q.Rebind(query)
@@ -136,8 +222,8 @@ func (r *walletRepository) UpdateWalletBalance(ctx context.Context, userID strin
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("UpdateWalletBalance execution err")
-		return err
+		}).Error("TransitionWalletStatus execution err")
+		return false, err
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -145,18 +231,59 @@ func (r *walletRepository) UpdateWalletBalance(ctx context.Context, userID strin
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("UpdateWalletBalance rows affected err")
-		return err
+		}).Error("TransitionWalletStatus rows affected err")
+		return false, err
 	}
 
-	if rowsAffected == 0 {
+	return rowsAffected > 0, nil
+}
+
+// ApplyBalanceDelta adds delta to the wallet balance in a single statement so
+// concurrent postings cannot overwrite each other. A debit that would take the
+// balance below zero affects no rows and is reported as ErrInsufficientBalance.
+func (r *walletRepository) ApplyBalanceDelta(ctx context.Context, userID string, delta money.Amount) (money.Amount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var balance money.Amount
+
+	argsKV := map[string]interface{}{
+		"user_id":    userID,
+		"delta":      delta,
+		"updated_at": time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryApplyWalletBalanceDelta, argsKV)
+	if err != nil {
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
-		}).Warn("UpdateWalletBalance no rows affected")
-		return sentrapay.ErrWalletNotFound
+			"error":      err.Error(),
+		}).Error("ApplyBalanceDelta named query preparation err")
+		return 0, err
 	}
 
-	return nil
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).Scan(&balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, getErr := r.GetWallet(ctx, userID); getErr != nil {
+				return 0, getErr
+			}
+
+			r.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"delta":      delta,
+			}).Warn("ApplyBalanceDelta rejected, balance would become negative")
+			return 0, sentrapay.ErrInsufficientBalance
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ApplyBalanceDelta execution err")
+		return 0, err
+	}
+
+	return balance, nil
 }
 
 func (r *walletRepository) CreateTransaction(ctx context.Context, transaction sentrapay.WalletTransaction) error {
@@ -173,6 +300,9 @@ func (r *walletRepository) CreateTransaction(ctx context.Context, transaction se
 		"bank_account":   transaction.BankAccount,
 		"bank_name":      transaction.BankName,
 		"description":    transaction.Description,
+		"expires_at":     transaction.ExpiresAt,
+		"status_reason":  sql.NullString{String: transaction.StatusReason, Valid: transaction.StatusReason != ""},
+		"gateway":        sql.NullString{String: transaction.Gateway, Valid: transaction.Gateway != ""},
 		"created_at":     transaction.CreatedAt,
 		"updated_at":     transaction.UpdatedAt,
 	}
@@ -239,12 +369,15 @@ func (r *walletRepository) GetTransactionByID(ctx context.Context, id string) (s
 	return r.makeWalletTransaction(transaction), nil
 }
 
-func (r *walletRepository) GetTransactionByReferenceNo(ctx context.Context, referenceNo string) (sentrapay.WalletTransaction, error) {
+// GetTransactionByReferenceNo returns the user's own row under referenceNo.
+// A transfer has one row per party, so the lookup is always scoped to a user.
+func (r *walletRepository) GetTransactionByReferenceNo(ctx context.Context, userID, referenceNo string) (sentrapay.WalletTransaction, error) {
 	requestID := contextPkg.GetRequestID(ctx)
 	var transaction WalletTransactionDB
 
 	argsKV := map[string]interface{}{
 		"reference_no": referenceNo,
+		"user_id":      userID,
 	}
 
 	query, args, err := sqlx.Named(queryGetTransactionByReferenceNo, argsKV)
@@ -278,21 +411,138 @@ func (r *walletRepository) GetTransactionByReferenceNo(ctx context.Context, refe
 	return r.makeWalletTransaction(transaction), nil
 }
 
-func (r *walletRepository) UpdateTransactionStatus(ctx context.Context, referenceNo string, status string) error {
+// GetTransactionsByReferenceNo returns every row under referenceNo, one per
+// user involved.
+func (r *walletRepository) GetTransactionsByReferenceNo(ctx context.Context, referenceNo string) ([]sentrapay.WalletTransaction, error) {
 	requestID := contextPkg.GetRequestID(ctx)
+	var transactions []WalletTransactionDB
 
 	argsKV := map[string]interface{}{
 		"reference_no": referenceNo,
-		"status":       status,
-		"updated_at":   time.Now(),
 	}
 
-	query, args, err := sqlx.Named(queryUpdateTransactionStatus, argsKV)
+	query, args, err := sqlx.Named(queryGetTransactionsByReferenceNo, argsKV)
 	if err != nil {
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("UpdateTransactionStatus named query preparation err")
+		}).Error("GetTransactionsByReferenceNo named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &transactions, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetTransactionsByReferenceNo execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.WalletTransaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		result = append(result, r.makeWalletTransaction(transaction))
+	}
+
+	return result, nil
+}
+
+// GetPendingTopUpByVirtualAccount returns the oldest unexpired pending top-up
+// billed to a reusable virtual account for exactly amount.
+func (r *walletRepository) GetPendingTopUpByVirtualAccount(ctx context.Context, gateway, virtualAccountNo string, amount money.Amount, now time.Time) (sentrapay.WalletTransaction, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var transaction WalletTransactionDB
+
+	argsKV := map[string]interface{}{
+		"gateway":            gateway,
+		"virtual_account_no": virtualAccountNo,
+		"amount":             amount,
+		"now":                now,
+	}
+
+	query, args, err := sqlx.Named(queryGetPendingTopUpByVirtualAccount, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPendingTopUpByVirtualAccount named query preparation err")
+		return sentrapay.WalletTransaction{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&transaction); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.WalletTransaction{}, sentrapay.ErrTransactionNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetPendingTopUpByVirtualAccount execution err")
+		return sentrapay.WalletTransaction{}, err
+	}
+
+	return r.makeWalletTransaction(transaction), nil
+}
+
+// GetTransactionByGatewayPayment returns the transaction a gateway payment
+// was already applied to.
+func (r *walletRepository) GetTransactionByGatewayPayment(ctx context.Context, gateway, paymentID string) (sentrapay.WalletTransaction, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var transaction WalletTransactionDB
+
+	argsKV := map[string]interface{}{
+		"gateway":            gateway,
+		"gateway_payment_id": paymentID,
+	}
+
+	query, args, err := sqlx.Named(queryGetTransactionByGatewayPayment, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetTransactionByGatewayPayment named query preparation err")
+		return sentrapay.WalletTransaction{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&transaction); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.WalletTransaction{}, sentrapay.ErrTransactionNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetTransactionByGatewayPayment execution err")
+		return sentrapay.WalletTransaction{}, err
+	}
+
+	return r.makeWalletTransaction(transaction), nil
+}
+
+// SetTransactionGatewayPayment records the gateway payment that settles a
+// transaction. A payment can settle only one transaction, so applying it to a
+// second one fails on the unique index.
+func (r *walletRepository) SetTransactionGatewayPayment(ctx context.Context, referenceNo, gateway, paymentID string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"reference_no":       referenceNo,
+		"gateway":            gateway,
+		"gateway_payment_id": paymentID,
+		"updated_at":         time.Now(),
+	}
+
+	query, args, err := sqlx.Named(querySetTransactionGatewayPayment, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SetTransactionGatewayPayment named query preparation err")
 		return err
 	}
 
@@ -303,61 +553,221 @@ func (r *walletRepository) UpdateTransactionStatus(ctx context.Context, referenc
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("UpdateTransactionStatus execution err")
+		}).Error("SetTransactionGatewayPayment execution err")
 		return err
 	}
 
 	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sentrapay.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// UpdateTransactionStatus moves every row under referenceNo to status to.
+// Transitions not allowed by the status model, or rows that changed status
+// concurrently, fail with ErrInvalidTransactionState. Each transition is
+// recorded in the status history together with change.
+func (r *walletRepository) UpdateTransactionStatus(ctx context.Context, referenceNo string, to sentrapay.TransactionStatus, change sentrapay.StatusChange) error {
+	requestID := contextPkg.GetRequestID(ctx)
+	var rows []TransactionStatusDB
+
+	query, args, err := sqlx.Named(queryGetTransactionStatusesForUpdate, map[string]interface{}{
+		"reference_no": referenceNo,
+	})
 	if err != nil {
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("UpdateTransactionStatus rows affected err")
+		}).Error("UpdateTransactionStatus named query preparation err")
 		return err
 	}
 
-	if rowsAffected == 0 {
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &rows, query, args...); err != nil {
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
-		}).Warn("UpdateTransactionStatus no rows affected")
+			"error":      err.Error(),
+		}).Error("UpdateTransactionStatus execution err")
+		return err
+	}
+
+	if len(rows) == 0 {
+		r.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": referenceNo,
+		}).Warn("UpdateTransactionStatus no rows found")
 		return sentrapay.ErrTransactionNotFound
 	}
 
+	for _, row := range rows {
+		from := sentrapay.TransactionStatus(row.Status)
+		if !from.CanTransitionTo(to) {
+			r.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": referenceNo,
+				"from_status":  from,
+				"to_status":    to,
+			}).Warn("UpdateTransactionStatus illegal transition")
+			return sentrapay.ErrInvalidTransactionState
+		}
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		if err := r.transitionTransaction(ctx, row, referenceNo, to, change, now); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (r *walletRepository) GetTransactionsByUserID(ctx context.Context, userID string, limit, offset int) ([]sentrapay.WalletTransaction, int, error) {
+func (r *walletRepository) transitionTransaction(ctx context.Context, row TransactionStatusDB, referenceNo string, to sentrapay.TransactionStatus, change sentrapay.StatusChange, now time.Time) error {
 	requestID := contextPkg.GetRequestID(ctx)
-	var transactions []WalletTransactionDB
-	var total int
+	reason := sql.NullString{String: change.Reason, Valid: change.Reason != ""}
 
-	countArgsKV := map[string]interface{}{
-		"user_id": userID,
+	argsKV := map[string]interface{}{
+		"id":            row.ID,
+		"from_status":   row.Status,
+		"to_status":     string(to),
+		"status_reason": reason,
+		"updated_at":    now,
 	}
 
-	countQuery, countArgs, err := sqlx.Named(queryCountTransactionsByUserID, countArgsKV)
+	query, args, err := sqlx.Named(queryTransitionTransactionStatus, argsKV)
 	if err != nil {
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("CountTransactionsByUserID named query preparation err")
-		return nil, 0, err
+		}).Error("transitionTransaction named query preparation err")
+		return err
 	}
 
-	countQuery = r.q.Rebind(countQuery)
+	query = r.q.Rebind(query)
 
-	if err := r.q.QueryRowxContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
 		r.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("CountTransactionsByUserID execution err")
-		return nil, 0, err
+		}).Error("transitionTransaction execution err")
+		return err
 	}
 
-	argsKV := map[string]interface{}{
-		"user_id": userID,
-		"limit":   limit,
-		"offset":  offset,
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("transitionTransaction rows affected err")
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.log.WithFields(logrus.Fields{
+			"request_id":     requestID,
+			"transaction_id": row.ID,
+		}).Warn("transitionTransaction status changed concurrently")
+		return sentrapay.ErrInvalidTransactionState
+	}
+
+	historyKV := map[string]interface{}{
+		"transaction_id": row.ID,
+		"reference_no":   referenceNo,
+		"from_status":    row.Status,
+		"to_status":      string(to),
+		"reason":         reason,
+		"actor":          change.Actor,
+		"created_at":     now,
+	}
+
+	query, args, err = sqlx.Named(queryCreateTransactionStatusHistory, historyKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateTransactionStatusHistory named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateTransactionStatusHistory execution err")
+		return err
+	}
+
+	return nil
+}
+
+// GetTransactionStatusHistory returns the recorded transitions of the user's
+// own row under referenceNo, oldest first.
+func (r *walletRepository) GetTransactionStatusHistory(ctx context.Context, userID, referenceNo string) ([]sentrapay.TransactionStatusHistory, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var rows []TransactionStatusHistoryDB
+
+	query, args, err := sqlx.Named(queryGetTransactionStatusHistory, map[string]interface{}{
+		"reference_no": referenceNo,
+		"user_id":      userID,
+	})
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetTransactionStatusHistory named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &rows, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetTransactionStatusHistory execution err")
+		return nil, err
+	}
+
+	history := make([]sentrapay.TransactionStatusHistory, 0, len(rows))
+	for _, row := range rows {
+		history = append(history, sentrapay.TransactionStatusHistory{
+			ID:            row.ID,
+			TransactionID: row.TransactionID,
+			ReferenceNo:   row.ReferenceNo,
+			FromStatus:    sentrapay.TransactionStatus(row.FromStatus),
+			ToStatus:      sentrapay.TransactionStatus(row.ToStatus),
+			Reason:        row.Reason.String,
+			Actor:         row.Actor,
+			CreatedAt:     row.CreatedAt,
+		})
+	}
+
+	return history, nil
+}
+
+// GetTransactionsByUserID returns up to limit of the user's transactions
+// matching filter, newest first, starting after the given cursor.
+func (r *walletRepository) GetTransactionsByUserID(ctx context.Context, userID string, filter sentrapay.TransactionFilter, after *sentrapay.TransactionCursor, limit int) ([]sentrapay.WalletTransaction, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var transactions []WalletTransactionDB
+
+	argsKV := transactionFilterArgs(userID, filter)
+	argsKV["cursor_created_at"] = sql.NullTime{}
+	argsKV["cursor_id"] = ""
+	argsKV["limit"] = limit
+	if after != nil {
+		argsKV["cursor_created_at"] = sql.NullTime{Time: after.CreatedAt, Valid: true}
+		argsKV["cursor_id"] = after.ID
 	}
 
 	query, args, err := sqlx.Named(queryGetTransactionsByUserID, argsKV)
@@ -366,7 +776,7 @@ func (r *walletRepository) GetTransactionsByUserID(ctx context.Context, userID s
 			"request_id": requestID,
 			"error":      err.Error(),
 		}).Error("GetTransactionsByUserID named query preparation err")
-		return nil, 0, err
+		return nil, err
 	}
 
 	query = r.q.Rebind(query)
@@ -376,7 +786,7 @@ func (r *walletRepository) GetTransactionsByUserID(ctx context.Context, userID s
 			"request_id": requestID,
 			"error":      err.Error(),
 		}).Error("GetTransactionsByUserID execution err")
-		return nil, 0, err
+		return nil, err
 	}
 
 	result := make([]sentrapay.WalletTransaction, 0, len(transactions))
@@ -384,22 +794,305 @@ func (r *walletRepository) GetTransactionsByUserID(ctx context.Context, userID s
 		result = append(result, r.makeWalletTransaction(transaction))
 	}
 
-	return result, total, nil
+	return result, nil
+}
+
+func (r *walletRepository) SummarizeTransactions(ctx context.Context, userID string, filter sentrapay.TransactionFilter) (sentrapay.TransactionSummary, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var summary TransactionSummaryDB
+
+	query, args, err := sqlx.Named(querySummarizeTransactionsByUserID, transactionFilterArgs(userID, filter))
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SummarizeTransactions named query preparation err")
+		return sentrapay.TransactionSummary{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&summary); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SummarizeTransactions execution err")
+		return sentrapay.TransactionSummary{}, err
+	}
+
+	return sentrapay.TransactionSummary{
+		Total:    summary.Total,
+		MoneyIn:  summary.MoneyIn,
+		MoneyOut: summary.MoneyOut,
+	}, nil
+}
+
+func transactionFilterArgs(userID string, filter sentrapay.TransactionFilter) map[string]interface{} {
+	search := ""
+	if filter.Search != "" {
+		search = "%" + likeEscaper.Replace(filter.Search) + "%"
+	}
+
+	return map[string]interface{}{
+		"user_id":        userID,
+		"from":           sql.NullTime{Time: filter.From, Valid: !filter.From.IsZero()},
+		"to":             sql.NullTime{Time: filter.To, Valid: !filter.To.IsZero()},
+		"type":           filter.Type,
+		"status":         filter.Status,
+		"payment_method": filter.PaymentMethod,
+		"search":         search,
+	}
 }
 
+// likeEscaper escapes the LIKE wildcards so search text matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (r *walletRepository) makeWalletTransaction(transaction WalletTransactionDB) sentrapay.WalletTransaction {
+	var expiresAt *time.Time
+	if transaction.ExpiresAt.Valid {
+		expiresAt = &transaction.ExpiresAt.Time
+	}
+
 	return sentrapay.WalletTransaction{
-		ID:            transaction.ID.String,
-		UserID:        transaction.UserID.String,
-		Amount:        transaction.Amount.Float64,
-		Type:          transaction.Type.String,
-		ReferenceNo:   transaction.ReferenceNo.String,
-		PaymentMethod: transaction.PaymentMethod.String,
-		Status:        transaction.Status.String,
-		BankAccount:   transaction.BankAccount.String,
-		BankName:      transaction.BankName.String,
-		Description:   transaction.Description.String,
-		CreatedAt:     transaction.CreatedAt,
-		UpdatedAt:     transaction.UpdatedAt,
+		ID:               transaction.ID.String,
+		UserID:           transaction.UserID.String,
+		Amount:           transaction.Amount.Amount,
+		Type:             transaction.Type.String,
+		ReferenceNo:      transaction.ReferenceNo.String,
+		PaymentMethod:    transaction.PaymentMethod.String,
+		Status:           sentrapay.TransactionStatus(transaction.Status.String),
+		BankAccount:      transaction.BankAccount.String,
+		BankName:         transaction.BankName.String,
+		Description:      transaction.Description.String,
+		ExpiresAt:        expiresAt,
+		StatusReason:     transaction.StatusReason.String,
+		Gateway:          transaction.Gateway.String,
+		GatewayPaymentID: transaction.GatewayPaymentID.String,
+		CreatedAt:        transaction.CreatedAt,
+		UpdatedAt:        transaction.UpdatedAt,
+	}
+}
+
+// LockSpending serialises outgoing payments of one user until the surrounding
+// transaction ends, so concurrent debits cannot both pass the daily limit.
+func (r *walletRepository) LockSpending(ctx context.Context, userID string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	query, args, err := sqlx.Named(queryLockUserSpending, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("LockSpending named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("LockSpending execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *walletRepository) SumDebitsSince(ctx context.Context, userID string, since time.Time) (money.Amount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var total money.Amount
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+		"since":   since,
+	}
+
+	query, args, err := sqlx.Named(querySumDebitsSince, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SumDebitsSince named query preparation err")
+		return 0, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).Scan(&total); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SumDebitsSince execution err")
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// ExpirePendingTopUps moves at most limit pending top-ups whose VA expired
+// before now to the expired status, records the transition in the status
+// history and returns their reference numbers.
+func (r *walletRepository) ExpirePendingTopUps(ctx context.Context, now time.Time, change sentrapay.StatusChange, limit int) ([]string, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var referenceNos []string
+
+	argsKV := map[string]interface{}{
+		"now":           now,
+		"status_reason": change.Reason,
+		"actor":         change.Actor,
+		"updated_at":    now,
+		"limit":         limit,
+	}
+
+	query, args, err := sqlx.Named(queryExpirePendingTopUps, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ExpirePendingTopUps named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &referenceNos, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("ExpirePendingTopUps execution err")
+		return nil, err
+	}
+
+	return referenceNos, nil
+}
+
+// SetOpenTransactionAmount records the amount actually paid into a pending
+// transaction created without one, such as an open-amount QRIS code. It
+// returns ErrInvalidTransactionState when the amount is already set.
+func (r *walletRepository) SetOpenTransactionAmount(ctx context.Context, referenceNo string, amount money.Amount) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"reference_no": referenceNo,
+		"amount":       amount,
+		"updated_at":   time.Now(),
+	}
+
+	query, args, err := sqlx.Named(querySetOpenTransactionAmount, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SetOpenTransactionAmount named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("SetOpenTransactionAmount execution err")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sentrapay.ErrInvalidTransactionState
+	}
+
+	return nil
+}
+
+// UpdateTransactionStatusReason annotates a transaction without changing its
+// status; status changes go through UpdateTransactionStatus.
+func (r *walletRepository) UpdateTransactionStatusReason(ctx context.Context, referenceNo string, reason string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"reference_no":  referenceNo,
+		"status_reason": reason,
+		"updated_at":    time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryUpdateTransactionStatusReason, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateTransactionStatusReason named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateTransactionStatusReason execution err")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sentrapay.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// GetOpenTopUps returns top-ups still waiting for payment, ordered by ID and
+// starting after afterID so callers can page through them with a keyset.
+func (r *walletRepository) GetOpenTopUps(ctx context.Context, afterID string, limit int) ([]sentrapay.WalletTransaction, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var transactions []WalletTransactionDB
+
+	argsKV := map[string]interface{}{
+		"after_id": afterID,
+		"limit":    limit,
+	}
+
+	query, args, err := sqlx.Named(queryGetOpenTopUps, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetOpenTopUps named query preparation err")
+		return nil, err
 	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &transactions, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetOpenTopUps execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.WalletTransaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		result = append(result, r.makeWalletTransaction(transaction))
+	}
+
+	return result, nil
 }
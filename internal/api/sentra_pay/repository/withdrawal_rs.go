@@ -0,0 +1,384 @@
+package sentrapayRepository
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type BankAccountDB struct {
+	ID            sql.NullString `db:"id"`
+	UserID        sql.NullString `db:"user_id"`
+	BankCode      sql.NullString `db:"bank_code"`
+	AccountNumber sql.NullString `db:"account_number"`
+	AccountName   sql.NullString `db:"account_name"`
+	Status        sql.NullString `db:"status"`
+	VerifiedAt    sql.NullTime   `db:"verified_at"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+}
+
+type WithdrawalDB struct {
+	ID               sql.NullString   `db:"id"`
+	ReferenceNo      sql.NullString   `db:"reference_no"`
+	UserID           sql.NullString   `db:"user_id"`
+	BankAccountID    sql.NullString   `db:"bank_account_id"`
+	Amount           money.NullAmount `db:"amount"`
+	Status           sql.NullString   `db:"status"`
+	GatewayReference sql.NullString   `db:"gateway_reference"`
+	FailureReason    sql.NullString   `db:"failure_reason"`
+	CreatedAt        time.Time        `db:"created_at"`
+	UpdatedAt        time.Time        `db:"updated_at"`
+}
+
+func (r *bankAccountRepository) Create(ctx context.Context, account sentrapay.BankAccount) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":             account.ID,
+		"user_id":        account.UserID,
+		"bank_code":      account.BankCode,
+		"account_number": account.AccountNumber,
+		"account_name":   sql.NullString{String: account.AccountName, Valid: account.AccountName != ""},
+		"status":         account.Status,
+		"verified_at":    account.VerifiedAt,
+		"created_at":     account.CreatedAt,
+		"updated_at":     account.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateBankAccount, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateBankAccount named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateBankAccount execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *bankAccountRepository) GetByID(ctx context.Context, userID, id string) (sentrapay.BankAccount, error) {
+	argsKV := map[string]interface{}{
+		"id":      id,
+		"user_id": userID,
+	}
+
+	return r.get(ctx, queryGetBankAccountByID, argsKV, "GetBankAccountByID")
+}
+
+func (r *bankAccountRepository) GetByNumber(ctx context.Context, userID, bankCode, accountNumber string) (sentrapay.BankAccount, error) {
+	argsKV := map[string]interface{}{
+		"user_id":        userID,
+		"bank_code":      bankCode,
+		"account_number": accountNumber,
+	}
+
+	return r.get(ctx, queryGetBankAccountByNumber, argsKV, "GetBankAccountByNumber")
+}
+
+func (r *bankAccountRepository) get(ctx context.Context, namedQuery string, argsKV map[string]interface{}, name string) (sentrapay.BankAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var account BankAccountDB
+
+	query, args, err := sqlx.Named(namedQuery, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error(name + " named query preparation err")
+		return sentrapay.BankAccount{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&account); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.BankAccount{}, sentrapay.ErrBankAccountNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error(name + " execution err")
+		return sentrapay.BankAccount{}, err
+	}
+
+	return makeBankAccount(account), nil
+}
+
+func (r *bankAccountRepository) GetByUserID(ctx context.Context, userID string) ([]sentrapay.BankAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var accounts []BankAccountDB
+
+	argsKV := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	query, args, err := sqlx.Named(queryGetBankAccountsByUserID, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetBankAccountsByUserID named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &accounts, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetBankAccountsByUserID execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.BankAccount, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, makeBankAccount(account))
+	}
+
+	return result, nil
+}
+
+func (r *bankAccountRepository) UpdateVerification(ctx context.Context, account sentrapay.BankAccount) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":           account.ID,
+		"account_name": sql.NullString{String: account.AccountName, Valid: account.AccountName != ""},
+		"status":       account.Status,
+		"verified_at":  account.VerifiedAt,
+		"updated_at":   account.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryUpdateBankAccountVerification, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateBankAccountVerification named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateBankAccountVerification execution err")
+		return err
+	}
+
+	return nil
+}
+
+func makeBankAccount(account BankAccountDB) sentrapay.BankAccount {
+	var verifiedAt *time.Time
+	if account.VerifiedAt.Valid {
+		verifiedAt = &account.VerifiedAt.Time
+	}
+
+	return sentrapay.BankAccount{
+		ID:            account.ID.String,
+		UserID:        account.UserID.String,
+		BankCode:      account.BankCode.String,
+		AccountNumber: account.AccountNumber.String,
+		AccountName:   account.AccountName.String,
+		Status:        account.Status.String,
+		VerifiedAt:    verifiedAt,
+		CreatedAt:     account.CreatedAt,
+		UpdatedAt:     account.UpdatedAt,
+	}
+}
+
+func (r *withdrawalRepository) Create(ctx context.Context, withdrawal sentrapay.Withdrawal) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"id":              withdrawal.ID,
+		"reference_no":    withdrawal.ReferenceNo,
+		"user_id":         withdrawal.UserID,
+		"bank_account_id": withdrawal.BankAccountID,
+		"amount":          withdrawal.Amount,
+		"status":          withdrawal.Status,
+		"created_at":      withdrawal.CreatedAt,
+		"updated_at":      withdrawal.UpdatedAt,
+	}
+
+	query, args, err := sqlx.Named(queryCreateWithdrawal, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateWithdrawal named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	if _, err := r.q.ExecContext(ctx, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("CreateWithdrawal execution err")
+		return err
+	}
+
+	return nil
+}
+
+func (r *withdrawalRepository) GetByReferenceNo(ctx context.Context, referenceNo string) (sentrapay.Withdrawal, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var withdrawal WithdrawalDB
+
+	argsKV := map[string]interface{}{
+		"reference_no": referenceNo,
+	}
+
+	query, args, err := sqlx.Named(queryGetWithdrawalByReferenceNo, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWithdrawalByReferenceNo named query preparation err")
+		return sentrapay.Withdrawal{}, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.QueryRowxContext(ctx, query, args...).StructScan(&withdrawal); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sentrapay.Withdrawal{}, sentrapay.ErrWithdrawalNotFound
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetWithdrawalByReferenceNo execution err")
+		return sentrapay.Withdrawal{}, err
+	}
+
+	return makeWithdrawal(withdrawal), nil
+}
+
+// GetProcessing returns withdrawals still waiting for a disbursement outcome
+// that were created before the given time.
+func (r *withdrawalRepository) GetProcessing(ctx context.Context, before time.Time, limit int) ([]sentrapay.Withdrawal, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	var withdrawals []WithdrawalDB
+
+	argsKV := map[string]interface{}{
+		"before": before,
+		"limit":  limit,
+	}
+
+	query, args, err := sqlx.Named(queryGetProcessingWithdrawals, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetProcessingWithdrawals named query preparation err")
+		return nil, err
+	}
+
+	query = r.q.Rebind(query)
+
+	if err := r.q.SelectContext(ctx, &withdrawals, query, args...); err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("GetProcessingWithdrawals execution err")
+		return nil, err
+	}
+
+	result := make([]sentrapay.Withdrawal, 0, len(withdrawals))
+	for _, withdrawal := range withdrawals {
+		result = append(result, makeWithdrawal(withdrawal))
+	}
+
+	return result, nil
+}
+
+// UpdateStatus moves a processing withdrawal to status. It returns
+// ErrWithdrawalNotProcessing when the withdrawal was already finalised, so a
+// disbursement outcome is only ever applied once.
+func (r *withdrawalRepository) UpdateStatus(ctx context.Context, referenceNo, status, gatewayReference, failureReason string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	argsKV := map[string]interface{}{
+		"reference_no":      referenceNo,
+		"status":            status,
+		"gateway_reference": sql.NullString{String: gatewayReference, Valid: gatewayReference != ""},
+		"failure_reason":    sql.NullString{String: failureReason, Valid: failureReason != ""},
+		"updated_at":        time.Now(),
+	}
+
+	query, args, err := sqlx.Named(queryUpdateWithdrawalStatus, argsKV)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateWithdrawalStatus named query preparation err")
+		return err
+	}
+
+	query = r.q.Rebind(query)
+
+	result, err := r.q.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateWithdrawalStatus execution err")
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("UpdateWithdrawalStatus rows affected err")
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sentrapay.ErrWithdrawalNotProcessing
+	}
+
+	return nil
+}
+
+func makeWithdrawal(withdrawal WithdrawalDB) sentrapay.Withdrawal {
+	return sentrapay.Withdrawal{
+		ID:               withdrawal.ID.String,
+		ReferenceNo:      withdrawal.ReferenceNo.String,
+		UserID:           withdrawal.UserID.String,
+		BankAccountID:    withdrawal.BankAccountID.String,
+		Amount:           withdrawal.Amount.Amount,
+		Status:           withdrawal.Status.String,
+		GatewayReference: withdrawal.GatewayReference.String,
+		FailureReason:    withdrawal.FailureReason.String,
+		CreatedAt:        withdrawal.CreatedAt,
+		UpdatedAt:        withdrawal.UpdatedAt,
+	}
+}
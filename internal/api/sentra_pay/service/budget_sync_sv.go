@@ -0,0 +1,58 @@
+package sentrapayService
+
+import (
+	"ProjectGolang/internal/api/budget_manager"
+	contextPkg "ProjectGolang/pkg/context"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// recordInBudget offers a settled wallet transaction to the budget manager,
+// which records it if the user has opted in. It runs after the wallet change
+// is committed and never fails it: a missed budget entry only costs the user
+// a manual entry, and the link on the wallet transaction ID keeps retries
+// from recording it twice.
+func (s *sentraPayService) recordInBudget(ctx context.Context, userID, referenceNo, merchantName string) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return
+	}
+
+	transaction, err := repo.Wallet.GetTransactionByReferenceNo(ctx, userID, referenceNo)
+	if err != nil {
+		return
+	}
+
+	entry := budget_manager.WalletEntry{
+		WalletTransactionID: transaction.ID,
+		UserID:              transaction.UserID,
+		ReferenceNo:         transaction.ReferenceNo,
+		Amount:              transaction.Amount,
+		MerchantName:        merchantName,
+		OccurredAt:          transaction.CreatedAt,
+	}
+
+	switch transaction.Type {
+	case "topup":
+		entry.Kind = budget_manager.WalletEntryTopUp
+	case "qris_payment":
+		entry.Kind = budget_manager.WalletEntryQRISPayment
+	default:
+		return
+	}
+
+	if err := s.budgetService.RecordWalletTransaction(ctx, entry); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": referenceNo,
+			"user_id":      transaction.UserID,
+			"error":        err.Error(),
+		}).Warn("Failed to record wallet transaction in budget")
+	}
+}
@@ -0,0 +1,126 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/events"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// SubscribeWalletEvents starts the user's event stream and returns it with
+// the balance at the moment it started, so the client has a baseline that no
+// later event can predate.
+func (s *sentraPayService) SubscribeWalletEvents(ctx context.Context, userID string) (*events.Subscription, *sentrapay.WalletBalance, error) {
+	if s.events == nil {
+		return nil, nil, sentrapay.ErrWalletEventsUnavailable
+	}
+
+	subscription := s.events.Subscribe(userID)
+
+	balance, err := s.GetWalletBalance(ctx, userID)
+	if err != nil {
+		subscription.Close()
+		return nil, nil, err
+	}
+
+	return subscription, balance, nil
+}
+
+// publishTransactionEvents pushes the committed state of every row under
+// referenceNo, and the resulting balances, to the users involved. It runs
+// after the change is committed and never fails it: a client that misses an
+// event still sees the change on its next refresh.
+func (s *sentraPayService) publishTransactionEvents(ctx context.Context, referenceNo string) {
+	if s.events == nil {
+		return
+	}
+
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return
+	}
+
+	transactions, err := repo.Wallet.GetTransactionsByReferenceNo(ctx, referenceNo)
+	if err != nil {
+		return
+	}
+
+	users := make(map[string]bool, len(transactions))
+	for _, transaction := range transactions {
+		s.publishWalletEvent(ctx, transaction.UserID, sentrapay.WalletEventTransaction, sentrapay.TransactionEvent{
+			ReferenceNo:   transaction.ReferenceNo,
+			Type:          transaction.Type,
+			Status:        transaction.Status,
+			Amount:        transaction.Amount,
+			PaymentMethod: transaction.PaymentMethod,
+			StatusReason:  transaction.StatusReason,
+			Message:       transactionEventMessage(transaction),
+			UpdatedAt:     transaction.UpdatedAt,
+		})
+		users[transaction.UserID] = true
+	}
+
+	for userID := range users {
+		s.publishBalance(ctx, repo, userID)
+	}
+}
+
+func (s *sentraPayService) publishBalance(ctx context.Context, repo sentrapayRepository.Client, userID string) {
+	wallet, err := repo.Wallet.GetWallet(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	s.publishWalletEvent(ctx, userID, sentrapay.WalletEventBalance, wallet)
+}
+
+func (s *sentraPayService) publishWalletEvent(ctx context.Context, userID, eventType string, data interface{}) {
+	if err := s.events.Publish(ctx, userID, eventType, data); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": contextPkg.GetRequestID(ctx),
+			"user_id":    userID,
+			"type":       eventType,
+			"error":      err.Error(),
+		}).Warn("Failed to publish wallet event")
+	}
+}
+
+// transactionEventMessage describes a transaction's status for the user, or
+// returns "" for states that need no announcement.
+func transactionEventMessage(transaction sentrapay.WalletTransaction) string {
+	amount := transaction.Amount.Abs().Format()
+
+	switch transaction.Type + "/" + string(transaction.Status) {
+	case "topup/success":
+		return fmt.Sprintf("Top up %s berhasil.", amount)
+	case "topup/expired":
+		return fmt.Sprintf("Top up %s kedaluwarsa.", amount)
+	case "topup/failed":
+		return fmt.Sprintf("Top up %s gagal.", amount)
+	case "transfer_out/success":
+		return fmt.Sprintf("Transfer %s berhasil.", amount)
+	case "transfer_in/success":
+		return fmt.Sprintf("Anda menerima %s.", amount)
+	case "qris_payment/success":
+		return fmt.Sprintf("Pembayaran QRIS %s berhasil.", amount)
+	case "qris_payment/reversed":
+		return fmt.Sprintf("Pembayaran QRIS %s dibatalkan, saldo dikembalikan.", amount)
+	case "withdrawal/processing":
+		return fmt.Sprintf("Penarikan %s sedang diproses.", amount)
+	case "withdrawal/success":
+		return fmt.Sprintf("Penarikan %s berhasil.", amount)
+	case "withdrawal/failed":
+		return fmt.Sprintf("Penarikan %s gagal, saldo dikembalikan.", amount)
+	default:
+		return ""
+	}
+}
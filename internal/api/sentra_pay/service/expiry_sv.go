@@ -0,0 +1,118 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	topUpExpiry          = 24 * time.Hour
+	topUpExpiryBatchSize = 500
+
+	topUpExpiredReason        = "virtual account or QRIS code expired before payment was received"
+	topUpLatePaymentReason    = "virtual account or QRIS code expired before payment was received; late payment notification received, needs review"
+	topUpAmountMismatchReason = "paid amount differs from top-up amount; wallet not credited, needs review"
+)
+
+// ExpirePendingTopUps moves every pending top-up or QRIS receive request whose
+// virtual account or code has expired to the expired status. It runs in batches so a large backlog does
+// not hold row locks for long, and is safe to run on several instances.
+func (s *sentraPayService) ExpirePendingTopUps(ctx context.Context) (int, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	total := 0
+
+	for {
+		repo, err := s.walletRepository.NewClient(true)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("Failed to create repository client")
+			return total, err
+		}
+
+		referenceNos, err := repo.Wallet.ExpirePendingTopUps(ctx, time.Now(), sentrapay.StatusChange{
+			Reason: topUpExpiredReason,
+			Actor:  sentrapay.ActorTopUpExpiry,
+		}, topUpExpiryBatchSize)
+		if err != nil {
+			repo.Rollback()
+			return total, err
+		}
+
+		if err := repo.Commit(); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("Failed to commit transaction")
+			return total, err
+		}
+
+		total += len(referenceNos)
+
+		for _, referenceNo := range referenceNos {
+			s.publishTransactionEvents(ctx, referenceNo)
+		}
+
+		if len(referenceNos) > 0 {
+			s.log.WithFields(logrus.Fields{
+				"request_id":    requestID,
+				"expired_count": len(referenceNos),
+				"reference_nos": referenceNos,
+			}).Info("Expired pending top-ups")
+		}
+
+		if len(referenceNos) < topUpExpiryBatchSize {
+			return total, nil
+		}
+
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+	}
+}
+
+// rejectLateTopUpPayment handles a payment notification for a top-up whose
+// virtual account is already past its expiry. The wallet is not credited;
+// the transaction is marked expired (if the worker has not done so yet) and
+// flagged so the payment can be reviewed and refunded or credited manually.
+func (s *sentraPayService) rejectLateTopUpPayment(ctx context.Context, repo sentrapayRepository.Client, transaction sentrapay.WalletTransaction, req sentrapay.PaymentCallbackRequest) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if transaction.Status != sentrapay.TransactionExpired {
+		if err := repo.Wallet.UpdateTransactionStatus(ctx, transaction.ReferenceNo, sentrapay.TransactionExpired, sentrapay.StatusChange{
+			Reason: topUpLatePaymentReason,
+			Actor:  sentrapay.ActorPaymentCallback,
+		}); err != nil {
+			return err
+		}
+	} else if err := repo.Wallet.UpdateTransactionStatusReason(ctx, transaction.ReferenceNo, topUpLatePaymentReason); err != nil {
+		return err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return err
+	}
+
+	s.publishTransactionEvents(ctx, transaction.ReferenceNo)
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":     requestID,
+		"reference_no":   transaction.ReferenceNo,
+		"user_id":        transaction.UserID,
+		"paid_amount":    req.PaidAmount.Value,
+		"trx_date_time":  req.TrxDateTime,
+		"expires_at":     transaction.ExpiresAt,
+		"virtual_acc_no": req.VirtualAccountNo,
+	}).Error("Payment notification received for expired top-up, flagged for review")
+
+	return sentrapay.ErrTransactionExpired
+}
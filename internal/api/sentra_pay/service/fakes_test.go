@@ -0,0 +1,294 @@
+package sentrapayService
+
+import (
+	authRepository "ProjectGolang/internal/api/auth/repository"
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	"ProjectGolang/internal/entity"
+	"ProjectGolang/pkg/redis"
+	"context"
+	"errors"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+func newTestLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return log
+}
+
+type fakeRedisValue struct {
+	value     string
+	expiresAt time.Time
+}
+
+// fakeRedis keeps keys in memory and expires them against its own clock,
+// which tests move forward with advance.
+type fakeRedis struct {
+	mu   sync.Mutex
+	now  time.Time
+	keys map[string]fakeRedisValue
+}
+
+var _ redis.IRedis = (*fakeRedis)(nil)
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		now:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		keys: make(map[string]fakeRedisValue),
+	}
+}
+
+func (r *fakeRedis) advance(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = r.now.Add(d)
+}
+
+func (r *fakeRedis) get(key string) (fakeRedisValue, bool) {
+	entry, ok := r.keys[key]
+	if !ok {
+		return fakeRedisValue{}, false
+	}
+	if !entry.expiresAt.IsZero() && !r.now.Before(entry.expiresAt) {
+		delete(r.keys, key)
+		return fakeRedisValue{}, false
+	}
+	return entry, true
+}
+
+func (r *fakeRedis) set(key, value string, expiration time.Duration) {
+	entry := fakeRedisValue{value: value}
+	if expiration > 0 {
+		entry.expiresAt = r.now.Add(expiration)
+	}
+	r.keys[key] = entry
+}
+
+func (r *fakeRedis) SetOTP(ctx context.Context, key string, code string, expiration time.Duration) error {
+	return r.Set(ctx, key, code, expiration)
+}
+
+func (r *fakeRedis) GetOTP(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.get(key)
+	if !ok {
+		return "", goredis.Nil
+	}
+	return entry.value, nil
+}
+
+func (r *fakeRedis) DeleteOTP(ctx context.Context, key string) error {
+	return r.Delete(ctx, key)
+}
+
+func (r *fakeRedis) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set(key, value, expiration)
+	return nil
+}
+
+func (r *fakeRedis) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.keys, key)
+	return nil
+}
+
+func (r *fakeRedis) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.get(key)
+	if !ok {
+		r.set(key, "1", expiration)
+		return 1, nil
+	}
+
+	count, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	count++
+	entry.value = strconv.FormatInt(count, 10)
+	r.keys[key] = entry
+
+	return count, nil
+}
+
+func (r *fakeRedis) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.get(key)
+	if !ok || entry.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return entry.expiresAt.Sub(r.now), nil
+}
+
+func (r *fakeRedis) Publish(ctx context.Context, channel, message string) error {
+	return nil
+}
+
+func (r *fakeRedis) PSubscribe(ctx context.Context, pattern string) <-chan redis.Message {
+	messages := make(chan redis.Message)
+	go func() {
+		<-ctx.Done()
+		close(messages)
+	}()
+	return messages
+}
+
+var errFakeNotImplemented = errors.New("not implemented by fake")
+
+// fakeAuthRepository serves users from memory. Only lookups by ID are
+// supported.
+type fakeAuthRepository struct {
+	users map[string]entity.User
+}
+
+func (r *fakeAuthRepository) NewClient(tx bool) (authRepository.Client, error) {
+	return authRepository.Client{
+		Users:    &fakeUserRepository{users: r.users},
+		Commit:   func() error { return nil },
+		Rollback: func() error { return nil },
+	}, nil
+}
+
+type fakeUserRepository struct {
+	users map[string]entity.User
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id string) (entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return entity.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) CreateUser(ctx context.Context, user entity.User) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) GetByPhoneNumber(ctx context.Context, phoneNumber string) (entity.User, error) {
+	return entity.User{}, errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (entity.User, error) {
+	return entity.User{}, errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateUser(ctx context.Context, user entity.User) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateUserPIN(ctx context.Context, phoneNum string, pin string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateUserPassword(ctx context.Context, phoneNum string, password string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) DeleteUser(ctx context.Context, id string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) EnableTouchID(ctx context.Context, id string, hash string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateProfilePhoto(ctx context.Context, id string, photoURL string) error {
+	return errFakeNotImplemented
+}
+
+func (r *fakeUserRepository) UpdateFacePhoto(ctx context.Context, id string, facePhotoURL string) error {
+	return errFakeNotImplemented
+}
+
+// fakeWalletRepository hands out clients with only the risk repository set.
+type fakeWalletRepository struct {
+	risk *fakeRiskRepository
+}
+
+func (r *fakeWalletRepository) NewClient(tx bool) (sentrapayRepository.Client, error) {
+	return sentrapayRepository.Client{
+		Risk:     r.risk,
+		Commit:   func() error { return nil },
+		Rollback: func() error { return nil },
+	}, nil
+}
+
+// fakeRiskRepository keeps each user's known devices and the decisions it
+// was asked to store. Movement history is empty.
+type fakeRiskRepository struct {
+	mu        sync.Mutex
+	devices   map[string][]string
+	decisions []sentrapay.RiskDecision
+}
+
+func newFakeRiskRepository() *fakeRiskRepository {
+	return &fakeRiskRepository{devices: make(map[string][]string)}
+}
+
+func (r *fakeRiskRepository) CountMovementsSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeRiskRepository) MovementTimesSince(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	return nil, nil
+}
+
+func (r *fakeRiskRepository) HasPaidPayee(ctx context.Context, userID, kind, payee string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeRiskRepository) KnownDevices(ctx context.Context, userID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.devices[userID]...), nil
+}
+
+func (r *fakeRiskRepository) RememberDevice(ctx context.Context, userID, deviceID string, seenAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, known := range r.devices[userID] {
+		if known == deviceID {
+			return nil
+		}
+	}
+	r.devices[userID] = append(r.devices[userID], deviceID)
+	return nil
+}
+
+func (r *fakeRiskRepository) CreateDecision(ctx context.Context, decision sentrapay.RiskDecision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decisions = append(r.decisions, decision)
+	return nil
+}
+
+func (r *fakeRiskRepository) GetDecisions(ctx context.Context, outcome, userID string, limit int) ([]sentrapay.RiskDecision, error) {
+	return nil, errFakeNotImplemented
+}
+
+func (r *fakeRiskRepository) lastDecision() sentrapay.RiskDecision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.decisions) == 0 {
+		return sentrapay.RiskDecision{}
+	}
+	return r.decisions[len(r.decisions)-1]
+}
@@ -0,0 +1,151 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const idempotencyKeyTTL = 24 * time.Hour
+
+// BeginIdempotentRequest claims an Idempotency-Key for a request. It returns
+// nil when the caller should process the request, or the stored record when
+// the same request already completed and its response should be replayed.
+func (s *sentraPayService) BeginIdempotentRequest(ctx context.Context, userID, endpoint, key string, request interface{}) (*sentrapay.IdempotencyKey, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	requestHash, err := hashIdempotentRequest(request)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to hash idempotent request")
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	now := time.Now()
+
+	reserved, err := repo.Idempotency.Reserve(ctx, sentrapay.IdempotencyKey{
+		UserID:      userID,
+		Endpoint:    endpoint,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      sentrapay.IdempotencyStatusProcessing,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(idempotencyKeyTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reserved {
+		return nil, nil
+	}
+
+	existing, err := repo.Idempotency.Get(ctx, userID, endpoint, key)
+	if err != nil {
+		if errors.Is(err, sentrapay.ErrIdempotencyKeyNotFound) {
+			// Released by a failed attempt between our insert and select.
+			return nil, sentrapay.ErrIdempotencyKeyInProgress
+		}
+		return nil, err
+	}
+
+	if existing.RequestHash != requestHash {
+		s.log.WithFields(logrus.Fields{
+			"request_id":      requestID,
+			"user_id":         userID,
+			"endpoint":        endpoint,
+			"idempotency_key": key,
+		}).Warn("Idempotency key reused with a different request body")
+		return nil, sentrapay.ErrDuplicateTransaction
+	}
+
+	if existing.Status != sentrapay.IdempotencyStatusCompleted {
+		return nil, sentrapay.ErrIdempotencyKeyInProgress
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":      requestID,
+		"user_id":         userID,
+		"endpoint":        endpoint,
+		"idempotency_key": key,
+	}).Info("Replaying stored response for idempotency key")
+
+	return &existing, nil
+}
+
+func (s *sentraPayService) CompleteIdempotentRequest(ctx context.Context, userID, endpoint, key string, statusCode int, response interface{}) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to encode idempotent response")
+		return err
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+
+	return repo.Idempotency.Complete(ctx, sentrapay.IdempotencyKey{
+		UserID:         userID,
+		Endpoint:       endpoint,
+		Key:            key,
+		Status:         sentrapay.IdempotencyStatusCompleted,
+		ResponseStatus: statusCode,
+		ResponseBody:   body,
+		UpdatedAt:      time.Now(),
+	})
+}
+
+// AbortIdempotentRequest releases a key whose request was rejected before
+// processing, so the client can retry it with the same key.
+func (s *sentraPayService) AbortIdempotentRequest(ctx context.Context, userID, endpoint, key string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+
+	return repo.Idempotency.Delete(ctx, userID, endpoint, key)
+}
+
+func hashIdempotentRequest(request interface{}) (string, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,198 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"sort"
+	"time"
+)
+
+// postLedger writes a balanced journal and applies its wallet legs to
+// wallets.balance inside the caller's database transaction. Legs are applied
+// in account order so postings that touch the same wallets always take the
+// row locks in the same order.
+func (s *sentraPayService) postLedger(ctx context.Context, repo sentrapayRepository.Client, posting sentrapay.LedgerPosting) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	var totalDebit, totalCredit money.Amount
+	for _, leg := range posting.Legs {
+		if leg.Amount <= 0 {
+			return sentrapay.ErrInvalidAmount
+		}
+
+		switch leg.Direction {
+		case sentrapay.LedgerDebit:
+			totalDebit += leg.Amount
+		case sentrapay.LedgerCredit:
+			totalCredit += leg.Amount
+		default:
+			return sentrapay.ErrUnbalancedPosting
+		}
+	}
+
+	if len(posting.Legs) < 2 || totalDebit != totalCredit {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"journal_key":  posting.JournalKey,
+			"total_debit":  totalDebit,
+			"total_credit": totalCredit,
+		}).Error("Rejected unbalanced ledger posting")
+		return sentrapay.ErrUnbalancedPosting
+	}
+
+	now := time.Now()
+
+	journalID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate journal ID")
+		return err
+	}
+
+	journal := sentrapay.LedgerJournal{
+		ID:          journalID,
+		JournalKey:  posting.JournalKey,
+		ReferenceNo: posting.ReferenceNo,
+		Kind:        posting.Kind,
+		Description: posting.Description,
+		CreatedAt:   now,
+	}
+
+	if err := repo.Ledger.CreateJournal(ctx, journal); err != nil {
+		return err
+	}
+
+	legs := make([]sentrapay.LedgerLeg, len(posting.Legs))
+	copy(legs, posting.Legs)
+	sort.SliceStable(legs, func(i, j int) bool {
+		return legs[i].Account < legs[j].Account
+	})
+
+	for _, leg := range legs {
+		var balanceAfter *money.Amount
+
+		if leg.UserID != "" {
+			delta := leg.Amount
+			if leg.Direction == sentrapay.LedgerDebit {
+				delta = -leg.Amount
+			}
+
+			balance, err := repo.Wallet.ApplyBalanceDelta(ctx, leg.UserID, delta)
+			if err != nil {
+				s.log.WithFields(logrus.Fields{
+					"request_id":  requestID,
+					"journal_key": posting.JournalKey,
+					"user_id":     leg.UserID,
+					"delta":       delta,
+					"error":       err.Error(),
+				}).Warn("Failed to apply ledger leg to wallet balance")
+				return err
+			}
+			balanceAfter = &balance
+		}
+
+		entryID, err := s.utils.NewULIDFromTimestamp(now)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("Failed to generate ledger entry ID")
+			return err
+		}
+
+		entry := sentrapay.LedgerEntry{
+			ID:           entryID,
+			JournalID:    journalID,
+			Account:      leg.Account,
+			UserID:       leg.UserID,
+			Direction:    leg.Direction,
+			Amount:       leg.Amount,
+			BalanceAfter: balanceAfter,
+			CreatedAt:    now,
+		}
+
+		if err := repo.Ledger.CreateEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walletCreditLeg(userID string, amount money.Amount) sentrapay.LedgerLeg {
+	return sentrapay.LedgerLeg{
+		Account:   sentrapay.WalletLedgerAccount(userID),
+		UserID:    userID,
+		Direction: sentrapay.LedgerCredit,
+		Amount:    amount,
+	}
+}
+
+func walletDebitLeg(userID string, amount money.Amount) sentrapay.LedgerLeg {
+	return sentrapay.LedgerLeg{
+		Account:   sentrapay.WalletLedgerAccount(userID),
+		UserID:    userID,
+		Direction: sentrapay.LedgerDebit,
+		Amount:    amount,
+	}
+}
+
+func accountLeg(account string, direction sentrapay.LedgerDirection, amount money.Amount) sentrapay.LedgerLeg {
+	return sentrapay.LedgerLeg{
+		Account:   account,
+		Direction: direction,
+		Amount:    amount,
+	}
+}
+
+func (s *sentraPayService) CheckLedgerConsistency(ctx context.Context) (*sentrapay.LedgerConsistencyReport, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	walletsChecked, err := repo.Ledger.CountWallets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches, err := repo.Ledger.GetBalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unbalanced, err := repo.Ledger.GetUnbalancedJournals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &sentrapay.LedgerConsistencyReport{
+		CheckedAt:          time.Now(),
+		WalletsChecked:     walletsChecked,
+		Consistent:         len(mismatches) == 0 && len(unbalanced) == 0,
+		BalanceMismatches:  mismatches,
+		UnbalancedJournals: unbalanced,
+	}
+
+	if !report.Consistent {
+		s.log.WithFields(logrus.Fields{
+			"request_id":          requestID,
+			"balance_mismatches":  len(mismatches),
+			"unbalanced_journals": len(unbalanced),
+		}).Error("Wallet ledger consistency check failed")
+	}
+
+	return report, nil
+}
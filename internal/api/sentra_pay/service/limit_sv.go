@@ -0,0 +1,349 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+var spendingDayLocation = loadSpendingDayLocation()
+
+func loadSpendingDayLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// startOfSpendingDay returns midnight in Jakarta converted to server local
+// time, which is how wallet_transactions.created_at is stored.
+func startOfSpendingDay(now time.Time) time.Time {
+	local := now.In(spendingDayLocation)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, spendingDayLocation)
+	return midnight.In(time.Local)
+}
+
+func (s *sentraPayService) isUserVerified(ctx context.Context, userID string) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return false, err
+	}
+
+	user, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get user info")
+		return false, err
+	}
+
+	return user.IsVerified, nil
+}
+
+// resolveWalletLimits combines the user's profile, the unverified ceiling and
+// the user's own overrides. Overrides can only tighten the profile.
+func (s *sentraPayService) resolveWalletLimits(ctx context.Context, repo sentrapayRepository.Client, userID string, isVerified bool) (*sentrapay.WalletLimits, error) {
+	override, err := repo.Limit.GetUserLimit(ctx, userID)
+	if err != nil && !errors.Is(err, sentrapay.ErrUserLimitNotFound) {
+		return nil, err
+	}
+
+	profileCode := override.ProfileCode
+	if profileCode == "" {
+		profileCode = sentrapay.LimitProfileDefault
+	}
+
+	profile, err := repo.Limit.GetProfile(ctx, profileCode)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDaily := profile.DailyLimit
+	maxPerTransaction := profile.PerTransactionLimit
+
+	if !isVerified {
+		unverified, err := repo.Limit.GetProfile(ctx, sentrapay.LimitProfileUnverified)
+		if err != nil {
+			return nil, err
+		}
+
+		profileCode = unverified.Code
+		maxDaily = money.Min(maxDaily, unverified.DailyLimit)
+		maxPerTransaction = money.Min(maxPerTransaction, unverified.PerTransactionLimit)
+	}
+
+	limits := &sentrapay.WalletLimits{
+		Profile:                profileCode,
+		IsVerified:             isVerified,
+		DailyLimit:             maxDaily,
+		PerTransactionLimit:    maxPerTransaction,
+		MaxDailyLimit:          maxDaily,
+		MaxPerTransactionLimit: maxPerTransaction,
+	}
+
+	if override.DailyLimit != nil && *override.DailyLimit < limits.DailyLimit {
+		limits.DailyLimit = *override.DailyLimit
+	}
+
+	if override.PerTransactionLimit != nil && *override.PerTransactionLimit < limits.PerTransactionLimit {
+		limits.PerTransactionLimit = *override.PerTransactionLimit
+	}
+
+	if limits.PerTransactionLimit > limits.DailyLimit {
+		limits.PerTransactionLimit = limits.DailyLimit
+	}
+
+	usedToday, err := repo.Wallet.SumDebitsSince(ctx, userID, startOfSpendingDay(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	limits.UsedToday = usedToday
+	limits.RemainingToday = money.Max(0, limits.DailyLimit-usedToday)
+
+	return limits, nil
+}
+
+// enforceSpendingLimits must run inside the debit's database transaction,
+// before the debit is written, so the spending lock covers the whole check.
+// Every debit path goes through it, so it also refuses debits from a wallet
+// that is not active.
+func (s *sentraPayService) enforceSpendingLimits(ctx context.Context, repo sentrapayRepository.Client, userID string, amount money.Amount) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	isVerified, err := s.isUserVerified(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.Wallet.LockSpending(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.ensureWalletActive(ctx, repo, userID); err != nil {
+		return err
+	}
+
+	limits, err := s.resolveWalletLimits(ctx, repo, userID, isVerified)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to resolve wallet limits")
+		return err
+	}
+
+	if amount > limits.PerTransactionLimit {
+		s.log.WithFields(logrus.Fields{
+			"request_id":            requestID,
+			"user_id":               userID,
+			"amount":                amount,
+			"per_transaction_limit": limits.PerTransactionLimit,
+		}).Warn("Per transaction limit exceeded")
+		return sentrapay.ErrMaxPerTransactionExceeded
+	}
+
+	if limits.UsedToday+amount > limits.DailyLimit {
+		s.log.WithFields(logrus.Fields{
+			"request_id":  requestID,
+			"user_id":     userID,
+			"amount":      amount,
+			"used_today":  limits.UsedToday,
+			"daily_limit": limits.DailyLimit,
+		}).Warn("Daily limit exceeded")
+		return sentrapay.ErrMaxDailyLimitExceeded
+	}
+
+	return nil
+}
+
+func (s *sentraPayService) GetWalletLimits(ctx context.Context, userID string) (*sentrapay.WalletLimits, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	isVerified, err := s.isUserVerified(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	limits, err := s.resolveWalletLimits(ctx, repo, userID, isVerified)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to resolve wallet limits")
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// UpdateWalletLimits lets users change their own limits within the profile
+// ceiling. Lowering is free; raising either limit needs the OTP sent to the
+// user's phone number.
+func (s *sentraPayService) UpdateWalletLimits(ctx context.Context, userID string, req sentrapay.UpdateWalletLimitsRequest) (*sentrapay.WalletLimits, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if req.DailyLimit == nil && req.PerTransactionLimit == nil {
+		return nil, sentrapay.ErrNoLimitChange
+	}
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return nil, err
+	}
+
+	user, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get user info")
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	current, err := s.resolveWalletLimits(ctx, repo, userID, user.IsVerified)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to resolve wallet limits")
+		return nil, err
+	}
+
+	dailyLimit := current.DailyLimit
+	if req.DailyLimit != nil {
+		dailyLimit = *req.DailyLimit
+	}
+
+	perTransactionLimit := current.PerTransactionLimit
+	if req.PerTransactionLimit != nil {
+		perTransactionLimit = *req.PerTransactionLimit
+	}
+
+	if dailyLimit > current.MaxDailyLimit || perTransactionLimit > current.MaxPerTransactionLimit {
+		return nil, sentrapay.ErrLimitAboveMaximum
+	}
+
+	if perTransactionLimit > dailyLimit {
+		return nil, sentrapay.ErrInvalidLimit
+	}
+
+	raising := dailyLimit > current.DailyLimit ||
+		perTransactionLimit > current.PerTransactionLimit
+
+	if raising {
+		if req.OTPCode == "" {
+			return nil, sentrapay.ErrLimitOTPRequired
+		}
+
+		storedOTP, err := s.redisServer.GetOTP(ctx, user.PhoneNumber)
+		if err != nil || storedOTP != req.OTPCode {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+			}).Warn("Invalid OTP for raising wallet limit")
+			return nil, sentrapay.ErrInvalidOTP
+		}
+	}
+
+	override, err := repo.Limit.GetUserLimit(ctx, userID)
+	if err != nil && !errors.Is(err, sentrapay.ErrUserLimitNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	if override.CreatedAt.IsZero() {
+		override.CreatedAt = now
+	}
+	override.UserID = userID
+	override.UpdatedAt = now
+
+	// A limit equal to the ceiling is stored as NULL so it keeps following
+	// the profile when the ceiling changes.
+	override.DailyLimit = nil
+	if dailyLimit < current.MaxDailyLimit {
+		override.DailyLimit = &dailyLimit
+	}
+
+	override.PerTransactionLimit = nil
+	if perTransactionLimit < current.MaxPerTransactionLimit {
+		override.PerTransactionLimit = &perTransactionLimit
+	}
+
+	if err := repo.Limit.UpsertUserLimit(ctx, override); err != nil {
+		return nil, err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return nil, err
+	}
+
+	if raising {
+		if err := s.redisServer.DeleteOTP(ctx, user.PhoneNumber); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"error":      err.Error(),
+			}).Warn("Failed to delete used OTP")
+		}
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":            requestID,
+		"user_id":               userID,
+		"daily_limit":           dailyLimit,
+		"per_transaction_limit": perTransactionLimit,
+		"raised":                raising,
+	}).Info("Wallet limits updated")
+
+	current.DailyLimit = dailyLimit
+	current.PerTransactionLimit = perTransactionLimit
+	current.RemainingToday = money.Max(0, dailyLimit-current.UsedToday)
+
+	return current, nil
+}
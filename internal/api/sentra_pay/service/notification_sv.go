@@ -0,0 +1,203 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const defaultNotificationListLimit = 20
+
+// redactedNotificationHeaders are kept out of the inbox because they carry
+// credentials rather than evidence.
+var redactedNotificationHeaders = []string{"Authorization"}
+
+// storeNotification writes an inbound notification to the inbox before
+// anything else looks at it, so a notification that later fails to verify or
+// process can still be inspected and replayed.
+func (s *sentraPayService) storeNotification(ctx context.Context, provider string, notification sentrapay.PaymentNotification) (sentrapay.PaymentNotificationRecord, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	id, err := s.utils.NewULIDFromTimestamp(time.Now())
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate notification ID")
+		return sentrapay.PaymentNotificationRecord{}, err
+	}
+
+	headers := make(map[string]string, len(notification.Headers))
+	for key, value := range notification.Headers {
+		headers[key] = value
+	}
+	for _, key := range redactedNotificationHeaders {
+		if _, ok := headers[key]; ok {
+			headers[key] = "[redacted]"
+		}
+	}
+
+	now := time.Now()
+	record := sentrapay.PaymentNotificationRecord{
+		ID:           id,
+		Provider:     provider,
+		HTTPMethod:   notification.HTTPMethod,
+		EndpointURL:  notification.EndpointURL,
+		Headers:      headers,
+		Body:         string(notification.Body),
+		Verification: sentrapay.NotificationVerificationPending,
+		Outcome:      sentrapay.NotificationReceived,
+		ReceivedAt:   now,
+		UpdatedAt:    now,
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return sentrapay.PaymentNotificationRecord{}, err
+	}
+
+	if err := repo.PaymentNotification.Create(ctx, record); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"provider":   provider,
+			"error":      err.Error(),
+		}).Error("Failed to store payment notification")
+		return sentrapay.PaymentNotificationRecord{}, err
+	}
+
+	return record, nil
+}
+
+// updateNotification saves the verification result and outcome of a stored
+// notification.
+func (s *sentraPayService) updateNotification(ctx context.Context, record sentrapay.PaymentNotificationRecord) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	record.UpdatedAt = time.Now()
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return err
+	}
+
+	if err := repo.PaymentNotification.Update(ctx, record); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":      requestID,
+			"notification_id": record.ID,
+			"outcome":         record.Outcome,
+			"error":           err.Error(),
+		}).Error("Failed to update payment notification")
+		return err
+	}
+
+	return nil
+}
+
+// finishNotification records the result of running ProcessPaymentCallback on
+// a stored notification.
+func (s *sentraPayService) finishNotification(ctx context.Context, record *sentrapay.PaymentNotificationRecord, processErr error) error {
+	now := time.Now()
+
+	record.Outcome = sentrapay.NotificationProcessed
+	record.OutcomeError = ""
+	if processErr != nil {
+		record.Outcome = sentrapay.NotificationFailed
+		record.OutcomeError = processErr.Error()
+	}
+	record.ProcessedAt = &now
+
+	return s.updateNotification(ctx, *record)
+}
+
+func (s *sentraPayService) ListPaymentNotifications(ctx context.Context, req sentrapay.ListPaymentNotificationsRequest) ([]sentrapay.PaymentNotificationRecord, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultNotificationListLimit
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	return repo.PaymentNotification.GetList(ctx, req.Outcome, req.ReferenceNo, limit)
+}
+
+func (s *sentraPayService) GetPaymentNotification(ctx context.Context, id string) (*sentrapay.PaymentNotificationRecord, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	record, err := repo.PaymentNotification.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// ReplayPaymentNotification runs ProcessPaymentCallback again on the
+// authenticated content of a stored notification. Only verified notifications
+// that reported a payment can be replayed. Settling is idempotent per gateway
+// payment, so replaying one that was already applied changes nothing. A
+// failed replay is reported in the record's outcome, not as an error.
+func (s *sentraPayService) ReplayPaymentNotification(ctx context.Context, id string) (*sentrapay.PaymentNotificationRecord, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	record, err := s.GetPaymentNotification(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Verification != sentrapay.NotificationVerified || record.Callback == nil ||
+		record.Outcome == sentrapay.NotificationAcknowledged {
+		s.log.WithFields(logrus.Fields{
+			"request_id":      requestID,
+			"notification_id": id,
+			"verification":    record.Verification,
+			"outcome":         record.Outcome,
+		}).Warn("Payment notification cannot be replayed")
+		return nil, sentrapay.ErrNotificationNotReplayable
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":      requestID,
+		"notification_id": id,
+		"provider":        record.Provider,
+		"reference_no":    record.ReferenceNo,
+		"previous":        record.Outcome,
+		"replay_count":    record.ReplayCount,
+	}).Info("Replaying payment notification")
+
+	processErr := s.ProcessPaymentCallback(ctx, *record.Callback)
+
+	record.ReplayCount++
+	if err := s.finishNotification(ctx, record, processErr); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
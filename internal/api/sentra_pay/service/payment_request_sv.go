@@ -0,0 +1,713 @@
+package sentrapayService
+
+import (
+	"ProjectGolang/internal/api/auth"
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	paymentRequestDefaultExpiry = 72 * time.Hour
+	paymentRequestListLimit     = 50
+	paymentRequestBatchSize     = 100
+
+	// Automatic reminders go out once a day, at most three times; a
+	// requester may nudge by hand once an hour.
+	paymentReminderInterval       = 24 * time.Hour
+	paymentReminderMax            = 3
+	paymentReminderManualInterval = time.Hour
+)
+
+type paymentShare struct {
+	PhoneNumber string
+	Amount      money.Amount
+}
+
+func (s *sentraPayService) CreatePaymentRequest(ctx context.Context, userID string, req sentrapay.CreatePaymentRequest) (*sentrapay.PaymentRequest, error) {
+	shares := make([]paymentShare, 0, len(req.Participants))
+	total := money.Amount(0)
+	for _, participant := range req.Participants {
+		if !participant.Amount.IsPositive() {
+			return nil, sentrapay.ErrInvalidAmount
+		}
+		shares = append(shares, paymentShare{PhoneNumber: participant.PhoneNumber, Amount: participant.Amount})
+		total += participant.Amount
+	}
+
+	return s.createPaymentRequest(ctx, userID, shares, total, req.Note, "", req.ExpiresInHours)
+}
+
+// SplitQRISPayment asks the participants to share a QRIS payment the user
+// made. The request total is what the participants owe, not the payment
+// total, since the requester's own share is already paid.
+func (s *sentraPayService) SplitQRISPayment(ctx context.Context, userID string, req sentrapay.SplitQRISRequest) (*sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	payment, err := repo.QRIS.GetByReferenceNo(ctx, req.QRISReferenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.UserID != userID {
+		return nil, sentrapay.ErrQRISPaymentNotFound
+	}
+
+	if payment.Status != sentrapay.QRISPaymentSettled {
+		return nil, sentrapay.ErrQRISPaymentNotSettled
+	}
+
+	shares := make([]paymentShare, 0, len(req.Participants))
+	total := money.Amount(0)
+
+	switch req.Mode {
+	case sentrapay.SplitEven:
+		// The requester is one of the people sharing the bill and keeps the
+		// remainder, so nobody is asked for more than an equal share.
+		share := payment.TotalAmount / money.Amount(len(req.Participants)+1)
+		if !share.IsPositive() {
+			return nil, sentrapay.ErrInvalidAmount
+		}
+
+		for _, participant := range req.Participants {
+			shares = append(shares, paymentShare{PhoneNumber: participant.PhoneNumber, Amount: share})
+			total += share
+		}
+	case sentrapay.SplitCustom:
+		for _, participant := range req.Participants {
+			if !participant.Amount.IsPositive() {
+				return nil, sentrapay.ErrSplitAmountRequired
+			}
+			shares = append(shares, paymentShare{PhoneNumber: participant.PhoneNumber, Amount: participant.Amount})
+			total += participant.Amount
+		}
+
+		if total > payment.TotalAmount {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": payment.ReferenceNo,
+				"total_amount": payment.TotalAmount,
+				"split_amount": total,
+			}).Warn("Split shares exceed QRIS payment total")
+			return nil, sentrapay.ErrSplitExceedsTotal
+		}
+	}
+
+	note := req.Note
+	if note == "" && payment.MerchantName != "" {
+		note = payment.MerchantName
+	}
+
+	return s.createPaymentRequest(ctx, userID, shares, total, note, payment.ReferenceNo, req.ExpiresInHours)
+}
+
+func (s *sentraPayService) createPaymentRequest(ctx context.Context, userID string, shares []paymentShare, total money.Amount, note, qrisReferenceNo string, expiresInHours int) (*sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return nil, err
+	}
+
+	requester, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get requester info")
+		return nil, err
+	}
+
+	expiry := paymentRequestDefaultExpiry
+	if expiresInHours > 0 {
+		expiry = time.Duration(expiresInHours) * time.Hour
+	}
+
+	now := time.Now()
+
+	id, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate ULID")
+		return nil, err
+	}
+
+	request := sentrapay.PaymentRequest{
+		ID:              id,
+		RequesterID:     requester.ID,
+		RequesterName:   requester.Name,
+		Note:            note,
+		QRISReferenceNo: qrisReferenceNo,
+		TotalAmount:     total,
+		Status:          sentrapay.PaymentRequestOpen,
+		ExpiresAt:       now.Add(expiry),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	seen := make(map[string]bool, len(shares))
+	for _, share := range shares {
+		user, err := authRepo.Users.GetByPhoneNumber(ctx, share.PhoneNumber)
+		if err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				s.log.WithFields(logrus.Fields{
+					"request_id":   requestID,
+					"phone_number": share.PhoneNumber,
+				}).Warn("Payment request participant not found")
+				return nil, sentrapay.ErrRecipientNotFound
+			}
+
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("Failed to get participant info")
+			return nil, err
+		}
+
+		if user.ID == requester.ID {
+			return nil, sentrapay.ErrSelfPaymentRequest
+		}
+
+		if seen[user.ID] {
+			return nil, sentrapay.ErrDuplicateParticipant
+		}
+		seen[user.ID] = true
+
+		participantID, err := s.utils.NewULIDFromTimestamp(now)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("Failed to generate ULID")
+			return nil, err
+		}
+
+		request.Participants = append(request.Participants, sentrapay.PaymentRequestParticipant{
+			ID:          participantID,
+			RequestID:   id,
+			UserID:      user.ID,
+			Name:        user.Name,
+			PhoneNumber: user.PhoneNumber,
+			Amount:      share.Amount,
+			Status:      sentrapay.ParticipantPending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	if err := repo.PaymentRequest.Create(ctx, request); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to create payment request")
+		return nil, err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":         requestID,
+		"payment_request_id": id,
+		"user_id":            userID,
+		"participants":       len(request.Participants),
+		"total_amount":       total,
+	}).Info("Payment request created")
+
+	for _, participant := range request.Participants {
+		s.sendWhatsApp(ctx, participant.PhoneNumber, fmt.Sprintf(
+			"%s meminta pembayaran %s melalui SentraPay%s. Buka aplikasi Sentra untuk membayar atau menolak sebelum %s.",
+			request.RequesterName, participant.Amount.Format(), notePhrase(request.Note), formatJakartaTime(request.ExpiresAt),
+		))
+	}
+
+	return &request, nil
+}
+
+// GetPaymentRequests lists the requests the user sent, or with role
+// "received" the ones the user was asked to pay.
+func (s *sentraPayService) GetPaymentRequests(ctx context.Context, userID string, req sentrapay.ListPaymentRequestsRequest) ([]sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	if req.Role == sentrapay.PaymentRequestsReceived {
+		requests, err := repo.PaymentRequest.GetByParticipant(ctx, userID, paymentRequestListLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range requests {
+			requests[i] = paymentRequestView(requests[i], userID)
+		}
+		return requests, nil
+	}
+
+	return repo.PaymentRequest.GetByRequester(ctx, userID, paymentRequestListLimit)
+}
+
+func (s *sentraPayService) GetPaymentRequest(ctx context.Context, userID, id string) (*sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	request, err := repo.PaymentRequest.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.RequesterID != userID && findParticipant(request, userID) == nil {
+		return nil, sentrapay.ErrPaymentRequestNotFound
+	}
+
+	view := paymentRequestView(request, userID)
+	return &view, nil
+}
+
+// AcceptPaymentRequest pays the user's share with a wallet transfer to the
+// requester. The participant is marked paid in the transfer's database
+// transaction: a concurrent or retried accept finds the participant answered
+// and rolls its transfer back, and a failed transfer leaves it pending.
+func (s *sentraPayService) AcceptPaymentRequest(ctx context.Context, userID, id string, req sentrapay.RespondPaymentRequest) (*sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	request, participant, err := s.getOwnParticipant(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		return nil, err
+	}
+
+	requester, err := authRepo.Users.GetByID(ctx, request.RequesterID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    request.RequesterID,
+			"error":      err.Error(),
+		}).Error("Failed to get requester info")
+		return nil, err
+	}
+
+	transferNote := "Payment request"
+	if request.Note != "" {
+		transferNote = truncateNote(transferNote + " " + request.Note)
+	}
+
+	transfer, err := s.transferBalance(ctx, userID, sentrapay.TransferRequest{
+		RecipientPhone: requester.PhoneNumber,
+		Amount:         participant.Amount,
+		PIN:            req.PIN,
+		Note:           transferNote,
+		OTPCode:        req.OTPCode,
+	}, func(repo sentrapayRepository.Client, referenceNo string) error {
+		return s.applyParticipantTransition(ctx, repo, participant, sentrapay.ParticipantPending, sentrapay.ParticipantPaid, referenceNo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":         requestID,
+		"payment_request_id": id,
+		"participant_id":     participant.ID,
+		"reference_no":       transfer.ReferenceNo,
+	}).Info("Payment request participant paid")
+
+	s.sendWhatsApp(ctx, requester.PhoneNumber, fmt.Sprintf(
+		"%s telah membayar %s untuk permintaan pembayaran Anda%s.",
+		participant.Name, participant.Amount.Format(), notePhrase(request.Note),
+	))
+
+	return s.GetPaymentRequest(ctx, userID, id)
+}
+
+func (s *sentraPayService) DeclinePaymentRequest(ctx context.Context, userID, id string) (*sentrapay.PaymentRequest, error) {
+	request, participant, err := s.getOwnParticipant(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transitionParticipant(ctx, participant, sentrapay.ParticipantPending, sentrapay.ParticipantDeclined, ""); err != nil {
+		return nil, err
+	}
+
+	s.notifyUser(ctx, request.RequesterID, fmt.Sprintf(
+		"%s menolak permintaan pembayaran %s dari Anda%s.",
+		participant.Name, participant.Amount.Format(), notePhrase(request.Note),
+	))
+
+	return s.GetPaymentRequest(ctx, userID, id)
+}
+
+// RemindPaymentRequest sends a WhatsApp reminder to every participant who
+// has not answered and was not reminded within the last hour.
+func (s *sentraPayService) RemindPaymentRequest(ctx context.Context, userID, id string) (*sentrapay.PaymentRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	request, err := repo.PaymentRequest.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.RequesterID != userID {
+		return nil, sentrapay.ErrPaymentRequestNotFound
+	}
+
+	now := time.Now()
+	if request.Status != sentrapay.PaymentRequestOpen || !now.Before(request.ExpiresAt) {
+		return nil, sentrapay.ErrPaymentRequestClosed
+	}
+
+	reminded := 0
+	for _, participant := range request.Participants {
+		if participant.Status != sentrapay.ParticipantPending {
+			continue
+		}
+
+		sent, err := s.remindParticipant(ctx, repo, request, participant, now, now.Add(-paymentReminderManualInterval))
+		if err != nil {
+			return nil, err
+		}
+		if sent {
+			reminded++
+		}
+	}
+
+	if reminded == 0 {
+		return nil, sentrapay.ErrReminderTooSoon
+	}
+
+	return s.GetPaymentRequest(ctx, userID, id)
+}
+
+// ProcessPaymentRequests expires requests past their deadline and sends the
+// daily reminders. It is safe to run on several instances.
+func (s *sentraPayService) ProcessPaymentRequests(ctx context.Context) (int, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+	now := time.Now()
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return 0, err
+	}
+
+	expired, err := repo.PaymentRequest.Expire(ctx, now, paymentRequestBatchSize)
+	if err != nil {
+		repo.Rollback()
+		return 0, err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return 0, err
+	}
+
+	if len(expired) > 0 {
+		s.log.WithFields(logrus.Fields{
+			"request_id":          requestID,
+			"expired_count":       len(expired),
+			"payment_request_ids": expired,
+		}).Info("Expired payment requests")
+	}
+
+	reader, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return len(expired), err
+	}
+
+	remindedBefore := now.Add(-paymentReminderInterval)
+
+	due, err := reader.PaymentRequest.GetDueReminders(ctx, now, remindedBefore, paymentReminderMax, paymentRequestBatchSize)
+	if err != nil {
+		return len(expired), err
+	}
+
+	requests := make(map[string]sentrapay.PaymentRequest)
+	reminded := 0
+
+	for _, participant := range due {
+		if ctx.Err() != nil {
+			return len(expired) + reminded, ctx.Err()
+		}
+
+		request, ok := requests[participant.RequestID]
+		if !ok {
+			request, err = reader.PaymentRequest.GetByID(ctx, participant.RequestID)
+			if err != nil {
+				return len(expired) + reminded, err
+			}
+			requests[participant.RequestID] = request
+		}
+
+		sent, err := s.remindParticipant(ctx, reader, request, participant, now, remindedBefore)
+		if err != nil {
+			return len(expired) + reminded, err
+		}
+		if sent {
+			reminded++
+		}
+	}
+
+	return len(expired) + reminded, nil
+}
+
+// remindParticipant records the reminder first and sends it only if this
+// call recorded it, so concurrent runs never remind twice.
+func (s *sentraPayService) remindParticipant(ctx context.Context, repo sentrapayRepository.Client, request sentrapay.PaymentRequest, participant sentrapay.PaymentRequestParticipant, now, remindedBefore time.Time) (bool, error) {
+	claimed, err := repo.PaymentRequest.MarkReminded(ctx, participant.ID, now, remindedBefore)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	s.sendWhatsApp(ctx, participant.PhoneNumber, fmt.Sprintf(
+		"Pengingat: %s menunggu pembayaran %s dari Anda melalui SentraPay%s. Permintaan ini berlaku sampai %s.",
+		request.RequesterName, participant.Amount.Format(), notePhrase(request.Note), formatJakartaTime(request.ExpiresAt),
+	))
+
+	return true, nil
+}
+
+func (s *sentraPayService) getOwnParticipant(ctx context.Context, userID, id string) (sentrapay.PaymentRequest, sentrapay.PaymentRequestParticipant, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return sentrapay.PaymentRequest{}, sentrapay.PaymentRequestParticipant{}, err
+	}
+
+	request, err := repo.PaymentRequest.GetByID(ctx, id)
+	if err != nil {
+		return sentrapay.PaymentRequest{}, sentrapay.PaymentRequestParticipant{}, err
+	}
+
+	participant := findParticipant(request, userID)
+	if participant == nil {
+		return sentrapay.PaymentRequest{}, sentrapay.PaymentRequestParticipant{}, sentrapay.ErrPaymentRequestNotFound
+	}
+
+	if request.Status != sentrapay.PaymentRequestOpen || !time.Now().Before(request.ExpiresAt) {
+		return sentrapay.PaymentRequest{}, sentrapay.PaymentRequestParticipant{}, sentrapay.ErrPaymentRequestClosed
+	}
+
+	if participant.Status != sentrapay.ParticipantPending {
+		return sentrapay.PaymentRequest{}, sentrapay.PaymentRequestParticipant{}, sentrapay.ErrPaymentRequestAnswered
+	}
+
+	return request, *participant, nil
+}
+
+// transitionParticipant applies one participant state change in its own
+// transaction and completes the request when it was the last answer.
+func (s *sentraPayService) transitionParticipant(ctx context.Context, participant sentrapay.PaymentRequestParticipant, from, to, transferReferenceNo string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+	defer repo.Rollback()
+
+	if err := s.applyParticipantTransition(ctx, repo, participant, from, to, transferReferenceNo); err != nil {
+		return err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":         requestID,
+		"payment_request_id": participant.RequestID,
+		"participant_id":     participant.ID,
+		"from_status":        from,
+		"to_status":          to,
+	}).Info("Payment request participant updated")
+
+	return nil
+}
+
+// applyParticipantTransition writes one participant state change with repo
+// and completes the request when it was the last answer.
+func (s *sentraPayService) applyParticipantTransition(ctx context.Context, repo sentrapayRepository.Client, participant sentrapay.PaymentRequestParticipant, from, to, transferReferenceNo string) error {
+	participant.Status = to
+	participant.TransferReferenceNo = transferReferenceNo
+	participant.RespondedAt = nil
+	if to == sentrapay.ParticipantPaid || to == sentrapay.ParticipantDeclined {
+		now := time.Now()
+		participant.RespondedAt = &now
+	}
+
+	if err := repo.PaymentRequest.TransitionParticipant(ctx, participant, from); err != nil {
+		return err
+	}
+
+	_, err := repo.PaymentRequest.CompleteIfAnswered(ctx, participant.RequestID)
+	return err
+}
+
+func (s *sentraPayService) notifyUser(ctx context.Context, userID, message string) {
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		return
+	}
+
+	user, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		return
+	}
+
+	s.sendWhatsApp(ctx, user.PhoneNumber, message)
+}
+
+// sendWhatsApp delivers a notification on a best-effort basis; the request
+// it is about has already been stored and stays visible in the app.
+func (s *sentraPayService) sendWhatsApp(ctx context.Context, phoneNumber, message string) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if s.whatsapp == nil || !s.whatsapp.IsConnected() {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"phone_number": phoneNumber,
+		}).Warn("WhatsApp unavailable, notification not sent")
+		return
+	}
+
+	if err := s.whatsapp.SendMessage(ctx, phoneNumber, message); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"phone_number": phoneNumber,
+			"error":        err.Error(),
+		}).Warn("Failed to send WhatsApp notification")
+	}
+}
+
+func findParticipant(request sentrapay.PaymentRequest, userID string) *sentrapay.PaymentRequestParticipant {
+	for i := range request.Participants {
+		if request.Participants[i].UserID == userID {
+			return &request.Participants[i]
+		}
+	}
+	return nil
+}
+
+// paymentRequestView trims the participants of a request to the user's own
+// entry unless the user is the requester.
+func paymentRequestView(request sentrapay.PaymentRequest, userID string) sentrapay.PaymentRequest {
+	if request.RequesterID == userID {
+		return request
+	}
+
+	participants := make([]sentrapay.PaymentRequestParticipant, 0, 1)
+	if participant := findParticipant(request, userID); participant != nil {
+		participants = append(participants, *participant)
+	}
+	request.Participants = participants
+
+	return request
+}
+
+func notePhrase(note string) string {
+	if note == "" {
+		return ""
+	}
+	return fmt.Sprintf(" untuk \"%s\"", note)
+}
+
+func formatJakartaTime(t time.Time) string {
+	return t.In(spendingDayLocation).Format("02/01/2006 15:04") + " WIB"
+}
+
+func truncateNote(note string) string {
+	const maxNoteLength = 255
+	if len(note) > maxNoteLength {
+		return note[:maxNoteLength]
+	}
+	return note
+}
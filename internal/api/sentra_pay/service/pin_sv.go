@@ -0,0 +1,183 @@
+package sentrapayService
+
+import (
+	authRepository "ProjectGolang/internal/api/auth/repository"
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	"ProjectGolang/pkg/bcrypt"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/redis"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPINMaxAttempts   = 3
+	defaultPINAttemptWindow = 15 * time.Minute
+	defaultPINLockDuration  = 30 * time.Minute
+)
+
+// IPINVerifier checks a user's transaction PIN before money leaves the
+// wallet. Failed attempts are counted per user and the PIN is locked for a
+// cooldown once the limit is reached, even if the correct PIN is sent later.
+type IPINVerifier interface {
+	VerifyTransactionPIN(ctx context.Context, userID string, pin string) error
+}
+
+type pinVerifier struct {
+	log           *logrus.Logger
+	authRepo      authRepository.Repository
+	redisServer   redis.IRedis
+	bcryptUtils   bcrypt.IBcrypt
+	maxAttempts   int64
+	attemptWindow time.Duration
+	lockDuration  time.Duration
+}
+
+func NewPINVerifier(log *logrus.Logger, ar authRepository.Repository, redisServer redis.IRedis, bcryptUtils bcrypt.IBcrypt) IPINVerifier {
+	maxAttempts, err := strconv.ParseInt(os.Getenv("PIN_MAX_ATTEMPTS"), 10, 64)
+	if err != nil || maxAttempts < 1 {
+		maxAttempts = defaultPINMaxAttempts
+	}
+
+	attemptWindow, err := time.ParseDuration(os.Getenv("PIN_ATTEMPT_WINDOW"))
+	if err != nil || attemptWindow <= 0 {
+		attemptWindow = defaultPINAttemptWindow
+	}
+
+	lockDuration, err := time.ParseDuration(os.Getenv("PIN_LOCK_DURATION"))
+	if err != nil || lockDuration <= 0 {
+		lockDuration = defaultPINLockDuration
+	}
+
+	return &pinVerifier{
+		log:           log,
+		authRepo:      ar,
+		redisServer:   redisServer,
+		bcryptUtils:   bcryptUtils,
+		maxAttempts:   maxAttempts,
+		attemptWindow: attemptWindow,
+		lockDuration:  lockDuration,
+	}
+}
+
+func (p *pinVerifier) VerifyTransactionPIN(ctx context.Context, userID string, pin string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	lockKey := pinLockKey(userID)
+	attemptsKey := pinAttemptsKey(userID)
+
+	lockedFor, err := p.redisServer.GetTTL(ctx, lockKey)
+	if err != nil {
+		p.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to read PIN lock state")
+		return err
+	}
+
+	if lockedFor > 0 {
+		p.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"locked_for": lockedFor.String(),
+		}).Warn("Transaction PIN is locked")
+		return sentrapay.ErrPINAttemptExceeded
+	}
+
+	repo, err := p.authRepo.NewClient(false)
+	if err != nil {
+		p.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return err
+	}
+
+	user, err := repo.Users.GetByID(ctx, userID)
+	if err != nil {
+		p.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get user for PIN verification")
+		return err
+	}
+
+	if user.PersonalIdentificationNumber == "" {
+		p.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+		}).Warn("Transaction PIN has not been set")
+		return sentrapay.ErrPINNotSet
+	}
+
+	if err := p.bcryptUtils.ComparePassword(user.PersonalIdentificationNumber, pin); err == nil {
+		if err := p.redisServer.Delete(ctx, attemptsKey); err != nil {
+			p.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"error":      err.Error(),
+			}).Warn("Failed to reset PIN attempt counter")
+		}
+		return nil
+	}
+
+	attempts, err := p.redisServer.Increment(ctx, attemptsKey, p.attemptWindow)
+	if err != nil {
+		p.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to record failed PIN attempt")
+		return err
+	}
+
+	if attempts >= p.maxAttempts {
+		if err := p.redisServer.Set(ctx, lockKey, strconv.FormatInt(attempts, 10), p.lockDuration); err != nil {
+			p.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"error":      err.Error(),
+			}).Error("Failed to lock transaction PIN")
+			return err
+		}
+
+		if err := p.redisServer.Delete(ctx, attemptsKey); err != nil {
+			p.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"error":      err.Error(),
+			}).Warn("Failed to reset PIN attempt counter")
+		}
+
+		p.log.WithFields(logrus.Fields{
+			"request_id":    requestID,
+			"user_id":       userID,
+			"attempts":      attempts,
+			"lock_duration": p.lockDuration.String(),
+		}).Warn("Transaction PIN locked after too many failed attempts")
+		return sentrapay.ErrPINAttemptExceeded
+	}
+
+	p.log.WithFields(logrus.Fields{
+		"request_id":         requestID,
+		"user_id":            userID,
+		"attempts":           attempts,
+		"remaining_attempts": p.maxAttempts - attempts,
+	}).Warn("Invalid transaction PIN")
+
+	return sentrapay.ErrInvalidPIN
+}
+
+func pinAttemptsKey(userID string) string {
+	return fmt.Sprintf("wallet:pin:attempts:%s", userID)
+}
+
+func pinLockKey(userID string) string {
+	return fmt.Sprintf("wallet:pin:lock:%s", userID)
+}
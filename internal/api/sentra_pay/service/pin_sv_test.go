@@ -0,0 +1,132 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	"ProjectGolang/internal/entity"
+	"ProjectGolang/pkg/bcrypt"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	testPINUserID = "user-1"
+	testPIN       = "123456"
+)
+
+func newTestPINVerifier(t *testing.T, redisServer *fakeRedis) *pinVerifier {
+	t.Helper()
+
+	bcryptUtils := bcrypt.NewWithCost(4)
+	hash, err := bcryptUtils.HashPassword(testPIN)
+	if err != nil {
+		t.Fatalf("hash PIN: %v", err)
+	}
+
+	return &pinVerifier{
+		log: newTestLogger(),
+		authRepo: &fakeAuthRepository{users: map[string]entity.User{
+			testPINUserID: {ID: testPINUserID, PersonalIdentificationNumber: hash},
+			"user-no-pin": {ID: "user-no-pin"},
+		}},
+		redisServer:   redisServer,
+		bcryptUtils:   bcryptUtils,
+		maxAttempts:   3,
+		attemptWindow: 15 * time.Minute,
+		lockDuration:  30 * time.Minute,
+	}
+}
+
+func TestVerifyTransactionPINAttempts(t *testing.T) {
+	tests := []struct {
+		name  string
+		pins  []string
+		wants []error
+	}{
+		{
+			name:  "correct PIN",
+			pins:  []string{testPIN},
+			wants: []error{nil},
+		},
+		{
+			name:  "wrong PIN below the limit",
+			pins:  []string{"000000", "000000"},
+			wants: []error{sentrapay.ErrInvalidPIN, sentrapay.ErrInvalidPIN},
+		},
+		{
+			name:  "limit reached locks the PIN",
+			pins:  []string{"000000", "000000", "000000"},
+			wants: []error{sentrapay.ErrInvalidPIN, sentrapay.ErrInvalidPIN, sentrapay.ErrPINAttemptExceeded},
+		},
+		{
+			name:  "correct PIN is refused while locked",
+			pins:  []string{"000000", "000000", "000000", testPIN},
+			wants: []error{sentrapay.ErrInvalidPIN, sentrapay.ErrInvalidPIN, sentrapay.ErrPINAttemptExceeded, sentrapay.ErrPINAttemptExceeded},
+		},
+		{
+			name:  "correct PIN resets the counter",
+			pins:  []string{"000000", "000000", testPIN, "000000", "000000"},
+			wants: []error{sentrapay.ErrInvalidPIN, sentrapay.ErrInvalidPIN, nil, sentrapay.ErrInvalidPIN, sentrapay.ErrInvalidPIN},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := newTestPINVerifier(t, newFakeRedis())
+
+			for i, pin := range tt.pins {
+				err := verifier.VerifyTransactionPIN(context.Background(), testPINUserID, pin)
+				if !errors.Is(err, tt.wants[i]) {
+					t.Fatalf("attempt %d: got error %v, want %v", i+1, err, tt.wants[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyTransactionPINNotSet(t *testing.T) {
+	verifier := newTestPINVerifier(t, newFakeRedis())
+
+	err := verifier.VerifyTransactionPIN(context.Background(), "user-no-pin", testPIN)
+	if !errors.Is(err, sentrapay.ErrPINNotSet) {
+		t.Fatalf("got error %v, want %v", err, sentrapay.ErrPINNotSet)
+	}
+}
+
+func TestVerifyTransactionPINLockExpires(t *testing.T) {
+	redisServer := newFakeRedis()
+	verifier := newTestPINVerifier(t, redisServer)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_ = verifier.VerifyTransactionPIN(ctx, testPINUserID, "000000")
+	}
+
+	redisServer.advance(verifier.lockDuration - time.Second)
+	if err := verifier.VerifyTransactionPIN(ctx, testPINUserID, testPIN); !errors.Is(err, sentrapay.ErrPINAttemptExceeded) {
+		t.Fatalf("before the lock expires: got error %v, want %v", err, sentrapay.ErrPINAttemptExceeded)
+	}
+
+	redisServer.advance(time.Second)
+	if err := verifier.VerifyTransactionPIN(ctx, testPINUserID, testPIN); err != nil {
+		t.Fatalf("after the lock expires: got error %v, want nil", err)
+	}
+}
+
+func TestVerifyTransactionPINAttemptWindowExpires(t *testing.T) {
+	redisServer := newFakeRedis()
+	verifier := newTestPINVerifier(t, redisServer)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_ = verifier.VerifyTransactionPIN(ctx, testPINUserID, "000000")
+	}
+
+	redisServer.advance(verifier.attemptWindow)
+
+	// The earlier failures fell out of the window, so this is the first.
+	if err := verifier.VerifyTransactionPIN(ctx, testPINUserID, "000000"); !errors.Is(err, sentrapay.ErrInvalidPIN) {
+		t.Fatalf("got error %v, want %v", err, sentrapay.ErrInvalidPIN)
+	}
+}
@@ -0,0 +1,211 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/qris"
+	"ProjectGolang/pkg/receiving"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/net/context"
+	"strings"
+	"time"
+)
+
+const (
+	qrisReceiveType          = "qris_receive"
+	qrisReceiveDefaultExpiry = 30 * time.Minute
+	qrisReceiveImageSize     = 512
+)
+
+// CreateQRISReceive issues a dynamic QRIS that pays into the user's wallet.
+// The code is tracked as a pending wallet transaction under its reference
+// number, so the acquirer's notification settles it through the same
+// callback as a top-up and the expiry worker closes it if nobody pays.
+func (s *sentraPayService) CreateQRISReceive(ctx context.Context, userID string, req sentrapay.QRISReceiveRequest) (*sentrapay.QRISReceiveResponse, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if req.Amount.IsNegative() {
+		return nil, sentrapay.ErrInvalidAmount
+	}
+
+	expiry := qrisReceiveDefaultExpiry
+	if req.ExpiresInMinutes > 0 {
+		expiry = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return nil, err
+	}
+
+	user, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get user info")
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	if _, err := repo.Wallet.GetWallet(ctx, userID); err != nil {
+		if !errors.Is(err, sentrapay.ErrWalletNotFound) {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"error":      err.Error(),
+			}).Error("Failed to get wallet")
+			return nil, err
+		}
+
+		if err := repo.Wallet.CreateWallet(ctx, userID); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"error":      err.Error(),
+			}).Error("Failed to create wallet")
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(expiry)
+
+	transactionID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate ULID")
+		return nil, err
+	}
+
+	refNo := fmt.Sprintf("QRC%s", transactionID)
+
+	code, err := s.receiving.CreateQRIS(ctx, receiving.QRISRequest{
+		ReferenceNo:  refNo,
+		MerchantName: qrisMerchantName(user.Name),
+		Amount:       req.Amount,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": refNo,
+			"error":        err.Error(),
+		}).Error("Failed to create QRIS code")
+		return nil, sentrapay.ErrCreateQRISReceive
+	}
+
+	transaction := sentrapay.WalletTransaction{
+		ID:            transactionID,
+		UserID:        userID,
+		Amount:        req.Amount,
+		Type:          qrisReceiveType,
+		ReferenceNo:   refNo,
+		PaymentMethod: "qris",
+		Status:        sentrapay.TransactionPending,
+		BankAccount:   code.NMID,
+		BankName:      "QRIS",
+		Description:   "Receive via QRIS",
+		ExpiresAt:     &expiresAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := repo.Wallet.CreateTransaction(ctx, transaction); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create transaction")
+		return nil, sentrapay.ErrCreateTransaction
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return nil, err
+	}
+
+	response := &sentrapay.QRISReceiveResponse{
+		TransactionID: transactionID,
+		ReferenceNo:   refNo,
+		Payload:       code.Payload,
+		Amount:        req.Amount,
+		FixedAmount:   req.Amount.IsPositive(),
+		NMID:          code.NMID,
+		Status:        string(sentrapay.TransactionPending),
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+	}
+
+	if req.IncludeImage {
+		png, err := qrcode.Encode(code.Payload, qrcode.Medium, qrisReceiveImageSize)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": refNo,
+				"error":        err.Error(),
+			}).Error("Failed to render QRIS image")
+			return nil, err
+		}
+		response.Image = base64.StdEncoding.EncodeToString(png)
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":   requestID,
+		"reference_no": refNo,
+		"user_id":      userID,
+		"amount":       req.Amount,
+		"expires_at":   expiresAt,
+	}).Info("QRIS receive code created")
+
+	return response, nil
+}
+
+// isOpenAmountQRISReceive reports whether transaction is a QRIS receive code
+// that left the amount to the payer and has not been paid yet.
+func isOpenAmountQRISReceive(transaction sentrapay.WalletTransaction) bool {
+	return transaction.Type == qrisReceiveType && transaction.Amount.IsZero()
+}
+
+// qrisMerchantName turns a user's name into the merchant name shown to the
+// payer: printable ASCII in uppercase, within the length QRIS allows.
+func qrisMerchantName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(strings.TrimSpace(name)) {
+		if r >= ' ' && r <= '~' {
+			b.WriteRune(r)
+		}
+	}
+
+	merchantName := strings.TrimSpace(b.String())
+	if len(merchantName) > qris.MaxMerchantNameLength {
+		merchantName = strings.TrimSpace(merchantName[:qris.MaxMerchantNameLength])
+	}
+	if merchantName == "" {
+		merchantName = "SENTRAPAY USER"
+	}
+
+	return merchantName
+}
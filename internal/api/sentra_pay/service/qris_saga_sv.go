@@ -0,0 +1,393 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/gateway"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	// qrisRecoveryDelay keeps the recovery worker away from payments whose
+	// request may still be waiting for DOKU.
+	qrisRecoveryDelay     = 2 * time.Minute
+	qrisRecoveryBatchSize = 100
+
+	qrisNotPaidReason = "payment was not completed at the gateway"
+)
+
+// initiateQRISPayment debits the wallet into the QRIS clearing account and
+// records the payment as initiated, all in one database transaction.
+func (s *sentraPayService) initiateQRISPayment(ctx context.Context, userID, gatewayName string, decoded *sentrapay.QRISDecodeResponse) (sentrapay.QRISPayment, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	totalAmount := decoded.Amount + decoded.FeeAmount
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return sentrapay.QRISPayment{}, err
+	}
+	defer repo.Rollback()
+
+	if err := s.enforceSpendingLimits(ctx, repo, userID, totalAmount); err != nil {
+		return sentrapay.QRISPayment{}, err
+	}
+
+	wallet, err := repo.Wallet.GetWalletForUpdate(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get wallet")
+		return sentrapay.QRISPayment{}, err
+	}
+
+	if wallet.Balance < totalAmount {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"user_id":      userID,
+			"balance":      wallet.Balance,
+			"total_amount": totalAmount,
+		}).Warn("Insufficient balance for QRIS payment")
+		return sentrapay.QRISPayment{}, sentrapay.ErrInsufficientBalance
+	}
+
+	now := time.Now()
+
+	transactionID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate transaction ID")
+		return sentrapay.QRISPayment{}, err
+	}
+
+	payment := sentrapay.QRISPayment{
+		ID:           transactionID,
+		UserID:       userID,
+		ReferenceNo:  fmt.Sprintf("QRP%s", transactionID),
+		MerchantName: decoded.MerchantName,
+		Amount:       decoded.Amount,
+		FeeAmount:    decoded.FeeAmount,
+		TotalAmount:  totalAmount,
+		Status:       sentrapay.QRISPaymentInitiated,
+		Gateway:      gatewayName,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	transaction := sentrapay.WalletTransaction{
+		ID:            transactionID,
+		UserID:        userID,
+		Amount:        totalAmount * -1,
+		Type:          "qris_payment",
+		ReferenceNo:   payment.ReferenceNo,
+		PaymentMethod: "qris",
+		Status:        sentrapay.TransactionProcessing,
+		BankAccount:   decoded.MerchantName,
+		BankName:      "QRIS",
+		Description:   fmt.Sprintf("QRIS payment to %s", decoded.MerchantName),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := repo.Wallet.CreateTransaction(ctx, transaction); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create transaction record")
+		return sentrapay.QRISPayment{}, err
+	}
+
+	if err := repo.QRIS.Create(ctx, payment); err != nil {
+		return sentrapay.QRISPayment{}, err
+	}
+
+	if err := s.postLedger(ctx, repo, sentrapay.LedgerPosting{
+		JournalKey:  "qris:" + transactionID,
+		ReferenceNo: payment.ReferenceNo,
+		Kind:        "qris_payment",
+		Description: transaction.Description,
+		Legs: []sentrapay.LedgerLeg{
+			walletDebitLeg(userID, totalAmount),
+			accountLeg(sentrapay.LedgerAccountQRISClearing, sentrapay.LedgerCredit, totalAmount),
+		},
+	}); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to debit wallet for QRIS payment")
+		return sentrapay.QRISPayment{}, err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return sentrapay.QRISPayment{}, err
+	}
+
+	s.publishTransactionEvents(ctx, payment.ReferenceNo)
+
+	return payment, nil
+}
+
+// confirmQRISPayment records that DOKU has taken the payment. From here on the
+// payment can only be settled.
+func (s *sentraPayService) confirmQRISPayment(ctx context.Context, payment sentrapay.QRISPayment) error {
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		return err
+	}
+
+	payment.Status = sentrapay.QRISPaymentGatewayConfirmed
+
+	return repo.QRIS.Transition(ctx, payment, sentrapay.QRISPaymentInitiated)
+}
+
+func (s *sentraPayService) settleQRISPayment(ctx context.Context, payment sentrapay.QRISPayment) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+	defer repo.Rollback()
+
+	payment.Status = sentrapay.QRISPaymentSettled
+
+	if err := repo.QRIS.Transition(ctx, payment, sentrapay.QRISPaymentGatewayConfirmed); err != nil {
+		return err
+	}
+
+	if err := repo.Wallet.UpdateTransactionStatus(ctx, payment.ReferenceNo, sentrapay.TransactionSuccess, sentrapay.StatusChange{
+		Actor: sentrapay.ActorQRISPayment,
+	}); err != nil {
+		return err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return err
+	}
+
+	s.publishTransactionEvents(ctx, payment.ReferenceNo)
+	s.recordInBudget(ctx, payment.UserID, payment.ReferenceNo, payment.MerchantName)
+
+	return nil
+}
+
+// reverseQRISPayment returns the debit of a payment DOKU did not take. Only
+// initiated payments can be reversed; a gateway-confirmed payment has reached
+// the merchant.
+func (s *sentraPayService) reverseQRISPayment(ctx context.Context, payment sentrapay.QRISPayment, reason string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+	defer repo.Rollback()
+
+	payment.Status = sentrapay.QRISPaymentReversed
+	payment.FailureReason = reason
+
+	if err := repo.QRIS.Transition(ctx, payment, sentrapay.QRISPaymentInitiated); err != nil {
+		return err
+	}
+
+	if err := repo.Wallet.UpdateTransactionStatus(ctx, payment.ReferenceNo, sentrapay.TransactionReversed, sentrapay.StatusChange{
+		Reason: reason,
+		Actor:  sentrapay.ActorQRISPayment,
+	}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	reversalID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		return err
+	}
+
+	reversalRefNo := fmt.Sprintf("QRR%s", payment.ID)
+
+	if err := repo.Wallet.CreateTransaction(ctx, sentrapay.WalletTransaction{
+		ID:            reversalID,
+		UserID:        payment.UserID,
+		Amount:        payment.TotalAmount,
+		Type:          "qris_reversal",
+		ReferenceNo:   reversalRefNo,
+		PaymentMethod: "wallet",
+		Status:        sentrapay.TransactionSuccess,
+		BankAccount:   payment.MerchantName,
+		BankName:      "QRIS",
+		Description:   fmt.Sprintf("Reversal of QRIS payment to %s", payment.MerchantName),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}); err != nil {
+		return sentrapay.ErrCreateTransaction
+	}
+
+	if err := s.postLedger(ctx, repo, sentrapay.LedgerPosting{
+		JournalKey:  "qris-reversal:" + payment.ID,
+		ReferenceNo: reversalRefNo,
+		Kind:        "qris_reversal",
+		Description: "QRIS payment reversed",
+		Legs: []sentrapay.LedgerLeg{
+			accountLeg(sentrapay.LedgerAccountQRISClearing, sentrapay.LedgerDebit, payment.TotalAmount),
+			walletCreditLeg(payment.UserID, payment.TotalAmount),
+		},
+	}); err != nil {
+		return err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":   requestID,
+		"reference_no": payment.ReferenceNo,
+		"user_id":      payment.UserID,
+		"amount":       payment.TotalAmount,
+		"reason":       reason,
+	}).Info("QRIS payment reversed")
+
+	s.publishTransactionEvents(ctx, payment.ReferenceNo)
+
+	return nil
+}
+
+// RecoverQRISPayments finishes payments left incomplete by a crash or an
+// unknown gateway outcome. Gateway-confirmed payments are settled; initiated
+// payments are looked up at DOKU and settled or reversed accordingly. Payments
+// DOKU still reports as pending are retried on the next run.
+func (s *sentraPayService) RecoverQRISPayments(ctx context.Context) (int, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return 0, err
+	}
+
+	payments, err := repo.QRIS.GetIncomplete(ctx, time.Now().Add(-qrisRecoveryDelay), qrisRecoveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			return recovered, ctx.Err()
+		}
+
+		done, err := s.recoverQRISPayment(ctx, payment)
+		if err != nil {
+			if errors.Is(err, sentrapay.ErrQRISPaymentStateChanged) {
+				continue
+			}
+
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": payment.ReferenceNo,
+				"status":       payment.Status,
+				"error":        err.Error(),
+			}).Error("Failed to recover QRIS payment")
+			continue
+		}
+
+		if done {
+			recovered++
+		}
+	}
+
+	return recovered, nil
+}
+
+func (s *sentraPayService) recoverQRISPayment(ctx context.Context, payment sentrapay.QRISPayment) (bool, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if payment.Status == sentrapay.QRISPaymentGatewayConfirmed {
+		return true, s.settleQRISPayment(ctx, payment)
+	}
+
+	provider := payment.Gateway
+	if provider == "" {
+		provider = gateway.ProviderDOKU
+	}
+
+	paymentGateway, err := s.gateways.Get(provider)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := paymentGateway.QueryQRISPayment(ctx, payment.ReferenceNo)
+	if err != nil {
+		return false, err
+	}
+
+	switch status.Status {
+	case gateway.QRISPaymentPaid:
+		payment.GatewayReferenceNo = status.ReferenceNo
+		payment.TransactionDate = status.TransactionDate
+
+		if err := s.confirmQRISPayment(ctx, payment); err != nil {
+			return false, err
+		}
+		if err := s.settleQRISPayment(ctx, payment); err != nil {
+			return false, err
+		}
+	case gateway.QRISPaymentNotPaid:
+		if err := s.reverseQRISPayment(ctx, payment, qrisNotPaidReason); err != nil {
+			return false, err
+		}
+	default:
+		s.log.WithFields(logrus.Fields{
+			"request_id":    requestID,
+			"reference_no":  payment.ReferenceNo,
+			"response_code": status.ResponseCode,
+			"created_at":    payment.CreatedAt,
+		}).Warn("QRIS payment still pending at gateway")
+		return false, nil
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":   requestID,
+		"reference_no": payment.ReferenceNo,
+		"outcome":      status.Status,
+	}).Info("Recovered incomplete QRIS payment")
+
+	return true, nil
+}
@@ -3,204 +3,237 @@ package sentrapayService
 import (
 	sentrapay "ProjectGolang/internal/api/sentra_pay"
 	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/gateway"
 	"ProjectGolang/pkg/log"
+	"ProjectGolang/pkg/qris"
+	"ProjectGolang/pkg/risk"
 	"context"
+	"errors"
 	"fmt"
-	"time"
 )
 
+// DecodeQRIS validates a QR code locally before asking the gateway to decode
+// it, so malformed codes never reach the gateway. When the gateway cannot be
+// reached the locally parsed code is returned as an offline preview.
 func (s *sentraPayService) DecodeQRIS(ctx context.Context, req sentrapay.QRISDecodeRequest) (*sentrapay.QRISDecodeResponse, error) {
 	requestID := contextPkg.GetRequestID(ctx)
 
-	decodeResponse, err := s.dokuService.DecodeQRIS(req.QRContent)
+	code, err := qris.Parse(req.QRContent)
 	if err != nil {
 		s.log.WithFields(log.Fields{
 			"request_id": requestID,
 			"error":      err.Error(),
-		}).Error("Failed to decode QRIS")
-		return nil, err
+		}).Warn("Rejected malformed QRIS code")
+		return nil, sentrapay.ErrInvalidQRISCode
 	}
 
-	var amount, feeAmount float64
-
-	if v, err := decodeResponse.TransactionAmount.Value.Float64(); err == nil {
-		amount = v
-	} else {
+	paymentGateway, err := s.gateways.ForChannel(gateway.ChannelQRIS)
+	if err != nil {
 		s.log.WithFields(log.Fields{
 			"request_id": requestID,
-			"value":      decodeResponse.TransactionAmount.Value.String(),
 			"error":      err.Error(),
-		}).Error("Failed to parse transaction amount")
-		return nil, fmt.Errorf("failed to parse transaction amount: %v", err)
+		}).Error("No payment gateway for QRIS, returning offline preview")
+		return qrisPreview(code), nil
 	}
 
-	if v, err := decodeResponse.FeeAmount.Value.Float64(); err == nil {
-		feeAmount = v
-	} else {
+	decoded, err := paymentGateway.DecodeQRIS(ctx, req.QRContent)
+	if err != nil {
+		if errors.Is(err, gateway.ErrQRISRejected) {
+			s.log.WithFields(log.Fields{
+				"request_id": requestID,
+				"nmid":       code.NMID,
+				"error":      err.Error(),
+			}).Warn("QRIS code rejected by gateway")
+			return nil, sentrapay.ErrInvalidQRISCode
+		}
+
 		s.log.WithFields(log.Fields{
 			"request_id": requestID,
-			"value":      decodeResponse.FeeAmount.Value.String(),
+			"gateway":    paymentGateway.Name(),
 			"error":      err.Error(),
-		}).Error("Failed to parse fee amount")
-		return nil, fmt.Errorf("failed to parse fee amount: %v", err)
+		}).Error("Failed to decode QRIS, returning offline preview")
+		return qrisPreview(code), nil
 	}
 
 	response := &sentrapay.QRISDecodeResponse{
-		ReferenceNo:  decodeResponse.ReferenceNo,
-		MerchantName: decodeResponse.MerchantName,
-		Amount:       amount,
-		FeeAmount:    feeAmount,
-		TotalAmount:  amount + feeAmount,
-		PaymentType:  getPaymentType(decodeResponse.AdditionalInfo.PointOfInitiationMethod),
+		ReferenceNo:          decoded.ReferenceNo,
+		MerchantName:         decoded.MerchantName,
+		MerchantCity:         code.MerchantCity,
+		MerchantCategoryCode: code.MerchantCategoryCode,
+		NMID:                 code.NMID,
+		Amount:               decoded.Amount,
+		FeeAmount:            decoded.FeeAmount,
+		TotalAmount:          decoded.Amount + decoded.FeeAmount,
+		PaymentType:          getPaymentType(decoded.PointOfInitiationMethod),
 		AdditionalInfo: sentrapay.QRISDecodeAdditionalInfo{
-			PointOfInitiationMethod:            decodeResponse.AdditionalInfo.PointOfInitiationMethod,
-			PointOfInitiationMethodDescription: decodeResponse.AdditionalInfo.PointOfInitiationMethodDescription,
-			FeeType:                            decodeResponse.AdditionalInfo.FeeType,
-			FeeTypeDescription:                 decodeResponse.AdditionalInfo.FeeTypeDescription,
+			PointOfInitiationMethod:            decoded.PointOfInitiationMethod,
+			PointOfInitiationMethodDescription: decoded.PointOfInitiationMethodDescription,
+			FeeType:                            decoded.FeeType,
+			FeeTypeDescription:                 decoded.FeeTypeDescription,
 		},
 	}
 
 	return response, nil
 }
 
+// PaymentQRIS pays a QRIS code from the wallet as a saga. The wallet is
+// debited and the payment recorded as initiated in one database transaction
+// before DOKU is charged, so a crash can never leave a paid merchant with an
+// undebited wallet. The gateway outcome then either settles the payment or
+// reverses the debit; when the outcome is unknown the payment is left for
+// RecoverQRISPayments.
 func (s *sentraPayService) PaymentQRIS(ctx context.Context, userID string, req sentrapay.QRISPaymentRequest) (*sentrapay.QRISPaymentResponse, error) {
 	requestID := contextPkg.GetRequestID(ctx)
 
+	if err := s.pinVerifier.VerifyTransactionPIN(ctx, userID, req.PIN); err != nil {
+		return nil, err
+	}
+
 	decodeRequest := sentrapay.QRISDecodeRequest{
 		QRContent: req.QRContent,
 	}
 	decodeResponse, err := s.DecodeQRIS(ctx, decodeRequest)
 	if err != nil {
+		if errors.Is(err, sentrapay.ErrInvalidQRISCode) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to decode QRIS: %v", err)
 	}
 
-	repo, err := s.walletRepository.NewClient(true)
-	if err != nil {
-		s.log.WithFields(log.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
-		}).Error("Failed to create repository client")
-		return nil, err
+	if decodeResponse.Offline {
+		return nil, sentrapay.ErrQRISGatewayUnavailable
 	}
-	defer repo.Rollback()
 
-	wallet, err := repo.Wallet.GetWallet(ctx, userID)
-	if err != nil {
-		s.log.WithFields(log.Fields{
-			"request_id": requestID,
-			"user_id":    userID,
-			"error":      err.Error(),
-		}).Error("Failed to get wallet")
+	if err := s.screenMovement(ctx, risk.Operation{
+		UserID: userID,
+		Kind:   "qris_payment",
+		Amount: decodeResponse.TotalAmount,
+		Payee:  decodeResponse.MerchantName,
+	}, req.OTPCode); err != nil {
 		return nil, err
 	}
 
-	if wallet.Balance < decodeResponse.TotalAmount {
-		s.log.WithFields(log.Fields{
-			"request_id":   requestID,
-			"user_id":      userID,
-			"balance":      wallet.Balance,
-			"total_amount": decodeResponse.TotalAmount,
-		}).Warn("Insufficient balance for QRIS payment")
-		return nil, sentrapay.ErrInsufficientBalance
-	}
-
-	paymentResponse, err := s.dokuService.PaymentQRIS(
-		req.QRContent,
-		decodeResponse.Amount,
-		decodeResponse.FeeAmount,
-		req.AuthCode,
-	)
+	paymentGateway, err := s.gateways.ForChannel(gateway.ChannelQRIS)
 	if err != nil {
-		s.log.WithFields(log.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
-		}).Error("Failed to process QRIS payment")
-		return nil, err
+		return nil, sentrapay.ErrQRISGatewayUnavailable
 	}
 
-	transactionID, err := s.utils.NewULIDFromTimestamp(time.Now())
+	payment, err := s.initiateQRISPayment(ctx, userID, paymentGateway.Name(), decodeResponse)
 	if err != nil {
-		s.log.WithFields(log.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
-		}).Error("Failed to generate transaction ID")
 		return nil, err
 	}
 
-	totalAmount := decodeResponse.Amount + decodeResponse.FeeAmount
-
-	transaction := sentrapay.WalletTransaction{
-		ID:            transactionID,
-		UserID:        userID,
-		Amount:        totalAmount * -1,
-		Type:          "qris_payment",
-		ReferenceNo:   paymentResponse.ReferenceNo,
+	response := &sentrapay.QRISPaymentResponse{
+		TransactionID: payment.ID,
+		ReferenceNo:   payment.ReferenceNo,
+		Amount:        payment.Amount,
+		FeeAmount:     payment.FeeAmount,
+		TotalAmount:   payment.TotalAmount,
+		MerchantName:  payment.MerchantName,
+		Status:        "processing",
 		PaymentMethod: "qris",
-		Status:        "success",
-		BankAccount:   decodeResponse.MerchantName,
-		BankName:      "QRIS",
-		Description:   fmt.Sprintf("QRIS payment to %s", decodeResponse.MerchantName),
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
 	}
 
-	if err := repo.Wallet.CreateTransaction(ctx, transaction); err != nil {
-		s.log.WithFields(log.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
-		}).Error("Failed to create transaction record")
+	paymentResult, err := paymentGateway.PayQRIS(ctx, gateway.QRISPaymentRequest{
+		ReferenceNo: payment.ReferenceNo,
+		QRContent:   req.QRContent,
+		Amount:      decodeResponse.Amount,
+		FeeAmount:   decodeResponse.FeeAmount,
+		AuthCode:    req.AuthCode,
+	})
+	if err != nil {
+		if errors.Is(err, gateway.ErrPaymentDeclined) {
+			s.log.WithFields(log.Fields{
+				"request_id":   requestID,
+				"reference_no": payment.ReferenceNo,
+				"error":        err.Error(),
+			}).Warn("QRIS payment declined by gateway")
+
+			if err := s.reverseQRISPayment(ctx, payment, err.Error()); err != nil {
+				s.log.WithFields(log.Fields{
+					"request_id":   requestID,
+					"reference_no": payment.ReferenceNo,
+					"error":        err.Error(),
+				}).Error("Failed to reverse declined QRIS payment, leaving it for recovery")
+				return response, nil
+			}
+
+			return nil, sentrapay.ErrQRISPaymentDeclined
+		}
 
-		return nil, err
+		s.log.WithFields(log.Fields{
+			"request_id":   requestID,
+			"reference_no": payment.ReferenceNo,
+			"error":        err.Error(),
+		}).Error("QRIS payment outcome unknown, leaving it for recovery")
+		return response, nil
 	}
 
-	newBalance := wallet.Balance - totalAmount
-	if err := repo.Wallet.UpdateWalletBalance(ctx, userID, newBalance); err != nil {
-		s.log.WithFields(log.Fields{
-			"request_id": requestID,
-			"user_id":    userID,
-			"error":      err.Error(),
-		}).Error("Failed to update wallet balance")
+	payment.GatewayReferenceNo = paymentResult.GatewayReference
+	payment.TransactionDate = paymentResult.TransactionDate
 
-		return nil, err
+	response.GatewayReferenceNo = paymentResult.GatewayReference
+	response.TransactionDate = paymentResult.TransactionDate
+	response.AdditionalInfo = sentrapay.QRISPaymentAdditionalInfo{
+		TransactionType:            paymentResult.TransactionType,
+		TransactionTypeDescription: paymentResult.TransactionTypeDescription,
+		Acquirer:                   paymentResult.Acquirer,
+		AcquirerName:               paymentResult.AcquirerName,
 	}
 
-	if err := repo.Commit(); err != nil {
+	if err := s.confirmQRISPayment(ctx, payment); err != nil {
 		s.log.WithFields(log.Fields{
-			"request_id": requestID,
-			"error":      err.Error(),
-		}).Error("Failed to commit transaction")
-		return nil, err
+			"request_id":   requestID,
+			"reference_no": payment.ReferenceNo,
+			"error":        err.Error(),
+		}).Error("Failed to record QRIS gateway confirmation, leaving it for recovery")
+		return response, nil
 	}
 
-	response := &sentrapay.QRISPaymentResponse{
-		TransactionID:   transactionID,
-		ReferenceNo:     paymentResponse.ReferenceNo,
-		Amount:          decodeResponse.Amount,
-		FeeAmount:       decodeResponse.FeeAmount,
-		TotalAmount:     totalAmount,
-		MerchantName:    decodeResponse.MerchantName,
-		Status:          "success",
-		TransactionDate: paymentResponse.TransactionDate,
-		PaymentMethod:   "qris",
-		AdditionalInfo: sentrapay.QRISPaymentAdditionalInfo{
-			TransactionType:            paymentResponse.AdditionalInfo.TransactionType,
-			TransactionTypeDescription: paymentResponse.AdditionalInfo.TransactionTypeDescription,
-			Acquirer:                   paymentResponse.AdditionalInfo.Acquirer,
-			AcquirerName:               paymentResponse.AdditionalInfo.AcquirerName,
-		},
+	if err := s.settleQRISPayment(ctx, payment); err != nil {
+		s.log.WithFields(log.Fields{
+			"request_id":   requestID,
+			"reference_no": payment.ReferenceNo,
+			"error":        err.Error(),
+		}).Error("Failed to settle QRIS payment, leaving it for recovery")
+		return response, nil
 	}
 
+	response.Status = "success"
+
 	s.log.WithFields(log.Fields{
 		"request_id":     requestID,
 		"user_id":        userID,
-		"transaction_id": transactionID,
-		"amount":         totalAmount,
-		"merchant":       decodeResponse.MerchantName,
+		"transaction_id": payment.ID,
+		"amount":         payment.TotalAmount,
+		"merchant":       payment.MerchantName,
 	}).Info("QRIS payment completed successfully")
 
 	return response, nil
 }
 
+// qrisPreview describes a code from its own contents, for when DOKU cannot
+// decode it. Descriptions are left empty; only DOKU provides them.
+func qrisPreview(code *qris.Code) *sentrapay.QRISDecodeResponse {
+	fee := code.Fee(code.Amount)
+
+	return &sentrapay.QRISDecodeResponse{
+		MerchantName:         code.MerchantName,
+		MerchantCity:         code.MerchantCity,
+		MerchantCategoryCode: code.MerchantCategoryCode,
+		NMID:                 code.NMID,
+		Amount:               code.Amount,
+		FeeAmount:            fee,
+		TotalAmount:          code.Amount + fee,
+		PaymentType:          getPaymentType(code.PointOfInitiation),
+		Offline:              true,
+		AdditionalInfo: sentrapay.QRISDecodeAdditionalInfo{
+			PointOfInitiationMethod: code.PointOfInitiation,
+			FeeType:                 code.TipIndicator,
+		},
+	}
+}
+
 func getPaymentType(pointOfInitiation string) string {
 	switch pointOfInitiation {
 	case "11":
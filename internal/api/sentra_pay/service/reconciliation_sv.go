@@ -0,0 +1,407 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	reconciliationBatchSize = 200
+	reconciliationLockKey   = "wallet:reconciliation:lock"
+	reconciliationLockTTL   = 6 * time.Hour
+)
+
+// ReconcileTopUps asks DOKU for the status of every open top-up and credits
+// payments whose notification never reached us, using the same ledger path as
+// the payment callback. Amount differences and gateway failures are only
+// recorded in the reconciliation report for finance to review.
+func (s *sentraPayService) ReconcileTopUps(ctx context.Context) (*sentrapay.ReconciliationReport, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	holders, err := s.redisServer.Increment(ctx, reconciliationLockKey, reconciliationLockTTL)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to acquire reconciliation lock")
+		return nil, err
+	}
+	if holders > 1 {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+		}).Warn("Reconciliation already running, skipping")
+		return nil, sentrapay.ErrReconciliationInProgress
+	}
+	defer func() {
+		if err := s.redisServer.Delete(context.Background(), reconciliationLockKey); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Warn("Failed to release reconciliation lock")
+		}
+	}()
+
+	now := time.Now()
+
+	runID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate reconciliation run ID")
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	report := &sentrapay.ReconciliationReport{
+		Run: sentrapay.ReconciliationRun{
+			ID:        runID,
+			Status:    sentrapay.ReconciliationRunning,
+			StartedAt: now,
+		},
+		Items: []sentrapay.ReconciliationItem{},
+	}
+
+	if err := repo.Reconciliation.CreateRun(ctx, report.Run); err != nil {
+		return nil, err
+	}
+
+	runErr := s.reconcileOpenTopUps(ctx, repo, report)
+
+	finishedAt := time.Now()
+	report.Run.FinishedAt = &finishedAt
+	report.Run.Status = sentrapay.ReconciliationCompleted
+	if runErr != nil {
+		report.Run.Status = sentrapay.ReconciliationFailed
+		report.Run.Error = runErr.Error()
+	}
+
+	// The run context may already be cancelled; the summary still has to be saved.
+	finishCtx, cancel := context.WithTimeout(contextPkg.WithRequestID(context.Background(), requestID), 10*time.Second)
+	defer cancel()
+
+	if err := repo.Reconciliation.FinishRun(finishCtx, report.Run); err != nil {
+		return report, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":     requestID,
+		"run_id":         report.Run.ID,
+		"status":         report.Run.Status,
+		"checked_count":  report.Run.CheckedCount,
+		"credited_count": report.Run.CreditedCount,
+		"mismatch_count": report.Run.MismatchCount,
+		"error_count":    report.Run.ErrorCount,
+	}).Info("Top-up reconciliation finished")
+
+	if runErr != nil {
+		return report, runErr
+	}
+
+	return report, nil
+}
+
+func (s *sentraPayService) reconcileOpenTopUps(ctx context.Context, repo sentrapayRepository.Client, report *sentrapay.ReconciliationReport) error {
+	afterID := ""
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		transactions, err := repo.Wallet.GetOpenTopUps(ctx, afterID, reconciliationBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, transaction := range transactions {
+			afterID = transaction.ID
+			report.Run.CheckedCount++
+
+			item, err := s.reconcileTopUp(ctx, transaction)
+			if err != nil {
+				return err
+			}
+			if item == nil {
+				continue
+			}
+
+			now := time.Now()
+
+			item.ID, err = s.utils.NewULIDFromTimestamp(now)
+			if err != nil {
+				return err
+			}
+			item.RunID = report.Run.ID
+			item.CreatedAt = now
+
+			if err := repo.Reconciliation.CreateItem(ctx, *item); err != nil {
+				return err
+			}
+
+			report.Items = append(report.Items, *item)
+
+			switch item.Kind {
+			case sentrapay.ReconciliationMissedPaymentCredited, sentrapay.ReconciliationStatusCorrected:
+				report.Run.CreditedCount++
+			case sentrapay.ReconciliationAmountMismatch:
+				report.Run.MismatchCount++
+			case sentrapay.ReconciliationGatewayError:
+				report.Run.ErrorCount++
+			}
+		}
+
+		if len(transactions) < reconciliationBatchSize {
+			return nil
+		}
+	}
+}
+
+// reconcileTopUp compares one open top-up with DOKU. It returns nil when
+// there is nothing to report, i.e. the virtual account is still unpaid.
+func (s *sentraPayService) reconcileTopUp(ctx context.Context, transaction sentrapay.WalletTransaction) (*sentrapay.ReconciliationItem, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	item := &sentrapay.ReconciliationItem{
+		TransactionID:  transaction.ID,
+		ReferenceNo:    transaction.ReferenceNo,
+		UserID:         transaction.UserID,
+		LocalStatus:    string(transaction.Status),
+		ExpectedAmount: transaction.Amount,
+	}
+
+	vaStatus, err := s.checkTopUpVAStatus(ctx, transaction)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": transaction.ReferenceNo,
+			"error":        err.Error(),
+		}).Warn("Failed to check VA status during reconciliation")
+
+		item.Kind = sentrapay.ReconciliationGatewayError
+		item.Note = err.Error()
+		return item, nil
+	}
+
+	if !vaStatus.Paid {
+		return nil, nil
+	}
+
+	paidAmount := vaStatus.PaidAmount
+	difference := paidAmount - transaction.Amount
+
+	item.GatewayAmount = &paidAmount
+	item.Difference = &difference
+	item.GatewayResponseCode = vaStatus.ResponseCode
+
+	if difference != 0 {
+		s.log.WithFields(logrus.Fields{
+			"request_id":      requestID,
+			"reference_no":    transaction.ReferenceNo,
+			"expected_amount": transaction.Amount,
+			"paid_amount":     paidAmount,
+		}).Warn("Top-up paid with a different amount, left for review")
+
+		item.Kind = sentrapay.ReconciliationAmountMismatch
+		item.Note = "paid amount differs from top-up amount; wallet not credited"
+		return item, nil
+	}
+
+	kind, err := s.creditMissedTopUp(ctx, transaction, paidAmount, vaStatus.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Kind = kind
+	return item, nil
+}
+
+func (s *sentraPayService) creditMissedTopUp(ctx context.Context, transaction sentrapay.WalletTransaction, paidAmount money.Amount, paymentID string) (string, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return "", err
+	}
+	defer repo.Rollback()
+
+	if paymentID != "" {
+		if err := repo.Wallet.SetTransactionGatewayPayment(ctx, transaction.ReferenceNo, transactionGateway(transaction), paymentID); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": transaction.ReferenceNo,
+				"payment_id":   paymentID,
+				"error":        err.Error(),
+			}).Error("Failed to record gateway payment")
+			return "", err
+		}
+	}
+
+	if err := s.settleTopUp(ctx, repo, transaction, paidAmount, sentrapay.ActorReconciliation); err != nil {
+		if !errors.Is(err, sentrapay.ErrJournalAlreadyPosted) {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": transaction.ReferenceNo,
+				"error":        err.Error(),
+			}).Error("Failed to settle missed top-up")
+			return "", err
+		}
+
+		// The wallet was credited but the status update was lost; only the
+		// status needs fixing.
+		repo.Rollback()
+
+		statusRepo, err := s.walletRepository.NewClient(true)
+		if err != nil {
+			return "", err
+		}
+		defer statusRepo.Rollback()
+
+		if err := statusRepo.Wallet.UpdateTransactionStatus(ctx, transaction.ReferenceNo, sentrapay.TransactionSuccess, sentrapay.StatusChange{
+			Reason: "wallet already credited; status corrected by reconciliation",
+			Actor:  sentrapay.ActorReconciliation,
+		}); err != nil {
+			return "", err
+		}
+
+		if err := statusRepo.Commit(); err != nil {
+			return "", err
+		}
+
+		s.publishTransactionEvents(ctx, transaction.ReferenceNo)
+		s.recordInBudget(ctx, transaction.UserID, transaction.ReferenceNo, "")
+
+		return sentrapay.ReconciliationStatusCorrected, nil
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return "", err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":   requestID,
+		"reference_no": transaction.ReferenceNo,
+		"user_id":      transaction.UserID,
+		"amount":       paidAmount,
+	}).Info("Credited missed top-up payment during reconciliation")
+
+	s.publishTransactionEvents(ctx, transaction.ReferenceNo)
+	s.recordInBudget(ctx, transaction.UserID, transaction.ReferenceNo, "")
+
+	return sentrapay.ReconciliationMissedPaymentCredited, nil
+}
+
+// flagUnmatchedPayment records a payment that settles no open transaction,
+// such as a second payment into a reusable virtual account after its top-up
+// was settled. The wallet is not credited; the payment is kept as a
+// reconciliation item of its own run so finance can credit or refund it.
+func (s *sentraPayService) flagUnmatchedPayment(ctx context.Context, transaction sentrapay.WalletTransaction, req sentrapay.PaymentCallbackRequest, paidAmount money.Amount) error {
+	requestID := contextPkg.GetRequestID(ctx)
+	now := time.Now()
+
+	runID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate reconciliation run ID")
+		return err
+	}
+
+	itemID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate reconciliation item ID")
+		return err
+	}
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+	defer repo.Rollback()
+
+	run := sentrapay.ReconciliationRun{
+		ID:            runID,
+		Status:        sentrapay.ReconciliationCompleted,
+		CheckedCount:  1,
+		MismatchCount: 1,
+		StartedAt:     now,
+		FinishedAt:    &now,
+	}
+
+	if err := repo.Reconciliation.CreateRun(ctx, run); err != nil {
+		return err
+	}
+
+	if err := repo.Reconciliation.FinishRun(ctx, run); err != nil {
+		return err
+	}
+
+	difference := paidAmount - transaction.Amount
+	if err := repo.Reconciliation.CreateItem(ctx, sentrapay.ReconciliationItem{
+		ID:             itemID,
+		RunID:          runID,
+		TransactionID:  transaction.ID,
+		ReferenceNo:    transaction.ReferenceNo,
+		UserID:         transaction.UserID,
+		Kind:           sentrapay.ReconciliationUnmatchedPayment,
+		LocalStatus:    string(transaction.Status),
+		ExpectedAmount: transaction.Amount,
+		GatewayAmount:  &paidAmount,
+		Difference:     &difference,
+		Note:           fmt.Sprintf("payment %q into %s matches no open top-up; wallet not credited", req.PaymentRequestId, req.VirtualAccountNo),
+		CreatedAt:      now,
+	}); err != nil {
+		return err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":     requestID,
+		"reference_no":   transaction.ReferenceNo,
+		"user_id":        transaction.UserID,
+		"payment_id":     req.PaymentRequestId,
+		"paid_amount":    paidAmount,
+		"virtual_acc_no": req.VirtualAccountNo,
+		"run_id":         runID,
+	}).Error("Payment matches no open top-up, flagged for review")
+
+	return sentrapay.ErrUnmatchedPayment
+}
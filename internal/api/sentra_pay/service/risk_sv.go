@@ -0,0 +1,268 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/risk"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	defaultRiskDecisionListLimit = 20
+
+	// maxDeviceIDLength is the size of wallet_devices.device_id. Longer IDs
+	// are treated as missing rather than cut, so they cannot collide.
+	maxDeviceIDLength = 100
+
+	// A challenge OTP is voided after riskOTPMaxAttempts wrong codes within
+	// riskOTPAttemptWindow, so the client has to request a new one.
+	riskOTPMaxAttempts   = 3
+	riskOTPAttemptWindow = 15 * time.Minute
+)
+
+// screenMovement runs the risk rules on a money movement before any of it is
+// written. A denied movement is refused. A challenged one goes ahead only
+// when otpCode matches the OTP sent to the user's phone number; without a
+// code the client gets ErrRiskChallengeRequired and repeats the request with
+// one. Wrong codes are counted per user and the OTP is voided once they
+// reach riskOTPMaxAttempts. Every screening is logged for review.
+func (s *sentraPayService) screenMovement(ctx context.Context, op risk.Operation, otpCode string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	op.At = time.Now()
+	op.DeviceID = contextPkg.GetDeviceID(ctx)
+	if len(op.DeviceID) > maxDeviceIDLength {
+		op.DeviceID = ""
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+
+	decision, err := s.risk.Evaluate(ctx, op, repo.Risk)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    op.UserID,
+			"operation":  op.Kind,
+			"error":      err.Error(),
+		}).Error("Failed to evaluate risk rules")
+		return err
+	}
+
+	var outcome string
+	var phoneNumber string
+	switch decision.Action {
+	case risk.Deny:
+		outcome = sentrapay.RiskDenied
+	case risk.Challenge:
+		if otpCode == "" {
+			outcome = sentrapay.RiskChallenged
+			break
+		}
+
+		phoneNumber, err = s.userPhoneNumber(ctx, op.UserID)
+		if err != nil {
+			return err
+		}
+
+		storedOTP, err := s.redisServer.GetOTP(ctx, phoneNumber)
+		if err != nil || storedOTP != otpCode {
+			outcome = sentrapay.RiskChallengeFailed
+		} else {
+			outcome = sentrapay.RiskChallengePassed
+		}
+	default:
+		outcome = sentrapay.RiskAllowed
+	}
+
+	s.logRiskDecision(ctx, repo, op, decision, outcome)
+
+	switch outcome {
+	case sentrapay.RiskDenied:
+		return sentrapay.ErrRiskDenied
+	case sentrapay.RiskChallenged:
+		return sentrapay.ErrRiskChallengeRequired
+	case sentrapay.RiskChallengeFailed:
+		return s.recordFailedChallenge(ctx, op.UserID, phoneNumber)
+	case sentrapay.RiskChallengePassed:
+		if err := s.redisServer.DeleteOTP(ctx, phoneNumber); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    op.UserID,
+				"error":      err.Error(),
+			}).Warn("Failed to delete used OTP")
+		}
+
+		if err := s.redisServer.Delete(ctx, riskOTPAttemptsKey(op.UserID)); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    op.UserID,
+				"error":      err.Error(),
+			}).Warn("Failed to reset OTP attempt counter")
+		}
+	}
+
+	if op.DeviceID != "" {
+		if err := repo.Risk.RememberDevice(ctx, op.UserID, op.DeviceID, op.At); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    op.UserID,
+				"error":      err.Error(),
+			}).Warn("Failed to remember device")
+		}
+	}
+
+	return nil
+}
+
+// recordFailedChallenge counts a wrong challenge OTP. Once the limit is
+// reached the stored OTP is deleted, so guessing cannot continue against it.
+func (s *sentraPayService) recordFailedChallenge(ctx context.Context, userID string, phoneNumber string) error {
+	requestID := contextPkg.GetRequestID(ctx)
+	attemptsKey := riskOTPAttemptsKey(userID)
+
+	attempts, err := s.redisServer.Increment(ctx, attemptsKey, riskOTPAttemptWindow)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to record failed OTP attempt")
+		return err
+	}
+
+	if attempts < riskOTPMaxAttempts {
+		s.log.WithFields(logrus.Fields{
+			"request_id":         requestID,
+			"user_id":            userID,
+			"attempts":           attempts,
+			"remaining_attempts": riskOTPMaxAttempts - attempts,
+		}).Warn("Invalid risk challenge OTP")
+		return sentrapay.ErrInvalidOTP
+	}
+
+	if err := s.redisServer.DeleteOTP(ctx, phoneNumber); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to void OTP")
+		return err
+	}
+
+	if err := s.redisServer.Delete(ctx, attemptsKey); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Warn("Failed to reset OTP attempt counter")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"user_id":    userID,
+		"attempts":   attempts,
+	}).Warn("Risk challenge OTP voided after too many failed attempts")
+	return sentrapay.ErrOTPAttemptExceeded
+}
+
+func riskOTPAttemptsKey(userID string) string {
+	return fmt.Sprintf("wallet:risk:otp:attempts:%s", userID)
+}
+
+// logRiskDecision stores the decision for review. A decision that cannot be
+// stored is still in the application log and does not hold up the payment.
+func (s *sentraPayService) logRiskDecision(ctx context.Context, repo sentrapayRepository.Client, op risk.Operation, decision risk.Decision, outcome string) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	fields := logrus.Fields{
+		"request_id": requestID,
+		"user_id":    op.UserID,
+		"operation":  op.Kind,
+		"amount":     op.Amount,
+		"action":     decision.Action,
+		"outcome":    outcome,
+	}
+	if decision.Action != risk.Allow {
+		fields["results"] = decision.Results
+		s.log.WithFields(fields).Warn("Money movement flagged by risk rules")
+	}
+
+	id, err := s.utils.NewULIDFromTimestamp(op.At)
+	if err != nil {
+		fields["error"] = err.Error()
+		s.log.WithFields(fields).Error("Failed to generate risk decision ID")
+		return
+	}
+
+	if err := repo.Risk.CreateDecision(ctx, sentrapay.RiskDecision{
+		ID:        id,
+		UserID:    op.UserID,
+		Operation: op.Kind,
+		Amount:    op.Amount,
+		Payee:     op.Payee,
+		DeviceID:  op.DeviceID,
+		Action:    decision.Action,
+		Outcome:   outcome,
+		Results:   decision.Results,
+		CreatedAt: op.At,
+	}); err != nil {
+		fields["error"] = err.Error()
+		s.log.WithFields(fields).Error("Failed to store risk decision")
+	}
+}
+
+func (s *sentraPayService) userPhoneNumber(ctx context.Context, userID string) (string, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return "", err
+	}
+
+	user, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get user info")
+		return "", err
+	}
+
+	return user.PhoneNumber, nil
+}
+
+func (s *sentraPayService) ListRiskDecisions(ctx context.Context, req sentrapay.ListRiskDecisionsRequest) ([]sentrapay.RiskDecision, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultRiskDecisionListLimit
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	return repo.Risk.GetDecisions(ctx, req.Outcome, req.UserID, limit)
+}
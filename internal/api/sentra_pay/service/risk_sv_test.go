@@ -0,0 +1,207 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	"ProjectGolang/internal/entity"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/risk"
+	"ProjectGolang/pkg/utils"
+	"errors"
+	"golang.org/x/net/context"
+	"testing"
+	"time"
+)
+
+const (
+	riskTestUserID = "user-1"
+	riskTestPhone  = "6281234567890"
+)
+
+// newTestRiskService screens movements with the new_device rule configured
+// from the environment the way the server builds it, every other rule off.
+func newTestRiskService(t *testing.T, unidentifiedDevice string) (*sentraPayService, *fakeRiskRepository, *fakeRedis) {
+	t.Helper()
+
+	t.Setenv("RISK_RULES", "velocity=off,new_payee=off,unusual_hours=off")
+	t.Setenv("RISK_UNIDENTIFIED_DEVICE", unidentifiedDevice)
+
+	engine, err := risk.New()
+	if err != nil {
+		t.Fatalf("risk.New: %v", err)
+	}
+
+	riskRepository := newFakeRiskRepository()
+	redis := newFakeRedis()
+
+	service := &sentraPayService{
+		log:              newTestLogger(),
+		walletRepository: &fakeWalletRepository{risk: riskRepository},
+		authRepo: &fakeAuthRepository{users: map[string]entity.User{
+			riskTestUserID: {ID: riskTestUserID, PhoneNumber: riskTestPhone},
+		}},
+		risk:        engine,
+		redisServer: redis,
+		utils:       utils.New(),
+	}
+
+	return service, riskRepository, redis
+}
+
+func topUpOperation() risk.Operation {
+	return risk.Operation{UserID: riskTestUserID, Kind: "topup", Amount: money.FromRupiah(100_000)}
+}
+
+func TestScreenMovementDeviceIdentification(t *testing.T) {
+	tests := []struct {
+		name               string
+		unidentifiedDevice string
+		deviceID           string
+		want               error
+		wantOutcome        string
+		wantDevices        []string
+	}{
+		{
+			name:        "no header is allowed by default",
+			want:        nil,
+			wantOutcome: sentrapay.RiskAllowed,
+			wantDevices: []string{"phone-a"},
+		},
+		{
+			name:               "no header challenged when configured",
+			unidentifiedDevice: "challenge",
+			want:               sentrapay.ErrRiskChallengeRequired,
+			wantOutcome:        sentrapay.RiskChallenged,
+			wantDevices:        []string{"phone-a"},
+		},
+		{
+			name:        "known device",
+			deviceID:    "phone-a",
+			want:        nil,
+			wantOutcome: sentrapay.RiskAllowed,
+			wantDevices: []string{"phone-a"},
+		},
+		{
+			name:        "new device",
+			deviceID:    "phone-b",
+			want:        sentrapay.ErrRiskChallengeRequired,
+			wantOutcome: sentrapay.RiskChallenged,
+			wantDevices: []string{"phone-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, riskRepository, _ := newTestRiskService(t, tt.unidentifiedDevice)
+			riskRepository.devices[riskTestUserID] = []string{"phone-a"}
+
+			ctx := context.Background()
+			if tt.deviceID != "" {
+				ctx = contextPkg.WithDeviceID(ctx, tt.deviceID)
+			}
+
+			err := service.screenMovement(ctx, topUpOperation(), "")
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("screenMovement error = %v, want %v", err, tt.want)
+			}
+
+			decision := riskRepository.lastDecision()
+			if decision.Outcome != tt.wantOutcome {
+				t.Fatalf("stored outcome = %q, want %q", decision.Outcome, tt.wantOutcome)
+			}
+
+			devices, _ := riskRepository.KnownDevices(ctx, riskTestUserID)
+			if len(devices) != len(tt.wantDevices) {
+				t.Fatalf("known devices = %v, want %v", devices, tt.wantDevices)
+			}
+		})
+	}
+}
+
+func TestScreenMovementChallengeOTPAttempts(t *testing.T) {
+	const otp = "123456"
+
+	tests := []struct {
+		name    string
+		codes   []string
+		wants   []error
+		wantOTP bool
+	}{
+		{
+			name:    "correct code",
+			codes:   []string{otp},
+			wants:   []error{nil},
+			wantOTP: false,
+		},
+		{
+			name:    "correct code after a wrong one",
+			codes:   []string{"000000", otp},
+			wants:   []error{sentrapay.ErrInvalidOTP, nil},
+			wantOTP: false,
+		},
+		{
+			name:    "wrong codes below the limit keep the OTP",
+			codes:   []string{"000000", "111111"},
+			wants:   []error{sentrapay.ErrInvalidOTP, sentrapay.ErrInvalidOTP},
+			wantOTP: true,
+		},
+		{
+			name:    "OTP is voided at the limit",
+			codes:   []string{"000000", "111111", "222222", otp},
+			wants:   []error{sentrapay.ErrInvalidOTP, sentrapay.ErrInvalidOTP, sentrapay.ErrOTPAttemptExceeded, sentrapay.ErrInvalidOTP},
+			wantOTP: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, redis := newTestRiskService(t, "challenge")
+			ctx := context.Background()
+
+			if err := redis.SetOTP(ctx, riskTestPhone, otp, time.Minute); err != nil {
+				t.Fatalf("SetOTP: %v", err)
+			}
+
+			for i, code := range tt.codes {
+				err := service.screenMovement(ctx, topUpOperation(), code)
+				if !errors.Is(err, tt.wants[i]) || (tt.wants[i] == nil && err != nil) {
+					t.Fatalf("attempt %d: got error %v, want %v", i+1, err, tt.wants[i])
+				}
+			}
+
+			if _, err := redis.GetOTP(ctx, riskTestPhone); (err == nil) != tt.wantOTP {
+				t.Fatalf("OTP stored = %v, want %v", err == nil, tt.wantOTP)
+			}
+		})
+	}
+}
+
+func TestScreenMovementChallengeOTPAttemptsReset(t *testing.T) {
+	const otp = "123456"
+
+	service, _, redis := newTestRiskService(t, "challenge")
+	ctx := context.Background()
+
+	// Two misses, a pass, then two more misses stay under the limit.
+	for _, code := range []string{"000000", "111111", otp} {
+		if err := redis.SetOTP(ctx, riskTestPhone, otp, time.Minute); err != nil {
+			t.Fatalf("SetOTP: %v", err)
+		}
+		_ = service.screenMovement(ctx, topUpOperation(), code)
+	}
+
+	if err := redis.SetOTP(ctx, riskTestPhone, otp, time.Minute); err != nil {
+		t.Fatalf("SetOTP: %v", err)
+	}
+	for i := 0; i < riskOTPMaxAttempts-1; i++ {
+		if err := service.screenMovement(ctx, topUpOperation(), "999999"); !errors.Is(err, sentrapay.ErrInvalidOTP) {
+			t.Fatalf("attempt %d after a pass: got error %v, want %v", i+1, err, sentrapay.ErrInvalidOTP)
+		}
+	}
+
+	// Misses outside the window do not add up.
+	redis.advance(riskOTPAttemptWindow)
+	if err := service.screenMovement(ctx, topUpOperation(), "999999"); !errors.Is(err, sentrapay.ErrInvalidOTP) {
+		t.Fatalf("after the window: got error %v, want %v", err, sentrapay.ErrInvalidOTP)
+	}
+}
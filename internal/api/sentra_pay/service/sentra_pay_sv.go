@@ -2,14 +2,15 @@ package sentrapayService
 
 import (
 	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
 	contextPkg "ProjectGolang/pkg/context"
-	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/gateway"
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/risk"
 	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -33,6 +34,14 @@ func (s *sentraPayService) CreateTopUpTransaction(ctx context.Context, userID st
 		return nil, sentrapay.ErrInvalidAmount
 	}
 
+	if err := s.screenMovement(ctx, risk.Operation{
+		UserID: userID,
+		Kind:   "topup",
+		Amount: req.Amount,
+	}, req.OTPCode); err != nil {
+		return nil, err
+	}
+
 	repo, err := s.walletRepository.NewClient(true)
 	if err != nil {
 		s.log.WithFields(logrus.Fields{
@@ -102,27 +111,58 @@ func (s *sentraPayService) CreateTopUpTransaction(ctx context.Context, userID st
 		}).Warn("Using placeholder email for DOKU integration")
 	}
 
-	dokuReq := doku.CreateVaRequest{
-		UserID:          userID,
-		Name:            user.Name,
-		Email:           user.Email,
-		Phone:           user.PhoneNumber,
-		Amount:          req.Amount,
-		TrxId:           refNo,
-		Bank:            req.Bank,
-		ExpiredDuration: 24 * time.Hour,
-		ReusableStatus:  false,
+	paymentGateway, err := s.topUpGateway(ctx, repo, userID, req.Bank)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"bank":       req.Bank,
+			"error":      err.Error(),
+		}).Error("No payment gateway for channel")
+		return nil, sentrapay.ErrCreateVirtualAccount
+	}
+
+	userVA, err := s.userVirtualAccount(ctx, repo, userID, req.Bank, paymentGateway.Name())
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"bank":       req.Bank,
+			"error":      err.Error(),
+		}).Error("Failed to allocate virtual account number")
+		return nil, sentrapay.ErrCreateVirtualAccount
 	}
 
-	dokuRes, err := s.dokuService.CreateVirtualAccount(dokuReq)
+	expiresAt := time.Now().Add(topUpExpiry)
+
+	virtualAccount, err := paymentGateway.CreateVirtualAccount(ctx, gateway.VirtualAccountRequest{
+		ReferenceNo:      refNo,
+		Channel:          req.Bank,
+		CustomerID:       userID,
+		PartnerServiceID: userVA.PartnerServiceID,
+		CustomerNo:       userVA.CustomerNo,
+		Name:             user.Name,
+		Email:            user.Email,
+		Phone:            user.PhoneNumber,
+		Amount:           req.Amount,
+		ExpiresIn:        topUpExpiry,
+	})
 	if err != nil {
 		s.log.WithFields(logrus.Fields{
 			"request_id": requestID,
+			"gateway":    paymentGateway.Name(),
 			"error":      err.Error(),
 		}).Error("Failed to create virtual account")
 		return nil, sentrapay.ErrCreateVirtualAccount
 	}
 
+	if err := s.recordIssuedNumber(ctx, repo, userVA, virtualAccount.VirtualAccountNo); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to record issued virtual account number")
+		return nil, sentrapay.ErrCreateVirtualAccount
+	}
+
 	transaction := sentrapay.WalletTransaction{
 		ID:            transactionID,
 		UserID:        userID,
@@ -130,10 +170,12 @@ func (s *sentraPayService) CreateTopUpTransaction(ctx context.Context, userID st
 		Type:          "topup",
 		ReferenceNo:   refNo,
 		PaymentMethod: "virtual_account",
-		Status:        "pending",
-		BankAccount:   dokuRes.VirtualAccountNo,
+		Status:        sentrapay.TransactionPending,
+		BankAccount:   virtualAccount.VirtualAccountNo,
 		BankName:      getBankName(req.Bank),
 		Description:   fmt.Sprintf("Top up via %s", getBankName(req.Bank)),
+		ExpiresAt:     &expiresAt,
+		Gateway:       paymentGateway.Name(),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
@@ -157,11 +199,11 @@ func (s *sentraPayService) CreateTopUpTransaction(ctx context.Context, userID st
 	response := &sentrapay.TopUpResponse{
 		TransactionID:   transactionID,
 		ReferenceNo:     refNo,
-		VirtualAccount:  dokuRes.VirtualAccountNo,
+		VirtualAccount:  virtualAccount.VirtualAccountNo,
 		Bank:            getBankName(req.Bank),
 		Amount:          req.Amount,
-		ExpiresAt:       dokuRes.ExpiryDate,
-		PaymentGuideURL: dokuRes.VirtualAccountURL,
+		ExpiresAt:       virtualAccount.ExpiryDate,
+		PaymentGuideURL: virtualAccount.PaymentGuideURL,
 		Status:          "pending",
 		CreatedAt:       transaction.CreatedAt,
 	}
@@ -169,14 +211,109 @@ func (s *sentraPayService) CreateTopUpTransaction(ctx context.Context, userID st
 	return response, nil
 }
 
-func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentrapay.PaymentCallbackRequest, channelID, xExternalID, xTimestamp, xPartnerID string) error {
+// HandlePaymentNotification authenticates a provider's payment notification
+// before any of its content is trusted, then applies it. Notifications of
+// payments that are not complete yet are acknowledged without effect. The
+// parsed callback is returned whenever authentication succeeded, so the
+// caller can echo it in the provider's response format.
+func (s *sentraPayService) HandlePaymentNotification(ctx context.Context, provider string, notification sentrapay.PaymentNotification) (*sentrapay.PaymentCallbackRequest, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	paymentGateway, err := s.gateways.Get(provider)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"provider":   provider,
+		}).Warn("Payment notification from unknown provider")
+		return nil, sentrapay.ErrUnknownPaymentProvider
+	}
+
+	record, err := s.storeNotification(ctx, paymentGateway.Name(), notification)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := paymentGateway.ParseNotification(ctx, gateway.Notification{
+		HTTPMethod:  notification.HTTPMethod,
+		EndpointURL: notification.EndpointURL,
+		Body:        notification.Body,
+		Headers:     notification.Headers,
+	})
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"provider":     provider,
+			"endpoint_url": notification.EndpointURL,
+			"error":        err.Error(),
+		}).Warn("Rejected payment notification")
+
+		record.Verification = sentrapay.NotificationRejected
+		record.VerificationError = err.Error()
+		record.Outcome = sentrapay.NotificationFailed
+		_ = s.updateNotification(ctx, record)
+
+		switch {
+		case errors.Is(err, gateway.ErrInvalidTimestamp):
+			return nil, sentrapay.ErrInvalidNotificationTimestamp
+		case errors.Is(err, gateway.ErrStaleTimestamp):
+			return nil, sentrapay.ErrStaleNotification
+		case errors.Is(err, gateway.ErrInvalidNotification):
+			return nil, sentrapay.ErrInvalidCallback
+		case errors.Is(err, gateway.ErrNotificationNotConfigured):
+			return nil, err
+		default:
+			return nil, sentrapay.ErrInvalidSignature
+		}
+	}
+
+	req := &sentrapay.PaymentCallbackRequest{
+		PartnerServiceId:   parsed.PartnerServiceID,
+		CustomerNo:         parsed.CustomerNo,
+		VirtualAccountNo:   parsed.VirtualAccountNo,
+		VirtualAccountName: parsed.VirtualAccountName,
+		TrxId:              parsed.ReferenceNo,
+		PaymentRequestId:   parsed.PaymentRequestID,
+		PaidAmount:         sentrapay.Amount{Value: parsed.PaidAmount.String(), Currency: "IDR"},
+		TotalAmount:        sentrapay.Amount{Value: parsed.TotalAmount.String(), Currency: "IDR"},
+		TrxDateTime:        parsed.PaidAt,
+		AdditionalInfo: sentrapay.AdditionalInfo{
+			Channel: parsed.Channel,
+		},
+		Provider: parsed.Provider,
+	}
+
+	record.Verification = sentrapay.NotificationVerified
+	record.Callback = req
+	record.ReferenceNo = parsed.ReferenceNo
+	record.PaymentID = parsed.PaymentRequestID
+
+	if parsed.Status != gateway.NotificationPaid {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"provider":     provider,
+			"reference_no": parsed.ReferenceNo,
+			"status":       parsed.Status,
+		}).Info("Acknowledged payment notification without a payment")
+
+		record.Outcome = sentrapay.NotificationAcknowledged
+		_ = s.updateNotification(ctx, record)
+		return req, nil
+	}
+
+	err = s.ProcessPaymentCallback(ctx, *req)
+	_ = s.finishNotification(ctx, &record, err)
+
+	return req, err
+}
+
+func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentrapay.PaymentCallbackRequest) error {
 	requestID := contextPkg.GetRequestID(ctx)
 
 	req.PartnerServiceId = strings.TrimSpace(req.PartnerServiceId)
 	req.VirtualAccountNo = strings.TrimSpace(req.VirtualAccountNo)
 	req.CustomerNo = strings.TrimSpace(req.CustomerNo)
 
-	if req.TrxId == "" || req.VirtualAccountNo == "" {
+	if req.TrxId == "" {
 		s.log.WithFields(logrus.Fields{
 			"request_id":   requestID,
 			"reference_no": req.TrxId,
@@ -184,30 +321,6 @@ func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentr
 		return sentrapay.ErrInvalidCallback
 	}
 
-	expectedPartnerID := os.Getenv("DOKU_CLIENT_ID")
-	if expectedPartnerID != "" && xPartnerID != expectedPartnerID {
-		s.log.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"expected":   expectedPartnerID,
-			"received":   xPartnerID,
-		}).Error("Invalid partner ID in payment callback")
-		return sentrapay.ErrInvalidCallback
-	}
-
-	if xTimestamp != "" {
-		parsedTime, err := time.Parse(time.RFC3339, xTimestamp)
-		if err == nil {
-			timeWindow := 5 * time.Minute
-			if time.Since(parsedTime) > timeWindow {
-				s.log.WithFields(logrus.Fields{
-					"request_id": requestID,
-					"timestamp":  xTimestamp,
-					"window":     timeWindow,
-				}).Warn("Payment callback timestamp is older than expected window")
-			}
-		}
-	}
-
 	s.log.WithFields(logrus.Fields{
 		"request_id":       requestID,
 		"reference_no":     req.TrxId,
@@ -228,7 +341,17 @@ func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentr
 	}
 	defer repo.Rollback()
 
-	transaction, err := repo.Wallet.GetTransactionByReferenceNo(ctx, req.TrxId)
+	paidAmount, err := money.Parse(req.PaidAmount.Value)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"amount":     req.PaidAmount.Value,
+			"error":      err.Error(),
+		}).Error("Failed to parse amount")
+		return err
+	}
+
+	transaction, err := s.findNotifiedTransaction(ctx, repo, req, paidAmount)
 	if err != nil {
 		s.log.WithFields(logrus.Fields{
 			"request_id":   requestID,
@@ -238,7 +361,35 @@ func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentr
 		return err
 	}
 
-	if transaction.Status == "success" {
+	// A top-up can only be settled by the provider that issued it. Rows
+	// without a provider are QRIS receive codes, which any path may settle.
+	if req.Provider != "" && transaction.Gateway != "" && req.Provider != transaction.Gateway {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": req.TrxId,
+			"provider":     req.Provider,
+			"gateway":      transaction.Gateway,
+		}).Warn("Payment notification from a provider that did not issue the top-up")
+		return sentrapay.ErrTransactionNotFound
+	}
+
+	// QRIS notifications carry no virtual account; only VA top-ups need one.
+	if transaction.PaymentMethod == "virtual_account" && req.VirtualAccountNo == "" {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": req.TrxId,
+		}).Error("Missing required fields in payment callback")
+		return sentrapay.ErrInvalidCallback
+	}
+
+	// Only the payment that settled the transaction is a duplicate. Another
+	// payment naming a settled bill is money paid into a reusable virtual
+	// account without an open top-up, and has to be reviewed.
+	if transaction.Status == sentrapay.TransactionSuccess {
+		if req.PaymentRequestId != transaction.GatewayPaymentID {
+			return s.flagUnmatchedPayment(ctx, transaction, req, paidAmount)
+		}
+
 		s.log.WithFields(logrus.Fields{
 			"request_id":   requestID,
 			"reference_no": req.TrxId,
@@ -246,7 +397,12 @@ func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentr
 		return nil
 	}
 
-	if transaction.Status != "pending" && transaction.Status != "processing" {
+	if transaction.Status == sentrapay.TransactionExpired ||
+		(transaction.Status == sentrapay.TransactionPending && transaction.ExpiresAt != nil && time.Now().After(*transaction.ExpiresAt)) {
+		return s.rejectLateTopUpPayment(ctx, repo, transaction, req)
+	}
+
+	if !transaction.Status.CanTransitionTo(sentrapay.TransactionSuccess) {
 		s.log.WithFields(logrus.Fields{
 			"request_id":   requestID,
 			"reference_no": req.TrxId,
@@ -255,18 +411,19 @@ func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentr
 		return sentrapay.ErrInvalidTransactionState
 	}
 
-	paidAmount, err := strconv.ParseFloat(strings.TrimSpace(req.PaidAmount.Value), 64)
-	if err != nil {
-		s.log.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"amount":     req.PaidAmount.Value,
-			"error":      err.Error(),
-		}).Error("Failed to parse amount")
-		return err
+	if isOpenAmountQRISReceive(transaction) && paidAmount.IsPositive() {
+		if err := repo.Wallet.SetOpenTransactionAmount(ctx, transaction.ReferenceNo, paidAmount); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": req.TrxId,
+				"error":        err.Error(),
+			}).Error("Failed to record paid amount")
+			return err
+		}
+		transaction.Amount = paidAmount
 	}
 
-	const amountTolerance = 0.01
-	if paidAmount < transaction.Amount-amountTolerance || paidAmount > transaction.Amount+amountTolerance {
+	if paidAmount != transaction.Amount {
 		s.log.WithFields(logrus.Fields{
 			"request_id":      requestID,
 			"expected_amount": transaction.Amount,
@@ -277,34 +434,33 @@ func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentr
 		return sentrapay.ErrInvalidAmount
 	}
 
-	if err := repo.Wallet.UpdateTransactionStatus(ctx, req.TrxId, "success"); err != nil {
-		s.log.WithFields(logrus.Fields{
-			"request_id":   requestID,
-			"reference_no": req.TrxId,
-			"error":        err.Error(),
-		}).Error("Failed to update transaction status")
-		return err
+	if req.PaymentRequestId != "" {
+		if err := repo.Wallet.SetTransactionGatewayPayment(ctx, transaction.ReferenceNo, notificationProvider(req), req.PaymentRequestId); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": req.TrxId,
+				"payment_id":   req.PaymentRequestId,
+				"error":        err.Error(),
+			}).Error("Failed to record gateway payment")
+			return err
+		}
 	}
 
-	wallet, err := repo.Wallet.GetWallet(ctx, transaction.UserID)
-	if err != nil {
-		s.log.WithFields(logrus.Fields{
-			"request_id": requestID,
-			"user_id":    transaction.UserID,
-			"error":      err.Error(),
-		}).Error("Failed to get wallet")
-		return err
-	}
+	if err := s.settleTopUp(ctx, repo, transaction, paidAmount, sentrapay.ActorPaymentCallback); err != nil {
+		if errors.Is(err, sentrapay.ErrJournalAlreadyPosted) {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": req.TrxId,
+			}).Info("Top-up already credited to wallet")
+			return nil
+		}
 
-	newBalance := wallet.Balance + paidAmount
-	if err := repo.Wallet.UpdateWalletBalance(ctx, transaction.UserID, newBalance); err != nil {
 		s.log.WithFields(logrus.Fields{
-			"request_id":  requestID,
-			"user_id":     transaction.UserID,
-			"old_balance": wallet.Balance,
-			"new_balance": newBalance,
-			"error":       err.Error(),
-		}).Error("Failed to update wallet balance")
+			"request_id":   requestID,
+			"reference_no": req.TrxId,
+			"user_id":      transaction.UserID,
+			"error":        err.Error(),
+		}).Error("Failed to settle top-up")
 		return err
 	}
 
@@ -320,14 +476,86 @@ func (s *sentraPayService) ProcessPaymentCallback(ctx context.Context, req sentr
 		"request_id":   requestID,
 		"reference_no": req.TrxId,
 		"user_id":      transaction.UserID,
-		"old_balance":  wallet.Balance,
-		"new_balance":  newBalance,
 		"amount":       paidAmount,
 	}).Info("Payment processed successfully")
 
+	s.publishTransactionEvents(ctx, transaction.ReferenceNo)
+	s.recordInBudget(ctx, transaction.UserID, transaction.ReferenceNo, "")
+
 	return nil
 }
 
+// findNotifiedTransaction finds the transaction a payment notification pays.
+// A gateway payment that was already applied resolves to the transaction it
+// settled, so a retried or replayed notification cannot settle another one.
+// Otherwise payments into a reusable virtual account are matched by number
+// and amount first: the provider's trxId names the latest bill on the
+// account, which is not necessarily the pending top-up the customer paid.
+// That match needs the payment ID, which is what keeps it from repeating.
+func (s *sentraPayService) findNotifiedTransaction(ctx context.Context, repo sentrapayRepository.Client, req sentrapay.PaymentCallbackRequest, paidAmount money.Amount) (sentrapay.WalletTransaction, error) {
+	if req.PaymentRequestId != "" {
+		transaction, err := repo.Wallet.GetTransactionByGatewayPayment(ctx, notificationProvider(req), req.PaymentRequestId)
+		if err == nil {
+			return transaction, nil
+		}
+		if !errors.Is(err, sentrapay.ErrTransactionNotFound) {
+			return sentrapay.WalletTransaction{}, err
+		}
+	}
+
+	if req.PaymentRequestId != "" && req.VirtualAccountNo != "" && paidAmount.IsPositive() {
+		transaction, err := repo.Wallet.GetPendingTopUpByVirtualAccount(ctx, notificationProvider(req), req.VirtualAccountNo, paidAmount, time.Now())
+		if err == nil {
+			return transaction, nil
+		}
+		if !errors.Is(err, sentrapay.ErrTransactionNotFound) {
+			return sentrapay.WalletTransaction{}, err
+		}
+	}
+
+	// Gateways only bill top-ups and QRIS receives, which have a single row. A
+	// reference with a row per party is a transfer and never paid this way.
+	transactions, err := repo.Wallet.GetTransactionsByReferenceNo(ctx, req.TrxId)
+	if err != nil {
+		return sentrapay.WalletTransaction{}, err
+	}
+	if len(transactions) != 1 {
+		return sentrapay.WalletTransaction{}, sentrapay.ErrTransactionNotFound
+	}
+
+	return transactions[0], nil
+}
+
+// notificationProvider is the gateway a callback came from. Callbacks that
+// predate provider routing came from DOKU.
+func notificationProvider(req sentrapay.PaymentCallbackRequest) string {
+	if req.Provider == "" {
+		return gateway.ProviderDOKU
+	}
+	return req.Provider
+}
+
+// settleTopUp marks a pending top-up as successful and credits the wallet
+// through the ledger. The journal key is derived from the reference number, so
+// a top-up can be credited at most once no matter how many paths settle it.
+// actor identifies the settling path in the status history.
+func (s *sentraPayService) settleTopUp(ctx context.Context, repo sentrapayRepository.Client, transaction sentrapay.WalletTransaction, paidAmount money.Amount, actor string) error {
+	if err := repo.Wallet.UpdateTransactionStatus(ctx, transaction.ReferenceNo, sentrapay.TransactionSuccess, sentrapay.StatusChange{Actor: actor}); err != nil {
+		return err
+	}
+
+	return s.postLedger(ctx, repo, sentrapay.LedgerPosting{
+		JournalKey:  "topup:" + transaction.ReferenceNo,
+		ReferenceNo: transaction.ReferenceNo,
+		Kind:        "topup",
+		Description: transaction.Description,
+		Legs: []sentrapay.LedgerLeg{
+			walletCreditLeg(transaction.UserID, paidAmount),
+			accountLeg(sentrapay.LedgerAccountTopUpClearing, sentrapay.LedgerDebit, paidAmount),
+		},
+	})
+}
+
 func (s *sentraPayService) GetWalletBalance(ctx context.Context, userID string) (*sentrapay.WalletBalance, error) {
 	requestID := contextPkg.GetRequestID(ctx)
 
@@ -354,6 +582,7 @@ func (s *sentraPayService) GetWalletBalance(ctx context.Context, userID string)
 			return &sentrapay.WalletBalance{
 				UserID:      userID,
 				Balance:     0,
+				Status:      sentrapay.WalletActive,
 				LastUpdated: time.Now(),
 			}, nil
 		}
@@ -370,9 +599,54 @@ func (s *sentraPayService) GetWalletBalance(ctx context.Context, userID string)
 	return &wallet, nil
 }
 
-func (s *sentraPayService) GetTransactionHistory(ctx context.Context, userID string, page, limit int) (*sentrapay.TransactionHistoryResponse, error) {
+const defaultHistoryLimit = 10
+
+// GetTransactionHistory returns one page of the user's transactions matching
+// req, newest first, with totals over every matching transaction.
+func (s *sentraPayService) GetTransactionHistory(ctx context.Context, userID string, req sentrapay.TransactionHistoryRequest) (*sentrapay.TransactionHistoryResponse, error) {
 	requestID := contextPkg.GetRequestID(ctx)
 
+	filter := sentrapay.TransactionFilter{
+		Type:          req.Type,
+		Status:        req.Status,
+		PaymentMethod: req.PaymentMethod,
+		Search:        strings.TrimSpace(req.Search),
+	}
+
+	if req.From != "" {
+		from, err := time.ParseInLocation(time.DateOnly, req.From, spendingDayLocation)
+		if err != nil {
+			return nil, sentrapay.ErrInvalidHistoryRange
+		}
+		filter.From = from.In(time.Local)
+	}
+
+	if req.To != "" {
+		to, err := time.ParseInLocation(time.DateOnly, req.To, spendingDayLocation)
+		if err != nil {
+			return nil, sentrapay.ErrInvalidHistoryRange
+		}
+		filter.To = to.AddDate(0, 0, 1).In(time.Local)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, sentrapay.ErrInvalidHistoryRange
+	}
+
+	var after *sentrapay.TransactionCursor
+	if req.Cursor != "" {
+		cursor, err := sentrapay.ParseTransactionCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &cursor
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
 	repo, err := s.walletRepository.NewClient(false)
 	if err != nil {
 		s.log.WithFields(logrus.Fields{
@@ -382,28 +656,44 @@ func (s *sentraPayService) GetTransactionHistory(ctx context.Context, userID str
 		return nil, err
 	}
 
-	offset := (page - 1) * limit
-	if offset < 0 {
-		offset = 0
+	// One extra row tells whether another page follows.
+	transactions, err := repo.Wallet.GetTransactionsByUserID(ctx, userID, filter, after, limit+1)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get transactions")
+		return nil, err
 	}
 
-	transactions, total, err := repo.Wallet.GetTransactionsByUserID(ctx, userID, limit, offset)
+	summary, err := repo.Wallet.SummarizeTransactions(ctx, userID, filter)
 	if err != nil {
 		s.log.WithFields(logrus.Fields{
 			"request_id": requestID,
 			"user_id":    userID,
 			"error":      err.Error(),
-		}).Error("Failed to get transactions")
+		}).Error("Failed to summarize transactions")
 		return nil, err
 	}
 
-	return &sentrapay.TransactionHistoryResponse{
+	response := &sentrapay.TransactionHistoryResponse{
 		Transactions: transactions,
-		Total:        total,
-	}, nil
+		Total:        summary.Total,
+		MoneyIn:      summary.MoneyIn,
+		MoneyOut:     summary.MoneyOut,
+	}
+
+	if len(transactions) > limit {
+		response.Transactions = transactions[:limit]
+		last := response.Transactions[limit-1]
+		response.NextCursor = sentrapay.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+
+	return response, nil
 }
 
-func (s *sentraPayService) CheckTransactionStatus(ctx context.Context, referenceNo string) (string, error) {
+func (s *sentraPayService) CheckTransactionStatus(ctx context.Context, userID, referenceNo string) (string, error) {
 	requestID := contextPkg.GetRequestID(ctx)
 
 	repo, err := s.walletRepository.NewClient(false)
@@ -415,71 +705,90 @@ func (s *sentraPayService) CheckTransactionStatus(ctx context.Context, reference
 		return "", err
 	}
 
-	transaction, err := repo.Wallet.GetTransactionByReferenceNo(ctx, referenceNo)
+	transaction, err := repo.Wallet.GetTransactionByReferenceNo(ctx, userID, referenceNo)
 	if err != nil {
 		s.log.WithFields(logrus.Fields{
 			"request_id":   requestID,
+			"user_id":      userID,
 			"reference_no": referenceNo,
 			"error":        err.Error(),
 		}).Error("Failed to get transaction")
 		return "", err
 	}
 
-	if transaction.Status == "success" {
-		return "success", nil
+	if transaction.Status == sentrapay.TransactionSuccess {
+		return string(sentrapay.TransactionSuccess), nil
 	}
 
-	partnerServiceId := "  820901"
-	customerNo := fmt.Sprintf("%020s", transaction.UserID)
+	// QRIS receive requests are only settled by the acquirer's notification.
+	if transaction.Type == qrisReceiveType {
+		return string(transaction.Status), nil
+	}
 
-	isPaid, err := s.dokuService.CheckVAStatus(transaction.BankAccount, customerNo, partnerServiceId, referenceNo)
+	vaStatus, err := s.checkTopUpVAStatus(ctx, transaction)
 	if err != nil {
 		s.log.WithFields(logrus.Fields{
 			"request_id":   requestID,
 			"reference_no": referenceNo,
 			"error":        err.Error(),
 		}).Error("Failed to check VA status")
-		return transaction.Status, nil
+		return string(transaction.Status), nil
 	}
 
-	if isPaid && transaction.Status == "pending" {
-		repoTx, err := s.walletRepository.NewClient(true)
-		if err != nil {
-			s.log.WithFields(logrus.Fields{
-				"request_id": requestID,
-				"error":      err.Error(),
-			}).Error("Failed to create new client")
-			return "", err
-		}
-		defer repoTx.Rollback()
+	if vaStatus.Paid && transaction.Status == sentrapay.TransactionPending && vaStatus.PaidAmount != transaction.Amount {
+		s.log.WithFields(logrus.Fields{
+			"request_id":      requestID,
+			"reference_no":    referenceNo,
+			"expected_amount": transaction.Amount,
+			"paid_amount":     vaStatus.PaidAmount,
+		}).Warn("Top-up paid with a different amount, left for review")
 
-		if err := repoTx.Wallet.UpdateTransactionStatus(ctx, referenceNo, "success"); err != nil {
+		if err := repo.Wallet.UpdateTransactionStatusReason(ctx, referenceNo, topUpAmountMismatchReason); err != nil {
 			s.log.WithFields(logrus.Fields{
 				"request_id":   requestID,
 				"reference_no": referenceNo,
 				"error":        err.Error(),
-			}).Error("Failed to update transaction status")
-			return transaction.Status, nil
+			}).Error("Failed to flag top-up amount mismatch")
 		}
 
-		wallet, err := repoTx.Wallet.GetWallet(ctx, transaction.UserID)
+		return string(transaction.Status), nil
+	}
+
+	if vaStatus.Paid && transaction.Status == sentrapay.TransactionPending {
+		repoTx, err := s.walletRepository.NewClient(true)
 		if err != nil {
 			s.log.WithFields(logrus.Fields{
 				"request_id": requestID,
-				"user_id":    transaction.UserID,
 				"error":      err.Error(),
-			}).Error("Failed to get wallet")
-			return transaction.Status, nil
+			}).Error("Failed to create new client")
+			return "", err
+		}
+		defer repoTx.Rollback()
+
+		if vaStatus.PaymentID != "" {
+			if err := repoTx.Wallet.SetTransactionGatewayPayment(ctx, transaction.ReferenceNo, transactionGateway(transaction), vaStatus.PaymentID); err != nil {
+				s.log.WithFields(logrus.Fields{
+					"request_id":   requestID,
+					"reference_no": referenceNo,
+					"payment_id":   vaStatus.PaymentID,
+					"error":        err.Error(),
+				}).Error("Failed to record gateway payment")
+				return string(transaction.Status), nil
+			}
 		}
 
-		newBalance := wallet.Balance + transaction.Amount
-		if err := repoTx.Wallet.UpdateWalletBalance(ctx, transaction.UserID, newBalance); err != nil {
+		if err := s.settleTopUp(ctx, repoTx, transaction, vaStatus.PaidAmount, sentrapay.ActorStatusCheck); err != nil {
+			if errors.Is(err, sentrapay.ErrJournalAlreadyPosted) {
+				return string(sentrapay.TransactionSuccess), nil
+			}
+
 			s.log.WithFields(logrus.Fields{
-				"request_id": requestID,
-				"user_id":    transaction.UserID,
-				"error":      err.Error(),
-			}).Error("Failed to update wallet balance")
-			return transaction.Status, nil
+				"request_id":   requestID,
+				"reference_no": referenceNo,
+				"user_id":      transaction.UserID,
+				"error":        err.Error(),
+			}).Error("Failed to settle top-up")
+			return string(transaction.Status), nil
 		}
 
 		if err := repoTx.Commit(); err != nil {
@@ -487,51 +796,106 @@ func (s *sentraPayService) CheckTransactionStatus(ctx context.Context, reference
 				"request_id": requestID,
 				"error":      err.Error(),
 			}).Error("Failed to commit transaction")
-			return transaction.Status, nil
+			return string(transaction.Status), nil
 		}
 
-		return "success", nil
+		s.publishTransactionEvents(ctx, transaction.ReferenceNo)
+		s.recordInBudget(ctx, transaction.UserID, transaction.ReferenceNo, "")
+
+		return string(sentrapay.TransactionSuccess), nil
 	}
 
-	return transaction.Status, nil
+	return string(transaction.Status), nil
+}
+
+// GetTransactionStatusHistory returns every recorded status transition of
+// the user's transaction under referenceNo, oldest first.
+func (s *sentraPayService) GetTransactionStatusHistory(ctx context.Context, userID, referenceNo string) ([]sentrapay.TransactionStatusHistory, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	if _, err := repo.Wallet.GetTransactionByReferenceNo(ctx, userID, referenceNo); err != nil {
+		return nil, err
+	}
+
+	return repo.Wallet.GetTransactionStatusHistory(ctx, userID, referenceNo)
+}
+
+func (s *sentraPayService) checkTopUpVAStatus(ctx context.Context, transaction sentrapay.WalletTransaction) (*gateway.VirtualAccountStatus, error) {
+	paymentGateway, err := s.gateways.Get(transactionGateway(transaction))
+	if err != nil {
+		return nil, err
+	}
+
+	request := gateway.VirtualAccountStatusRequest{
+		ReferenceNo:      transaction.ReferenceNo,
+		VirtualAccountNo: transaction.BankAccount,
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Top-ups from before reusable accounts have no stored numbering; the
+	// gateway then derives it from the number itself.
+	account, err := repo.VirtualAccount.GetByNumber(ctx, paymentGateway.Name(), strings.TrimSpace(transaction.BankAccount))
+	reusable := err == nil
+	switch {
+	case err == nil:
+		request.PartnerServiceID = account.PartnerServiceID
+		request.CustomerNo = account.CustomerNo
+	case !errors.Is(err, sentrapay.ErrVirtualAccountNotFound):
+		return nil, err
+	}
+
+	status, err := paymentGateway.CheckVirtualAccount(ctx, request)
+	if err != nil || !reusable || !status.Paid {
+		return status, err
+	}
+
+	// A payment on a reusable account may have settled an earlier top-up.
+	// Only a payment that has not been applied yet can settle this one.
+	if status.PaymentID != "" {
+		applied, err := repo.Wallet.GetTransactionByGatewayPayment(ctx, paymentGateway.Name(), status.PaymentID)
+		switch {
+		case errors.Is(err, sentrapay.ErrTransactionNotFound):
+			return status, nil
+		case err != nil:
+			return nil, err
+		case applied.ReferenceNo == transaction.ReferenceNo:
+			return status, nil
+		}
+	}
+
+	status.Paid = false
+	status.PaidAmount = 0
+	return status, nil
+}
+
+// transactionGateway names the provider of a top-up. Top-ups created before
+// providers were recorded all went through DOKU.
+func transactionGateway(transaction sentrapay.WalletTransaction) string {
+	if transaction.Gateway == "" {
+		return gateway.ProviderDOKU
+	}
+	return transaction.Gateway
 }
 
 func isValidBank(bank string) bool {
-	validBanks := map[string]bool{
-		doku.BankBCA:      true,
-		doku.BankMANDIRI:  true,
-		doku.BankBRI:      true,
-		doku.BankBNI:      true,
-		doku.BankDANAMON:  true,
-		doku.BankPERMATA:  true,
-		doku.BankMAYBANK:  true,
-		doku.BankBTN:      true,
-		doku.BankBSI:      true,
-		doku.BankCIMB:     true,
-		doku.BankSINARMAS: true,
-		doku.BankDOKU:     true,
-	}
-
-	return validBanks[bank]
+	return gateway.IsVirtualAccountChannel(bank)
 }
 
 func getBankName(bank string) string {
-	bankNames := map[string]string{
-		doku.BankBCA:      "BCA",
-		doku.BankMANDIRI:  "MANDIRI",
-		doku.BankBRI:      "BRI",
-		doku.BankBNI:      "BNI",
-		doku.BankDANAMON:  "DANAMON",
-		doku.BankPERMATA:  "PERMATA",
-		doku.BankMAYBANK:  "MAYBANK",
-		doku.BankBTN:      "BTN",
-		doku.BankBSI:      "BSI",
-		doku.BankCIMB:     "CIMB",
-		doku.BankSINARMAS: "SINARMAS",
-		doku.BankDOKU:     "DOKU",
-	}
-
-	name, exists := bankNames[bank]
+	name, exists := gateway.VirtualAccountChannels[bank]
 	if !exists {
 		return "UNKNOWN"
 	}
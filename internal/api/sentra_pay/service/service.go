@@ -2,49 +2,126 @@ package sentrapayService
 
 import (
 	authRepository "ProjectGolang/internal/api/auth/repository"
+	budgetService "ProjectGolang/internal/api/budget_manager/service"
 	sentrapay "ProjectGolang/internal/api/sentra_pay"
 	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
-	"ProjectGolang/pkg/bcrypt"
-	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/disbursement"
+	"ProjectGolang/pkg/events"
+	"ProjectGolang/pkg/gateway"
+	"ProjectGolang/pkg/receiving"
+	"ProjectGolang/pkg/redis"
+	"ProjectGolang/pkg/risk"
+	"ProjectGolang/pkg/s3"
 	"ProjectGolang/pkg/utils"
+	"ProjectGolang/pkg/whatsapp"
 	"context"
 	"github.com/sirupsen/logrus"
 )
 
 type ISentraPayService interface {
 	CreateTopUpTransaction(ctx context.Context, userID string, req sentrapay.TopUpRequest) (*sentrapay.TopUpResponse, error)
-	ProcessPaymentCallback(ctx context.Context, req sentrapay.PaymentCallbackRequest, channelID, xExternalID, xTimestamp, xPartnerID string) error
+	GetVirtualAccounts(ctx context.Context, userID string) ([]sentrapay.UserVirtualAccount, error)
+	HandlePaymentNotification(ctx context.Context, provider string, notification sentrapay.PaymentNotification) (*sentrapay.PaymentCallbackRequest, error)
+	ProcessPaymentCallback(ctx context.Context, req sentrapay.PaymentCallbackRequest) error
+	ListPaymentNotifications(ctx context.Context, req sentrapay.ListPaymentNotificationsRequest) ([]sentrapay.PaymentNotificationRecord, error)
+	GetPaymentNotification(ctx context.Context, id string) (*sentrapay.PaymentNotificationRecord, error)
+	ReplayPaymentNotification(ctx context.Context, id string) (*sentrapay.PaymentNotificationRecord, error)
 	GetWalletBalance(ctx context.Context, userID string) (*sentrapay.WalletBalance, error)
-	GetTransactionHistory(ctx context.Context, userID string, page, limit int) (*sentrapay.TransactionHistoryResponse, error)
-	CheckTransactionStatus(ctx context.Context, referenceNo string) (string, error)
+	SubscribeWalletEvents(ctx context.Context, userID string) (*events.Subscription, *sentrapay.WalletBalance, error)
+	GetTransactionHistory(ctx context.Context, userID string, req sentrapay.TransactionHistoryRequest) (*sentrapay.TransactionHistoryResponse, error)
+	CheckTransactionStatus(ctx context.Context, userID, referenceNo string) (string, error)
+	GetTransactionStatusHistory(ctx context.Context, userID, referenceNo string) ([]sentrapay.TransactionStatusHistory, error)
+	TransferBalance(ctx context.Context, userID string, req sentrapay.TransferRequest) (*sentrapay.TransferResponse, error)
+	CheckLedgerConsistency(ctx context.Context) (*sentrapay.LedgerConsistencyReport, error)
+	ExpirePendingTopUps(ctx context.Context) (int, error)
+	ReconcileTopUps(ctx context.Context) (*sentrapay.ReconciliationReport, error)
+
+	RegisterBankAccount(ctx context.Context, userID string, req sentrapay.RegisterBankAccountRequest) (*sentrapay.BankAccount, error)
+	VerifyBankAccount(ctx context.Context, userID, bankAccountID string) (*sentrapay.BankAccount, error)
+	GetBankAccounts(ctx context.Context, userID string) ([]sentrapay.BankAccount, error)
+	RequestWithdrawal(ctx context.Context, userID string, req sentrapay.WithdrawalRequest) (*sentrapay.WithdrawalResponse, error)
+	GetWithdrawal(ctx context.Context, userID, referenceNo string) (*sentrapay.WithdrawalResponse, error)
+	SettleProcessingWithdrawals(ctx context.Context) (int, error)
+	GetWalletLimits(ctx context.Context, userID string) (*sentrapay.WalletLimits, error)
+	UpdateWalletLimits(ctx context.Context, userID string, req sentrapay.UpdateWalletLimitsRequest) (*sentrapay.WalletLimits, error)
+	FreezeWallet(ctx context.Context, userID string, req sentrapay.FreezeWalletRequest) (*sentrapay.WalletBalance, error)
+	UnfreezeWallet(ctx context.Context, userID string, req sentrapay.UnfreezeWalletRequest) (*sentrapay.WalletBalance, error)
+	ListRiskDecisions(ctx context.Context, req sentrapay.ListRiskDecisionsRequest) ([]sentrapay.RiskDecision, error)
+
+	BeginIdempotentRequest(ctx context.Context, userID, endpoint, key string, request interface{}) (*sentrapay.IdempotencyKey, error)
+	CompleteIdempotentRequest(ctx context.Context, userID, endpoint, key string, statusCode int, response interface{}) error
+	AbortIdempotentRequest(ctx context.Context, userID, endpoint, key string) error
 
 	DecodeQRIS(ctx context.Context, req sentrapay.QRISDecodeRequest) (*sentrapay.QRISDecodeResponse, error)
 	PaymentQRIS(ctx context.Context, userID string, req sentrapay.QRISPaymentRequest) (*sentrapay.QRISPaymentResponse, error)
+	RecoverQRISPayments(ctx context.Context) (int, error)
+	CreateQRISReceive(ctx context.Context, userID string, req sentrapay.QRISReceiveRequest) (*sentrapay.QRISReceiveResponse, error)
+	SimulateVAPayment(ctx context.Context, userID, referenceNo string, req sentrapay.SimulateVAPaymentRequest) (*sentrapay.SimulateVAPaymentResponse, error)
+
+	CreatePaymentRequest(ctx context.Context, userID string, req sentrapay.CreatePaymentRequest) (*sentrapay.PaymentRequest, error)
+	SplitQRISPayment(ctx context.Context, userID string, req sentrapay.SplitQRISRequest) (*sentrapay.PaymentRequest, error)
+	GetPaymentRequests(ctx context.Context, userID string, req sentrapay.ListPaymentRequestsRequest) ([]sentrapay.PaymentRequest, error)
+	GetPaymentRequest(ctx context.Context, userID, id string) (*sentrapay.PaymentRequest, error)
+	AcceptPaymentRequest(ctx context.Context, userID, id string, req sentrapay.RespondPaymentRequest) (*sentrapay.PaymentRequest, error)
+	DeclinePaymentRequest(ctx context.Context, userID, id string) (*sentrapay.PaymentRequest, error)
+	RemindPaymentRequest(ctx context.Context, userID, id string) (*sentrapay.PaymentRequest, error)
+	ProcessPaymentRequests(ctx context.Context) (int, error)
+
+	ExportStatement(ctx context.Context, userID string, req sentrapay.StatementRequest) (*sentrapay.StatementFile, *sentrapay.StatementJob, error)
+	GetStatementJob(ctx context.Context, userID, jobID string) (*sentrapay.StatementJob, error)
+	ProcessStatementJobs(ctx context.Context) (int, error)
 }
 
 type sentraPayService struct {
 	log              *logrus.Logger
 	walletRepository sentrapayRepository.Repository
-	dokuService      doku.IDokuService
+	gateways         gateway.IRouter
+	vaNumbering      gateway.VirtualAccountNumbering
+	disbursement     disbursement.IDisbursementGateway
+	receiving        receiving.IReceivingChannel
 	authRepo         authRepository.Repository
+	budgetService    budgetService.IBudgetService
+	pinVerifier      IPINVerifier
+	risk             risk.IEngine
+	redisServer      redis.IRedis
+	events           events.IHub
+	s3               s3.ItfS3
+	whatsapp         whatsapp.IWhatsappSender
 	utils            utils.IUtils
-	bcryptUtils      bcrypt.IBcrypt
 }
 
 func NewSentraPayService(
 	log *logrus.Logger,
 	wr sentrapayRepository.Repository,
-	ds doku.IDokuService,
+	pg gateway.IRouter,
+	vn gateway.VirtualAccountNumbering,
+	dg disbursement.IDisbursementGateway,
+	rc receiving.IReceivingChannel,
 	ar authRepository.Repository,
+	bs budgetService.IBudgetService,
+	pv IPINVerifier,
+	re risk.IEngine,
+	redisServer redis.IRedis,
+	eh events.IHub,
+	s3 s3.ItfS3,
+	whatsappSender whatsapp.IWhatsappSender,
 	utils utils.IUtils,
-	bcryptUtils bcrypt.IBcrypt,
 ) ISentraPayService {
 	return &sentraPayService{
 		log:              log,
 		walletRepository: wr,
-		dokuService:      ds,
+		gateways:         pg,
+		vaNumbering:      vn,
+		disbursement:     dg,
+		receiving:        rc,
 		authRepo:         ar,
+		budgetService:    bs,
+		pinVerifier:      pv,
+		risk:             re,
+		redisServer:      redisServer,
+		events:           eh,
+		s3:               s3,
+		whatsapp:         whatsappSender,
 		utils:            utils,
-		bcryptUtils:      bcryptUtils,
 	}
 }
@@ -0,0 +1,74 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/gateway"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// SimulateVAPayment pays one of the user's own top-ups through the simulator
+// of the gateway that issued it. The simulator delivers a signed notification to the callback
+// endpoint, so the top-up settles through the same path as a real payment.
+func (s *sentraPayService) SimulateVAPayment(ctx context.Context, userID, referenceNo string, req sentrapay.SimulateVAPaymentRequest) (*sentrapay.SimulateVAPaymentResponse, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	transaction, err := repo.Wallet.GetTransactionByReferenceNo(ctx, userID, referenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.PaymentMethod != "virtual_account" {
+		return nil, sentrapay.ErrTransactionNotFound
+	}
+
+	paymentGateway, err := s.gateways.Get(transactionGateway(transaction))
+	if err != nil {
+		return nil, sentrapay.ErrSimulatorDisabled
+	}
+
+	simulator, ok := paymentGateway.(gateway.ISimulator)
+	if !ok {
+		return nil, sentrapay.ErrSimulatorDisabled
+	}
+
+	notification, err := simulator.PayVirtualAccount(ctx, transaction.ReferenceNo, req.Amount)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": referenceNo,
+			"error":        err.Error(),
+		}).Error("Failed to simulate virtual account payment")
+
+		if errors.Is(err, gateway.ErrVirtualAccountNotFound) {
+			return nil, sentrapay.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	status, err := s.CheckTransactionStatus(ctx, userID, referenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sentrapay.SimulateVAPaymentResponse{
+		ReferenceNo:       transaction.ReferenceNo,
+		VirtualAccount:    notification.VirtualAccountNo,
+		PaidAmount:        notification.PaidAmount,
+		CallbackStatus:    notification.HTTPStatus,
+		ResponseCode:      notification.ResponseCode,
+		ResponseMessage:   notification.ResponseMessage,
+		TransactionStatus: status,
+	}, nil
+}
@@ -0,0 +1,548 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	// Statements spanning more days than this are built in the background
+	// and delivered through S3 instead of in the response.
+	statementSyncMaxDays = 31
+	statementMaxDays     = 366
+
+	statementPageSize      = 500
+	statementJobBatchSize  = 20
+	statementJobStaleAfter = 10 * time.Minute
+	statementJobTimeout    = 5 * time.Minute
+)
+
+// ExportStatement builds the user's statement for the requested days. Short
+// ranges are rendered immediately and returned as a file; longer ones are
+// queued and returned as a job to poll.
+func (s *sentraPayService) ExportStatement(ctx context.Context, userID string, req sentrapay.StatementRequest) (*sentrapay.StatementFile, *sentrapay.StatementJob, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	from, err := time.ParseInLocation(time.DateOnly, req.From, spendingDayLocation)
+	if err != nil {
+		return nil, nil, sentrapay.ErrInvalidStatementRange
+	}
+
+	to, err := time.ParseInLocation(time.DateOnly, req.To, spendingDayLocation)
+	if err != nil {
+		return nil, nil, sentrapay.ErrInvalidStatementRange
+	}
+
+	if to.Before(from) {
+		return nil, nil, sentrapay.ErrInvalidStatementRange
+	}
+
+	days := statementDays(from, to)
+	if days > statementMaxDays {
+		return nil, nil, sentrapay.ErrStatementRangeTooLarge
+	}
+
+	format := req.Format
+	if format == "" {
+		format = sentrapay.StatementFormatPDF
+	}
+
+	if days <= statementSyncMaxDays {
+		statement, err := s.buildStatement(ctx, userID, from, to)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		file, err := renderStatement(statement, format)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"user_id":    userID,
+				"error":      err.Error(),
+			}).Error("Failed to render statement")
+			return nil, nil, err
+		}
+
+		return file, nil, nil
+	}
+
+	now := time.Now()
+	jobID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate ULID")
+		return nil, nil, err
+	}
+
+	job := sentrapay.StatementJob{
+		ID:        jobID,
+		UserID:    userID,
+		From:      from,
+		To:        to,
+		Format:    format,
+		Status:    sentrapay.StatementJobPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, nil, err
+	}
+
+	if err := repo.Statement.Create(ctx, job); err != nil {
+		return nil, nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"user_id":    userID,
+		"job_id":     job.ID,
+		"from":       req.From,
+		"to":         req.To,
+	}).Info("Queued statement export")
+
+	// The sweep picks the job up if this instance stops before it finishes.
+	go s.runStatementJob(context.WithoutCancel(ctx), job)
+
+	return nil, &job, nil
+}
+
+func (s *sentraPayService) GetStatementJob(ctx context.Context, userID, jobID string) (*sentrapay.StatementJob, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	job, err := repo.Statement.GetByID(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status == sentrapay.StatementJobCompleted {
+		downloadURL, err := s.s3.PresignUrl(job.FileURL)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"job_id":     job.ID,
+				"error":      err.Error(),
+			}).Error("Failed to presign statement URL")
+			return nil, err
+		}
+		job.DownloadURL = downloadURL
+	}
+
+	return &job, nil
+}
+
+// ProcessStatementJobs builds statement jobs that were never started or whose
+// worker stopped before finishing.
+func (s *sentraPayService) ProcessStatementJobs(ctx context.Context) (int, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return 0, err
+	}
+
+	jobs, err := repo.Statement.GetOpen(ctx, time.Now().Add(-statementJobStaleAfter), statementJobBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+
+		if s.runStatementJob(ctx, job) {
+			processed++
+		}
+	}
+
+	return processed, nil
+}
+
+// runStatementJob claims, builds and uploads one statement job, and reports
+// whether this call did the work.
+func (s *sentraPayService) runStatementJob(ctx context.Context, job sentrapay.StatementJob) bool {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, statementJobTimeout)
+	defer cancel()
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return false
+	}
+
+	claimed, err := repo.Statement.Claim(ctx, job.ID, time.Now().Add(-statementJobStaleAfter))
+	if err != nil || !claimed {
+		return false
+	}
+
+	fileURL, err := s.exportStatementFile(ctx, job)
+
+	now := time.Now()
+	job.UpdatedAt = now
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = sentrapay.StatementJobFailed
+		job.Error = "statement could not be generated"
+
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"job_id":     job.ID,
+			"user_id":    job.UserID,
+			"error":      err.Error(),
+		}).Error("Statement export failed")
+	} else {
+		job.Status = sentrapay.StatementJobCompleted
+		job.FileURL = fileURL
+	}
+
+	if err := repo.Statement.Finish(ctx, job); err != nil {
+		return false
+	}
+
+	return true
+}
+
+func (s *sentraPayService) exportStatementFile(ctx context.Context, job sentrapay.StatementJob) (string, error) {
+	if s.s3 == nil {
+		return "", fmt.Errorf("statement storage is not configured")
+	}
+
+	// Job dates are stored as calendar days; read them back as Jakarta days.
+	from := time.Date(job.From.Year(), job.From.Month(), job.From.Day(), 0, 0, 0, 0, spendingDayLocation)
+	to := time.Date(job.To.Year(), job.To.Month(), job.To.Day(), 0, 0, 0, 0, spendingDayLocation)
+
+	statement, err := s.buildStatement(ctx, job.UserID, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := renderStatement(statement, job.Format)
+	if err != nil {
+		return "", err
+	}
+
+	return s.s3.UploadBytes(fmt.Sprintf("%s-%s", job.UserID, file.FileName), file.Data, file.ContentType)
+}
+
+// buildStatement collects the entries posted to the user's wallet between the
+// Jakarta days from and to, inclusive, with the running balance after each.
+func (s *sentraPayService) buildStatement(ctx context.Context, userID string, from, to time.Time) (*sentrapay.Statement, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return nil, err
+	}
+
+	user, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get user info")
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	filter := sentrapay.TransactionFilter{
+		From: from.In(time.Local),
+		To:   to.AddDate(0, 0, 1).In(time.Local),
+	}
+
+	openingBalance, err := repo.Ledger.GetWalletBalanceAt(ctx, userID, filter.From)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows, opening and closing balance all come from the wallet's ledger
+	// entries, so the statement adds up even when a transaction is posted
+	// some time after it was created.
+	var entries []sentrapay.WalletLedgerEntry
+	var after *sentrapay.TransactionCursor
+	for {
+		page, err := repo.Ledger.GetWalletEntries(ctx, userID, filter.From, filter.To, after, statementPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, page...)
+		if len(page) < statementPageSize {
+			break
+		}
+
+		last := page[len(page)-1]
+		after = &sentrapay.TransactionCursor{CreatedAt: last.PostedAt, ID: last.ID}
+	}
+
+	statement := &sentrapay.Statement{
+		UserID:         userID,
+		Name:           user.Name,
+		PhoneNumber:    user.PhoneNumber,
+		From:           from,
+		To:             to,
+		OpeningBalance: openingBalance,
+		Rows:           make([]sentrapay.StatementRow, 0, len(entries)),
+		GeneratedAt:    time.Now().In(spendingDayLocation),
+	}
+
+	balance := openingBalance
+	for _, entry := range entries {
+		row := sentrapay.StatementRow{
+			Date:        entry.PostedAt.In(spendingDayLocation),
+			ReferenceNo: entry.ReferenceNo,
+			Type:        entry.Kind,
+			Description: entry.Description,
+			Status:      entry.Status,
+		}
+
+		if entry.Direction == sentrapay.LedgerDebit {
+			row.Debit = entry.Amount
+			statement.TotalDebit += row.Debit
+			balance -= entry.Amount
+		} else {
+			row.Credit = entry.Amount
+			statement.TotalCredit += row.Credit
+			balance += entry.Amount
+		}
+
+		if balance != entry.BalanceAfter {
+			s.log.WithFields(logrus.Fields{
+				"request_id":    requestID,
+				"user_id":       userID,
+				"entry_id":      entry.ID,
+				"running":       balance.String(),
+				"balance_after": entry.BalanceAfter.String(),
+			}).Warn("Statement running balance differs from ledger entry")
+			balance = entry.BalanceAfter
+		}
+
+		row.Balance = balance
+		statement.Rows = append(statement.Rows, row)
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
+
+func statementDays(from, to time.Time) int {
+	days := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days++
+		if days > statementMaxDays {
+			break
+		}
+	}
+	return days
+}
+
+func renderStatement(statement *sentrapay.Statement, format string) (*sentrapay.StatementFile, error) {
+	fileName := fmt.Sprintf("statement-%s-%s.%s", statement.From.Format("20060102"), statement.To.Format("20060102"), format)
+
+	switch format {
+	case sentrapay.StatementFormatCSV:
+		data, err := renderStatementCSV(statement)
+		if err != nil {
+			return nil, err
+		}
+		return &sentrapay.StatementFile{FileName: fileName, ContentType: "text/csv", Data: data}, nil
+	case sentrapay.StatementFormatPDF:
+		data, err := renderStatementPDF(statement)
+		if err != nil {
+			return nil, err
+		}
+		return &sentrapay.StatementFile{FileName: fileName, ContentType: "application/pdf", Data: data}, nil
+	default:
+		return nil, fmt.Errorf("unsupported statement format %q", format)
+	}
+}
+
+func renderStatementCSV(statement *sentrapay.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	records := [][]string{
+		{"name", statement.Name},
+		{"phone_number", statement.PhoneNumber},
+		{"from", statement.From.Format(time.DateOnly)},
+		{"to", statement.To.Format(time.DateOnly)},
+		{"opening_balance", statement.OpeningBalance.String()},
+		{"closing_balance", statement.ClosingBalance.String()},
+		{"total_credit", statement.TotalCredit.String()},
+		{"total_debit", statement.TotalDebit.String()},
+		{},
+		{"date", "reference_no", "type", "description", "status", "credit", "debit", "balance"},
+	}
+
+	for _, row := range statement.Rows {
+		records = append(records, []string{
+			row.Date.Format(time.DateTime),
+			row.ReferenceNo,
+			row.Type,
+			row.Description,
+			row.Status,
+			row.Credit.String(),
+			row.Debit.String(),
+			row.Balance.String(),
+		})
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func renderStatementPDF(statement *sentrapay.Statement) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	widths := []float64{28, 40, 44, 26, 26, 26}
+	headers := []string{"Tanggal", "No. Referensi", "Keterangan", "Kredit", "Debit", "Saldo"}
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 8, "Mutasi Rekening SentraPay", "", 1, "L", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, tr(fmt.Sprintf("Nama: %s", statement.Name)), "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 5, tr(fmt.Sprintf("No. HP: %s", statement.PhoneNumber)), "", 1, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Periode: %s - %s", statement.From.Format("02/01/2006"), statement.To.Format("02/01/2006")), "", 1, "L", false, 0, "")
+		pdf.Ln(3)
+
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(230, 230, 230)
+		for i, header := range headers {
+			align := "L"
+			if i >= 3 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 7, header, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+	})
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Dicetak %s - Halaman %d/{nb}", statement.GeneratedAt.Format("02/01/2006 15:04"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AliasNbPages("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3]+widths[4], 6, "Saldo Awal", "1", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[5], 6, compactAmount(statement.OpeningBalance), "1", 1, "R", false, 0, "")
+
+	for _, row := range statement.Rows {
+		description := row.Description
+		if description == "" {
+			description = row.Type
+		}
+
+		credit, debit := "", ""
+		if !row.Credit.IsZero() {
+			credit = row.Credit.Format()
+		}
+		if !row.Debit.IsZero() {
+			debit = row.Debit.Format()
+		}
+
+		pdf.CellFormat(widths[0], 6, row.Date.Format("02/01/2006 15:04"), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, truncateCell(pdf, tr(row.ReferenceNo), widths[1]), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, truncateCell(pdf, tr(description), widths[2]), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, credit, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, debit, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, compactAmount(row.Balance), "1", 1, "R", false, 0, "")
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 9)
+	summary := [][2]string{
+		{"Saldo Awal", statement.OpeningBalance.Format()},
+		{"Total Kredit", statement.TotalCredit.Format()},
+		{"Total Debit", statement.TotalDebit.Format()},
+		{"Saldo Akhir", statement.ClosingBalance.Format()},
+	}
+	for _, line := range summary {
+		pdf.CellFormat(40, 6, line[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 6, line[1], "", 1, "R", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// compactAmount drops the currency prefix so balances fit the narrow column.
+func compactAmount(amount money.Amount) string {
+	formatted := amount.Format()
+	if amount.IsNegative() {
+		return "-" + formatted[len("-Rp"):]
+	}
+	return formatted[len("Rp"):]
+}
+
+func truncateCell(pdf *fpdf.Fpdf, text string, width float64) string {
+	const padding = 2
+	if pdf.GetStringWidth(text) <= width-padding {
+		return text
+	}
+
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width-padding {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
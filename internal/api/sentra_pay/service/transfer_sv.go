@@ -0,0 +1,241 @@
+package sentrapayService
+
+import (
+	"ProjectGolang/internal/api/auth"
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/risk"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+func (s *sentraPayService) TransferBalance(ctx context.Context, userID string, req sentrapay.TransferRequest) (*sentrapay.TransferResponse, error) {
+	return s.transferBalance(ctx, userID, req, nil)
+}
+
+// transferBalance moves money between two wallets. within, when set, runs in
+// the transfer's database transaction just before it commits, so whatever it
+// records is written together with the transfer or not at all.
+func (s *sentraPayService) transferBalance(ctx context.Context, userID string, req sentrapay.TransferRequest, within func(repo sentrapayRepository.Client, referenceNo string) error) (*sentrapay.TransferResponse, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if req.Amount <= 0 {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"amount":     req.Amount,
+		}).Warn("Invalid transfer amount")
+		return nil, sentrapay.ErrInvalidAmount
+	}
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return nil, err
+	}
+
+	sender, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get sender info")
+		return nil, err
+	}
+
+	recipient, err := authRepo.Users.GetByPhoneNumber(ctx, req.RecipientPhone)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			s.log.WithFields(logrus.Fields{
+				"request_id":      requestID,
+				"recipient_phone": req.RecipientPhone,
+			}).Warn("Transfer recipient not found")
+			return nil, sentrapay.ErrRecipientNotFound
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to get recipient info")
+		return nil, err
+	}
+
+	if recipient.ID == sender.ID {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+		}).Warn("Attempt to transfer to own wallet")
+		return nil, sentrapay.ErrSelfTransfer
+	}
+
+	if err := s.pinVerifier.VerifyTransactionPIN(ctx, sender.ID, req.PIN); err != nil {
+		return nil, err
+	}
+
+	if err := s.screenMovement(ctx, risk.Operation{
+		UserID: sender.ID,
+		Kind:   "transfer_out",
+		Amount: req.Amount,
+		Payee:  recipient.PhoneNumber,
+	}, req.OTPCode); err != nil {
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	if err := s.enforceSpendingLimits(ctx, repo, sender.ID, req.Amount); err != nil {
+		return nil, err
+	}
+
+	if err := repo.Wallet.CreateWallet(ctx, recipient.ID); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    recipient.ID,
+			"error":      err.Error(),
+		}).Error("Failed to ensure recipient wallet")
+		return nil, err
+	}
+
+	now := time.Now()
+
+	outID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate transaction ID")
+		return nil, err
+	}
+
+	inID, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate transaction ID")
+		return nil, err
+	}
+
+	refNo := fmt.Sprintf("TRF%s", outID)
+
+	outDescription := fmt.Sprintf("Transfer to %s", recipient.Name)
+	inDescription := fmt.Sprintf("Transfer from %s", sender.Name)
+	if req.Note != "" {
+		outDescription = fmt.Sprintf("%s: %s", outDescription, req.Note)
+		inDescription = fmt.Sprintf("%s: %s", inDescription, req.Note)
+	}
+
+	transferOut := sentrapay.WalletTransaction{
+		ID:            outID,
+		UserID:        sender.ID,
+		Amount:        req.Amount * -1,
+		Type:          "transfer_out",
+		ReferenceNo:   refNo,
+		PaymentMethod: "wallet",
+		Status:        sentrapay.TransactionSuccess,
+		BankAccount:   recipient.PhoneNumber,
+		BankName:      "SENTRA",
+		Description:   outDescription,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	transferIn := sentrapay.WalletTransaction{
+		ID:            inID,
+		UserID:        recipient.ID,
+		Amount:        req.Amount,
+		Type:          "transfer_in",
+		ReferenceNo:   refNo,
+		PaymentMethod: "wallet",
+		Status:        sentrapay.TransactionSuccess,
+		BankAccount:   sender.PhoneNumber,
+		BankName:      "SENTRA",
+		Description:   inDescription,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	for _, transaction := range []sentrapay.WalletTransaction{transferOut, transferIn} {
+		if err := repo.Wallet.CreateTransaction(ctx, transaction); err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": refNo,
+				"user_id":      transaction.UserID,
+				"error":        err.Error(),
+			}).Error("Failed to create transfer transaction")
+			return nil, sentrapay.ErrCreateTransaction
+		}
+	}
+
+	if err := s.postLedger(ctx, repo, sentrapay.LedgerPosting{
+		JournalKey:  "transfer:" + refNo,
+		ReferenceNo: refNo,
+		Kind:        "transfer",
+		Description: outDescription,
+		Legs: []sentrapay.LedgerLeg{
+			walletDebitLeg(sender.ID, req.Amount),
+			walletCreditLeg(recipient.ID, req.Amount),
+		},
+	}); err != nil {
+		if errors.Is(err, sentrapay.ErrWalletNotFound) {
+			return nil, sentrapay.ErrInsufficientBalance
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": refNo,
+			"error":        err.Error(),
+		}).Warn("Failed to post transfer to ledger")
+		return nil, err
+	}
+
+	if within != nil {
+		if err := within(repo, refNo); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":   requestID,
+		"reference_no": refNo,
+		"sender_id":    sender.ID,
+		"recipient_id": recipient.ID,
+		"amount":       req.Amount,
+	}).Info("Wallet transfer completed successfully")
+
+	s.publishTransactionEvents(ctx, refNo)
+
+	return &sentrapay.TransferResponse{
+		TransactionID:  outID,
+		ReferenceNo:    refNo,
+		RecipientName:  recipient.Name,
+		RecipientPhone: recipient.PhoneNumber,
+		Amount:         req.Amount,
+		Note:           req.Note,
+		Status:         "success",
+		CreatedAt:      now,
+	}, nil
+}
@@ -0,0 +1,131 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/gateway"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"strings"
+	"time"
+)
+
+// GetVirtualAccounts lists the reusable virtual accounts the user has been
+// issued. A channel gets its account with the first top-up through it.
+func (s *sentraPayService) GetVirtualAccounts(ctx context.Context, userID string) ([]sentrapay.UserVirtualAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create new client")
+		return nil, err
+	}
+
+	accounts, err := repo.VirtualAccount.GetByUserID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get virtual accounts")
+		return nil, err
+	}
+
+	for i := range accounts {
+		accounts[i].BankName = getBankName(accounts[i].Channel)
+	}
+
+	return accounts, nil
+}
+
+// topUpGateway picks the gateway for a top-up: the one that issued the
+// user's virtual account for the channel, or the channel's current route for
+// a first top-up. An account never moves, so its number never changes.
+func (s *sentraPayService) topUpGateway(ctx context.Context, repo sentrapayRepository.Client, userID, channel string) (gateway.PaymentGateway, error) {
+	account, err := repo.VirtualAccount.Get(ctx, userID, channel)
+	switch {
+	case err == nil:
+		return s.gateways.Get(account.Gateway)
+	case errors.Is(err, sentrapay.ErrVirtualAccountNotFound):
+		return s.gateways.ForChannel(channel)
+	default:
+		return nil, err
+	}
+}
+
+// userVirtualAccount returns the user's virtual account for a channel,
+// allocating a number on first use. Concurrent first top-ups settle on a
+// single account.
+func (s *sentraPayService) userVirtualAccount(ctx context.Context, repo sentrapayRepository.Client, userID, channel, gatewayName string) (sentrapay.UserVirtualAccount, error) {
+	account, err := repo.VirtualAccount.Get(ctx, userID, channel)
+	if err == nil {
+		return account, nil
+	}
+	if !errors.Is(err, sentrapay.ErrVirtualAccountNotFound) {
+		return sentrapay.UserVirtualAccount{}, err
+	}
+
+	sequence, err := repo.VirtualAccount.NextCustomerSequence(ctx)
+	if err != nil {
+		return sentrapay.UserVirtualAccount{}, err
+	}
+
+	number, err := s.vaNumbering.Number(channel, sequence)
+	if err != nil {
+		return sentrapay.UserVirtualAccount{}, err
+	}
+
+	id, err := s.utils.NewULIDFromTimestamp(time.Now())
+	if err != nil {
+		return sentrapay.UserVirtualAccount{}, err
+	}
+
+	now := time.Now()
+	account = sentrapay.UserVirtualAccount{
+		ID:               id,
+		UserID:           userID,
+		Channel:          channel,
+		Gateway:          gatewayName,
+		PartnerServiceID: number.PartnerServiceID,
+		CustomerNo:       number.CustomerNo,
+		VirtualAccountNo: number.VirtualAccountNo,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	created, err := repo.VirtualAccount.Create(ctx, account)
+	if err != nil {
+		return sentrapay.UserVirtualAccount{}, err
+	}
+	if !created {
+		return repo.VirtualAccount.Get(ctx, userID, channel)
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":     contextPkg.GetRequestID(ctx),
+		"user_id":        userID,
+		"channel":        channel,
+		"gateway":        gatewayName,
+		"virtual_acc_no": account.VirtualAccountNo,
+	}).Info("Allocated virtual account number")
+
+	return account, nil
+}
+
+// recordIssuedNumber keeps the number a gateway actually issued for the
+// account, for gateways that prefix or reformat the one we asked for.
+func (s *sentraPayService) recordIssuedNumber(ctx context.Context, repo sentrapayRepository.Client, account sentrapay.UserVirtualAccount, issued string) error {
+	if issued == "" || strings.TrimSpace(issued) == strings.TrimSpace(account.VirtualAccountNo) {
+		return nil
+	}
+
+	account.VirtualAccountNo = issued
+	account.UpdatedAt = time.Now()
+
+	return repo.VirtualAccount.UpdateNumber(ctx, account)
+}
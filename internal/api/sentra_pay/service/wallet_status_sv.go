@@ -0,0 +1,242 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	sentrapayRepository "ProjectGolang/internal/api/sentra_pay/repository"
+	contextPkg "ProjectGolang/pkg/context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	walletFrozenMessage   = "Dompet Sentra Anda telah dibekukan. Pembayaran, transfer, dan penarikan ditolak sampai Anda mengaktifkannya kembali dengan kode OTP. Jika ini bukan Anda, segera hubungi layanan pelanggan."
+	walletUnfrozenMessage = "Dompet Sentra Anda telah diaktifkan kembali. Jika ini bukan Anda, segera bekukan dompet Anda dan hubungi layanan pelanggan."
+)
+
+// ensureWalletActive refuses debits from a frozen or closed wallet. The
+// caller must hold the user's spending lock, which FreezeWallet also takes,
+// so a freeze cannot slip in between this check and the debit.
+func (s *sentraPayService) ensureWalletActive(ctx context.Context, repo sentrapayRepository.Client, userID string) error {
+	wallet, err := repo.Wallet.GetWallet(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sentrapay.ErrWalletNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := walletStatusError(wallet.Status); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": contextPkg.GetRequestID(ctx),
+			"user_id":    userID,
+			"status":     wallet.Status,
+		}).Warn("Debit refused for inactive wallet")
+		return err
+	}
+
+	return nil
+}
+
+func walletStatusError(status string) error {
+	switch status {
+	case sentrapay.WalletFrozen:
+		return sentrapay.ErrWalletFrozen
+	case sentrapay.WalletClosed:
+		return sentrapay.ErrWalletClosed
+	default:
+		return nil
+	}
+}
+
+// FreezeWallet stops every debit from the user's wallet. It only needs the
+// login session, not the PIN or an OTP, so that a user who has lost their
+// phone can lock the wallet from any device straight away. Freezing a frozen
+// wallet is a no-op.
+func (s *sentraPayService) FreezeWallet(ctx context.Context, userID string, req sentrapay.FreezeWalletRequest) (*sentrapay.WalletBalance, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	if err := repo.Wallet.CreateWallet(ctx, userID); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to ensure wallet")
+		return nil, err
+	}
+
+	if err := repo.Wallet.LockSpending(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	wallet, err := repo.Wallet.GetWalletForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch wallet.Status {
+	case sentrapay.WalletFrozen:
+		return &wallet, nil
+	case sentrapay.WalletClosed:
+		return nil, sentrapay.ErrWalletClosed
+	}
+
+	wallet, err = s.transitionWalletStatus(ctx, repo, userID, sentrapay.WalletActive, sentrapay.WalletFrozen, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"user_id":    userID,
+		"reason":     req.Reason,
+	}).Info("Wallet frozen")
+
+	s.notifyUser(ctx, userID, walletFrozenMessage)
+	s.publishWalletStatus(ctx, wallet)
+
+	return &wallet, nil
+}
+
+// UnfreezeWallet reactivates a frozen wallet. Unlike freezing, it needs the
+// OTP sent to the user's phone number.
+func (s *sentraPayService) UnfreezeWallet(ctx context.Context, userID string, req sentrapay.UnfreezeWalletRequest) (*sentrapay.WalletBalance, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	authRepo, err := s.authRepo.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create auth repository client")
+		return nil, err
+	}
+
+	user, err := authRepo.Users.GetByID(ctx, userID)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Error("Failed to get user info")
+		return nil, err
+	}
+
+	storedOTP, err := s.redisServer.GetOTP(ctx, user.PhoneNumber)
+	if err != nil || storedOTP != req.OTPCode {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+		}).Warn("Invalid OTP for unfreezing wallet")
+		return nil, sentrapay.ErrInvalidOTP
+	}
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	if err := repo.Wallet.LockSpending(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	wallet, err := repo.Wallet.GetWalletForUpdate(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sentrapay.ErrWalletNotFound) {
+			return nil, sentrapay.ErrWalletNotFrozen
+		}
+		return nil, err
+	}
+
+	switch wallet.Status {
+	case sentrapay.WalletActive:
+		return nil, sentrapay.ErrWalletNotFrozen
+	case sentrapay.WalletClosed:
+		return nil, sentrapay.ErrWalletClosed
+	}
+
+	wallet, err = s.transitionWalletStatus(ctx, repo, userID, sentrapay.WalletFrozen, sentrapay.WalletActive, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return nil, err
+	}
+
+	if err := s.redisServer.DeleteOTP(ctx, user.PhoneNumber); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"error":      err.Error(),
+		}).Warn("Failed to delete used OTP")
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"user_id":    userID,
+	}).Info("Wallet unfrozen")
+
+	s.notifyUser(ctx, userID, walletUnfrozenMessage)
+	s.publishWalletStatus(ctx, wallet)
+
+	return &wallet, nil
+}
+
+// transitionWalletStatus must run with the wallet row locked, so the wallet
+// cannot have left status from since the caller read it.
+func (s *sentraPayService) transitionWalletStatus(ctx context.Context, repo sentrapayRepository.Client, userID, from, to, reason string) (sentrapay.WalletBalance, error) {
+	ok, err := repo.Wallet.TransitionStatus(ctx, userID, from, to, reason, time.Now())
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": contextPkg.GetRequestID(ctx),
+			"user_id":    userID,
+			"from":       from,
+			"to":         to,
+			"error":      err.Error(),
+		}).Error("Failed to change wallet status")
+		return sentrapay.WalletBalance{}, err
+	}
+
+	if !ok {
+		return sentrapay.WalletBalance{}, sentrapay.ErrWalletNotFound
+	}
+
+	return repo.Wallet.GetWalletForUpdate(ctx, userID)
+}
+
+func (s *sentraPayService) publishWalletStatus(ctx context.Context, wallet sentrapay.WalletBalance) {
+	if s.events == nil {
+		return
+	}
+
+	s.publishWalletEvent(ctx, wallet.UserID, sentrapay.WalletEventBalance, wallet)
+}
@@ -0,0 +1,604 @@
+package sentrapayService
+
+import (
+	sentrapay "ProjectGolang/internal/api/sentra_pay"
+	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/disbursement"
+	"ProjectGolang/pkg/risk"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"time"
+)
+
+const (
+	// withdrawalSettleDelay keeps the background settler away from
+	// withdrawals whose request is still waiting for the gateway.
+	withdrawalSettleDelay     = 2 * time.Minute
+	withdrawalSettleBatchSize = 100
+
+	withdrawalNotReceivedReason = "disbursement was not received by the gateway"
+)
+
+func (s *sentraPayService) RegisterBankAccount(ctx context.Context, userID string, req sentrapay.RegisterBankAccountRequest) (*sentrapay.BankAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if !disbursement.IsSupportedBank(req.BankCode) {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"bank_code":  req.BankCode,
+		}).Warn("Unsupported withdrawal bank")
+		return nil, sentrapay.ErrUnsupportedWithdrawalBank
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	account, err := repo.BankAccount.GetByNumber(ctx, userID, req.BankCode, req.AccountNumber)
+	if err != nil {
+		if !errors.Is(err, sentrapay.ErrBankAccountNotFound) {
+			return nil, err
+		}
+
+		now := time.Now()
+
+		id, err := s.utils.NewULIDFromTimestamp(now)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"error":      err.Error(),
+			}).Error("Failed to generate bank account ID")
+			return nil, err
+		}
+
+		account = sentrapay.BankAccount{
+			ID:            id,
+			UserID:        userID,
+			BankCode:      req.BankCode,
+			AccountNumber: req.AccountNumber,
+			Status:        sentrapay.BankAccountPending,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+
+		if err := repo.BankAccount.Create(ctx, account); err != nil {
+			return nil, err
+		}
+	}
+
+	if account.Status == sentrapay.BankAccountVerified {
+		return &account, nil
+	}
+
+	return s.verifyBankAccount(ctx, account)
+}
+
+func (s *sentraPayService) VerifyBankAccount(ctx context.Context, userID, bankAccountID string) (*sentrapay.BankAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	account, err := repo.BankAccount.GetByID(ctx, userID, bankAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Status == sentrapay.BankAccountVerified {
+		return &account, nil
+	}
+
+	return s.verifyBankAccount(ctx, account)
+}
+
+// verifyBankAccount runs an account inquiry at the gateway and stores the
+// holder name it returns. Accounts the bank does not know are marked failed.
+func (s *sentraPayService) verifyBankAccount(ctx context.Context, account sentrapay.BankAccount) (*sentrapay.BankAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	inquiry, err := s.disbursement.InquireAccount(ctx, account.BankCode, account.AccountNumber)
+	if err != nil && !errors.Is(err, disbursement.ErrAccountNotFound) {
+		s.log.WithFields(logrus.Fields{
+			"request_id":      requestID,
+			"bank_account_id": account.ID,
+			"error":           err.Error(),
+		}).Error("Bank account inquiry failed")
+		return nil, err
+	}
+
+	now := time.Now()
+	account.UpdatedAt = now
+
+	if err != nil {
+		account.Status = sentrapay.BankAccountFailed
+	} else {
+		account.Status = sentrapay.BankAccountVerified
+		account.AccountName = inquiry.AccountName
+		account.VerifiedAt = &now
+	}
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	if err := repo.BankAccount.UpdateVerification(ctx, account); err != nil {
+		return nil, err
+	}
+
+	if account.Status == sentrapay.BankAccountFailed {
+		s.log.WithFields(logrus.Fields{
+			"request_id":      requestID,
+			"bank_account_id": account.ID,
+			"bank_code":       account.BankCode,
+		}).Warn("Bank account not found at bank")
+		return nil, sentrapay.ErrBankAccountVerificationFailed
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":      requestID,
+		"bank_account_id": account.ID,
+		"user_id":         account.UserID,
+	}).Info("Bank account verified")
+
+	return &account, nil
+}
+
+func (s *sentraPayService) GetBankAccounts(ctx context.Context, userID string) ([]sentrapay.BankAccount, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	return repo.BankAccount.GetByUserID(ctx, userID)
+}
+
+// RequestWithdrawal holds the amount in the withdrawal clearing account and
+// asks the gateway to pay it out. The hold is settled or refunded as soon as
+// the gateway answers; when it does not, the withdrawal stays processing and
+// SettleProcessingWithdrawals picks it up later.
+func (s *sentraPayService) RequestWithdrawal(ctx context.Context, userID string, req sentrapay.WithdrawalRequest) (*sentrapay.WithdrawalResponse, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	if req.Amount <= 0 {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"amount":     req.Amount,
+		}).Warn("Invalid withdrawal amount")
+		return nil, sentrapay.ErrInvalidAmount
+	}
+
+	readRepo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	account, err := readRepo.BankAccount.GetByID(ctx, userID, req.BankAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Status != sentrapay.BankAccountVerified {
+		return nil, sentrapay.ErrBankAccountNotVerified
+	}
+
+	if err := s.pinVerifier.VerifyTransactionPIN(ctx, userID, req.PIN); err != nil {
+		return nil, err
+	}
+
+	if err := s.screenMovement(ctx, risk.Operation{
+		UserID: userID,
+		Kind:   "withdrawal",
+		Amount: req.Amount,
+		Payee:  account.AccountNumber,
+	}, req.OTPCode); err != nil {
+		return nil, err
+	}
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	if err := s.enforceSpendingLimits(ctx, repo, userID, req.Amount); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	id, err := s.utils.NewULIDFromTimestamp(now)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to generate transaction ID")
+		return nil, err
+	}
+
+	refNo := fmt.Sprintf("WDR%s", id)
+	description := fmt.Sprintf("Withdrawal to %s %s", account.BankCode, maskAccountNumber(account.AccountNumber))
+
+	transaction := sentrapay.WalletTransaction{
+		ID:            id,
+		UserID:        userID,
+		Amount:        req.Amount * -1,
+		Type:          "withdrawal",
+		ReferenceNo:   refNo,
+		PaymentMethod: "bank_transfer",
+		Status:        sentrapay.TransactionProcessing,
+		BankAccount:   account.AccountNumber,
+		BankName:      account.BankCode,
+		Description:   description,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := repo.Wallet.CreateTransaction(ctx, transaction); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": refNo,
+			"error":        err.Error(),
+		}).Error("Failed to create withdrawal transaction")
+		return nil, sentrapay.ErrCreateTransaction
+	}
+
+	withdrawal := sentrapay.Withdrawal{
+		ID:            id,
+		ReferenceNo:   refNo,
+		UserID:        userID,
+		BankAccountID: account.ID,
+		Amount:        req.Amount,
+		Status:        sentrapay.WithdrawalProcessing,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := repo.Withdrawal.Create(ctx, withdrawal); err != nil {
+		return nil, err
+	}
+
+	if err := s.postLedger(ctx, repo, sentrapay.LedgerPosting{
+		JournalKey:  "withdrawal:" + refNo,
+		ReferenceNo: refNo,
+		Kind:        "withdrawal_hold",
+		Description: description,
+		Legs: []sentrapay.LedgerLeg{
+			walletDebitLeg(userID, req.Amount),
+			accountLeg(sentrapay.LedgerAccountWithdrawalHold, sentrapay.LedgerCredit, req.Amount),
+		},
+	}); err != nil {
+		if errors.Is(err, sentrapay.ErrWalletNotFound) {
+			return nil, sentrapay.ErrInsufficientBalance
+		}
+		return nil, err
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":      requestID,
+		"reference_no":    refNo,
+		"user_id":         userID,
+		"bank_account_id": account.ID,
+		"amount":          req.Amount,
+	}).Info("Withdrawal funds held")
+
+	s.publishTransactionEvents(ctx, refNo)
+
+	result, err := s.disbursement.Disburse(ctx, disbursement.DisbursementRequest{
+		ReferenceNo:   refNo,
+		BankCode:      account.BankCode,
+		AccountNumber: account.AccountNumber,
+		AccountName:   account.AccountName,
+		Amount:        req.Amount,
+		Description:   description,
+	})
+	if err != nil {
+		// The outcome is unknown; the settler will ask the gateway again.
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": refNo,
+			"error":        err.Error(),
+		}).Error("Disbursement request failed, leaving withdrawal processing")
+	} else if err := s.applyDisbursementResult(ctx, withdrawal, result); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id":   requestID,
+			"reference_no": refNo,
+			"error":        err.Error(),
+		}).Error("Failed to apply disbursement result")
+	}
+
+	return s.getWithdrawalResponse(ctx, account, refNo)
+}
+
+func (s *sentraPayService) GetWithdrawal(ctx context.Context, userID, referenceNo string) (*sentrapay.WithdrawalResponse, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return nil, err
+	}
+
+	withdrawal, err := repo.Withdrawal.GetByReferenceNo(ctx, referenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if withdrawal.UserID != userID {
+		return nil, sentrapay.ErrWithdrawalNotFound
+	}
+
+	account, err := repo.BankAccount.GetByID(ctx, userID, withdrawal.BankAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sentrapay.WithdrawalResponse{
+		Withdrawal:    withdrawal,
+		BankCode:      account.BankCode,
+		AccountNumber: account.AccountNumber,
+		AccountName:   account.AccountName,
+	}, nil
+}
+
+func (s *sentraPayService) getWithdrawalResponse(ctx context.Context, account sentrapay.BankAccount, referenceNo string) (*sentrapay.WithdrawalResponse, error) {
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawal, err := repo.Withdrawal.GetByReferenceNo(ctx, referenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sentrapay.WithdrawalResponse{
+		Withdrawal:    withdrawal,
+		BankCode:      account.BankCode,
+		AccountNumber: account.AccountNumber,
+		AccountName:   account.AccountName,
+	}, nil
+}
+
+// SettleProcessingWithdrawals asks the gateway for the outcome of every
+// withdrawal that is still processing and settles or refunds its hold.
+func (s *sentraPayService) SettleProcessingWithdrawals(ctx context.Context) (int, error) {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(false)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return 0, err
+	}
+
+	withdrawals, err := repo.Withdrawal.GetProcessing(ctx, time.Now().Add(-withdrawalSettleDelay), withdrawalSettleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, withdrawal := range withdrawals {
+		if ctx.Err() != nil {
+			return settled, ctx.Err()
+		}
+
+		result, err := s.disbursement.GetDisbursement(ctx, withdrawal.ReferenceNo)
+		if err != nil {
+			if !errors.Is(err, disbursement.ErrDisbursementNotFound) {
+				s.log.WithFields(logrus.Fields{
+					"request_id":   requestID,
+					"reference_no": withdrawal.ReferenceNo,
+					"error":        err.Error(),
+				}).Warn("Failed to get disbursement status")
+				continue
+			}
+
+			result = &disbursement.DisbursementResult{
+				ReferenceNo:   withdrawal.ReferenceNo,
+				Status:        disbursement.StatusFailed,
+				FailureReason: withdrawalNotReceivedReason,
+			}
+		}
+
+		if result.Status == disbursement.StatusPending {
+			continue
+		}
+
+		if err := s.applyDisbursementResult(ctx, withdrawal, result); err != nil {
+			if errors.Is(err, sentrapay.ErrWithdrawalNotProcessing) {
+				continue
+			}
+
+			s.log.WithFields(logrus.Fields{
+				"request_id":   requestID,
+				"reference_no": withdrawal.ReferenceNo,
+				"error":        err.Error(),
+			}).Error("Failed to apply disbursement result")
+			continue
+		}
+
+		settled++
+	}
+
+	return settled, nil
+}
+
+// applyDisbursementResult moves a processing withdrawal to its final state.
+// On success the hold is released to the bank payout account; on failure it
+// is refunded to the wallet with a separate withdrawal_refund transaction so
+// the refund shows up in the user's history.
+func (s *sentraPayService) applyDisbursementResult(ctx context.Context, withdrawal sentrapay.Withdrawal, result *disbursement.DisbursementResult) error {
+	requestID := contextPkg.GetRequestID(ctx)
+
+	repo, err := s.walletRepository.NewClient(true)
+	if err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to create repository client")
+		return err
+	}
+	defer repo.Rollback()
+
+	refNo := withdrawal.ReferenceNo
+
+	switch result.Status {
+	case disbursement.StatusPending:
+		if err := repo.Withdrawal.UpdateStatus(ctx, refNo, sentrapay.WithdrawalProcessing, result.GatewayReference, ""); err != nil {
+			return err
+		}
+	case disbursement.StatusSuccess:
+		if err := repo.Withdrawal.UpdateStatus(ctx, refNo, sentrapay.WithdrawalSuccess, result.GatewayReference, ""); err != nil {
+			return err
+		}
+
+		if err := repo.Wallet.UpdateTransactionStatus(ctx, refNo, sentrapay.TransactionSuccess, sentrapay.StatusChange{
+			Actor: sentrapay.ActorDisbursement,
+		}); err != nil {
+			return err
+		}
+
+		if err := s.postLedger(ctx, repo, sentrapay.LedgerPosting{
+			JournalKey:  "withdrawal-settle:" + refNo,
+			ReferenceNo: refNo,
+			Kind:        "withdrawal_settle",
+			Description: "Withdrawal paid out",
+			Legs: []sentrapay.LedgerLeg{
+				accountLeg(sentrapay.LedgerAccountWithdrawalHold, sentrapay.LedgerDebit, withdrawal.Amount),
+				accountLeg(sentrapay.LedgerAccountBankPayout, sentrapay.LedgerCredit, withdrawal.Amount),
+			},
+		}); err != nil {
+			return err
+		}
+	case disbursement.StatusFailed:
+		reason := result.FailureReason
+		if reason == "" {
+			reason = "disbursement failed"
+		}
+
+		if err := repo.Withdrawal.UpdateStatus(ctx, refNo, sentrapay.WithdrawalFailed, result.GatewayReference, reason); err != nil {
+			return err
+		}
+
+		if err := repo.Wallet.UpdateTransactionStatus(ctx, refNo, sentrapay.TransactionFailed, sentrapay.StatusChange{
+			Reason: reason,
+			Actor:  sentrapay.ActorDisbursement,
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		refundID, err := s.utils.NewULIDFromTimestamp(now)
+		if err != nil {
+			return err
+		}
+
+		refundRefNo := fmt.Sprintf("RFD%s", withdrawal.ID)
+
+		if err := repo.Wallet.CreateTransaction(ctx, sentrapay.WalletTransaction{
+			ID:            refundID,
+			UserID:        withdrawal.UserID,
+			Amount:        withdrawal.Amount,
+			Type:          "withdrawal_refund",
+			ReferenceNo:   refundRefNo,
+			PaymentMethod: "wallet",
+			Status:        sentrapay.TransactionSuccess,
+			Description:   fmt.Sprintf("Refund of withdrawal %s: %s", refNo, reason),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}); err != nil {
+			return sentrapay.ErrCreateTransaction
+		}
+
+		if err := s.postLedger(ctx, repo, sentrapay.LedgerPosting{
+			JournalKey:  "withdrawal-refund:" + refNo,
+			ReferenceNo: refundRefNo,
+			Kind:        "withdrawal_refund",
+			Description: "Withdrawal refunded",
+			Legs: []sentrapay.LedgerLeg{
+				accountLeg(sentrapay.LedgerAccountWithdrawalHold, sentrapay.LedgerDebit, withdrawal.Amount),
+				walletCreditLeg(withdrawal.UserID, withdrawal.Amount),
+			},
+		}); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown disbursement status %q", result.Status)
+	}
+
+	if err := repo.Commit(); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"error":      err.Error(),
+		}).Error("Failed to commit transaction")
+		return err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id":        requestID,
+		"reference_no":      refNo,
+		"user_id":           withdrawal.UserID,
+		"status":            result.Status,
+		"gateway_reference": result.GatewayReference,
+		"failure_reason":    result.FailureReason,
+	}).Info("Disbursement result applied to withdrawal")
+
+	if result.Status != disbursement.StatusPending {
+		s.publishTransactionEvents(ctx, refNo)
+	}
+
+	return nil
+}
+
+func maskAccountNumber(accountNumber string) string {
+	if len(accountNumber) <= 4 {
+		return accountNumber
+	}
+	return "****" + accountNumber[len(accountNumber)-4:]
+}
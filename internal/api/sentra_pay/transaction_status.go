@@ -0,0 +1,74 @@
+package sentrapay
+
+import (
+	"time"
+)
+
+// TransactionStatus is the lifecycle state of a wallet transaction.
+type TransactionStatus string
+
+const (
+	TransactionPending    TransactionStatus = "pending"
+	TransactionProcessing TransactionStatus = "processing"
+	TransactionSuccess    TransactionStatus = "success"
+	TransactionFailed     TransactionStatus = "failed"
+	TransactionExpired    TransactionStatus = "expired"
+	TransactionReversed   TransactionStatus = "reversed"
+	TransactionRefunded   TransactionStatus = "refunded"
+)
+
+// transactionTransitions lists, for each status, the statuses a transaction
+// may move to. Statuses without an entry are final.
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionPending:    {TransactionProcessing, TransactionSuccess, TransactionFailed, TransactionExpired},
+	TransactionProcessing: {TransactionSuccess, TransactionFailed, TransactionReversed},
+	TransactionSuccess:    {TransactionReversed, TransactionRefunded},
+}
+
+// CanTransitionTo reports whether a transaction in status s may move to next.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range transactionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal reports whether no further transition is possible from s.
+func (s TransactionStatus) IsFinal() bool {
+	return len(transactionTransitions[s]) == 0
+}
+
+// Actors recorded in the status history for changes made by the system.
+// Changes made on behalf of a user use UserActor.
+const (
+	ActorPaymentCallback = "system:payment_callback"
+	ActorStatusCheck     = "system:status_check"
+	ActorTopUpExpiry     = "system:topup_expiry"
+	ActorReconciliation  = "system:reconciliation"
+	ActorDisbursement    = "system:disbursement"
+	ActorQRISPayment     = "system:qris_payment"
+)
+
+func UserActor(userID string) string {
+	return "user:" + userID
+}
+
+// StatusChange explains a status transition. Reason is also stored as the
+// transaction's status_reason when it is not empty.
+type StatusChange struct {
+	Reason string
+	Actor  string
+}
+
+type TransactionStatusHistory struct {
+	ID            int64             `json:"id"`
+	TransactionID string            `json:"transaction_id"`
+	ReferenceNo   string            `json:"reference_no"`
+	FromStatus    TransactionStatus `json:"from_status"`
+	ToStatus      TransactionStatus `json:"to_status"`
+	Reason        string            `json:"reason,omitempty"`
+	Actor         string            `json:"actor"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
@@ -5,6 +5,7 @@ import (
 	voiceRepository "ProjectGolang/internal/api/voice/repository"
 	"ProjectGolang/internal/entity"
 	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
 	"ProjectGolang/pkg/nlp"
 	chatGPT "ProjectGolang/pkg/openai"
 	"context"
@@ -438,7 +439,7 @@ func (s *voiceService) handleTransactionIntent(
 	requestID := contextPkg.GetRequestID(ctx)
 
 	
-	amount, _ := intent.Data["amount"].(float64)
+	amount, _ := contextAmount(intent.Data["amount"])
 	description, _ := intent.Data["description"].(string)
 	category, _ := intent.Data["category"].(string)
 	txType, _ := intent.Data["type"].(string)
@@ -584,7 +585,7 @@ func (s *voiceService) handleCategoryConfirmation(
 		}, nil
 	}
 
-	amount, ok := session.Context["transaction_amount"].(float64)
+	amount, ok := contextAmount(session.Context["transaction_amount"])
 	if !ok {
 		return &voice.VoiceResponse{
 			Text:    "Maaf, terjadi kesalahan. Nominal transaksi tidak valid.",
@@ -777,18 +778,19 @@ func (s *voiceService) handleGPTTransaction(
 	session *entity.VoiceSession,
 ) (*voice.VoiceResponse, error) {
 	requestID := contextPkg.GetRequestID(ctx)
+	amount := money.FromFloat64(txIntent.Amount)
 
 	s.log.WithFields(logrus.Fields{
 		"request_id": requestID,
 		"tx_type":    txIntent.Type,
-		"amount":     txIntent.Amount,
+		"amount":     amount,
 		"category":   txIntent.SuggestedCategory,
 	}).Info("Processing GPT transaction")
 
 	if txIntent.SuggestedCategory != "" && txIntent.Confidence > 0.8 {
 		txData := &nlp.TransactionData{
 			Type:        txIntent.Type,
-			Amount:      txIntent.Amount,
+			Amount:      amount,
 			Description: txIntent.Description,
 			Category:    txIntent.SuggestedCategory,
 			Confidence:  txIntent.Confidence,
@@ -805,7 +807,7 @@ func (s *voiceService) handleGPTTransaction(
 	session.Context = map[string]interface{}{
 		"step":               "awaiting_category",
 		"transaction_type":   txIntent.Type,
-		"transaction_amount": txIntent.Amount,
+		"transaction_amount": amount,
 		"transaction_desc":   txIntent.Description,
 	}
 	session.PendingConfirmation = true
@@ -814,9 +816,9 @@ func (s *voiceService) handleGPTTransaction(
 	categoriesText := strings.Join(categories, ", ")
 
 	responseText := fmt.Sprintf(
-		"Saya catat %s Rp%.0f untuk %s. Kategori apa? Pilihan: %s",
+		"Saya catat %s %s untuk %s. Kategori apa? Pilihan: %s",
 		txIntent.Type,
-		txIntent.Amount,
+		amount.Format(),
 		txIntent.Description,
 		categoriesText,
 	)
@@ -948,7 +950,7 @@ func (s *voiceService) handleDeleteTransactionIntent(
 ) (*voice.VoiceResponse, error) {
 	requestID := contextPkg.GetRequestID(ctx)
 
-	amount, _ := intent.Data["amount"].(float64)
+	amount, _ := contextAmount(intent.Data["amount"])
 	description, _ := intent.Data["description"].(string)
 
 	s.log.WithFields(logrus.Fields{
@@ -985,7 +987,7 @@ func (s *voiceService) handleDeleteTransactionIntent(
 	matchedTransactions := s.filterTransactionsByNominalAndDesc(allTransactions, amount, description)
 
 	if len(matchedTransactions) == 0 {
-		responseText := fmt.Sprintf("Tidak ada transaksi dengan nominal Rp%.0f", amount)
+		responseText := fmt.Sprintf("Tidak ada transaksi dengan nominal %s", amount.Format())
 		if description != "" {
 			responseText += fmt.Sprintf(" dan deskripsi yang mengandung '%s'", description)
 		}
@@ -1028,9 +1030,9 @@ func (s *voiceService) handleDeleteTransactionIntent(
 	var responseText string
 	if deletedCount > 0 {
 		if deletedCount == 1 {
-			responseText = fmt.Sprintf("Berhasil menghapus 1 transaksi dengan nominal Rp%.0f", amount)
+			responseText = fmt.Sprintf("Berhasil menghapus 1 transaksi dengan nominal %s", amount.Format())
 		} else {
-			responseText = fmt.Sprintf("Berhasil menghapus %d transaksi dengan nominal Rp%.0f", deletedCount, amount)
+			responseText = fmt.Sprintf("Berhasil menghapus %d transaksi dengan nominal %s", deletedCount, amount.Format())
 		}
 		
 		if description != "" {
@@ -1103,7 +1105,7 @@ func (s *voiceService) handleLogoutIntent(
 
 func (s *voiceService) filterTransactionsByNominalAndDesc(
 	transactions []entity.BudgetTransaction,
-	amount float64,
+	amount money.Amount,
 	description string,
 ) []entity.BudgetTransaction {
 	var matched []entity.BudgetTransaction
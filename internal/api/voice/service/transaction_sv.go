@@ -5,8 +5,10 @@ import (
 	"ProjectGolang/internal/api/voice"
 	"ProjectGolang/internal/entity"
 	contextPkg "ProjectGolang/pkg/context"
+	"ProjectGolang/pkg/money"
 	"ProjectGolang/pkg/nlp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -96,7 +98,15 @@ func (s *voiceService) ProcessCategorySelection(
 
 	
 	txType := session.Context["transaction_type"].(string)
-	amount := session.Context["transaction_amount"].(float64)
+	amount, ok := contextAmount(session.Context["transaction_amount"])
+	if !ok {
+		s.log.WithFields(logrus.Fields{
+			"request_id": contextPkg.GetRequestID(ctx),
+			"user_id":    userID,
+			"amount":     session.Context["transaction_amount"],
+		}).Error("Pending transaction has no valid amount")
+		return nil, voice.ErrInvalidSession
+	}
 	description := session.Context["transaction_desc"].(string)
 
 	
@@ -179,9 +189,9 @@ func (s *voiceService) createBudgetTransaction(
 	}
 
 	responseText := fmt.Sprintf(
-		"Dicatat: %s Rp%.0f untuk %s, kategori %s.",
+		"Dicatat: %s %s untuk %s, kategori %s.",
 		typeText,
-		txData.Amount,
+		txData.Amount.Format(),
 		txData.Description,
 		txData.Category,
 	)
@@ -248,7 +258,7 @@ func (s *voiceService) ProcessDeleteTransaction(
 
 	if matchedTx == nil {
 		return &voice.VoiceResponse{
-			Text:    fmt.Sprintf("Transaksi Rp%.0f %s tidak ditemukan. Silakan coba dengan nominal dan keterangan lain.", amount, description),
+			Text:    fmt.Sprintf("Transaksi %s %s tidak ditemukan. Silakan coba dengan nominal dan keterangan lain.", amount.Format(), description),
 			Action:  "not_found",
 			Success: false,
 		}, nil
@@ -256,8 +266,8 @@ func (s *voiceService) ProcessDeleteTransaction(
 
 	
 	responseText := fmt.Sprintf(
-		"Apakah Anda yakin ingin hapus transaksi Rp%.0f %s?",
-		matchedTx.Nominal,
+		"Apakah Anda yakin ingin hapus transaksi %s %s?",
+		matchedTx.Nominal.Format(),
 		matchedTx.Description,
 	)
 
@@ -293,11 +303,11 @@ func (s *voiceService) ProcessMonthlySummary(
 		}, err
 	}
 
-	var totalIncome, totalExpense float64
+	var totalIncome, totalExpense money.Amount
 	for _, tx := range transactions {
 		if tx.Type == "income" {
 			totalIncome += tx.Nominal
-		} else {
+		} else if tx.Type == "expense" {
 			totalExpense += tx.Nominal
 		}
 	}
@@ -305,10 +315,10 @@ func (s *voiceService) ProcessMonthlySummary(
 	balance := totalIncome - totalExpense
 
 	responseText := fmt.Sprintf(
-		"Saldo saat ini Rp%.0f. Total pemasukan Rp%.0f. Total pengeluaran Rp%.0f.",
-		balance,
-		totalIncome,
-		totalExpense,
+		"Saldo saat ini %s. Total pemasukan %s. Total pengeluaran %s.",
+		balance.Format(),
+		totalIncome.Format(),
+		totalExpense.Format(),
 	)
 
 	return &voice.VoiceResponse{
@@ -379,4 +389,24 @@ func (s *voiceService) detectCommandType(transcript string) string {
 	}
 
 	return "unknown"
-}
\ No newline at end of file
+}
+
+// contextAmount reads an amount kept in a session or intent map. Amounts
+// put there as money.Amount come back as rupiah numbers once the session has
+// been stored and loaded.
+func contextAmount(value interface{}) (money.Amount, bool) {
+	switch v := value.(type) {
+	case money.Amount:
+		return v, true
+	case float64:
+		return money.FromFloat64(v), true
+	case json.Number:
+		amount, err := money.Parse(v.String())
+		return amount, err == nil
+	case string:
+		amount, err := money.Parse(v)
+		return amount, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -21,19 +21,26 @@ import (
 	voiceService "ProjectGolang/internal/api/voice/service"
 	"ProjectGolang/internal/middleware"
 	"ProjectGolang/pkg/bcrypt"
-	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/disbursement"
+	"ProjectGolang/pkg/events"
+	"ProjectGolang/pkg/gateway"
 	"ProjectGolang/pkg/gemini"
 	"ProjectGolang/pkg/google"
 	"ProjectGolang/pkg/nlp"
 	chatGPT "ProjectGolang/pkg/openai"
+	"ProjectGolang/pkg/receiving"
 	"ProjectGolang/pkg/redis"
+	"ProjectGolang/pkg/risk"
 	"ProjectGolang/pkg/s3"
+	"ProjectGolang/pkg/scheduler"
 	"ProjectGolang/pkg/smtp"
 	"ProjectGolang/pkg/utils"
 	websocketPkg "ProjectGolang/pkg/websocket"
 	"ProjectGolang/pkg/whatsapp"
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -60,6 +67,9 @@ type Server struct {
 	whatsappClient whatsapp.IWhatsappSender
 	geminiClient   gemini.IGemini
 	s3Client       s3.ItfS3
+	scheduler      scheduler.IScheduler
+	disbursement   disbursement.IDisbursementGateway
+	receiving      receiving.IReceivingChannel
 }
 
 type handler interface {
@@ -207,6 +217,41 @@ func WithUtils() ServerOption {
 	}
 }
 
+func WithScheduler(sched scheduler.IScheduler) ServerOption {
+	return func(s *Server) error {
+		s.scheduler = sched
+		return nil
+	}
+}
+
+func WithDisbursementGateway() ServerOption {
+	return func(s *Server) error {
+		gateway, err := disbursement.New(s.log)
+		if err != nil {
+			if s.log != nil {
+				s.log.Errorf("Failed to create disbursement gateway: %v", err)
+			}
+			return fmt.Errorf("failed to create disbursement gateway: %w", err)
+		}
+		s.disbursement = gateway
+		return nil
+	}
+}
+
+func WithReceivingChannel() ServerOption {
+	return func(s *Server) error {
+		channel, err := receiving.New(s.log)
+		if err != nil {
+			if s.log != nil {
+				s.log.Errorf("Failed to create receiving channel: %v", err)
+			}
+			return fmt.Errorf("failed to create receiving channel: %w", err)
+		}
+		s.receiving = channel
+		return nil
+	}
+}
+
 func WithBcryptUtils() ServerOption {
 	return func(s *Server) error {
 		s.bcryptUtils = bcrypt.New()
@@ -230,13 +275,61 @@ func (s *Server) RegisterHandler() {
 	budgetHandlers := budgetHandler.New(s.log, s.validator, s.middleware, budgetServices)
 
 	// Payment Domain
-	dokuClient := doku.NewDokuService(s.log)
-	dokuClient.Init()
+	paymentGateways, err := gateway.New(s.log)
+	if err != nil {
+		s.log.Fatalf("Failed to create payment gateways: %v", err)
+	}
+	vaNumbering, err := gateway.NumberingFromEnv()
+	if err != nil {
+		s.log.Fatalf("Invalid virtual account numbering: %v", err)
+	}
+	if err := paymentGateways.Init(); err != nil {
+		s.log.Errorf("Failed to initialize payment gateways: %v", err)
+	}
 	dokuRepo := sentrapayRepository.New(s.db, s.log)
 
-	dokuServices := sentrapayService.NewSentraPayService(s.log, dokuRepo, dokuClient, authRepo, s.utils, s.bcryptUtils)
+	pinVerifier := sentrapayService.NewPINVerifier(s.log, authRepo, s.redisServer, s.bcryptUtils)
+	riskEngine, err := risk.New()
+	if err != nil {
+		s.log.Fatalf("Invalid risk rules: %v", err)
+	}
+	walletEvents := events.New(s.log, s.redisServer)
+	dokuServices := sentrapayService.NewSentraPayService(s.log, dokuRepo, paymentGateways, vaNumbering, s.disbursement, s.receiving, authRepo, budgetServices, pinVerifier, riskEngine, s.redisServer, walletEvents, s.s3Client, s.whatsappClient, s.utils)
 	dokuHandlers := sentrapayHandler.New(s.log, s.validator, s.middleware, dokuServices)
 
+	if s.scheduler != nil {
+		s.scheduler.Every("expire-topups", envDuration("TOPUP_EXPIRY_INTERVAL", 5*time.Minute), func(ctx context.Context) error {
+			_, err := dokuServices.ExpirePendingTopUps(ctx)
+			return err
+		})
+
+		s.scheduler.Every("settle-withdrawals", envDuration("WITHDRAWAL_SETTLE_INTERVAL", 2*time.Minute), func(ctx context.Context) error {
+			_, err := dokuServices.SettleProcessingWithdrawals(ctx)
+			return err
+		})
+
+		s.scheduler.Every("recover-qris-payments", envDuration("QRIS_RECOVERY_INTERVAL", time.Minute), func(ctx context.Context) error {
+			_, err := dokuServices.RecoverQRISPayments(ctx)
+			return err
+		})
+
+		s.scheduler.Every("process-statement-jobs", envDuration("STATEMENT_JOB_INTERVAL", time.Minute), func(ctx context.Context) error {
+			_, err := dokuServices.ProcessStatementJobs(ctx)
+			return err
+		})
+
+		s.scheduler.Every("process-payment-requests", envDuration("PAYMENT_REQUEST_INTERVAL", 15*time.Minute), func(ctx context.Context) error {
+			_, err := dokuServices.ProcessPaymentRequests(ctx)
+			return err
+		})
+
+		hour, minute := envClock("RECONCILIATION_TIME", 2, 0)
+		s.scheduler.Daily("reconcile-topups", hour, minute, jakartaLocation(), func(ctx context.Context) error {
+			_, err := dokuServices.ReconcileTopUps(ctx)
+			return err
+		})
+	}
+
 	//Blog Domain
 	blogRepo := blogRepository.New(s.db, s.log)
 	blogServices := blogService.NewBlogsService(s.log, blogRepo, s.s3Client, s.utils)
@@ -281,8 +374,8 @@ func (s *Server) Run() error {
 	corsMiddleware := cors.New(cors.Config{
 		AllowOrigins:     "https://sentra-web-pi.vercel.app, http://localhost:3000, https://sentra-web-e8ma.vercel.app",
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID",
-		ExposeHeaders:    "X-Request-ID",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID, Idempotency-Key, X-Device-ID",
+		ExposeHeaders:    "X-Request-ID, Idempotent-Replayed",
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
@@ -309,6 +402,31 @@ func (s *Server) Run() error {
 	return nil
 }
 
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// envClock reads an "HH:MM" time of day from key.
+func envClock(key string, fallbackHour, fallbackMinute int) (int, int) {
+	value, err := time.Parse("15:04", os.Getenv(key))
+	if err != nil {
+		return fallbackHour, fallbackMinute
+	}
+	return value.Hour(), value.Minute()
+}
+
+func jakartaLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
 func (s *Server) setupHealthCheck() {
 	s.engine.Get("/", func(ctx *fiber.Ctx) error {
 		return ctx.JSON(fiber.Map{
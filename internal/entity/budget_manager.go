@@ -3,6 +3,7 @@ package entity
 
 import (
 	"ProjectGolang/internal/api/budget_manager"
+	"ProjectGolang/pkg/money"
 	"time"
 )
 
@@ -11,6 +12,9 @@ type TransactionType string
 const (
 	TransactionTypeIncome  TransactionType = "income"
 	TransactionTypeExpense TransactionType = "expense"
+	// TransactionTypeTransfer moves money between the user's own accounts,
+	// such as a wallet top-up, and counts as neither income nor expense.
+	TransactionTypeTransfer TransactionType = "transfer"
 )
 
 type IncomeCategory string
@@ -45,6 +49,13 @@ const (
 	ExpenseCategoryOther          ExpenseCategory = "lainnya" 
 )
 
+type TransferCategory string
+
+const (
+	TransferCategoryTopUp TransferCategory = "top up"
+	TransferCategoryOther TransferCategory = "lainnya"
+)
+
 func IsValidIncomeCategory(category string) bool {
 	switch IncomeCategory(category) {
 	case IncomeCategorySalary, IncomeCategoryBonus, IncomeCategoryInvestment, IncomeCategoryPartTime, IncomeCategoryOther: 
@@ -67,32 +78,47 @@ func IsValidExpenseCategory(category string) bool {
 	}
 }
 
+func IsValidTransferCategory(category string) bool {
+	switch TransferCategory(category) {
+	case TransferCategoryTopUp, TransferCategoryOther:
+		return true
+	default:
+		return false
+	}
+}
+
 func IsValidCategory(transactionType, category string) bool {
 	switch TransactionType(transactionType) {
 	case TransactionTypeIncome:
 		return IsValidIncomeCategory(category)
 	case TransactionTypeExpense:
 		return IsValidExpenseCategory(category)
+	case TransactionTypeTransfer:
+		return IsValidTransferCategory(category)
 	default:
 		return false
 	}
 }
 
+// BudgetTransaction is a budget entry. WalletTransactionID links entries
+// recorded automatically from the wallet; the entry is a copy, so editing it
+// never changes the wallet transaction.
 type BudgetTransaction struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Nominal     float64   `json:"nominal"`
-	Type        string    `json:"type"`
-	Category    string    `json:"category"`
-	AudioLink   string    `json:"audio_link"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                  string       `json:"id"`
+	UserID              string       `json:"user_id"`
+	Title               string       `json:"title"`
+	Description         string       `json:"description"`
+	Nominal             money.Amount `json:"nominal"`
+	Type                string       `json:"type"`
+	Category            string       `json:"category"`
+	AudioLink           string       `json:"audio_link"`
+	WalletTransactionID string       `json:"wallet_transaction_id,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
 }
 
 func (t *BudgetTransaction) Validate() error {
-	if t.Type != string(TransactionTypeIncome) && t.Type != string(TransactionTypeExpense) {
+	if t.Type != string(TransactionTypeIncome) && t.Type != string(TransactionTypeExpense) && t.Type != string(TransactionTypeTransfer) {
 		return budget_manager.ErrInvalidTransactionType
 	}
 
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+const (
+	AdminAPIKey    = "ADMIN_API_KEY"
+	AdminKeyHeader = "X-Admin-Key"
+)
+
+// NewAdminKeyMiddleware guards operator endpoints with the shared key in
+// ADMIN_API_KEY. Every request is refused while the key is not configured.
+func (m *middleware) NewAdminKeyMiddleware(ctx *fiber.Ctx) error {
+	expected := os.Getenv(AdminAPIKey)
+	provided := ctx.Get(AdminKeyHeader)
+
+	if expected == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+		m.log.WithFields(logrus.Fields{
+			"path":       ctx.Path(),
+			"method":     ctx.Method(),
+			"client_ip":  ctx.IP(),
+			"configured": expected != "",
+		}).Warn("Admin key check failed")
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized, admin key invalid",
+		})
+	}
+
+	return ctx.Next()
+}
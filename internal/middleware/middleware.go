@@ -8,6 +8,7 @@ import (
 type Middleware interface {
 	NewRateLimiter(ctx *fiber.Ctx) error
 	NewTokenMiddleware(ctx *fiber.Ctx) error
+	NewAdminKeyMiddleware(ctx *fiber.Ctx) error
 	NewRequestIDMiddleware() fiber.Handler
 	GetRequestID(ctx *fiber.Ctx) string
 }
@@ -3,9 +3,13 @@ package context
 import (
 	"context"
 	"github.com/gofiber/fiber/v2"
+	"strings"
 )
 
-const RequestIDKey = "request_id"
+const (
+	RequestIDKey = "request_id"
+	DeviceIDKey  = "device_id"
+)
 
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, RequestIDKey, requestID)
@@ -19,6 +23,18 @@ func GetRequestID(ctx context.Context) string {
 	return requestID
 }
 
+// WithDeviceID records the client's own identifier of the device the request
+// came from, as sent in the X-Device-ID header.
+func WithDeviceID(ctx context.Context, deviceID string) context.Context {
+	return context.WithValue(ctx, DeviceIDKey, deviceID)
+}
+
+// GetDeviceID returns "" when the client did not identify its device.
+func GetDeviceID(ctx context.Context) string {
+	deviceID, _ := ctx.Value(DeviceIDKey).(string)
+	return deviceID
+}
+
 func FromFiberCtx(c *fiber.Ctx) context.Context {
 	ctx := context.Background()
 
@@ -31,5 +47,11 @@ func FromFiberCtx(c *fiber.Ctx) context.Context {
 		}
 	}
 
-	return WithRequestID(ctx, requestID)
+	ctx = WithRequestID(ctx, requestID)
+
+	if deviceID := strings.TrimSpace(c.Get("X-Device-ID")); deviceID != "" {
+		ctx = WithDeviceID(ctx, deviceID)
+	}
+
+	return ctx
 }
@@ -0,0 +1,91 @@
+package disbursement
+
+import (
+	"ProjectGolang/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+var (
+	ErrUnsupportedBank      = errors.New("bank is not supported for disbursement")
+	ErrAccountNotFound      = errors.New("bank account not found")
+	ErrDisbursementNotFound = errors.New("disbursement not found")
+	ErrUnsupportedProvider  = errors.New("unsupported disbursement provider")
+)
+
+// IDisbursementGateway sends money from the platform's settlement account to a
+// user's bank account. ReferenceNo is our own reference and doubles as the
+// idempotency key at the gateway, so a disbursement whose outcome is unknown
+// can always be looked up again with GetDisbursement.
+type IDisbursementGateway interface {
+	InquireAccount(ctx context.Context, bankCode, accountNumber string) (*AccountInquiry, error)
+	Disburse(ctx context.Context, req DisbursementRequest) (*DisbursementResult, error)
+	GetDisbursement(ctx context.Context, referenceNo string) (*DisbursementResult, error)
+}
+
+type AccountInquiry struct {
+	BankCode      string
+	AccountNumber string
+	AccountName   string
+}
+
+type DisbursementRequest struct {
+	ReferenceNo   string
+	BankCode      string
+	AccountNumber string
+	AccountName   string
+	Amount        money.Amount
+	Description   string
+}
+
+type DisbursementResult struct {
+	ReferenceNo      string
+	GatewayReference string
+	Status           string
+	FailureReason    string
+}
+
+// Banks lists the bank codes accepted for withdrawals.
+var Banks = map[string]string{
+	"BCA":      "Bank Central Asia",
+	"MANDIRI":  "Bank Mandiri",
+	"BRI":      "Bank Rakyat Indonesia",
+	"BNI":      "Bank Negara Indonesia",
+	"BSI":      "Bank Syariah Indonesia",
+	"CIMB":     "CIMB Niaga",
+	"PERMATA":  "Bank Permata",
+	"DANAMON":  "Bank Danamon",
+	"BTN":      "Bank Tabungan Negara",
+	"SINARMAS": "Bank Sinarmas",
+}
+
+func IsSupportedBank(bankCode string) bool {
+	_, ok := Banks[bankCode]
+	return ok
+}
+
+// New returns the gateway selected by DISBURSEMENT_PROVIDER. Only the local
+// fake exists for now; it is also the default when the variable is empty.
+func New(log *logrus.Logger) (IDisbursementGateway, error) {
+	provider := strings.ToLower(os.Getenv("DISBURSEMENT_PROVIDER"))
+
+	switch provider {
+	case "", "fake":
+		if os.Getenv("PRODUCTION") == "true" {
+			log.Warn("Using fake disbursement gateway in production, withdrawals will not move real money")
+		}
+		return NewFake(log), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, provider)
+	}
+}
@@ -0,0 +1,113 @@
+package disbursement
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeGateway is an in-memory gateway for local development. Its behaviour is
+// driven by the destination account number:
+//
+//	ends in 0000  account inquiry fails with ErrAccountNotFound
+//	ends in 9999  disbursement is rejected
+//	ends in 8888  disbursement stays pending until it is looked up again,
+//	              then succeeds
+//
+// Every other account is found and paid out immediately.
+type fakeGateway struct {
+	log *logrus.Logger
+
+	mu            sync.Mutex
+	disbursements map[string]*DisbursementResult
+}
+
+func NewFake(log *logrus.Logger) IDisbursementGateway {
+	return &fakeGateway{
+		log:           log,
+		disbursements: make(map[string]*DisbursementResult),
+	}
+}
+
+func (f *fakeGateway) InquireAccount(ctx context.Context, bankCode, accountNumber string) (*AccountInquiry, error) {
+	if !IsSupportedBank(bankCode) {
+		return nil, ErrUnsupportedBank
+	}
+
+	if strings.HasSuffix(accountNumber, "0000") {
+		return nil, ErrAccountNotFound
+	}
+
+	suffix := accountNumber
+	if len(suffix) > 4 {
+		suffix = suffix[len(suffix)-4:]
+	}
+
+	return &AccountInquiry{
+		BankCode:      bankCode,
+		AccountNumber: accountNumber,
+		AccountName:   "ACCOUNT HOLDER " + suffix,
+	}, nil
+}
+
+func (f *fakeGateway) Disburse(ctx context.Context, req DisbursementRequest) (*DisbursementResult, error) {
+	if !IsSupportedBank(req.BankCode) {
+		return nil, ErrUnsupportedBank
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Same reference, same disbursement: the gateway is idempotent.
+	if existing, ok := f.disbursements[req.ReferenceNo]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+
+	result := &DisbursementResult{
+		ReferenceNo:      req.ReferenceNo,
+		GatewayReference: fmt.Sprintf("FAKE-%d", time.Now().UnixNano()),
+		Status:           StatusSuccess,
+	}
+
+	switch {
+	case strings.HasSuffix(req.AccountNumber, "9999"):
+		result.Status = StatusFailed
+		result.FailureReason = "rejected by beneficiary bank"
+	case strings.HasSuffix(req.AccountNumber, "8888"):
+		result.Status = StatusPending
+	}
+
+	f.disbursements[req.ReferenceNo] = result
+
+	f.log.WithFields(logrus.Fields{
+		"reference_no":      req.ReferenceNo,
+		"gateway_reference": result.GatewayReference,
+		"bank_code":         req.BankCode,
+		"amount":            req.Amount,
+		"status":            result.Status,
+	}).Info("Fake disbursement created")
+
+	copied := *result
+	return &copied, nil
+}
+
+func (f *fakeGateway) GetDisbursement(ctx context.Context, referenceNo string) (*DisbursementResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result, ok := f.disbursements[referenceNo]
+	if !ok {
+		return nil, ErrDisbursementNotFound
+	}
+
+	if result.Status == StatusPending {
+		result.Status = StatusSuccess
+	}
+
+	copied := *result
+	return &copied, nil
+}
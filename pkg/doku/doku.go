@@ -1,19 +1,22 @@
 package doku
 
 import (
+	"ProjectGolang/pkg/money"
 	"bytes"
 	"crypto/hmac"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/PTNUSASATUINTIARTHA-DOKU/doku-golang-library/controllers"
 	"github.com/PTNUSASATUINTIARTHA-DOKU/doku-golang-library/doku"
 	checkVaModels "github.com/PTNUSASATUINTIARTHA-DOKU/doku-golang-library/models/va/checkVa"
 	createVa "github.com/PTNUSASATUINTIARTHA-DOKU/doku-golang-library/models/va/createVa"
-	"github.com/PTNUSASATUINTIARTHA-DOKU/doku-golang-library/models/va/notification/payment"
+	updateVa "github.com/PTNUSASATUINTIARTHA-DOKU/doku-golang-library/models/va/updateVa"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/http"
@@ -23,23 +26,60 @@ import (
 	"time"
 )
 
+// ErrPaymentDeclined is returned when DOKU rejects a payment outright, as
+// opposed to a failure where the outcome is unknown.
+var ErrPaymentDeclined = errors.New("payment declined")
+
+// ErrQRISRejected is returned when DOKU answers a decode request but refuses
+// the code, as opposed to DOKU being unreachable.
+var ErrQRISRejected = errors.New("QRIS code rejected")
+
+var ErrUnsupportedMode = errors.New("unsupported DOKU mode")
+
 type IDokuService interface {
 	Init() error
 	CreateVirtualAccount(req CreateVaRequest) (*CreateVaResponse, error)
-	ValidateCallback(token string, notification payment.PaymentNotificationRequestBodyDTO) (payment.PaymentNotificationResponseBodyDTO, error)
-	CheckVAStatus(vaNumber string, customerNo string, partnerServiceId string, trxId string) (bool, error)
+	VerifyNotification(notification Notification) error
+	CheckVAStatus(vaNumber string, customerNo string, partnerServiceId string, trxId string) (*VAStatus, error)
 	DecodeQRIS(qrContent string) (*DecodeQRISResponse, error)
-	PaymentQRIS(qrContent string, transactionAmount, feeAmount float64, authCode string) (*PaymentQRISResponse, error)
+	PaymentQRIS(partnerReferenceNo string, qrContent string, transactionAmount, feeAmount money.Amount, authCode string) (*PaymentQRISResponse, error)
+	QueryQRISPayment(partnerReferenceNo string) (*QRISPaymentStatus, error)
 }
 
 type dokuService struct {
-	client *doku.Snap
-	log    *logrus.Logger
+	client    *doku.Snap
+	log       *logrus.Logger
+	publicKey *rsa.PublicKey
+}
+
+// New returns the gateway selected by DOKU_MODE: "live", the default, talks
+// to DOKU and "simulator" keeps everything in memory. The simulator is
+// refused in production.
+func New(log *logrus.Logger) (IDokuService, error) {
+	mode := strings.ToLower(os.Getenv("DOKU_MODE"))
+
+	switch mode {
+	case "", "live":
+		return NewDokuService(log), nil
+	case "simulator":
+		if os.Getenv("PRODUCTION") == "true" {
+			return nil, fmt.Errorf("%w: simulator is not allowed in production", ErrUnsupportedMode)
+		}
+		return newSimulatorFromEnv(log)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMode, mode)
+	}
 }
 
 func NewDokuService(log *logrus.Logger) IDokuService {
+	publicKey, err := ParseRSAPublicKey(os.Getenv("DOKU_PUBLIC_KEY"))
+	if err != nil {
+		log.WithError(err).Warn("DOKU public key unavailable, payment notifications will be rejected")
+	}
+
 	return &dokuService{
-		log: log,
+		log:       log,
+		publicKey: publicKey,
 	}
 }
 
@@ -96,12 +136,14 @@ func (d *dokuService) Init() error {
 }
 
 func (d *dokuService) CreateVirtualAccount(req CreateVaRequest) (*CreateVaResponse, error) {
-	amountStr := fmt.Sprintf("%.2f", req.Amount)
+	amountStr := req.Amount.String()
 
-	customerNo := "3"
-
-	partnerServiceId := "   84923"
+	if req.PartnerServiceId == "" || req.CustomerNo == "" {
+		return nil, fmt.Errorf("failed to create virtual account: missing virtual account number")
+	}
 
+	partnerServiceId := req.PartnerServiceId
+	customerNo := req.CustomerNo
 	virtualAccountNo := partnerServiceId + customerNo
 
 	loc, _ := time.LoadLocation("Asia/Jakarta")
@@ -137,6 +179,12 @@ func (d *dokuService) CreateVirtualAccount(req CreateVaRequest) (*CreateVaRespon
 		return nil, err
 	}
 
+	// A reusable account keeps its number across bills, so every top-up
+	// after the first finds it already registered.
+	if req.ReusableStatus && strings.HasPrefix(response.ResponseCode, "409") {
+		return d.updateVirtualAccount(req, createVaRequest)
+	}
+
 	if response.ResponseCode != "2002500" && response.ResponseCode != "2002700" {
 		d.log.WithFields(logrus.Fields{
 			"response_code":    response.ResponseCode,
@@ -159,17 +207,53 @@ func (d *dokuService) CreateVirtualAccount(req CreateVaRequest) (*CreateVaRespon
 	}, nil
 }
 
-func (d *dokuService) ValidateCallback(token string, notification payment.PaymentNotificationRequestBodyDTO) (payment.PaymentNotificationResponseBodyDTO, error) {
-	response, err := d.client.ValidateTokenAndGenerateNotificationResponse(token, notification)
+// updateVirtualAccount replaces the open bill on an existing reusable
+// virtual account with the one in createVaRequest.
+func (d *dokuService) updateVirtualAccount(req CreateVaRequest, createVaRequest createVa.CreateVaRequestDto) (*CreateVaResponse, error) {
+	updateVaRequest := updateVa.UpdateVaDTO{
+		PartnerServiceId:    createVaRequest.PartnerServiceId,
+		CustomerNo:          createVaRequest.CustomerNo,
+		VirtualAccountNo:    createVaRequest.VirtualAccountNo,
+		VirtualAccountName:  createVaRequest.VirtualAccountName,
+		VirtualAccountEmail: createVaRequest.VirtualAccountEmail,
+		VirtualAccountPhone: createVaRequest.VirtualAccountPhone,
+		TrxId:               createVaRequest.TrxId,
+		TotalAmount:         createVaRequest.TotalAmount,
+		AdditionalInfo: updateVa.UpdateVaAdditionalInfoDTO{
+			Channel: req.Bank,
+			VirtualAccountConfig: updateVa.UpdateVaVirtualAccountConfigDTO{
+				ReusableStatus: true,
+				Status:         "ACTIVE",
+			},
+		},
+		VirtualAccountTrxType: createVaRequest.VirtualAccountTrxType,
+		ExpiredDate:           createVaRequest.ExpiredDate,
+	}
+
+	response, err := d.client.UpdateVa(updateVaRequest)
 	if err != nil {
-		d.log.WithError(err).Error("Failed to validate callback")
-		return payment.PaymentNotificationResponseBodyDTO{}, err
+		d.log.WithError(err).Error("Failed to update virtual account")
+		return nil, err
+	}
+
+	if response.ResponseCode != "2002800" {
+		d.log.WithFields(logrus.Fields{
+			"response_code":    response.ResponseCode,
+			"response_message": response.ResponseMessage,
+		}).Error("Failed to update virtual account")
+		return nil, fmt.Errorf("failed to update virtual account: %s", response.ResponseMessage)
 	}
 
-	return response, nil
+	return &CreateVaResponse{
+		VirtualAccountNo: createVaRequest.VirtualAccountNo,
+		Bank:             req.Bank,
+		Amount:           req.Amount,
+		TransactionID:    req.TrxId,
+		ExpiryDate:       createVaRequest.ExpiredDate,
+	}, nil
 }
 
-func (d *dokuService) CheckVAStatus(vaNumber string, customerNo string, partnerServiceId string, trxId string) (bool, error) {
+func (d *dokuService) CheckVAStatus(vaNumber string, customerNo string, partnerServiceId string, trxId string) (*VAStatus, error) {
 	checkStatusRequest := checkVaModels.CheckStatusVARequestDto{
 		PartnerServiceId: partnerServiceId,
 		CustomerNo:       customerNo,
@@ -179,16 +263,40 @@ func (d *dokuService) CheckVAStatus(vaNumber string, customerNo string, partnerS
 	response, err := d.client.CheckStatusVa(checkStatusRequest)
 	if err != nil {
 		d.log.WithError(err).Error("Failed to check VA status")
-		return false, err
+		return nil, err
 	}
 
+	status := &VAStatus{
+		ResponseCode:    response.ResponseCode,
+		ResponseMessage: response.ResponseMessage,
+	}
+
+	// A reusable virtual account reports its latest bill. When that is not
+	// trxId, the payment on it belongs to another top-up.
 	if (response.ResponseCode == "2002600" || response.ResponseCode == "2002400") && response.VirtualAccountData != nil {
-		if response.VirtualAccountData.PaidAmount.Value != "0.00" {
-			return true, nil
+		data := response.VirtualAccountData
+		if trxId != "" && data.TrxId != "" && data.TrxId != trxId {
+			return status, nil
+		}
+
+		status.TrxID = data.TrxId
+		if data.PaymentRequestId != nil {
+			status.PaymentRequestID = *data.PaymentRequestId
+		}
+
+		if value := data.PaidAmount.Value; value != "" {
+			paidAmount, err := money.Parse(value)
+			if err != nil {
+				d.log.WithError(err).Error("Failed to parse VA paid amount")
+				return nil, err
+			}
+
+			status.PaidAmount = paidAmount
+			status.Paid = paidAmount.IsPositive()
 		}
 	}
 
-	return false, nil
+	return status, nil
 }
 
 func (d *dokuService) DecodeQRIS(qrContent string) (*DecodeQRISResponse, error) {
@@ -262,23 +370,28 @@ func (d *dokuService) DecodeQRIS(qrContent string) (*DecodeQRISResponse, error)
 	}
 
 	if response.ResponseCode != "2004800" {
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrQRISRejected, response.ResponseMessage)
+		}
 		return nil, fmt.Errorf("decode QRIS failed: %s", response.ResponseMessage)
 	}
 
 	return &response, nil
 }
 
-func (d *dokuService) PaymentQRIS(qrContent string, transactionAmount, feeAmount float64, authCode string) (*PaymentQRISResponse, error) {
-	partnerReferenceNo := fmt.Sprintf("PAY%d", time.Now().Unix())
-
+// PaymentQRIS charges a QRIS payment. partnerReferenceNo must be unique per
+// payment; it is what QueryQRISPayment uses to find the payment again when
+// the outcome of this call is unknown. A response that rejects the payment
+// is returned as ErrPaymentDeclined, any other error leaves the outcome open.
+func (d *dokuService) PaymentQRIS(partnerReferenceNo string, qrContent string, transactionAmount, feeAmount money.Amount, authCode string) (*PaymentQRISResponse, error) {
 	request := PaymentQRISRequest{
 		PartnerReferenceNo: partnerReferenceNo,
 		Amount: Amount{
-			Value:    json.Number(fmt.Sprintf("%.2f", transactionAmount)),
+			Value:    json.Number(transactionAmount.String()),
 			Currency: "IDR",
 		},
 		FeeAmount: Amount{
-			Value:    json.Number(fmt.Sprintf("%.2f", feeAmount)),
+			Value:    json.Number(feeAmount.String()),
 			Currency: "IDR",
 		},
 		AdditionalInfo: PaymentQRISAdditionalInfo{
@@ -361,12 +474,103 @@ func (d *dokuService) PaymentQRIS(qrContent string, transactionAmount, feeAmount
 	if response.ResponseCode != "2005500" {
 		d.log.Warn(fmt.Sprintf("[response_code:%s] [response_message:%s] Unexpected response code for QRIS payment",
 			response.ResponseCode, response.ResponseMessage))
+		if strings.HasPrefix(response.ResponseCode, "4") {
+			return nil, fmt.Errorf("%w: %s", ErrPaymentDeclined, response.ResponseMessage)
+		}
 		return nil, fmt.Errorf("payment QRIS failed: %s", response.ResponseMessage)
 	}
 
 	return &response, nil
 }
 
+// QueryQRISPayment looks up a QRIS payment by the partner reference number it
+// was created with.
+func (d *dokuService) QueryQRISPayment(partnerReferenceNo string) (*QRISPaymentStatus, error) {
+	request := QueryQRISRequest{
+		OriginalPartnerReferenceNo: partnerReferenceNo,
+		ServiceCode:                "47",
+	}
+
+	tokenB2B := d.client.GetTokenB2B()
+	if tokenB2B.ResponseCode != "2007300" {
+		return nil, fmt.Errorf("failed to get B2B token: %s", tokenB2B.ResponseMessage)
+	}
+
+	timestamp := time.Now().Format("2006-01-02T15:04:05-07:00")
+	externalId := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	reqBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	endpointUrl := "/snap-adapter/b2b/v1.0/qr/qr-mpm-query"
+	signature := generateSignature("POST", endpointUrl, tokenB2B.AccessToken, reqBody, timestamp, d.client.SecretKey)
+
+	var url string
+	if d.client.IsProduction {
+		url = "https://api.doku.com"
+	} else {
+		url = "https://api-sandbox.doku.com"
+	}
+	url += endpointUrl
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-PARTNER-ID", d.client.ClientId)
+	req.Header.Set("X-TIMESTAMP", timestamp)
+	req.Header.Set("X-EXTERNAL-ID", externalId)
+	req.Header.Set("X-SIGNATURE", signature)
+	req.Header.Set("Authorization", "Bearer "+tokenB2B.AccessToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	d.log.WithFields(logrus.Fields{
+		"response_raw": string(respBody),
+	}).Debug("QRIS query raw response")
+
+	var response QueryQRISResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+
+	status := &QRISPaymentStatus{
+		ReferenceNo:     response.OriginalReferenceNo,
+		TransactionDate: response.PaidTime,
+		ResponseCode:    response.ResponseCode,
+	}
+
+	switch {
+	case response.ResponseCode == "4045101":
+		status.Status = QRISPaymentNotPaid
+	case response.ResponseCode != "2005100":
+		return nil, fmt.Errorf("query QRIS failed: %s", response.ResponseMessage)
+	case response.LatestTransactionStatus == "00":
+		status.Status = QRISPaymentPaid
+	case response.LatestTransactionStatus == "04" || response.LatestTransactionStatus == "05" ||
+		response.LatestTransactionStatus == "06" || response.LatestTransactionStatus == "07":
+		status.Status = QRISPaymentNotPaid
+	default:
+		status.Status = QRISPaymentPending
+	}
+
+	return status, nil
+}
+
 func generateSignature(httpMethod, endpointUrl, accessToken string, minifiedRequestBody []byte, timestamp, secretKey string) string {
 
 	hash := sha256.New()
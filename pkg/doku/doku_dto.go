@@ -1,6 +1,7 @@
 package doku
 
 import (
+	"ProjectGolang/pkg/money"
 	"encoding/json"
 	"time"
 )
@@ -54,6 +55,37 @@ type PaymentQRISResponse struct {
 	AdditionalInfo     PaymentQRISResponseAdditional `json:"additionalInfo"`
 }
 
+type QueryQRISRequest struct {
+	OriginalPartnerReferenceNo string `json:"originalPartnerReferenceNo"`
+	ServiceCode                string `json:"serviceCode"`
+}
+
+type QueryQRISResponse struct {
+	ResponseCode               string `json:"responseCode"`
+	ResponseMessage            string `json:"responseMessage"`
+	OriginalReferenceNo        string `json:"originalReferenceNo"`
+	OriginalPartnerReferenceNo string `json:"originalPartnerReferenceNo"`
+	LatestTransactionStatus    string `json:"latestTransactionStatus"`
+	TransactionStatusDesc      string `json:"transactionStatusDesc"`
+	PaidTime                   string `json:"paidTime"`
+	Amount                     Amount `json:"amount"`
+}
+
+const (
+	QRISPaymentPaid    = "paid"
+	QRISPaymentNotPaid = "not_paid"
+	QRISPaymentPending = "pending"
+)
+
+// QRISPaymentStatus is the outcome of a QRIS payment as reported by DOKU.
+// Status is one of QRISPaymentPaid, QRISPaymentNotPaid or QRISPaymentPending.
+type QRISPaymentStatus struct {
+	Status          string
+	ReferenceNo     string
+	TransactionDate string
+	ResponseCode    string
+}
+
 type Amount struct {
 	Value    json.Number `json:"value"`
 	Currency string      `json:"currency"`
@@ -92,30 +124,48 @@ type PaymentQRISResponseAdditional struct {
 	AcquirerName               string `json:"acquirerName"`
 }
 
+// CreateVaRequest bills Amount to the virtual account PartnerServiceId plus
+// CustomerNo. A reusable account that already exists is updated with the new
+// bill instead.
 type CreateVaRequest struct {
-	UserID          string
-	Name            string
-	Email           string
-	Phone           string
-	Amount          float64
-	TrxId           string
-	Bank            string
-	ExpiredDuration time.Duration
-	ReusableStatus  bool
+	UserID           string
+	PartnerServiceId string
+	CustomerNo       string
+	Name             string
+	Email            string
+	Phone            string
+	Amount           money.Amount
+	TrxId            string
+	Bank             string
+	ExpiredDuration  time.Duration
+	ReusableStatus   bool
 }
 
 type CreateVaResponse struct {
 	VirtualAccountNo  string
 	Bank              string
-	Amount            float64
+	Amount            money.Amount
 	TransactionID     string
 	ExpiryDate        string
 	VirtualAccountURL string
 }
 
+// VAStatus is the state of a virtual account as reported by DOKU's
+// check-status API. PaidAmount is zero until the customer has paid.
+// VAStatus is the state of one bill on a virtual account. PaymentRequestID
+// is DOKU's ID of the payment that paid it.
+type VAStatus struct {
+	Paid             bool
+	PaidAmount       money.Amount
+	TrxID            string
+	PaymentRequestID string
+	ResponseCode     string
+	ResponseMessage  string
+}
+
 type QRISPaymentRequest struct {
 	PartnerReferenceNo string                 `json:"partnerReferenceNo"`
-	Amount             float64                `json:"amount"`
+	Amount             money.Amount           `json:"amount"`
 	PaymentType        string                 `json:"paymentType"`
 	AdditionalInfo     map[string]interface{} `json:"additionalInfo"`
 }
@@ -0,0 +1,198 @@
+package doku
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NotificationTimestampWindow is how far X-TIMESTAMP of a notification may
+// drift from our clock before the notification is rejected.
+const NotificationTimestampWindow = 5 * time.Minute
+
+var (
+	ErrPublicKeyNotConfigured = errors.New("doku public key is not configured")
+	ErrInvalidSignature       = errors.New("invalid notification signature")
+	ErrInvalidTimestamp       = errors.New("invalid notification timestamp")
+	ErrStaleTimestamp         = errors.New("notification timestamp outside allowed window")
+)
+
+// Notification is the raw HTTP request DOKU sends to our callback endpoint.
+// Body must be the exact bytes received; it is minified before hashing.
+type Notification struct {
+	HTTPMethod  string
+	EndpointURL string
+	Body        []byte
+	Timestamp   string
+	Signature   string
+}
+
+// VAPaymentNotification is the body of a SNAP virtual account payment
+// notification, as DOKU posts it to the merchant.
+type VAPaymentNotification struct {
+	PartnerServiceId    string                        `json:"partnerServiceId"`
+	CustomerNo          string                        `json:"customerNo"`
+	VirtualAccountNo    string                        `json:"virtualAccountNo"`
+	VirtualAccountName  string                        `json:"virtualAccountName"`
+	VirtualAccountEmail string                        `json:"virtualAccountEmail"`
+	VirtualAccountPhone string                        `json:"virtualAccountPhone"`
+	TrxId               string                        `json:"trxId"`
+	PaymentRequestId    string                        `json:"paymentRequestId"`
+	PaidAmount          NotificationAmount            `json:"paidAmount"`
+	TotalAmount         NotificationAmount            `json:"totalAmount"`
+	TrxDateTime         string                        `json:"trxDateTime"`
+	AdditionalInfo      VAPaymentNotificationAddition `json:"additionalInfo"`
+}
+
+// NotificationAmount carries the value as a string, as DOKU sends it in
+// notifications.
+type NotificationAmount struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+type VAPaymentNotificationAddition struct {
+	Channel string `json:"channel"`
+}
+
+func (d *dokuService) VerifyNotification(notification Notification) error {
+	if d.publicKey == nil {
+		return ErrPublicKeyNotConfigured
+	}
+
+	if err := CheckNotificationTimestamp(notification.Timestamp, time.Now(), NotificationTimestampWindow); err != nil {
+		d.log.WithError(err).WithField("timestamp", notification.Timestamp).Warn("Rejected DOKU notification timestamp")
+		return err
+	}
+
+	if err := VerifyNotificationSignature(d.publicKey, notification); err != nil {
+		d.log.WithError(err).WithField("endpoint_url", notification.EndpointURL).Warn("Rejected DOKU notification signature")
+		return err
+	}
+
+	return nil
+}
+
+// VerifyNotificationSignature checks the SNAP asymmetric signature
+// SHA256withRSA(method:endpoint:lower(hex(sha256(minify(body)))):timestamp).
+func VerifyNotificationSignature(publicKey *rsa.PublicKey, notification Notification) error {
+	if publicKey == nil {
+		return ErrPublicKeyNotConfigured
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(notification.Signature))
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+
+	digest, err := notificationDigest(notification)
+	if err != nil {
+		return err
+	}
+
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest, signature); err != nil {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// SignNotification produces the X-SIGNATURE header DOKU would send for the
+// notification. It lets local tools and simulators emit notifications that
+// pass VerifyNotificationSignature.
+func SignNotification(privateKey *rsa.PrivateKey, notification Notification) (string, error) {
+	digest, err := notificationDigest(notification)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func CheckNotificationTimestamp(timestamp string, now time.Time, window time.Duration) error {
+	parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(timestamp))
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	drift := now.Sub(parsed)
+	if drift < 0 {
+		drift = -drift
+	}
+
+	if drift > window {
+		return ErrStaleTimestamp
+	}
+
+	return nil
+}
+
+// ParseRSAPublicKey accepts a PEM block or the bare base64 DER that DOKU
+// shows in its dashboard.
+func ParseRSAPublicKey(key string) (*rsa.PublicKey, error) {
+	key = strings.TrimSpace(strings.ReplaceAll(key, `\n`, "\n"))
+	if key == "" {
+		return nil, ErrPublicKeyNotConfigured
+	}
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key encoding: %v", err)
+		}
+		der = decoded
+	}
+
+	if parsed, err := x509.ParsePKIXPublicKey(der); err == nil {
+		publicKey, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is not an RSA key")
+		}
+		return publicKey, nil
+	}
+
+	publicKey, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RSA public key: %v", err)
+	}
+
+	return publicKey, nil
+}
+
+func notificationDigest(notification Notification) ([]byte, error) {
+	var minified bytes.Buffer
+	if len(notification.Body) > 0 {
+		if err := json.Compact(&minified, notification.Body); err != nil {
+			return nil, fmt.Errorf("invalid notification body: %v", err)
+		}
+	}
+
+	bodyHash := sha256.Sum256(minified.Bytes())
+
+	stringToSign := strings.ToUpper(notification.HTTPMethod) + ":" +
+		notification.EndpointURL + ":" +
+		strings.ToLower(hex.EncodeToString(bodyHash[:])) + ":" +
+		notification.Timestamp
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	return digest[:], nil
+}
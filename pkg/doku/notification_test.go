@@ -0,0 +1,247 @@
+package doku
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"io"
+	"testing"
+	"time"
+)
+
+const testNotificationBody = `{
+	"partnerServiceId": "   84923",
+	"customerNo": "000000000001",
+	"virtualAccountNo": "   84923000000000001",
+	"trxId": "TOP-01J0000000000000000000000",
+	"paymentRequestId": "SIM1",
+	"paidAmount": {"value": "150000.00", "currency": "IDR"}
+}`
+
+func newTestLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return log
+}
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// signedNotification returns a notification signed with key at timestamp.
+func signedNotification(t *testing.T, key *rsa.PrivateKey, timestamp time.Time) Notification {
+	t.Helper()
+
+	notification := Notification{
+		HTTPMethod:  "POST",
+		EndpointURL: "/api/v1/wallet/callback",
+		Body:        []byte(testNotificationBody),
+		Timestamp:   timestamp.Format(time.RFC3339),
+	}
+
+	signature, err := SignNotification(key, notification)
+	if err != nil {
+		t.Fatalf("sign notification: %v", err)
+	}
+	notification.Signature = signature
+
+	return notification
+}
+
+func TestVerifyNotificationSignature(t *testing.T) {
+	key := newTestKey(t)
+	otherKey := newTestKey(t)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		key    *rsa.PublicKey
+		modify func(*Notification)
+		want   error
+	}{
+		{
+			name: "valid notification",
+			key:  &key.PublicKey,
+			want: nil,
+		},
+		{
+			name: "body reformatted without changing content",
+			key:  &key.PublicKey,
+			modify: func(n *Notification) {
+				n.Body = []byte(`{"partnerServiceId":"   84923","customerNo":"000000000001","virtualAccountNo":"   84923000000000001","trxId":"TOP-01J0000000000000000000000","paymentRequestId":"SIM1","paidAmount":{"value":"150000.00","currency":"IDR"}}`)
+			},
+			want: nil,
+		},
+		{
+			name: "lowercase method",
+			key:  &key.PublicKey,
+			modify: func(n *Notification) {
+				n.HTTPMethod = "post"
+			},
+			want: nil,
+		},
+		{
+			name: "tampered body",
+			key:  &key.PublicKey,
+			modify: func(n *Notification) {
+				n.Body = []byte(`{"trxId": "TOP-01J0000000000000000000000", "paidAmount": {"value": "9150000.00", "currency": "IDR"}}`)
+			},
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "different endpoint",
+			key:  &key.PublicKey,
+			modify: func(n *Notification) {
+				n.EndpointURL = "/api/v1/wallet/callback/doku"
+			},
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "timestamp changed after signing",
+			key:  &key.PublicKey,
+			modify: func(n *Notification) {
+				n.Timestamp = now.Add(time.Second).Format(time.RFC3339)
+			},
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "signed with another key",
+			key:  &otherKey.PublicKey,
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "signature is not base64",
+			key:  &key.PublicKey,
+			modify: func(n *Notification) {
+				n.Signature = "not a signature!"
+			},
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "missing signature",
+			key:  &key.PublicKey,
+			modify: func(n *Notification) {
+				n.Signature = ""
+			},
+			want: ErrInvalidSignature,
+		},
+		{
+			name: "no public key",
+			want: ErrPublicKeyNotConfigured,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := signedNotification(t, key, now)
+			if tt.modify != nil {
+				tt.modify(&notification)
+			}
+
+			err := VerifyNotificationSignature(tt.key, notification)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("VerifyNotificationSignature error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNotificationTimestamp(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		want      error
+	}{
+		{name: "now", timestamp: now.Format(time.RFC3339), want: nil},
+		{name: "same instant in Jakarta time", timestamp: now.In(jakarta).Format(time.RFC3339), want: nil},
+		{name: "at the edge of the window", timestamp: now.Add(-NotificationTimestampWindow).Format(time.RFC3339), want: nil},
+		{name: "ahead within the window", timestamp: now.Add(4 * time.Minute).Format(time.RFC3339), want: nil},
+		{name: "older than the window", timestamp: now.Add(-NotificationTimestampWindow - time.Second).Format(time.RFC3339), want: ErrStaleTimestamp},
+		{name: "ahead of the window", timestamp: now.Add(NotificationTimestampWindow + time.Second).Format(time.RFC3339), want: ErrStaleTimestamp},
+		{name: "a day old", timestamp: now.Add(-24 * time.Hour).Format(time.RFC3339), want: ErrStaleTimestamp},
+		{name: "not RFC 3339", timestamp: "2026-03-10 14:30:00", want: ErrInvalidTimestamp},
+		{name: "missing", timestamp: "", want: ErrInvalidTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckNotificationTimestamp(tt.timestamp, now, NotificationTimestampWindow)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("CheckNotificationTimestamp(%q) error = %v, want %v", tt.timestamp, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyNotification(t *testing.T) {
+	key := newTestKey(t)
+	service := &dokuService{log: newTestLogger(), publicKey: &key.PublicKey}
+
+	if err := service.VerifyNotification(signedNotification(t, key, time.Now())); err != nil {
+		t.Fatalf("fresh notification: unexpected error %v", err)
+	}
+
+	// A replayed notification keeps its valid signature but not its age.
+	stale := signedNotification(t, key, time.Now().Add(-NotificationTimestampWindow-time.Minute))
+	if err := service.VerifyNotification(stale); !errors.Is(err, ErrStaleTimestamp) {
+		t.Fatalf("stale notification: error = %v, want %v", err, ErrStaleTimestamp)
+	}
+
+	unsigned := signedNotification(t, newTestKey(t), time.Now())
+	if err := service.VerifyNotification(unsigned); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("foreign signature: error = %v, want %v", err, ErrInvalidSignature)
+	}
+
+	unconfigured := &dokuService{log: newTestLogger()}
+	if err := unconfigured.VerifyNotification(signedNotification(t, key, time.Now())); !errors.Is(err, ErrPublicKeyNotConfigured) {
+		t.Fatalf("no public key: error = %v, want %v", err, ErrPublicKeyNotConfigured)
+	}
+}
+
+func TestParseRSAPublicKey(t *testing.T) {
+	key := newTestKey(t)
+
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	pkcs1 := x509.MarshalPKCS1PublicKey(&key.PublicKey)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "PKIX PEM", value: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))},
+		{name: "PKCS1 PEM", value: string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pkcs1}))},
+		{name: "bare base64 from the dashboard", value: base64.StdEncoding.EncodeToString(pkix)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseRSAPublicKey(tt.value)
+			if err != nil {
+				t.Fatalf("ParseRSAPublicKey unexpected error: %v", err)
+			}
+			if !parsed.Equal(&key.PublicKey) {
+				t.Fatal("ParseRSAPublicKey returned a different key")
+			}
+		})
+	}
+
+	if _, err := ParseRSAPublicKey(""); !errors.Is(err, ErrPublicKeyNotConfigured) {
+		t.Fatalf("empty key: error = %v, want %v", err, ErrPublicKeyNotConfigured)
+	}
+}
@@ -0,0 +1,507 @@
+package doku
+
+import (
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/qris"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const simulatorPartnerServiceID = "   84923"
+
+var ErrVirtualAccountNotFound = errors.New("virtual account not found")
+
+// ISimulator is implemented by the local DOKU simulator. Besides the gateway
+// calls it can act as the paying customer, which the real gateway cannot.
+type ISimulator interface {
+	IDokuService
+	PayVirtualAccount(ctx context.Context, trxID string, amount money.Amount) (*SimulatedNotification, error)
+}
+
+// SimulatorConfig configures NewSimulator. CallbackURL is where payment
+// notifications are delivered; PrivateKey signs them and a fresh key is
+// generated when it is nil.
+type SimulatorConfig struct {
+	CallbackURL string
+	PartnerID   string
+	PrivateKey  *rsa.PrivateKey
+	HTTPClient  *http.Client
+}
+
+// SimulatedNotification is what the callback endpoint answered to a
+// notification sent by the simulator.
+type SimulatedNotification struct {
+	TrxID            string
+	VirtualAccountNo string
+	PaidAmount       money.Amount
+	HTTPStatus       int
+	ResponseCode     string
+	ResponseMessage  string
+}
+
+type simulatedVA struct {
+	request          CreateVaRequest
+	virtualAccountNo string
+	partnerServiceID string
+	customerNo       string
+	expiresAt        time.Time
+	paidAmount       money.Amount
+	paymentID        string
+}
+
+type simulatedQRISPayment struct {
+	referenceNo     string
+	status          string
+	transactionDate string
+}
+
+// Simulator is an in-memory stand-in for DOKU for local development and
+// tests. Virtual accounts and QRIS payments live only as long as the
+// process. QRIS payments are driven by the merchant's NMID:
+//
+//	ends in 9999  payment is declined
+//	ends in 8888  payment outcome is unknown until it is queried, then paid
+//
+// Every other code is paid immediately.
+type Simulator struct {
+	log         *logrus.Logger
+	callbackURL string
+	partnerID   string
+	privateKey  *rsa.PrivateKey
+	httpClient  *http.Client
+
+	mu           sync.Mutex
+	sequence     int
+	vas          map[string]*simulatedVA
+	qrisPayments map[string]*simulatedQRISPayment
+}
+
+func NewSimulator(log *logrus.Logger, config SimulatorConfig) (*Simulator, error) {
+	privateKey := config.PrivateKey
+	if privateKey == nil {
+		generated, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate simulator key: %v", err)
+		}
+		privateKey = generated
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Simulator{
+		log:          log,
+		callbackURL:  config.CallbackURL,
+		partnerID:    config.PartnerID,
+		privateKey:   privateKey,
+		httpClient:   httpClient,
+		vas:          make(map[string]*simulatedVA),
+		qrisPayments: make(map[string]*simulatedQRISPayment),
+	}, nil
+}
+
+// newSimulatorFromEnv builds the simulator selected by DOKU_MODE. The
+// callback defaults to this server's own notification endpoint.
+func newSimulatorFromEnv(log *logrus.Logger) (*Simulator, error) {
+	callbackURL := os.Getenv("DOKU_SIMULATOR_CALLBACK_URL")
+	if callbackURL == "" {
+		port := os.Getenv("APP_PORT")
+		if port == "" {
+			port = "8080"
+		}
+		callbackURL = "http://localhost:" + port + "/api/v1/wallet/callback/doku"
+	}
+
+	var privateKey *rsa.PrivateKey
+	if key := os.Getenv("DOKU_SIMULATOR_PRIVATE_KEY"); key != "" {
+		parsed, err := ParseRSAPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		privateKey = parsed
+	}
+
+	return NewSimulator(log, SimulatorConfig{
+		CallbackURL: callbackURL,
+		PartnerID:   os.Getenv("DOKU_CLIENT_ID"),
+		PrivateKey:  privateKey,
+	})
+}
+
+// PublicKey returns the key that verifies the simulator's notifications.
+func (s *Simulator) PublicKey() *rsa.PublicKey {
+	return &s.privateKey.PublicKey
+}
+
+func (s *Simulator) Init() error {
+	s.log.WithFields(logrus.Fields{
+		"callback_url": s.callbackURL,
+	}).Warn("Using DOKU simulator, no payment reaches DOKU")
+	return nil
+}
+
+func (s *Simulator) CreateVirtualAccount(req CreateVaRequest) (*CreateVaResponse, error) {
+	if req.TrxId == "" || !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("failed to create virtual account: invalid request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if va, ok := s.vas[req.TrxId]; ok {
+		return s.vaResponse(va), nil
+	}
+
+	partnerServiceID, customerNo := req.PartnerServiceId, req.CustomerNo
+	if partnerServiceID == "" || customerNo == "" {
+		s.sequence++
+		partnerServiceID = simulatorPartnerServiceID
+		customerNo = fmt.Sprintf("%012d", s.sequence)
+	}
+
+	va := &simulatedVA{
+		request:          req,
+		virtualAccountNo: partnerServiceID + customerNo,
+		partnerServiceID: partnerServiceID,
+		customerNo:       customerNo,
+		expiresAt:        time.Now().Add(req.ExpiredDuration),
+	}
+	s.vas[req.TrxId] = va
+
+	s.log.WithFields(logrus.Fields{
+		"trx_id":         req.TrxId,
+		"virtual_acc_no": va.virtualAccountNo,
+		"amount":         req.Amount,
+	}).Info("Simulated virtual account created")
+
+	return s.vaResponse(va), nil
+}
+
+func (s *Simulator) vaResponse(va *simulatedVA) *CreateVaResponse {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+
+	return &CreateVaResponse{
+		VirtualAccountNo: va.virtualAccountNo,
+		Bank:             va.request.Bank,
+		Amount:           va.request.Amount,
+		TransactionID:    va.request.TrxId,
+		ExpiryDate:       va.expiresAt.In(loc).Format("2006-01-02T15:04:05") + "+07:00",
+	}
+}
+
+func (s *Simulator) VerifyNotification(notification Notification) error {
+	if err := CheckNotificationTimestamp(notification.Timestamp, time.Now(), NotificationTimestampWindow); err != nil {
+		return err
+	}
+
+	return VerifyNotificationSignature(s.PublicKey(), notification)
+}
+
+func (s *Simulator) CheckVAStatus(vaNumber string, customerNo string, partnerServiceId string, trxId string) (*VAStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	va := s.findVA(trxId, vaNumber)
+	if va == nil {
+		return &VAStatus{
+			ResponseCode:    "4042612",
+			ResponseMessage: "Bill not found",
+		}, nil
+	}
+
+	status := &VAStatus{
+		TrxID:           va.request.TrxId,
+		ResponseCode:    "2002600",
+		ResponseMessage: "Successful",
+	}
+
+	if trxId == "" || va.request.TrxId == trxId {
+		status.Paid = va.paidAmount.IsPositive()
+		status.PaidAmount = va.paidAmount
+		status.PaymentRequestID = va.paymentID
+	}
+
+	return status, nil
+}
+
+func (s *Simulator) findVA(trxID, vaNumber string) *simulatedVA {
+	if va, ok := s.vas[trxID]; ok {
+		return va
+	}
+
+	vaNumber = strings.TrimSpace(vaNumber)
+	for _, va := range s.vas {
+		if vaNumber != "" && strings.TrimSpace(va.virtualAccountNo) == vaNumber {
+			return va
+		}
+	}
+
+	return nil
+}
+
+// PayVirtualAccount pays the virtual account created for trxID as a customer
+// would and delivers the signed notification to the callback URL. A zero
+// amount pays the billed amount. The VA is recorded as paid even when the
+// callback rejects the notification, just as DOKU keeps the money.
+func (s *Simulator) PayVirtualAccount(ctx context.Context, trxID string, amount money.Amount) (*SimulatedNotification, error) {
+	s.mu.Lock()
+	va := s.findVA(trxID, "")
+	if va == nil {
+		s.mu.Unlock()
+		return nil, ErrVirtualAccountNotFound
+	}
+	if amount.IsZero() {
+		amount = va.request.Amount
+	}
+	va.paidAmount = amount
+	va.paymentID = fmt.Sprintf("SIM%d", time.Now().UnixNano())
+	request := va.request
+	paymentID := va.paymentID
+	virtualAccountNo := va.virtualAccountNo
+	partnerServiceID := va.partnerServiceID
+	customerNo := va.customerNo
+	s.mu.Unlock()
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+
+	body, err := json.Marshal(VAPaymentNotification{
+		PartnerServiceId:    partnerServiceID,
+		CustomerNo:          customerNo,
+		VirtualAccountNo:    virtualAccountNo,
+		VirtualAccountName:  request.Name,
+		VirtualAccountEmail: request.Email,
+		VirtualAccountPhone: request.Phone,
+		TrxId:               request.TrxId,
+		PaymentRequestId:    paymentID,
+		PaidAmount:          NotificationAmount{Value: amount.String(), Currency: "IDR"},
+		TotalAmount:         NotificationAmount{Value: request.Amount.String(), Currency: "IDR"},
+		TrxDateTime:         time.Now().In(loc).Format("2006-01-02T15:04:05-07:00"),
+		AdditionalInfo:      VAPaymentNotificationAddition{Channel: request.Bank},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %v", err)
+	}
+
+	result, err := s.deliver(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	result.TrxID = request.TrxId
+	result.VirtualAccountNo = virtualAccountNo
+	result.PaidAmount = amount
+
+	s.log.WithFields(logrus.Fields{
+		"trx_id":        request.TrxId,
+		"amount":        amount,
+		"http_status":   result.HTTPStatus,
+		"response_code": result.ResponseCode,
+	}).Info("Simulated virtual account payment delivered")
+
+	return result, nil
+}
+
+// deliver posts a notification body to the callback URL with the headers
+// DOKU sends, signed so that it passes VerifyNotification.
+func (s *Simulator) deliver(ctx context.Context, body []byte) (*SimulatedNotification, error) {
+	if s.callbackURL == "" {
+		return nil, fmt.Errorf("simulator callback URL is not configured")
+	}
+
+	callbackURL, err := url.Parse(s.callbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid simulator callback URL: %v", err)
+	}
+
+	timestamp := time.Now().Format(time.RFC3339)
+	signature, err := SignNotification(s.privateKey, Notification{
+		HTTPMethod:  http.MethodPost,
+		EndpointURL: callbackURL.RequestURI(),
+		Body:        body,
+		Timestamp:   timestamp,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign notification: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-PARTNER-ID", s.partnerID)
+	req.Header.Set("X-TIMESTAMP", timestamp)
+	req.Header.Set("X-EXTERNAL-ID", fmt.Sprintf("%d", time.Now().UnixNano()))
+	req.Header.Set("X-SIGNATURE", signature)
+	req.Header.Set("CHANNEL-ID", "95221")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deliver notification: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var response struct {
+		ResponseCode    string `json:"responseCode"`
+		ResponseMessage string `json:"responseMessage"`
+	}
+	_ = json.Unmarshal(respBody, &response)
+
+	return &SimulatedNotification{
+		HTTPStatus:      resp.StatusCode,
+		ResponseCode:    response.ResponseCode,
+		ResponseMessage: response.ResponseMessage,
+	}, nil
+}
+
+// DecodeQRIS parses the code locally instead of asking DOKU, so any payload
+// qris.Parse accepts, including the ones pkg/receiving issues, can be paid.
+func (s *Simulator) DecodeQRIS(qrContent string) (*DecodeQRISResponse, error) {
+	code, err := qris.Parse(qrContent)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrQRISRejected, err)
+	}
+
+	fee := code.Fee(code.Amount)
+
+	return &DecodeQRISResponse{
+		ResponseCode:       "2004800",
+		ResponseMessage:    "Successful",
+		ReferenceNo:        fmt.Sprintf("SIMQR%d", time.Now().UnixNano()),
+		PartnerReferenceNo: fmt.Sprintf("QRIS%d", time.Now().Unix()),
+		MerchantName:       code.MerchantName,
+		TransactionAmount:  Amount{Value: json.Number(code.Amount.String()), Currency: "IDR"},
+		FeeAmount:          Amount{Value: json.Number(fee.String()), Currency: "IDR"},
+		AdditionalInfo: AdditionalInfo{
+			PointOfInitiationMethod: code.PointOfInitiation,
+			FeeType:                 code.TipIndicator,
+		},
+	}, nil
+}
+
+func (s *Simulator) PaymentQRIS(partnerReferenceNo string, qrContent string, transactionAmount, feeAmount money.Amount, authCode string) (*PaymentQRISResponse, error) {
+	code, err := qris.Parse(qrContent)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, ok := s.qrisPayments[partnerReferenceNo]
+	if !ok {
+		payment = &simulatedQRISPayment{
+			referenceNo:     fmt.Sprintf("SIMQP%d", time.Now().UnixNano()),
+			status:          QRISPaymentPaid,
+			transactionDate: time.Now().Format("2006-01-02T15:04:05-07:00"),
+		}
+
+		switch {
+		case strings.HasSuffix(code.NMID, "9999"):
+			payment.status = QRISPaymentNotPaid
+		case strings.HasSuffix(code.NMID, "8888"):
+			payment.status = QRISPaymentPending
+		}
+
+		s.qrisPayments[partnerReferenceNo] = payment
+
+		s.log.WithFields(logrus.Fields{
+			"partner_reference_no": partnerReferenceNo,
+			"nmid":                 code.NMID,
+			"amount":               transactionAmount,
+			"status":               payment.status,
+		}).Info("Simulated QRIS payment")
+	}
+
+	switch payment.status {
+	case QRISPaymentNotPaid:
+		return nil, fmt.Errorf("%w: simulated decline", ErrPaymentDeclined)
+	case QRISPaymentPending:
+		return nil, fmt.Errorf("payment QRIS failed: simulated timeout")
+	}
+
+	return &PaymentQRISResponse{
+		ResponseCode:       "2005500",
+		ResponseMessage:    "Successful",
+		ReferenceNo:        payment.referenceNo,
+		PartnerReferenceNo: partnerReferenceNo,
+		TransactionDate:    payment.transactionDate,
+		Original: OriginalQRISTransaction{
+			ReferenceNo: payment.referenceNo,
+			Amount:      Amount{Value: json.Number(transactionAmount.String()), Currency: "IDR"},
+			FeeAmount:   Amount{Value: json.Number(feeAmount.String()), Currency: "IDR"},
+		},
+	}, nil
+}
+
+func (s *Simulator) QueryQRISPayment(partnerReferenceNo string) (*QRISPaymentStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, ok := s.qrisPayments[partnerReferenceNo]
+	if !ok {
+		return &QRISPaymentStatus{
+			Status:       QRISPaymentNotPaid,
+			ResponseCode: "4045101",
+		}, nil
+	}
+
+	if payment.status == QRISPaymentPending {
+		payment.status = QRISPaymentPaid
+	}
+
+	return &QRISPaymentStatus{
+		Status:          payment.status,
+		ReferenceNo:     payment.referenceNo,
+		TransactionDate: payment.transactionDate,
+		ResponseCode:    "2005100",
+	}, nil
+}
+
+// ParseRSAPrivateKey parses a PEM encoded PKCS#8 or PKCS#1 RSA key. Escaped
+// newlines are accepted, so the key can be kept on one line in the
+// environment.
+func ParseRSAPrivateKey(key string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(strings.ReplaceAll(key, `\n`, "\n"))))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key format")
+	}
+
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		privateKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is not an RSA key")
+		}
+		return privateKey, nil
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RSA private key: %v", err)
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"ProjectGolang/pkg/redis"
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	channelPrefix = "user-events:"
+
+	// subscriberBuffer is how many events a slow subscriber may fall behind
+	// before further events are dropped for it.
+	subscriberBuffer = 32
+)
+
+// Event is one message on a user's event stream.
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+	At   time.Time       `json:"at"`
+}
+
+// IHub fans events out to the users they belong to. Events go through Redis
+// pub/sub, so a subscriber receives events published by any instance.
+// Delivery is best effort: events published while a subscriber is not
+// connected are not kept.
+type IHub interface {
+	Publish(ctx context.Context, userID, eventType string, data interface{}) error
+	Subscribe(userID string) *Subscription
+}
+
+// Subscription receives a user's events on C until it is closed.
+type Subscription struct {
+	C <-chan Event
+
+	hub    *hub
+	userID string
+	events chan Event
+	once   sync.Once
+}
+
+// Close stops delivery and releases the subscription.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.remove(s)
+	})
+}
+
+type hub struct {
+	log         *logrus.Logger
+	redis       redis.IRedis
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+	listen      sync.Once
+}
+
+func New(log *logrus.Logger, redisClient redis.IRedis) IHub {
+	return &hub{
+		log:         log,
+		redis:       redisClient,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func (h *hub) Publish(ctx context.Context, userID, eventType string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(Event{
+		Type: eventType,
+		Data: encoded,
+		At:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.redis.Publish(ctx, channelPrefix+userID, string(payload))
+}
+
+// Subscribe starts receiving userID's events. The instance subscribes to
+// Redis once, with the first subscriber, and serves every later one from
+// that subscription.
+func (h *hub) Subscribe(userID string) *Subscription {
+	h.listen.Do(func() {
+		go h.run(context.Background())
+	})
+
+	events := make(chan Event, subscriberBuffer)
+	subscription := &Subscription{
+		C:      events,
+		hub:    h,
+		userID: userID,
+		events: events,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+func (h *hub) remove(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers[subscription.userID], subscription)
+	if len(h.subscribers[subscription.userID]) == 0 {
+		delete(h.subscribers, subscription.userID)
+	}
+	close(subscription.events)
+}
+
+func (h *hub) run(ctx context.Context) {
+	for message := range h.redis.PSubscribe(ctx, channelPrefix+"*") {
+		userID := strings.TrimPrefix(message.Channel, channelPrefix)
+
+		var event Event
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			h.log.WithFields(logrus.Fields{
+				"channel": message.Channel,
+				"error":   err.Error(),
+			}).Warn("Dropping malformed event")
+			continue
+		}
+
+		h.dispatch(userID, event)
+	}
+}
+
+func (h *hub) dispatch(userID string, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscribers[userID] {
+		select {
+		case subscription.events <- event:
+		default:
+			h.log.WithFields(logrus.Fields{
+				"user_id": userID,
+				"type":    event.Type,
+			}).Warn("Dropping event for slow subscriber")
+		}
+	}
+}
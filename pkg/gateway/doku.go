@@ -0,0 +1,250 @@
+package gateway
+
+import (
+	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/money"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type dokuGateway struct {
+	client    doku.IDokuService
+	partnerID string
+}
+
+// NewDoku adapts a DOKU client. partnerID is the client ID DOKU sends in
+// X-PARTNER-ID; notifications from any other partner are rejected when it
+// is set. A simulator client yields a gateway that implements ISimulator.
+func NewDoku(client doku.IDokuService, partnerID string) PaymentGateway {
+	gateway := &dokuGateway{
+		client:    client,
+		partnerID: partnerID,
+	}
+
+	if simulator, ok := client.(doku.ISimulator); ok {
+		return &dokuSimulatorGateway{dokuGateway: gateway, simulator: simulator}
+	}
+
+	return gateway
+}
+
+func (g *dokuGateway) Name() string {
+	return ProviderDOKU
+}
+
+func (g *dokuGateway) Init() error {
+	return g.client.Init()
+}
+
+func (g *dokuGateway) CreateVirtualAccount(ctx context.Context, req VirtualAccountRequest) (*VirtualAccount, error) {
+	response, err := g.client.CreateVirtualAccount(doku.CreateVaRequest{
+		UserID:           req.CustomerID,
+		PartnerServiceId: req.PartnerServiceID,
+		CustomerNo:       req.CustomerNo,
+		Name:             req.Name,
+		Email:            req.Email,
+		Phone:            req.Phone,
+		Amount:           req.Amount,
+		TrxId:            req.ReferenceNo,
+		Bank:             req.Channel,
+		ExpiredDuration:  req.ExpiresIn,
+		ReusableStatus:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualAccount{
+		ReferenceNo:      req.ReferenceNo,
+		Channel:          req.Channel,
+		VirtualAccountNo: response.VirtualAccountNo,
+		Amount:           response.Amount,
+		ExpiryDate:       response.ExpiryDate,
+		PaymentGuideURL:  response.VirtualAccountURL,
+	}, nil
+}
+
+func (g *dokuGateway) CheckVirtualAccount(ctx context.Context, req VirtualAccountStatusRequest) (*VirtualAccountStatus, error) {
+	partnerServiceID, customerNo := req.PartnerServiceID, req.CustomerNo
+	if partnerServiceID == "" && len(req.VirtualAccountNo) > partnerServiceIDLength {
+		// Virtual accounts issued before numbering was stored carry both
+		// parts in the number itself.
+		partnerServiceID = req.VirtualAccountNo[:partnerServiceIDLength]
+		customerNo = req.VirtualAccountNo[partnerServiceIDLength:]
+	}
+
+	status, err := g.client.CheckVAStatus(req.VirtualAccountNo, customerNo, partnerServiceID, req.ReferenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualAccountStatus{
+		Paid:            status.Paid,
+		PaidAmount:      status.PaidAmount,
+		PaymentID:       status.PaymentRequestID,
+		ResponseCode:    status.ResponseCode,
+		ResponseMessage: status.ResponseMessage,
+	}, nil
+}
+
+func (g *dokuGateway) DecodeQRIS(ctx context.Context, qrContent string) (*QRISDecode, error) {
+	response, err := g.client.DecodeQRIS(qrContent)
+	if err != nil {
+		if errors.Is(err, doku.ErrQRISRejected) {
+			return nil, fmt.Errorf("%w: %v", ErrQRISRejected, err)
+		}
+		return nil, err
+	}
+
+	amount, err := money.Parse(response.TransactionAmount.Value.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transaction amount %q: %v", response.TransactionAmount.Value, err)
+	}
+
+	feeAmount, err := money.Parse(response.FeeAmount.Value.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fee amount %q: %v", response.FeeAmount.Value, err)
+	}
+
+	return &QRISDecode{
+		ReferenceNo:                        response.ReferenceNo,
+		MerchantName:                       response.MerchantName,
+		Amount:                             amount,
+		FeeAmount:                          feeAmount,
+		PointOfInitiationMethod:            response.AdditionalInfo.PointOfInitiationMethod,
+		PointOfInitiationMethodDescription: response.AdditionalInfo.PointOfInitiationMethodDescription,
+		FeeType:                            response.AdditionalInfo.FeeType,
+		FeeTypeDescription:                 response.AdditionalInfo.FeeTypeDescription,
+	}, nil
+}
+
+func (g *dokuGateway) PayQRIS(ctx context.Context, req QRISPaymentRequest) (*QRISPaymentResult, error) {
+	response, err := g.client.PaymentQRIS(req.ReferenceNo, req.QRContent, req.Amount, req.FeeAmount, req.AuthCode)
+	if err != nil {
+		if errors.Is(err, doku.ErrPaymentDeclined) {
+			return nil, fmt.Errorf("%w: %v", ErrPaymentDeclined, err)
+		}
+		return nil, err
+	}
+
+	return &QRISPaymentResult{
+		GatewayReference:           response.ReferenceNo,
+		TransactionDate:            response.TransactionDate,
+		TransactionType:            response.AdditionalInfo.TransactionType,
+		TransactionTypeDescription: response.AdditionalInfo.TransactionTypeDescription,
+		Acquirer:                   response.AdditionalInfo.Acquirer,
+		AcquirerName:               response.AdditionalInfo.AcquirerName,
+	}, nil
+}
+
+func (g *dokuGateway) QueryQRISPayment(ctx context.Context, referenceNo string) (*QRISPaymentStatus, error) {
+	status, err := g.client.QueryQRISPayment(referenceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QRISPaymentStatus{
+		Status:          QRISPaymentPending,
+		ReferenceNo:     status.ReferenceNo,
+		TransactionDate: status.TransactionDate,
+		ResponseCode:    status.ResponseCode,
+	}
+
+	switch status.Status {
+	case doku.QRISPaymentPaid:
+		result.Status = QRISPaymentPaid
+	case doku.QRISPaymentNotPaid:
+		result.Status = QRISPaymentNotPaid
+	}
+
+	return result, nil
+}
+
+// ParseNotification authenticates a SNAP virtual account payment
+// notification: partner ID, timestamp window and the asymmetric signature
+// over the raw body. DOKU only notifies completed payments.
+func (g *dokuGateway) ParseNotification(ctx context.Context, notification Notification) (*PaymentNotification, error) {
+	if g.partnerID != "" && notification.Header("X-PARTNER-ID") != g.partnerID {
+		return nil, fmt.Errorf("%w: unexpected partner ID %q", ErrInvalidSignature, notification.Header("X-PARTNER-ID"))
+	}
+
+	err := g.client.VerifyNotification(doku.Notification{
+		HTTPMethod:  notification.HTTPMethod,
+		EndpointURL: notification.EndpointURL,
+		Body:        notification.Body,
+		Timestamp:   notification.Header("X-TIMESTAMP"),
+		Signature:   notification.Header("X-SIGNATURE"),
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, doku.ErrInvalidTimestamp):
+		return nil, ErrInvalidTimestamp
+	case errors.Is(err, doku.ErrStaleTimestamp):
+		return nil, ErrStaleTimestamp
+	case errors.Is(err, doku.ErrPublicKeyNotConfigured):
+		return nil, fmt.Errorf("%w: %v", ErrNotificationNotConfigured, err)
+	default:
+		return nil, ErrInvalidSignature
+	}
+
+	var body doku.VAPaymentNotification
+	if err := json.Unmarshal(notification.Body, &body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	if body.TrxId == "" {
+		return nil, fmt.Errorf("%w: missing trxId", ErrInvalidNotification)
+	}
+
+	paidAmount, err := money.Parse(body.PaidAmount.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: paid amount %q", ErrInvalidNotification, body.PaidAmount.Value)
+	}
+
+	// Some channels leave the billed amount out; it is informational only.
+	totalAmount, _ := money.Parse(body.TotalAmount.Value)
+
+	return &PaymentNotification{
+		Provider:           ProviderDOKU,
+		ReferenceNo:        body.TrxId,
+		Status:             NotificationPaid,
+		Channel:            body.AdditionalInfo.Channel,
+		PaidAmount:         paidAmount,
+		TotalAmount:        totalAmount,
+		PaidAt:             body.TrxDateTime,
+		VirtualAccountNo:   strings.TrimSpace(body.VirtualAccountNo),
+		VirtualAccountName: body.VirtualAccountName,
+		PartnerServiceID:   strings.TrimSpace(body.PartnerServiceId),
+		CustomerNo:         strings.TrimSpace(body.CustomerNo),
+		PaymentRequestID:   body.PaymentRequestId,
+	}, nil
+}
+
+// dokuSimulatorGateway is the DOKU adapter over the local simulator, which
+// can also pay the virtual accounts it issued.
+type dokuSimulatorGateway struct {
+	*dokuGateway
+	simulator doku.ISimulator
+}
+
+func (g *dokuSimulatorGateway) PayVirtualAccount(ctx context.Context, referenceNo string, amount money.Amount) (*SimulatedPayment, error) {
+	result, err := g.simulator.PayVirtualAccount(ctx, referenceNo, amount)
+	if err != nil {
+		if errors.Is(err, doku.ErrVirtualAccountNotFound) {
+			return nil, ErrVirtualAccountNotFound
+		}
+		return nil, err
+	}
+
+	return &SimulatedPayment{
+		ReferenceNo:      result.TrxID,
+		VirtualAccountNo: result.VirtualAccountNo,
+		PaidAmount:       result.PaidAmount,
+		HTTPStatus:       result.HTTPStatus,
+		ResponseCode:     result.ResponseCode,
+		ResponseMessage:  result.ResponseMessage,
+	}, nil
+}
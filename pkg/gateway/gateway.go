@@ -0,0 +1,238 @@
+// Package gateway puts the payment providers behind one interface. A
+// provider collects money into the platform (virtual accounts), pays QRIS
+// merchants on a user's behalf and notifies us of payments; the wallet only
+// ever talks to a PaymentGateway and never to a provider's SDK directly.
+package gateway
+
+import (
+	"ProjectGolang/pkg/money"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	ProviderDOKU     = "doku"
+	ProviderMidtrans = "midtrans"
+)
+
+// Payment channels. The virtual account codes are the values clients send
+// when they top up; they predate this package and match DOKU's channel names.
+const (
+	ChannelBCA      = "VIRTUAL_ACCOUNT_BCA"
+	ChannelMANDIRI  = "VIRTUAL_ACCOUNT_BANK_MANDIRI"
+	ChannelBRI      = "VIRTUAL_ACCOUNT_BRI"
+	ChannelBNI      = "VIRTUAL_ACCOUNT_BNI"
+	ChannelDANAMON  = "VIRTUAL_ACCOUNT_BANK_DANAMON"
+	ChannelPERMATA  = "VIRTUAL_ACCOUNT_BANK_PERMATA"
+	ChannelMAYBANK  = "VIRTUAL_ACCOUNT_MAYBANK"
+	ChannelBTN      = "VIRTUAL_ACCOUNT_BTN"
+	ChannelBSI      = "VIRTUAL_ACCOUNT_BSI"
+	ChannelCIMB     = "VIRTUAL_ACCOUNT_BANK_CIMB"
+	ChannelSINARMAS = "VIRTUAL_ACCOUNT_SINARMAS"
+	ChannelDOKU     = "VIRTUAL_ACCOUNT_DOKU"
+	ChannelQRIS     = "QRIS"
+)
+
+// VirtualAccountChannels maps every virtual account channel to the bank name
+// shown to users.
+var VirtualAccountChannels = map[string]string{
+	ChannelBCA:      "BCA",
+	ChannelMANDIRI:  "MANDIRI",
+	ChannelBRI:      "BRI",
+	ChannelBNI:      "BNI",
+	ChannelDANAMON:  "DANAMON",
+	ChannelPERMATA:  "PERMATA",
+	ChannelMAYBANK:  "MAYBANK",
+	ChannelBTN:      "BTN",
+	ChannelBSI:      "BSI",
+	ChannelCIMB:     "CIMB",
+	ChannelSINARMAS: "SINARMAS",
+	ChannelDOKU:     "DOKU",
+}
+
+func IsVirtualAccountChannel(channel string) bool {
+	_, ok := VirtualAccountChannels[channel]
+	return ok
+}
+
+const (
+	QRISPaymentPaid    = "paid"
+	QRISPaymentNotPaid = "not_paid"
+	QRISPaymentPending = "pending"
+)
+
+const (
+	NotificationPaid    = "paid"
+	NotificationPending = "pending"
+	NotificationFailed  = "failed"
+)
+
+var (
+	ErrUnsupportedProvider = errors.New("unsupported payment provider")
+	ErrUnsupportedChannel  = errors.New("payment channel is not supported by the provider")
+	ErrNotSupported        = errors.New("operation is not supported by the provider")
+
+	// ErrPaymentDeclined is returned when the provider rejects a payment
+	// outright, as opposed to a failure where the outcome is unknown.
+	ErrPaymentDeclined = errors.New("payment declined")
+
+	// ErrQRISRejected is returned when the provider answers a decode request
+	// but refuses the code, as opposed to being unreachable.
+	ErrQRISRejected = errors.New("QRIS code rejected")
+
+	ErrVirtualAccountNotFound = errors.New("virtual account not found")
+
+	ErrNotificationNotConfigured = errors.New("notification verification is not configured")
+	ErrInvalidSignature          = errors.New("invalid notification signature")
+	ErrInvalidTimestamp          = errors.New("invalid notification timestamp")
+	ErrStaleTimestamp            = errors.New("notification timestamp outside allowed window")
+	ErrInvalidNotification       = errors.New("invalid notification")
+)
+
+// PaymentGateway is one payment provider. ReferenceNo is always our own
+// reference; providers use it as their order or transaction ID so that an
+// operation whose outcome is unknown can be looked up again.
+type PaymentGateway interface {
+	Name() string
+	Init() error
+	CreateVirtualAccount(ctx context.Context, req VirtualAccountRequest) (*VirtualAccount, error)
+	CheckVirtualAccount(ctx context.Context, req VirtualAccountStatusRequest) (*VirtualAccountStatus, error)
+	DecodeQRIS(ctx context.Context, qrContent string) (*QRISDecode, error)
+	PayQRIS(ctx context.Context, req QRISPaymentRequest) (*QRISPaymentResult, error)
+	QueryQRISPayment(ctx context.Context, referenceNo string) (*QRISPaymentStatus, error)
+	ParseNotification(ctx context.Context, notification Notification) (*PaymentNotification, error)
+}
+
+// ISimulator is implemented by gateways that can also play the paying
+// customer. Only local simulators do.
+type ISimulator interface {
+	PaymentGateway
+	PayVirtualAccount(ctx context.Context, referenceNo string, amount money.Amount) (*SimulatedPayment, error)
+}
+
+// VirtualAccountRequest bills Amount to a user's reusable virtual account,
+// identified by PartnerServiceID and CustomerNo from VirtualAccountNumbering.
+// Providers that assign their own numbers may return a different one.
+type VirtualAccountRequest struct {
+	ReferenceNo      string
+	Channel          string
+	CustomerID       string
+	PartnerServiceID string
+	CustomerNo       string
+	Name             string
+	Email            string
+	Phone            string
+	Amount           money.Amount
+	ExpiresIn        time.Duration
+}
+
+type VirtualAccount struct {
+	ReferenceNo      string
+	Channel          string
+	VirtualAccountNo string
+	Amount           money.Amount
+	ExpiryDate       string
+	PaymentGuideURL  string
+}
+
+type VirtualAccountStatusRequest struct {
+	ReferenceNo      string
+	VirtualAccountNo string
+	PartnerServiceID string
+	CustomerNo       string
+}
+
+// VirtualAccountStatus is the state of a top-up's bill at the provider.
+// PaidAmount is zero until the customer has paid; PaymentID is the
+// provider's ID of the payment, as in its payment notification.
+type VirtualAccountStatus struct {
+	Paid            bool
+	PaidAmount      money.Amount
+	PaymentID       string
+	ResponseCode    string
+	ResponseMessage string
+}
+
+type QRISDecode struct {
+	ReferenceNo                        string
+	MerchantName                       string
+	Amount                             money.Amount
+	FeeAmount                          money.Amount
+	PointOfInitiationMethod            string
+	PointOfInitiationMethodDescription string
+	FeeType                            string
+	FeeTypeDescription                 string
+}
+
+type QRISPaymentRequest struct {
+	ReferenceNo string
+	QRContent   string
+	Amount      money.Amount
+	FeeAmount   money.Amount
+	AuthCode    string
+}
+
+type QRISPaymentResult struct {
+	GatewayReference           string
+	TransactionDate            string
+	TransactionType            string
+	TransactionTypeDescription string
+	Acquirer                   string
+	AcquirerName               string
+}
+
+// QRISPaymentStatus is the outcome of a QRIS payment as reported by the
+// provider. Status is one of QRISPaymentPaid, QRISPaymentNotPaid or
+// QRISPaymentPending.
+type QRISPaymentStatus struct {
+	Status          string
+	ReferenceNo     string
+	TransactionDate string
+	ResponseCode    string
+}
+
+// Notification is the raw HTTP request a provider sends to our callback
+// endpoint. Body must be the exact bytes received because signatures cover
+// it; Headers holds the request headers by their canonical names.
+type Notification struct {
+	HTTPMethod  string
+	EndpointURL string
+	Body        []byte
+	Headers     map[string]string
+}
+
+// Header returns the named header, whatever its case on the wire.
+func (n Notification) Header(name string) string {
+	return n.Headers[http.CanonicalHeaderKey(name)]
+}
+
+// PaymentNotification is an authenticated notification in provider-neutral
+// form. Status is one of NotificationPaid, NotificationPending or
+// NotificationFailed; only paid notifications move money.
+type PaymentNotification struct {
+	Provider           string
+	ReferenceNo        string
+	Status             string
+	Channel            string
+	PaidAmount         money.Amount
+	TotalAmount        money.Amount
+	PaidAt             string
+	VirtualAccountNo   string
+	VirtualAccountName string
+	PartnerServiceID   string
+	CustomerNo         string
+	PaymentRequestID   string
+}
+
+// SimulatedPayment is what our callback endpoint answered to a notification
+// sent by a simulator.
+type SimulatedPayment struct {
+	ReferenceNo      string
+	VirtualAccountNo string
+	PaidAmount       money.Amount
+	HTTPStatus       int
+	ResponseCode     string
+	ResponseMessage  string
+}
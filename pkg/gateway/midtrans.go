@@ -0,0 +1,328 @@
+package gateway
+
+import (
+	"ProjectGolang/pkg/money"
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// midtransBanks maps the virtual account channels Midtrans serves to its
+// bank_transfer bank codes. Mandiri is a bill payment there, not a VA, and
+// is left to other providers.
+var midtransBanks = map[string]string{
+	ChannelBCA:     "bca",
+	ChannelBNI:     "bni",
+	ChannelBRI:     "bri",
+	ChannelPERMATA: "permata",
+	ChannelCIMB:    "cimb",
+}
+
+type midtransGateway struct {
+	log        *logrus.Logger
+	serverKey  string
+	baseURL    string
+	httpClient *http.Client
+}
+
+type midtransChargeRequest struct {
+	PaymentType        string                     `json:"payment_type"`
+	TransactionDetails midtransTransactionDetails `json:"transaction_details"`
+	BankTransfer       midtransBankTransfer       `json:"bank_transfer"`
+	CustomerDetails    midtransCustomerDetails    `json:"customer_details"`
+	CustomExpiry       *midtransCustomExpiry      `json:"custom_expiry,omitempty"`
+}
+
+type midtransTransactionDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int64  `json:"gross_amount"`
+}
+
+type midtransBankTransfer struct {
+	Bank     string `json:"bank"`
+	VANumber string `json:"va_number,omitempty"`
+}
+
+type midtransCustomerDetails struct {
+	FirstName string `json:"first_name,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+}
+
+type midtransCustomExpiry struct {
+	ExpiryDuration int    `json:"expiry_duration"`
+	Unit           string `json:"unit"`
+}
+
+// midtransTransaction is the shape shared by charge responses, status
+// responses and HTTP notifications.
+type midtransTransaction struct {
+	StatusCode        string             `json:"status_code"`
+	StatusMessage     string             `json:"status_message"`
+	TransactionID     string             `json:"transaction_id"`
+	OrderID           string             `json:"order_id"`
+	GrossAmount       string             `json:"gross_amount"`
+	PaymentType       string             `json:"payment_type"`
+	TransactionTime   string             `json:"transaction_time"`
+	TransactionStatus string             `json:"transaction_status"`
+	SettlementTime    string             `json:"settlement_time"`
+	ExpiryTime        string             `json:"expiry_time"`
+	SignatureKey      string             `json:"signature_key"`
+	VANumbers         []midtransVANumber `json:"va_numbers"`
+	PermataVANumber   string             `json:"permata_va_number"`
+}
+
+type midtransVANumber struct {
+	Bank     string `json:"bank"`
+	VANumber string `json:"va_number"`
+}
+
+// NewMidtrans returns a Midtrans Core API adapter. Midtrans only acquires:
+// it issues virtual accounts and notifies payments, but cannot pay a QRIS
+// code on a user's behalf.
+func NewMidtrans(log *logrus.Logger, serverKey string, isProduction bool) PaymentGateway {
+	baseURL := "https://api.sandbox.midtrans.com"
+	if isProduction {
+		baseURL = "https://api.midtrans.com"
+	}
+
+	return &midtransGateway{
+		log:        log,
+		serverKey:  serverKey,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (g *midtransGateway) Name() string {
+	return ProviderMidtrans
+}
+
+func (g *midtransGateway) Init() error {
+	if g.serverKey == "" {
+		return fmt.Errorf("midtrans server key is not configured")
+	}
+	return nil
+}
+
+func (g *midtransGateway) CreateVirtualAccount(ctx context.Context, req VirtualAccountRequest) (*VirtualAccount, error) {
+	bank, ok := midtransBanks[req.Channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, req.Channel)
+	}
+
+	// Midtrans takes whole rupiah only.
+	if req.Amount%money.Rupiah != 0 {
+		return nil, fmt.Errorf("midtrans cannot bill fractional rupiah: %s", req.Amount)
+	}
+
+	charge := midtransChargeRequest{
+		PaymentType: "bank_transfer",
+		TransactionDetails: midtransTransactionDetails{
+			OrderID:     req.ReferenceNo,
+			GrossAmount: req.Amount.Rupiah(),
+		},
+		// Midtrans appends a custom number to the bank's own prefix and
+		// caps its length per bank, so the customer number goes without
+		// its zero padding.
+		BankTransfer: midtransBankTransfer{
+			Bank:     bank,
+			VANumber: strings.TrimLeft(req.CustomerNo, "0"),
+		},
+		CustomerDetails: midtransCustomerDetails{
+			FirstName: req.Name,
+			Email:     req.Email,
+			Phone:     req.Phone,
+		},
+	}
+	if req.ExpiresIn > 0 {
+		charge.CustomExpiry = &midtransCustomExpiry{
+			ExpiryDuration: int(req.ExpiresIn / time.Minute),
+			Unit:           "minute",
+		}
+	}
+
+	var response midtransTransaction
+	if err := g.do(ctx, http.MethodPost, "/v2/charge", charge, &response); err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != "201" {
+		return nil, fmt.Errorf("failed to create virtual account: %s %s", response.StatusCode, response.StatusMessage)
+	}
+
+	virtualAccountNo := response.PermataVANumber
+	for _, number := range response.VANumbers {
+		if number.Bank == bank {
+			virtualAccountNo = number.VANumber
+		}
+	}
+	if virtualAccountNo == "" {
+		return nil, fmt.Errorf("virtual account number missing from midtrans response")
+	}
+
+	return &VirtualAccount{
+		ReferenceNo:      req.ReferenceNo,
+		Channel:          req.Channel,
+		VirtualAccountNo: virtualAccountNo,
+		Amount:           req.Amount,
+		ExpiryDate:       response.ExpiryTime,
+	}, nil
+}
+
+func (g *midtransGateway) CheckVirtualAccount(ctx context.Context, req VirtualAccountStatusRequest) (*VirtualAccountStatus, error) {
+	var response midtransTransaction
+	if err := g.do(ctx, http.MethodGet, "/v2/"+url.PathEscape(req.ReferenceNo)+"/status", nil, &response); err != nil {
+		return nil, err
+	}
+
+	status := &VirtualAccountStatus{
+		ResponseCode:    response.StatusCode,
+		ResponseMessage: response.StatusMessage,
+	}
+
+	if midtransStatus(response.TransactionStatus) == NotificationPaid {
+		paidAmount, err := money.Parse(response.GrossAmount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse paid amount %q: %v", response.GrossAmount, err)
+		}
+		status.Paid = true
+		status.PaidAmount = paidAmount
+		status.PaymentID = response.TransactionID
+	}
+
+	return status, nil
+}
+
+func (g *midtransGateway) DecodeQRIS(ctx context.Context, qrContent string) (*QRISDecode, error) {
+	return nil, ErrNotSupported
+}
+
+func (g *midtransGateway) PayQRIS(ctx context.Context, req QRISPaymentRequest) (*QRISPaymentResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (g *midtransGateway) QueryQRISPayment(ctx context.Context, referenceNo string) (*QRISPaymentStatus, error) {
+	return nil, ErrNotSupported
+}
+
+// ParseNotification checks signature_key, SHA512 of order_id, status_code,
+// gross_amount and the server key, and reports every transaction status;
+// Midtrans also notifies pending and expired transactions.
+func (g *midtransGateway) ParseNotification(ctx context.Context, notification Notification) (*PaymentNotification, error) {
+	if g.serverKey == "" {
+		return nil, ErrNotificationNotConfigured
+	}
+
+	var body midtransTransaction
+	if err := json.Unmarshal(notification.Body, &body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	if body.OrderID == "" || body.SignatureKey == "" {
+		return nil, fmt.Errorf("%w: missing order_id or signature_key", ErrInvalidNotification)
+	}
+
+	digest := sha512.Sum512([]byte(body.OrderID + body.StatusCode + body.GrossAmount + g.serverKey))
+	expected := hex.EncodeToString(digest[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(body.SignatureKey))) != 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	grossAmount, err := money.Parse(body.GrossAmount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: gross amount %q", ErrInvalidNotification, body.GrossAmount)
+	}
+
+	result := &PaymentNotification{
+		Provider:         ProviderMidtrans,
+		ReferenceNo:      body.OrderID,
+		Status:           midtransStatus(body.TransactionStatus),
+		TotalAmount:      grossAmount,
+		PaidAt:           body.SettlementTime,
+		VirtualAccountNo: body.PermataVANumber,
+		PaymentRequestID: body.TransactionID,
+	}
+
+	for _, number := range body.VANumbers {
+		result.VirtualAccountNo = number.VANumber
+		for channel, bank := range midtransBanks {
+			if bank == number.Bank {
+				result.Channel = channel
+			}
+		}
+	}
+	if body.PermataVANumber != "" {
+		result.Channel = ChannelPERMATA
+	}
+
+	if result.Status == NotificationPaid {
+		result.PaidAmount = grossAmount
+	}
+
+	return result, nil
+}
+
+func midtransStatus(transactionStatus string) string {
+	switch transactionStatus {
+	case "settlement", "capture":
+		return NotificationPaid
+	case "pending", "authorize":
+		return NotificationPending
+	default:
+		return NotificationFailed
+	}
+}
+
+func (g *midtransGateway) do(ctx context.Context, method, path string, request, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		payload, err := json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %v", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.SetBasicAuth(g.serverKey, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	g.log.WithFields(logrus.Fields{
+		"path":         path,
+		"http_status":  resp.StatusCode,
+		"response_raw": string(respBody),
+	}).Debug("Midtrans raw response")
+
+	if err := json.Unmarshal(respBody, response); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SNAP virtual account numbers are the partner service ID, left padded with
+// spaces to eight characters, followed by the customer number. Together they
+// may not exceed 28 characters.
+const (
+	partnerServiceIDLength    = 8
+	maxVirtualAccountNoLength = 28
+
+	defaultPartnerServiceID = "84923"
+	defaultCustomerNoLength = 12
+)
+
+// VirtualAccountNumber is the number a user keeps for one channel.
+type VirtualAccountNumber struct {
+	PartnerServiceID string
+	CustomerNo       string
+	VirtualAccountNo string
+}
+
+// VirtualAccountNumbering derives users' reusable virtual account numbers:
+// a partner service ID per channel and a zero padded customer number.
+type VirtualAccountNumbering struct {
+	defaultPartnerServiceID string
+	partnerServiceIDs       map[string]string
+	customerNoLength        int
+}
+
+// NewVirtualAccountNumbering uses partnerServiceIDs per channel and
+// defaultPartnerServiceID for all other channels.
+func NewVirtualAccountNumbering(defaultPartnerServiceID string, partnerServiceIDs map[string]string, customerNoLength int) (VirtualAccountNumbering, error) {
+	if customerNoLength <= 0 || partnerServiceIDLength+customerNoLength > maxVirtualAccountNoLength {
+		return VirtualAccountNumbering{}, fmt.Errorf("invalid customer number length %d", customerNoLength)
+	}
+
+	numbering := VirtualAccountNumbering{
+		defaultPartnerServiceID: defaultPartnerServiceID,
+		partnerServiceIDs:       make(map[string]string, len(partnerServiceIDs)),
+		customerNoLength:        customerNoLength,
+	}
+
+	if err := validatePartnerServiceID(defaultPartnerServiceID); err != nil {
+		return VirtualAccountNumbering{}, err
+	}
+
+	for channel, partnerServiceID := range partnerServiceIDs {
+		if !IsVirtualAccountChannel(channel) {
+			return VirtualAccountNumbering{}, fmt.Errorf("%w: %s", ErrUnsupportedChannel, channel)
+		}
+		if err := validatePartnerServiceID(partnerServiceID); err != nil {
+			return VirtualAccountNumbering{}, err
+		}
+		numbering.partnerServiceIDs[channel] = partnerServiceID
+	}
+
+	return numbering, nil
+}
+
+// NumberingFromEnv reads VA_PARTNER_SERVICE_ID, the default partner service
+// ID, VA_PARTNER_SERVICE_IDS, per channel overrides as a comma separated list
+// of CHANNEL=id, and VA_CUSTOMER_NO_LENGTH.
+func NumberingFromEnv() (VirtualAccountNumbering, error) {
+	defaultID := strings.TrimSpace(os.Getenv("VA_PARTNER_SERVICE_ID"))
+	if defaultID == "" {
+		defaultID = defaultPartnerServiceID
+	}
+
+	partnerServiceIDs, err := parseChannelMap(os.Getenv("VA_PARTNER_SERVICE_IDS"))
+	if err != nil {
+		return VirtualAccountNumbering{}, err
+	}
+
+	customerNoLength := defaultCustomerNoLength
+	if value := strings.TrimSpace(os.Getenv("VA_CUSTOMER_NO_LENGTH")); value != "" {
+		customerNoLength, err = strconv.Atoi(value)
+		if err != nil {
+			return VirtualAccountNumbering{}, fmt.Errorf("invalid VA_CUSTOMER_NO_LENGTH %q", value)
+		}
+	}
+
+	return NewVirtualAccountNumbering(defaultID, partnerServiceIDs, customerNoLength)
+}
+
+func validatePartnerServiceID(partnerServiceID string) error {
+	if partnerServiceID == "" || len(partnerServiceID) > partnerServiceIDLength {
+		return fmt.Errorf("invalid partner service ID %q", partnerServiceID)
+	}
+	if _, err := strconv.ParseUint(partnerServiceID, 10, 64); err != nil {
+		return fmt.Errorf("invalid partner service ID %q", partnerServiceID)
+	}
+	return nil
+}
+
+// Number returns the virtual account number for a channel and a customer
+// sequence. The same inputs always give the same number.
+func (n VirtualAccountNumbering) Number(channel string, sequence int64) (VirtualAccountNumber, error) {
+	if !IsVirtualAccountChannel(channel) {
+		return VirtualAccountNumber{}, fmt.Errorf("%w: %s", ErrUnsupportedChannel, channel)
+	}
+
+	customerNo := fmt.Sprintf("%0*d", n.customerNoLength, sequence)
+	if sequence <= 0 || len(customerNo) > n.customerNoLength {
+		return VirtualAccountNumber{}, fmt.Errorf("customer sequence %d does not fit %d digits", sequence, n.customerNoLength)
+	}
+
+	partnerServiceID, ok := n.partnerServiceIDs[channel]
+	if !ok {
+		partnerServiceID = n.defaultPartnerServiceID
+	}
+	partnerServiceID = fmt.Sprintf("%*s", partnerServiceIDLength, partnerServiceID)
+
+	return VirtualAccountNumber{
+		PartnerServiceID: partnerServiceID,
+		CustomerNo:       customerNo,
+		VirtualAccountNo: partnerServiceID + customerNo,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"ProjectGolang/pkg/doku"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// IRouter hands out the gateway for a channel when money starts moving and
+// the gateway by name when a stored transaction or a notification names it.
+type IRouter interface {
+	Init() error
+	Get(provider string) (PaymentGateway, error)
+	ForChannel(channel string) (PaymentGateway, error)
+}
+
+type router struct {
+	gateways map[string]PaymentGateway
+	routes   map[string]string
+	fallback string
+}
+
+// NewRouter routes every channel in routes to the named gateway and all
+// other channels to fallback. Every name must be one of gateways.
+func NewRouter(fallback string, routes map[string]string, gateways ...PaymentGateway) (IRouter, error) {
+	r := &router{
+		gateways: make(map[string]PaymentGateway, len(gateways)),
+		routes:   make(map[string]string, len(routes)),
+		fallback: fallback,
+	}
+
+	for _, gateway := range gateways {
+		r.gateways[gateway.Name()] = gateway
+	}
+
+	if _, ok := r.gateways[fallback]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, fallback)
+	}
+
+	for channel, provider := range routes {
+		if _, ok := r.gateways[provider]; !ok {
+			return nil, fmt.Errorf("%w: %s for channel %s", ErrUnsupportedProvider, provider, channel)
+		}
+		r.routes[channel] = provider
+	}
+
+	return r, nil
+}
+
+// New builds the router from the environment. PAYMENT_GATEWAY_DEFAULT names
+// the fallback provider, "doku" when empty, and PAYMENT_GATEWAY_ROUTES
+// overrides it per channel as a comma separated list of CHANNEL=provider.
+// DOKU is always available because older transactions and QRIS receive
+// codes settle through it.
+func New(log *logrus.Logger) (IRouter, error) {
+	fallback := strings.ToLower(strings.TrimSpace(os.Getenv("PAYMENT_GATEWAY_DEFAULT")))
+	if fallback == "" {
+		fallback = ProviderDOKU
+	}
+
+	routes, err := parseChannelMap(os.Getenv("PAYMENT_GATEWAY_ROUTES"))
+	if err != nil {
+		return nil, err
+	}
+	for channel, provider := range routes {
+		routes[channel] = strings.ToLower(provider)
+	}
+
+	needed := map[string]bool{ProviderDOKU: true, fallback: true}
+	for _, provider := range routes {
+		needed[provider] = true
+	}
+
+	var gateways []PaymentGateway
+	for provider := range needed {
+		switch provider {
+		case ProviderDOKU:
+			client, err := doku.New(log)
+			if err != nil {
+				return nil, err
+			}
+			gateways = append(gateways, NewDoku(client, os.Getenv("DOKU_CLIENT_ID")))
+		case ProviderMidtrans:
+			isProduction, _ := strconv.ParseBool(os.Getenv("MIDTRANS_IS_PRODUCTION"))
+			gateways = append(gateways, NewMidtrans(log, os.Getenv("MIDTRANS_SERVER_KEY"), isProduction))
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, provider)
+		}
+	}
+
+	return NewRouter(fallback, routes, gateways...)
+}
+
+// parseChannelMap parses a comma separated list of CHANNEL=value.
+func parseChannelMap(value string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		channel, channelValue, ok := strings.Cut(entry, "=")
+		channel = strings.ToUpper(strings.TrimSpace(channel))
+		channelValue = strings.TrimSpace(channelValue)
+		if !ok || channel == "" || channelValue == "" {
+			return nil, fmt.Errorf("invalid channel mapping %q", entry)
+		}
+
+		values[channel] = channelValue
+	}
+
+	return values, nil
+}
+
+// Init initialises every gateway and reports all failures together, so one
+// provider being down does not keep the others from starting.
+func (r *router) Init() error {
+	var errs []error
+	for name, gateway := range r.gateways {
+		if err := gateway.Init(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *router) Get(provider string) (PaymentGateway, error) {
+	gateway, ok := r.gateways[strings.ToLower(provider)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, provider)
+	}
+	return gateway, nil
+}
+
+func (r *router) ForChannel(channel string) (PaymentGateway, error) {
+	provider, ok := r.routes[channel]
+	if !ok {
+		provider = r.fallback
+	}
+	return r.Get(provider)
+}
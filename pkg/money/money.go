@@ -0,0 +1,307 @@
+// Package money represents Indonesian rupiah amounts exactly.
+//
+// An Amount is a whole number of sen (1/100 rupiah), the precision of every
+// DECIMAL(_, 2) money column and of the amounts DOKU exchanges, so adding,
+// comparing and storing amounts never rounds. Conversion from float64 exists
+// only for inputs that arrive as floats, such as amounts extracted from
+// speech.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a rupiah amount in sen.
+type Amount int64
+
+const (
+	Sen    Amount = 1
+	Rupiah Amount = 100
+)
+
+var ErrInvalidAmount = errors.New("money: invalid amount")
+
+// FromRupiah returns an amount of whole rupiah.
+func FromRupiah(rupiah int64) Amount {
+	return Amount(rupiah) * Rupiah
+}
+
+// FromSen returns an amount of sen.
+func FromSen(sen int64) Amount {
+	return Amount(sen)
+}
+
+// FromFloat64 converts a rupiah float to the nearest sen. Use it only at the
+// edge where amounts arrive as floats; never for arithmetic.
+func FromFloat64(rupiah float64) Amount {
+	return Amount(math.Round(rupiah * float64(Rupiah)))
+}
+
+// Parse reads a machine formatted amount such as "1250000", "1250000.5" or
+// "1250000.50": an optional minus sign, digits, and at most two decimals.
+// More precise values are rejected rather than rounded.
+func Parse(value string) (Amount, error) {
+	s := strings.TrimSpace(value)
+
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || (hasFraction && (fraction == "" || len(fraction) > 2 || !isDigits(fraction))) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	return build(value, negative, whole, fraction)
+}
+
+// ParseIDR reads an amount written the Indonesian way, such as "Rp1.250.000",
+// "Rp 1.250.000,50" or "1.250.000": dots group thousands and a comma starts
+// the decimals.
+func ParseIDR(value string) (Amount, error) {
+	s := strings.TrimSpace(value)
+
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	s = strings.TrimSpace(strings.TrimPrefix(s, "Rp"))
+
+	whole, fraction, hasFraction := strings.Cut(s, ",")
+	if hasFraction && (fraction == "" || len(fraction) > 2 || !isDigits(fraction)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	groups := strings.Split(whole, ".")
+	for i, group := range groups {
+		if group == "" || !isDigits(group) || (i > 0 && len(group) != 3) || (i == 0 && len(groups) > 1 && len(group) > 3) {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+		}
+	}
+
+	return build(value, negative, strings.Join(groups, ""), fraction)
+}
+
+func build(value string, negative bool, whole, fraction string) (Amount, error) {
+	rupiah, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rupiah > math.MaxInt64/int64(Rupiah)-1 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	sen := int64(0)
+	if fraction != "" {
+		sen, _ = strconv.ParseInt(fraction, 10, 64)
+		if len(fraction) == 1 {
+			sen *= 10
+		}
+	}
+
+	amount := Amount(rupiah)*Rupiah + Amount(sen)
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Amount) Sen() int64 {
+	return int64(a)
+}
+
+// Rupiah returns the whole rupiah part of a, truncated toward zero.
+func (a Amount) Rupiah() int64 {
+	return int64(a / Rupiah)
+}
+
+// Float64 returns a in rupiah. It is meant for display and statistics only.
+func (a Amount) Float64() float64 {
+	return float64(a) / float64(Rupiah)
+}
+
+func (a Amount) IsZero() bool     { return a == 0 }
+func (a Amount) IsPositive() bool { return a > 0 }
+func (a Amount) IsNegative() bool { return a < 0 }
+
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func Min(a, b Amount) Amount {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func Max(a, b Amount) Amount {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// String returns a with two decimals and no grouping, e.g. "1250000.00", the
+// format DOKU and the database expect.
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+	}
+
+	abs := a.Abs()
+	return fmt.Sprintf("%s%d.%02d", sign, int64(abs/Rupiah), int64(abs%Rupiah))
+}
+
+// Format returns a for people, e.g. "Rp1.250.000" or "Rp1.250.000,50".
+// Decimals are shown only when a is not a whole rupiah.
+func (a Amount) Format() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+	}
+
+	abs := a.Abs()
+	digits := strconv.FormatInt(int64(abs/Rupiah), 10)
+
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(d)
+	}
+
+	if sen := int64(abs % Rupiah); sen != 0 {
+		return fmt.Sprintf("%sRp%s,%02d", sign, grouped.String(), sen)
+	}
+
+	return sign + "Rp" + grouped.String()
+}
+
+// MarshalJSON writes a as a JSON number in rupiah, without a fraction when a
+// is a whole rupiah, so existing clients keep receiving numbers.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if a%Rupiah == 0 {
+		return []byte(strconv.FormatInt(int64(a/Rupiah), 10)), nil
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a rupiah amount as a JSON number or a string. The
+// text is parsed directly, so it never passes through a float.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+// UnmarshalText lets form and query decoders read "1250000" as rupiah
+// instead of treating the integer as sen.
+func (a *Amount) UnmarshalText(text []byte) error {
+	amount, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+// Value stores a as a decimal string, which Postgres converts to NUMERIC
+// exactly.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return a.scanText(string(v))
+	case string:
+		return a.scanText(v)
+	case int64:
+		*a = FromRupiah(v)
+		return nil
+	case float64:
+		*a = FromFloat64(v)
+		return nil
+	case nil:
+		return fmt.Errorf("%w: cannot scan NULL, use NullAmount", ErrInvalidAmount)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+}
+
+func (a *Amount) scanText(s string) error {
+	// NUMERIC columns come back with as many decimals as their scale; the
+	// money columns all have scale 2 or less.
+	if whole, fraction, ok := strings.Cut(s, "."); ok && len(fraction) > 2 {
+		if strings.Trim(fraction[2:], "0") != "" {
+			return fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+		}
+		s = whole + "." + fraction[:2]
+	}
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+// NullAmount is an Amount that may be NULL, like sql.NullInt64.
+type NullAmount struct {
+	Amount Amount
+	Valid  bool
+}
+
+func (n NullAmount) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Amount.Value()
+}
+
+func (n *NullAmount) Scan(src interface{}) error {
+	if src == nil {
+		n.Amount, n.Valid = 0, false
+		return nil
+	}
+
+	n.Valid = true
+	return n.Amount.Scan(src)
+}
@@ -0,0 +1,275 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Amount
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "1250000", want: 125000000},
+		{value: "1250000.5", want: 125000050},
+		{value: "1250000.50", want: 125000050},
+		{value: "1250000.05", want: 125000005},
+		{value: "0.01", want: 1},
+		{value: " 10000 ", want: 1000000},
+		{value: "-1250000.75", want: -125000075},
+		{value: "-0.01", want: -1},
+		{value: "92233720368547757.99", want: 9223372036854775799},
+		{value: "92233720368547758", wantErr: true},
+		{value: "99999999999999999999", wantErr: true},
+		{value: "1250000.505", wantErr: true},
+		{value: "1250000.", wantErr: true},
+		{value: ".50", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "-", wantErr: true},
+		{value: "--1", wantErr: true},
+		{value: "+1", wantErr: true},
+		{value: "1,250,000", wantErr: true},
+		{value: "1e6", wantErr: true},
+		{value: "Rp10000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Fatalf("Parse(%q) error = %v, want ErrInvalidAmount", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Parse(%q) = %d sen, want %d sen", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIDR(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Amount
+		wantErr bool
+	}{
+		{value: "Rp1.250.000", want: 125000000},
+		{value: "Rp 1.250.000,50", want: 125000050},
+		{value: "Rp1.250.000,5", want: 125000050},
+		{value: "1.250.000", want: 125000000},
+		{value: "999", want: 99900},
+		{value: "-Rp1.000", want: -100000},
+		{value: "Rp1.250.000,505", wantErr: true},
+		{value: "Rp1.250.000,", wantErr: true},
+		{value: "Rp1.25.000", wantErr: true},
+		{value: "Rp1250.000", wantErr: true},
+		{value: "Rp.250.000", wantErr: true},
+		{value: "Rp1.250.000.", wantErr: true},
+		{value: "Rp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseIDR(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Fatalf("ParseIDR(%q) error = %v, want ErrInvalidAmount", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIDR(%q) unexpected error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseIDR(%q) = %d sen, want %d sen", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	tests := []struct {
+		amount     Amount
+		wantString string
+		wantFormat string
+	}{
+		{amount: 0, wantString: "0.00", wantFormat: "Rp0"},
+		{amount: 1, wantString: "0.01", wantFormat: "Rp0,01"},
+		{amount: 99900, wantString: "999.00", wantFormat: "Rp999"},
+		{amount: 100000, wantString: "1000.00", wantFormat: "Rp1.000"},
+		{amount: 125000050, wantString: "1250000.50", wantFormat: "Rp1.250.000,50"},
+		{amount: -125000005, wantString: "-1250000.05", wantFormat: "-Rp1.250.000,05"},
+		{amount: -1, wantString: "-0.01", wantFormat: "-Rp0,01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantString, func(t *testing.T) {
+			if got := tt.amount.String(); got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+			if got := tt.amount.Format(); got != tt.wantFormat {
+				t.Errorf("Format() = %q, want %q", got, tt.wantFormat)
+			}
+
+			parsed, err := Parse(tt.amount.String())
+			if err != nil || parsed != tt.amount {
+				t.Errorf("Parse(String()) = %d, %v, want %d", parsed, err, tt.amount)
+			}
+
+			parsed, err = ParseIDR(tt.amount.Format())
+			if err != nil || parsed != tt.amount {
+				t.Errorf("ParseIDR(Format()) = %d, %v, want %d", parsed, err, tt.amount)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{amount: 0, want: "0"},
+		{amount: 125000000, want: "1250000"},
+		{amount: 125000050, want: "1250000.50"},
+		{amount: -5, want: "-0.05"},
+		{amount: -100, want: "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			data, err := json.Marshal(tt.amount)
+			if err != nil {
+				t.Fatalf("Marshal unexpected error: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("Marshal = %s, want %s", data, tt.want)
+			}
+
+			var got Amount
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal(%s) unexpected error: %v", data, err)
+			}
+			if got != tt.amount {
+				t.Fatalf("Unmarshal(%s) = %d, want %d", data, got, tt.amount)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    Amount
+		wantErr bool
+	}{
+		{data: `10000`, want: 1000000},
+		{data: `"10000.25"`, want: 1000025},
+		{data: `0.1`, want: 10},
+		{data: `null`, want: 0},
+		{data: `10000.001`, wantErr: true},
+		{data: `1e4`, wantErr: true},
+		{data: `"abc"`, wantErr: true},
+		{data: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			var got Amount
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %d, want an error", tt.data, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) unexpected error: %v", tt.data, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Unmarshal(%s) = %d, want %d", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromFloat64(t *testing.T) {
+	tests := []struct {
+		rupiah float64
+		want   Amount
+	}{
+		{rupiah: 0.1 + 0.2, want: 30},
+		{rupiah: 19.99, want: 1999},
+		{rupiah: -19.99, want: -1999},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.FormatFloat(tt.rupiah, 'f', -1, 64), func(t *testing.T) {
+			if got := FromFloat64(tt.rupiah); got != tt.want {
+				t.Fatalf("FromFloat64(%v) = %d, want %d", tt.rupiah, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	// Ten thousand ten-sen top-ups must add up to exactly Rp1.000, which
+	// float64 rupiah cannot do.
+	var total Amount
+	for i := 0; i < 10000; i++ {
+		total += FromSen(10)
+	}
+	if total != FromRupiah(1000) {
+		t.Fatalf("total = %s, want 1000.00", total)
+	}
+
+	if got := FromSen(-250).Rupiah(); got != -2 {
+		t.Fatalf("Rupiah() = %d, want -2", got)
+	}
+	if got := FromSen(-250).Abs(); got != 250 {
+		t.Fatalf("Abs() = %d, want 250", got)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Amount
+		wantErr bool
+	}{
+		{name: "numeric text", src: []byte("1250000.50"), want: 125000050},
+		{name: "numeric with trailing zeros", src: "1250000.5000", want: 125000050},
+		{name: "integer", src: int64(1250000), want: 125000000},
+		{name: "sub-sen numeric", src: "1250000.505", wantErr: true},
+		{name: "null", src: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Amount
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAmount) {
+					t.Fatalf("Scan(%v) error = %v, want ErrInvalidAmount", tt.src, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v) unexpected error: %v", tt.src, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Scan(%v) = %d, want %d", tt.src, got, tt.want)
+			}
+		})
+	}
+}
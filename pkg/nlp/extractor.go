@@ -1,6 +1,7 @@
 package nlp
 
 import (
+	"ProjectGolang/pkg/money"
 	"regexp"
 	"strconv"
 	"strings"
@@ -8,7 +9,7 @@ import (
 
 type TransactionData struct {
 	Type        string  
-	Amount      float64
+	Amount      money.Amount
 	Description string
 	Category    string
 	Confidence  float64
@@ -53,16 +54,16 @@ func NewNumberExtractor() *NumberExtractor {
 	}
 }
 
-func (ne *NumberExtractor) ExtractAmount(text string) (float64, string) {
+// ExtractAmount finds the rupiah amount in text, written as digits
+// ("1.250.000"), with a unit ("50 ribu") or in words ("dua puluh ribu").
+func (ne *NumberExtractor) ExtractAmount(text string) (money.Amount, string) {
 	text = strings.ToLower(text)
 	
 	
 	numPattern := regexp.MustCompile(`(\d{1,3}(?:\.\d{3})*(?:,\d+)?)`)
 	if matches := numPattern.FindString(text); matches != "" {
 		
-		cleaned := strings.ReplaceAll(matches, ".", "")
-		cleaned = strings.ReplaceAll(cleaned, ",", ".")
-		if amount, err := strconv.ParseFloat(cleaned, 64); err == nil {
+		if amount, err := money.ParseIDR(matches); err == nil {
 			return amount, "numeric"
 		}
 	}
@@ -70,10 +71,10 @@ func (ne *NumberExtractor) ExtractAmount(text string) (float64, string) {
 	
 	unitPattern := regexp.MustCompile(`(\d+)\s*(ribu|rebu|juta|jt|rb|k)`)
 	if matches := unitPattern.FindStringSubmatch(text); len(matches) > 0 {
-		num, _ := strconv.ParseFloat(matches[1], 64)
+		num, _ := strconv.ParseInt(matches[1], 10, 64)
 		unit := matches[2]
 		
-		multiplier := 1.0
+		multiplier := int64(1)
 		switch unit {
 		case "ribu", "rebu", "rb", "k":
 			multiplier = 1000
@@ -81,13 +82,13 @@ func (ne *NumberExtractor) ExtractAmount(text string) (float64, string) {
 			multiplier = 1000000
 		}
 		
-		return num * multiplier, "unit"
+		return money.FromRupiah(num * multiplier), "unit"
 	}
 	
 	
 	amount := ne.parseIndonesianNumber(text)
 	if amount > 0 {
-		return amount, "words"
+		return money.FromFloat64(amount), "words"
 	}
 	
 	return 0, "none"
@@ -150,7 +151,7 @@ func (ne *NumberExtractor) ExtractTransactionType(text string) string {
 	return "unknown"
 }
 
-func (ne *NumberExtractor) ExtractDescription(text string, amount float64) string {
+func (ne *NumberExtractor) ExtractDescription(text string, amount money.Amount) string {
 	text = strings.ToLower(text)
 	
 	
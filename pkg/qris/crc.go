@@ -0,0 +1,45 @@
+package qris
+
+import (
+	"fmt"
+	"strings"
+)
+
+// crcPrefix is the tag and length of the CRC object, which are themselves
+// covered by the checksum.
+const crcPrefix = tagCRC + "04"
+
+// Checksum returns the CRC16/CCITT-FALSE of data (polynomial 0x1021, initial
+// value 0xFFFF) as four uppercase hex digits, the form tag 63 carries.
+func Checksum(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
+
+func verifyCRC(payload string) error {
+	if len(payload) < len(crcPrefix)+4 {
+		return invalid("payload too short")
+	}
+
+	body, crc := payload[:len(payload)-4], payload[len(payload)-4:]
+	if !strings.HasSuffix(body, crcPrefix) {
+		return invalid("payload does not end with a CRC")
+	}
+
+	// Some generators write the checksum in lowercase.
+	if expected := Checksum(body); !strings.EqualFold(crc, expected) {
+		return invalid(fmt.Sprintf("CRC mismatch: got %s, want %s", crc, expected))
+	}
+
+	return nil
+}
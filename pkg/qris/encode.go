@@ -0,0 +1,109 @@
+package qris
+
+import (
+	"ProjectGolang/pkg/money"
+	"fmt"
+	"strings"
+)
+
+// Maximum lengths of the fields a payer's app shows, from the QRIS MPM
+// specification.
+const (
+	MaxMerchantNameLength = 25
+	MaxMerchantCityLength = 15
+)
+
+// Encode returns c as a QRIS payload with its CRC. The payload is parsed back
+// before it is returned, so Encode never produces a code Parse would reject.
+func (c *Code) Encode() (string, error) {
+	objects := []tlv{
+		{tag: tagPayloadFormat, value: c.PayloadFormat},
+		{tag: tagPointOfInitiation, value: c.PointOfInitiation},
+	}
+
+	for _, account := range c.MerchantAccounts {
+		value, err := encodeTemplate([]tlv{
+			{tag: "00", value: account.GlobalID},
+			{tag: "01", value: account.MerchantPAN},
+			{tag: "02", value: account.MerchantID},
+			{tag: "03", value: account.Criteria},
+		})
+		if err != nil {
+			return "", err
+		}
+		objects = append(objects, tlv{tag: account.Tag, value: value})
+	}
+
+	objects = append(objects,
+		tlv{tag: tagMerchantCategoryCode, value: c.MerchantCategoryCode},
+		tlv{tag: tagTransactionCurrency, value: c.Currency},
+	)
+
+	if c.HasAmount {
+		objects = append(objects, tlv{tag: tagTransactionAmount, value: formatAmount(c.Amount)})
+	}
+
+	objects = append(objects, tlv{tag: tagTipOrConvenience, value: c.TipIndicator})
+	switch c.TipIndicator {
+	case ConvenienceFeeFixed:
+		objects = append(objects, tlv{tag: tagConvenienceFeeFixed, value: formatAmount(c.ConvenienceFee)})
+	case ConvenienceFeePercent:
+		objects = append(objects, tlv{tag: tagConvenienceFeePercent, value: c.ConvenienceFeeRate})
+	}
+
+	additionalData, err := encodeTemplate([]tlv{
+		{tag: "01", value: c.BillNumber},
+		{tag: "05", value: c.ReferenceLabel},
+		{tag: "07", value: c.TerminalLabel},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	objects = append(objects,
+		tlv{tag: tagCountryCode, value: c.CountryCode},
+		tlv{tag: tagMerchantName, value: c.MerchantName},
+		tlv{tag: tagMerchantCity, value: c.MerchantCity},
+		tlv{tag: tagPostalCode, value: c.PostalCode},
+		tlv{tag: tagAdditionalData, value: additionalData},
+	)
+
+	body, err := encodeTemplate(objects)
+	if err != nil {
+		return "", err
+	}
+
+	body += crcPrefix
+	payload := body + Checksum(body)
+
+	if _, err := Parse(payload); err != nil {
+		return "", err
+	}
+
+	return payload, nil
+}
+
+// encodeTemplate serialises the non-empty objects in order, either a whole
+// payload or the value of a nested template.
+func encodeTemplate(objects []tlv) (string, error) {
+	var b strings.Builder
+	for _, object := range objects {
+		if object.value == "" {
+			continue
+		}
+		if len(object.value) > 99 {
+			return "", invalid("object " + object.tag + " is longer than 99 characters")
+		}
+		fmt.Fprintf(&b, "%s%02d%s", object.tag, len(object.value), object.value)
+	}
+	return b.String(), nil
+}
+
+// formatAmount writes whole rupiah without decimals, as most issuers expect,
+// and keeps the sen otherwise.
+func formatAmount(amount money.Amount) string {
+	if amount%money.Rupiah == 0 {
+		return fmt.Sprintf("%d", amount.Rupiah())
+	}
+	return amount.String()
+}
@@ -0,0 +1,362 @@
+// Package qris reads QRIS codes, the Indonesian profile of the EMVCo
+// merchant-presented QR (MPM) format, without calling a payment gateway.
+//
+// A payload is a sequence of TLV objects: a two digit tag, a two digit
+// length and the value. Templates such as the merchant account information
+// (tags 26 to 51) and the additional data field (tag 62) nest TLV objects in
+// their value. The payload ends with tag 63, a CRC16 over everything before
+// its value.
+package qris
+
+import (
+	"ProjectGolang/pkg/money"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	tagPayloadFormat         = "00"
+	tagPointOfInitiation     = "01"
+	tagMerchantCategoryCode  = "52"
+	tagTransactionCurrency   = "53"
+	tagTransactionAmount     = "54"
+	tagTipOrConvenience      = "55"
+	tagConvenienceFeeFixed   = "56"
+	tagConvenienceFeePercent = "57"
+	tagCountryCode           = "58"
+	tagMerchantName          = "59"
+	tagMerchantCity          = "60"
+	tagPostalCode            = "61"
+	tagAdditionalData        = "62"
+	tagCRC                   = "63"
+
+	// QRIS registers every merchant under this global identifier in the
+	// merchant account template that carries the national merchant ID.
+	qrisGlobalID = "ID.CO.QRIS.WWW"
+
+	CurrencyRupiah = "360"
+)
+
+const (
+	PointOfInitiationStatic  = "11"
+	PointOfInitiationDynamic = "12"
+)
+
+// Tip or convenience indicator values of tag 55.
+const (
+	TipPrompt             = "01"
+	ConvenienceFeeFixed   = "02"
+	ConvenienceFeePercent = "03"
+)
+
+var ErrInvalidQRISCode = errors.New("qris: invalid QRIS code")
+
+// MerchantAccount is one merchant account information template, tags 26 to
+// 51, identifying the merchant at one acquirer or at the QRIS registry.
+type MerchantAccount struct {
+	Tag         string
+	GlobalID    string
+	MerchantPAN string
+	MerchantID  string
+	Criteria    string
+}
+
+// Code is a parsed QRIS payload. Amount is set only when HasAmount is true;
+// static codes usually leave the amount to the payer.
+type Code struct {
+	PayloadFormat        string
+	PointOfInitiation    string
+	MerchantAccounts     []MerchantAccount
+	NMID                 string
+	MerchantCategoryCode string
+	Currency             string
+	Amount               money.Amount
+	HasAmount            bool
+	TipIndicator         string
+	ConvenienceFee       money.Amount
+	ConvenienceFeeRate   string
+	CountryCode          string
+	MerchantName         string
+	MerchantCity         string
+	PostalCode           string
+	BillNumber           string
+	ReferenceLabel       string
+	TerminalLabel        string
+	CRC                  string
+}
+
+func (c *Code) IsDynamic() bool {
+	return c.PointOfInitiation == PointOfInitiationDynamic
+}
+
+// Fee returns the convenience fee the merchant adds to amount, or zero when
+// the code carries no fee. A percentage fee is rounded to the nearest sen.
+func (c *Code) Fee(amount money.Amount) money.Amount {
+	switch c.TipIndicator {
+	case ConvenienceFeeFixed:
+		return c.ConvenienceFee
+	case ConvenienceFeePercent:
+		// The rate has at most two decimals, so parse it as hundredths of a
+		// percent and stay in integers.
+		rate, err := money.Parse(c.ConvenienceFeeRate)
+		if err != nil {
+			return 0
+		}
+		return (amount*rate + 5000) / 10000
+	default:
+		return 0
+	}
+}
+
+// Parse decodes and validates a QRIS payload. It checks the CRC, the TLV
+// structure and the mandatory fields, and wraps ErrInvalidQRISCode with the
+// reason on any failure.
+func Parse(payload string) (*Code, error) {
+	payload = strings.TrimSpace(payload)
+
+	if err := verifyCRC(payload); err != nil {
+		return nil, err
+	}
+
+	objects, err := parseTLV(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects) == 0 || objects[0].tag != tagPayloadFormat {
+		return nil, invalid("payload format indicator must come first")
+	}
+	if objects[len(objects)-1].tag != tagCRC {
+		return nil, invalid("CRC must come last")
+	}
+
+	code := &Code{}
+	seen := make(map[string]bool, len(objects))
+
+	for _, object := range objects {
+		if seen[object.tag] {
+			return nil, invalid("duplicate tag " + object.tag)
+		}
+		seen[object.tag] = true
+
+		if err := code.set(object); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := code.validate(); err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
+
+func (c *Code) set(object tlv) error {
+	value := object.value
+
+	switch object.tag {
+	case tagPayloadFormat:
+		c.PayloadFormat = value
+	case tagPointOfInitiation:
+		c.PointOfInitiation = value
+	case tagMerchantCategoryCode:
+		c.MerchantCategoryCode = value
+	case tagTransactionCurrency:
+		c.Currency = value
+	case tagTransactionAmount:
+		// EMVCo allows a trailing decimal mark with no decimals.
+		amount, err := money.Parse(strings.TrimSuffix(value, "."))
+		if err != nil || !amount.IsPositive() {
+			return invalid("transaction amount " + strconv.Quote(value))
+		}
+		c.Amount = amount
+		c.HasAmount = true
+	case tagTipOrConvenience:
+		c.TipIndicator = value
+	case tagConvenienceFeeFixed:
+		fee, err := money.Parse(value)
+		if err != nil || fee.IsNegative() {
+			return invalid("convenience fee " + strconv.Quote(value))
+		}
+		c.ConvenienceFee = fee
+	case tagConvenienceFeePercent:
+		rate, err := money.Parse(value)
+		if err != nil || rate.IsNegative() || rate > money.FromRupiah(100) {
+			return invalid("convenience fee rate " + strconv.Quote(value))
+		}
+		c.ConvenienceFeeRate = value
+	case tagCountryCode:
+		c.CountryCode = value
+	case tagMerchantName:
+		c.MerchantName = value
+	case tagMerchantCity:
+		c.MerchantCity = value
+	case tagPostalCode:
+		c.PostalCode = value
+	case tagAdditionalData:
+		fields, err := parseTLV(value)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			switch field.tag {
+			case "01":
+				c.BillNumber = field.value
+			case "05":
+				c.ReferenceLabel = field.value
+			case "07":
+				c.TerminalLabel = field.value
+			}
+		}
+	case tagCRC:
+		c.CRC = value
+	default:
+		if isMerchantAccountTag(object.tag) {
+			account, err := parseMerchantAccount(object)
+			if err != nil {
+				return err
+			}
+			c.MerchantAccounts = append(c.MerchantAccounts, account)
+
+			if account.GlobalID == qrisGlobalID && account.MerchantID != "" {
+				c.NMID = account.MerchantID
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Code) validate() error {
+	if c.PayloadFormat != "01" {
+		return invalid("unsupported payload format " + strconv.Quote(c.PayloadFormat))
+	}
+
+	if c.PointOfInitiation != "" && c.PointOfInitiation != PointOfInitiationStatic && c.PointOfInitiation != PointOfInitiationDynamic {
+		return invalid("point of initiation " + strconv.Quote(c.PointOfInitiation))
+	}
+
+	if len(c.MerchantAccounts) == 0 {
+		return invalid("no merchant account information")
+	}
+
+	if len(c.MerchantCategoryCode) != 4 || !isDigits(c.MerchantCategoryCode) {
+		return invalid("merchant category code " + strconv.Quote(c.MerchantCategoryCode))
+	}
+
+	if c.Currency != CurrencyRupiah {
+		return invalid("currency " + strconv.Quote(c.Currency))
+	}
+
+	if len(c.CountryCode) != 2 {
+		return invalid("country code " + strconv.Quote(c.CountryCode))
+	}
+
+	if c.MerchantName == "" {
+		return invalid("missing merchant name")
+	}
+
+	if c.MerchantCity == "" {
+		return invalid("missing merchant city")
+	}
+
+	switch c.TipIndicator {
+	case "", TipPrompt:
+	case ConvenienceFeeFixed:
+		if c.ConvenienceFee.IsZero() {
+			return invalid("fixed convenience fee indicated without a fee")
+		}
+	case ConvenienceFeePercent:
+		if c.ConvenienceFeeRate == "" {
+			return invalid("percentage convenience fee indicated without a rate")
+		}
+	default:
+		return invalid("tip or convenience indicator " + strconv.Quote(c.TipIndicator))
+	}
+
+	return nil
+}
+
+type tlv struct {
+	tag   string
+	value string
+}
+
+func parseTLV(data string) ([]tlv, error) {
+	var objects []tlv
+
+	for i := 0; i < len(data); {
+		if i+4 > len(data) {
+			return nil, invalid("truncated object header")
+		}
+
+		tag, lengthText := data[i:i+2], data[i+2:i+4]
+		if !isDigits(tag) || !isDigits(lengthText) {
+			return nil, invalid(fmt.Sprintf("malformed object header at %d", i))
+		}
+
+		length, _ := strconv.Atoi(lengthText)
+		start := i + 4
+		if start+length > len(data) {
+			return nil, invalid("object " + tag + " runs past the end of the payload")
+		}
+
+		objects = append(objects, tlv{tag: tag, value: data[start : start+length]})
+		i = start + length
+	}
+
+	return objects, nil
+}
+
+func parseMerchantAccount(object tlv) (MerchantAccount, error) {
+	fields, err := parseTLV(object.value)
+	if err != nil {
+		return MerchantAccount{}, err
+	}
+
+	account := MerchantAccount{Tag: object.tag}
+	for _, field := range fields {
+		switch field.tag {
+		case "00":
+			account.GlobalID = field.value
+		case "01":
+			account.MerchantPAN = field.value
+		case "02":
+			account.MerchantID = field.value
+		case "03":
+			account.Criteria = field.value
+		}
+	}
+
+	if account.GlobalID == "" {
+		return MerchantAccount{}, invalid("merchant account " + object.tag + " has no global identifier")
+	}
+
+	return account, nil
+}
+
+// isMerchantAccountTag reports whether tag is one of the merchant account
+// information templates. Tags 02 to 25 hold card scheme identifiers as plain
+// values and are skipped.
+func isMerchantAccountTag(tag string) bool {
+	n, err := strconv.Atoi(tag)
+	return err == nil && n >= 26 && n <= 51
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func invalid(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQRISCode, reason)
+}
@@ -0,0 +1,311 @@
+package qris
+
+import (
+	"ProjectGolang/pkg/money"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	staticCode  = "00020101021126610015COM.EXAMPLE.WWW011893600014000001234502090000123450303UMI51440014ID.CO.QRIS.WWW0215ID10200123456780303UMI5204581253033605802ID5914WARUNG BU SITI6007JAKARTA6105101106304CD57"
+	dynamicCode = "00020101021226610015COM.EXAMPLE.WWW011893600014000001234502090000123450303UMI51440014ID.CO.QRIS.WWW0215ID10200123456780303UMI520458125303360540525000550202560410005802ID5914WARUNG BU SITI6007JAKARTA62180107INV-0010703T0163042049"
+
+	merchantAccounts = "26610015COM.EXAMPLE.WWW011893600014000001234502090000123450303UMI51440014ID.CO.QRIS.WWW0215ID10200123456780303UMI"
+)
+
+// withCRC appends a valid CRC to body, so a test reaches the checks after
+// the checksum.
+func withCRC(body string) string {
+	body += crcPrefix
+	return body + Checksum(body)
+}
+
+func TestChecksum(t *testing.T) {
+	// The CRC-16/CCITT-FALSE check value.
+	if got := Checksum("123456789"); got != "29B1" {
+		t.Fatalf("Checksum(%q) = %s, want 29B1", "123456789", got)
+	}
+}
+
+func TestParseStatic(t *testing.T) {
+	code, err := Parse(staticCode)
+	if err != nil {
+		t.Fatalf("Parse unexpected error: %v", err)
+	}
+
+	if code.IsDynamic() || code.PointOfInitiation != PointOfInitiationStatic {
+		t.Errorf("PointOfInitiation = %q, want %q", code.PointOfInitiation, PointOfInitiationStatic)
+	}
+	if code.HasAmount || !code.Amount.IsZero() {
+		t.Errorf("static code has amount %s", code.Amount)
+	}
+	if code.NMID != "ID1020012345678" {
+		t.Errorf("NMID = %q, want ID1020012345678", code.NMID)
+	}
+	if len(code.MerchantAccounts) != 2 || code.MerchantAccounts[0].MerchantPAN != "936000140000012345" {
+		t.Errorf("MerchantAccounts = %+v", code.MerchantAccounts)
+	}
+	if code.MerchantName != "WARUNG BU SITI" || code.MerchantCity != "JAKARTA" || code.PostalCode != "10110" {
+		t.Errorf("merchant = %q, %q, %q", code.MerchantName, code.MerchantCity, code.PostalCode)
+	}
+	if code.MerchantCategoryCode != "5812" || code.Currency != CurrencyRupiah || code.CountryCode != "ID" {
+		t.Errorf("MCC, currency, country = %q, %q, %q", code.MerchantCategoryCode, code.Currency, code.CountryCode)
+	}
+	if code.CRC != "CD57" {
+		t.Errorf("CRC = %q, want CD57", code.CRC)
+	}
+}
+
+func TestParseDynamic(t *testing.T) {
+	code, err := Parse(dynamicCode)
+	if err != nil {
+		t.Fatalf("Parse unexpected error: %v", err)
+	}
+
+	if !code.IsDynamic() {
+		t.Errorf("PointOfInitiation = %q, want %q", code.PointOfInitiation, PointOfInitiationDynamic)
+	}
+	if !code.HasAmount || code.Amount != money.FromRupiah(25000) {
+		t.Errorf("Amount = %s (HasAmount %v), want 25000.00", code.Amount, code.HasAmount)
+	}
+	if code.TipIndicator != ConvenienceFeeFixed || code.Fee(code.Amount) != money.FromRupiah(1000) {
+		t.Errorf("fee = %s with indicator %q, want 1000.00 fixed", code.Fee(code.Amount), code.TipIndicator)
+	}
+	if code.BillNumber != "INV-001" || code.TerminalLabel != "T01" {
+		t.Errorf("additional data = %q, %q", code.BillNumber, code.TerminalLabel)
+	}
+}
+
+func TestParseAcceptsLowercaseCRC(t *testing.T) {
+	payload := staticCode[:len(staticCode)-4] + strings.ToLower(staticCode[len(staticCode)-4:])
+	if _, err := Parse(payload); err != nil {
+		t.Fatalf("Parse unexpected error: %v", err)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name:    "bad CRC",
+			payload: staticCode[:len(staticCode)-4] + "0000",
+		},
+		{
+			name:    "changed content under the original CRC",
+			payload: strings.Replace(staticCode, "WARUNG BU SITI", "WARUNG BU SITA", 1),
+		},
+		{
+			name:    "no CRC",
+			payload: staticCode[:len(staticCode)-8],
+		},
+		{
+			name:    "too short",
+			payload: "6304",
+		},
+		{
+			name:    "length runs past the end",
+			payload: withCRC("000201010211" + merchantAccounts + "5204581253033605802ID5950WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "non-numeric length",
+			payload: withCRC("000201010211" + merchantAccounts + "5204581253033605802ID59XXWARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			// The long merchant name swallows the CRC object's header and
+			// leaves a piece too short for the next one.
+			name:    "truncated object header",
+			payload: withCRC("000201010211" + merchantAccounts + "5204581253033605802ID5930WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "malformed nested template",
+			payload: withCRC("000201010211" + "26050015C" + "5204581253033605802ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "missing merchant name",
+			payload: withCRC("000201010211" + merchantAccounts + "5204581253033605802ID6007JAKARTA"),
+		},
+		{
+			name:    "missing merchant city",
+			payload: withCRC("000201010211" + merchantAccounts + "5204581253033605802ID5914WARUNG BU SITI"),
+		},
+		{
+			name:    "missing merchant account",
+			payload: withCRC("000201010211" + "5204581253033605802ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "payload format not first",
+			payload: withCRC("010211000201" + merchantAccounts + "5204581253033605802ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "duplicate tag",
+			payload: withCRC("000201010211" + merchantAccounts + "5204581253033605802ID5914WARUNG BU SITI5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "unknown point of initiation",
+			payload: withCRC("000201010213" + merchantAccounts + "5204581253033605802ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "foreign currency",
+			payload: withCRC("000201010212" + merchantAccounts + "5204581253038405802ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "zero amount",
+			payload: withCRC("000201010212" + merchantAccounts + "52045812530336054010" + "5802ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "sub-sen amount",
+			payload: withCRC("000201010212" + merchantAccounts + "530336052045812" + "540610.5055802ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+		{
+			name:    "fixed fee indicated without a fee",
+			payload: withCRC("000201010212" + merchantAccounts + "52045812530336054055000055020258" + "02ID5914WARUNG BU SITI6007JAKARTA"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Parse(tt.payload)
+			if !errors.Is(err, ErrInvalidQRISCode) {
+				t.Fatalf("Parse error = %v (code %+v), want ErrInvalidQRISCode", err, code)
+			}
+		})
+	}
+}
+
+func TestParseWithoutAmount(t *testing.T) {
+	// Tag 54 is optional: the payer enters the amount.
+	for _, poi := range []string{PointOfInitiationStatic, PointOfInitiationDynamic} {
+		code, err := Parse(withCRC("000201" + "0102" + poi + merchantAccounts + "5204581253033605802ID5914WARUNG BU SITI6007JAKARTA"))
+		if err != nil {
+			t.Fatalf("POI %s: Parse unexpected error: %v", poi, err)
+		}
+		if code.HasAmount {
+			t.Fatalf("POI %s: HasAmount = true, want false", poi)
+		}
+	}
+}
+
+func TestFee(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   Code
+		amount money.Amount
+		want   money.Amount
+	}{
+		{name: "no fee", code: Code{}, amount: money.FromRupiah(10000), want: 0},
+		{name: "tip prompt", code: Code{TipIndicator: TipPrompt}, amount: money.FromRupiah(10000), want: 0},
+		{name: "fixed", code: Code{TipIndicator: ConvenienceFeeFixed, ConvenienceFee: money.FromRupiah(1500)}, amount: money.FromRupiah(10000), want: money.FromRupiah(1500)},
+		{name: "percentage", code: Code{TipIndicator: ConvenienceFeePercent, ConvenienceFeeRate: "0.7"}, amount: money.FromRupiah(10000), want: money.FromRupiah(70)},
+		{name: "percentage rounds to the nearest sen", code: Code{TipIndicator: ConvenienceFeePercent, ConvenienceFeeRate: "0.75"}, amount: money.FromSen(1234567), want: money.FromSen(9259)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.code.Fee(tt.amount); got != tt.want {
+				t.Fatalf("Fee(%s) = %s, want %s", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+// receiveCode is shaped like the personal codes pkg/receiving issues for
+// receiving money into a wallet.
+func receiveCode(amount money.Amount) Code {
+	return Code{
+		PayloadFormat:     "01",
+		PointOfInitiation: PointOfInitiationDynamic,
+		MerchantAccounts: []MerchantAccount{
+			{Tag: "26", GlobalID: "ID.CO.EXAMPLE.WWW", MerchantPAN: "936000140000099999", MerchantID: "ID1020099999999", Criteria: "UMI"},
+			{Tag: "51", GlobalID: qrisGlobalID, MerchantID: "ID1020099999999", Criteria: "UMI"},
+		},
+		MerchantCategoryCode: "4829",
+		Currency:             CurrencyRupiah,
+		Amount:               amount,
+		HasAmount:            amount.IsPositive(),
+		CountryCode:          "ID",
+		MerchantName:         "BUDI SANTOSO",
+		MerchantCity:         "JAKARTA",
+		ReferenceLabel:       "RCV-01HZX3J8Q6T9",
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount money.Amount
+	}{
+		{name: "fixed amount", amount: money.FromRupiah(150000)},
+		{name: "amount with sen", amount: money.FromSen(1500050)},
+		{name: "open amount", amount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := receiveCode(tt.amount)
+
+			payload, err := want.Encode()
+			if err != nil {
+				t.Fatalf("Encode unexpected error: %v", err)
+			}
+
+			got, err := Parse(payload)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", payload, err)
+			}
+
+			if got.HasAmount != want.HasAmount || got.Amount != want.Amount {
+				t.Errorf("amount = %s (HasAmount %v), want %s (HasAmount %v)", got.Amount, got.HasAmount, want.Amount, want.HasAmount)
+			}
+			if got.ReferenceLabel != want.ReferenceLabel {
+				t.Errorf("ReferenceLabel = %q, want %q", got.ReferenceLabel, want.ReferenceLabel)
+			}
+			if got.NMID != "ID1020099999999" {
+				t.Errorf("NMID = %q, want ID1020099999999", got.NMID)
+			}
+			if got.MerchantName != want.MerchantName || got.MerchantCity != want.MerchantCity || got.MerchantCategoryCode != want.MerchantCategoryCode {
+				t.Errorf("merchant = %q, %q, %q", got.MerchantName, got.MerchantCity, got.MerchantCategoryCode)
+			}
+			if len(got.MerchantAccounts) != len(want.MerchantAccounts) {
+				t.Fatalf("MerchantAccounts = %+v, want %+v", got.MerchantAccounts, want.MerchantAccounts)
+			}
+			for i := range want.MerchantAccounts {
+				if got.MerchantAccounts[i] != want.MerchantAccounts[i] {
+					t.Errorf("MerchantAccounts[%d] = %+v, want %+v", i, got.MerchantAccounts[i], want.MerchantAccounts[i])
+				}
+			}
+
+			again, err := got.Encode()
+			if err != nil {
+				t.Fatalf("re-Encode unexpected error: %v", err)
+			}
+			if again != payload {
+				t.Errorf("re-Encode = %q, want %q", again, payload)
+			}
+		})
+	}
+}
+
+func TestEncodeRejectsInvalidCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Code)
+	}{
+		{name: "missing merchant name", modify: func(c *Code) { c.MerchantName = "" }},
+		{name: "foreign currency", modify: func(c *Code) { c.Currency = "840" }},
+		{name: "value too long", modify: func(c *Code) { c.ReferenceLabel = strings.Repeat("R", 100) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := receiveCode(money.FromRupiah(10000))
+			tt.modify(&code)
+
+			if _, err := code.Encode(); !errors.Is(err, ErrInvalidQRISCode) {
+				t.Fatalf("Encode error = %v, want ErrInvalidQRISCode", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package receiving
+
+import (
+	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/money"
+	"ProjectGolang/pkg/qris"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	fakeNMID         = "ID1025000000001"
+	fakeAcquirerID   = "ID.CO.SENTRAPAY.WWW"
+	fakeMerchantPAN  = "936000000000000001"
+	fakeMerchantCity = "JAKARTA"
+
+	// Money transfer, the category QRIS uses for person-to-person codes.
+	transferMerchantCategory = "4829"
+)
+
+// fakeNotificationPath is the callback endpoint the acquirer's notifications
+// are signed for.
+const fakeNotificationPath = "/api/v1/wallet/callback/doku"
+
+// FakeConfig configures NewFake. PrivateKey signs simulated payment
+// notifications; without it codes can be issued but not paid.
+type FakeConfig struct {
+	PartnerID  string
+	PrivateKey *rsa.PrivateKey
+}
+
+// fakeGateway issues real, parseable QRIS payloads under a made-up NMID for
+// local development. No wallet can pay them; PayQRIS builds the signed
+// notification the acquirer would send instead, which wallet-admin
+// simulate-qris-receive delivers.
+type fakeGateway struct {
+	log        *logrus.Logger
+	partnerID  string
+	privateKey *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*QRISCode
+}
+
+func NewFake(log *logrus.Logger, config FakeConfig) ISimulator {
+	return &fakeGateway{
+		log:        log,
+		partnerID:  config.PartnerID,
+		privateKey: config.PrivateKey,
+		codes:      make(map[string]*QRISCode),
+	}
+}
+
+func (f *fakeGateway) CreateQRIS(ctx context.Context, req QRISRequest) (*QRISCode, error) {
+	if req.ReferenceNo == "" || req.MerchantName == "" || req.Amount.IsNegative() {
+		return nil, ErrInvalidRequest
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Same reference, same code: the channel is idempotent.
+	if existing, ok := f.codes[req.ReferenceNo]; ok {
+		copied := *existing
+		return &copied, nil
+	}
+
+	code := qris.Code{
+		PayloadFormat:     "01",
+		PointOfInitiation: qris.PointOfInitiationDynamic,
+		MerchantAccounts: []qris.MerchantAccount{
+			{Tag: "26", GlobalID: fakeAcquirerID, MerchantPAN: fakeMerchantPAN, MerchantID: fakeNMID, Criteria: "UMI"},
+			{Tag: "51", GlobalID: "ID.CO.QRIS.WWW", MerchantID: fakeNMID, Criteria: "UMI"},
+		},
+		MerchantCategoryCode: transferMerchantCategory,
+		Currency:             qris.CurrencyRupiah,
+		Amount:               req.Amount,
+		HasAmount:            req.Amount.IsPositive(),
+		CountryCode:          "ID",
+		MerchantName:         truncate(req.MerchantName, qris.MaxMerchantNameLength),
+		MerchantCity:         fakeMerchantCity,
+		ReferenceLabel:       req.ReferenceNo,
+	}
+
+	payload, err := code.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	result := &QRISCode{
+		ReferenceNo:      req.ReferenceNo,
+		GatewayReference: fmt.Sprintf("FAKE-%d", time.Now().UnixNano()),
+		NMID:             fakeNMID,
+		Payload:          payload,
+		ExpiresAt:        req.ExpiresAt,
+	}
+
+	f.codes[req.ReferenceNo] = result
+
+	f.log.WithFields(logrus.Fields{
+		"reference_no":      req.ReferenceNo,
+		"gateway_reference": result.GatewayReference,
+		"amount":            req.Amount,
+		"expires_at":        req.ExpiresAt,
+	}).Info("Fake QRIS code issued")
+
+	copied := *result
+	return &copied, nil
+}
+
+// PayQRIS pays the code issued under referenceNo and returns the payment
+// notification for it, in DOKU's format and signed like the DOKU simulator's
+// own notifications. The code does not have to have been issued by this
+// process.
+func (f *fakeGateway) PayQRIS(ctx context.Context, referenceNo string, amount money.Amount) (*Notification, error) {
+	if f.privateKey == nil {
+		return nil, ErrSimulationNotConfigured
+	}
+	if referenceNo == "" || !amount.IsPositive() {
+		return nil, ErrInvalidRequest
+	}
+
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now()
+
+	body, err := json.Marshal(doku.VAPaymentNotification{
+		TrxId:            referenceNo,
+		PaymentRequestId: fmt.Sprintf("FAKE%d", now.UnixNano()),
+		PaidAmount:       doku.NotificationAmount{Value: amount.String(), Currency: "IDR"},
+		TotalAmount:      doku.NotificationAmount{Value: amount.String(), Currency: "IDR"},
+		TrxDateTime:      now.In(loc).Format("2006-01-02T15:04:05-07:00"),
+		AdditionalInfo:   doku.VAPaymentNotificationAddition{Channel: "QRIS"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %v", err)
+	}
+
+	timestamp := now.Format(time.RFC3339)
+	signature, err := doku.SignNotification(f.privateKey, doku.Notification{
+		HTTPMethod:  http.MethodPost,
+		EndpointURL: fakeNotificationPath,
+		Body:        body,
+		Timestamp:   timestamp,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign notification: %v", err)
+	}
+
+	f.log.WithFields(logrus.Fields{
+		"reference_no": referenceNo,
+		"amount":       amount,
+	}).Info("Fake QRIS payment notification signed")
+
+	return &Notification{
+		HTTPMethod:  http.MethodPost,
+		EndpointURL: fakeNotificationPath,
+		Body:        body,
+		Headers: map[string]string{
+			"Content-Type":  "application/json",
+			"X-Partner-Id":  f.partnerID,
+			"X-Timestamp":   timestamp,
+			"X-External-Id": fmt.Sprintf("%d", now.UnixNano()),
+			"X-Signature":   signature,
+		},
+	}, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
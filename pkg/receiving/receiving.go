@@ -0,0 +1,92 @@
+package receiving
+
+import (
+	"ProjectGolang/pkg/doku"
+	"ProjectGolang/pkg/money"
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedProvider     = errors.New("unsupported receiving provider")
+	ErrInvalidRequest          = errors.New("invalid receiving request")
+	ErrSimulationNotConfigured = errors.New("receiving channel has no key to sign notifications")
+)
+
+// IReceivingChannel issues payment codes that others pay into a user's
+// wallet. ReferenceNo is our own reference: the acquirer echoes it as the trxId
+// of the payment notification, which is then settled like a top-up.
+type IReceivingChannel interface {
+	CreateQRIS(ctx context.Context, req QRISRequest) (*QRISCode, error)
+}
+
+// ISimulator is implemented by channels that can also play the payer. Only
+// the local fake does.
+type ISimulator interface {
+	IReceivingChannel
+	PayQRIS(ctx context.Context, referenceNo string, amount money.Amount) (*Notification, error)
+}
+
+// Notification is a payment notification as the acquirer posts it to our
+// callback endpoint.
+type Notification struct {
+	HTTPMethod  string
+	EndpointURL string
+	Body        []byte
+	Headers     map[string]string
+}
+
+// QRISRequest asks for a single-use dynamic QRIS. A zero Amount leaves the
+// amount to the payer. The merchant city is the one registered with the
+// acquirer, so the channel fills it in.
+type QRISRequest struct {
+	ReferenceNo  string
+	MerchantName string
+	Amount       money.Amount
+	ExpiresAt    time.Time
+}
+
+type QRISCode struct {
+	ReferenceNo      string
+	GatewayReference string
+	NMID             string
+	Payload          string
+	ExpiresAt        time.Time
+}
+
+// New returns the channel selected by QRIS_RECEIVE_PROVIDER. Only the local
+// fake exists for now; it is also the default when the variable is empty.
+// Acquirer notifications reach us through DOKU's callback, so the fake signs
+// its simulated payments with DOKU_SIMULATOR_PRIVATE_KEY.
+func New(log *logrus.Logger) (IReceivingChannel, error) {
+	provider := strings.ToLower(os.Getenv("QRIS_RECEIVE_PROVIDER"))
+
+	switch provider {
+	case "", "fake":
+		if os.Getenv("PRODUCTION") == "true" {
+			log.Warn("Using fake QRIS receiving channel in production, issued codes cannot be paid")
+		}
+
+		var privateKey *rsa.PrivateKey
+		if key := os.Getenv("DOKU_SIMULATOR_PRIVATE_KEY"); key != "" {
+			parsed, err := doku.ParseRSAPrivateKey(key)
+			if err != nil {
+				return nil, err
+			}
+			privateKey = parsed
+		}
+
+		return NewFake(log, FakeConfig{
+			PartnerID:  os.Getenv("DOKU_CLIENT_ID"),
+			PrivateKey: privateKey,
+		}), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, provider)
+	}
+}
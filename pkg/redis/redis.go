@@ -14,6 +14,19 @@ import (
 type IRedis interface {
 	SetOTP(ctx context.Context, key string, code string, expiration time.Duration) error
 	GetOTP(ctx context.Context, key string) (string, error)
+	DeleteOTP(ctx context.Context, key string) error
+	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Increment(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	GetTTL(ctx context.Context, key string) (time.Duration, error)
+	Publish(ctx context.Context, channel, message string) error
+	PSubscribe(ctx context.Context, pattern string) <-chan Message
+}
+
+// Message is a message received on a subscribed channel.
+type Message struct {
+	Channel string
+	Payload string
 }
 
 type redisClient struct {
@@ -86,3 +99,95 @@ func (r *redisClient) DeleteOTP(ctx context.Context, key string) error {
 	logrus.Debug(fmt.Sprintf("Successfully deleted OTP for key %s", key))
 	return nil
 }
+
+// Set stores value at key for the given expiration, replacing any previous
+// value and expiry.
+func (r *redisClient) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	if err := r.client.Set(ctx, key, value, expiration).Err(); err != nil {
+		logrus.Error(fmt.Sprintf("Error setting key %s: %v", key, err))
+		return err
+	}
+	return nil
+}
+
+// Delete removes key. Deleting a key that does not exist is not an error.
+func (r *redisClient) Delete(ctx context.Context, key string) error {
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		logrus.Error(fmt.Sprintf("Error deleting key %s: %v", key, err))
+		return err
+	}
+	return nil
+}
+
+// Increment bumps the counter stored at key and starts its expiry when the
+// counter is first created, so the window is fixed from the first increment.
+func (r *redisClient) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, expiration)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logrus.Error(fmt.Sprintf("Error incrementing key %s: %v", key, err))
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// GetTTL returns the remaining lifetime of key, or zero when the key does not
+// exist or has no expiry.
+func (r *redisClient) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		logrus.Error(fmt.Sprintf("Error getting TTL for key %s: %v", key, err))
+		return 0, err
+	}
+
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func (r *redisClient) Publish(ctx context.Context, channel, message string) error {
+	if err := r.client.Publish(ctx, channel, message).Err(); err != nil {
+		logrus.Error(fmt.Sprintf("Error publishing to channel %s: %v", channel, err))
+		return err
+	}
+	return nil
+}
+
+// PSubscribe delivers messages published to channels matching pattern until
+// ctx is cancelled, then closes the returned channel. The subscription
+// survives lost connections, but messages published while disconnected are
+// not delivered.
+func (r *redisClient) PSubscribe(ctx context.Context, pattern string) <-chan Message {
+	pubsub := r.client.PSubscribe(ctx, pattern)
+	messages := make(chan Message)
+
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		received := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-received:
+				if !ok {
+					return
+				}
+
+				select {
+				case messages <- Message{Channel: msg.Channel, Payload: msg.Payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages
+}
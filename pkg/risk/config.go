@@ -0,0 +1,135 @@
+package risk
+
+import (
+	"ProjectGolang/pkg/money"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVelocityMaxCount    = 5
+	defaultVelocityWindow      = 10 * time.Minute
+	defaultNewPayeeThreshold   = 1_000_000 * money.Rupiah
+	defaultUnusualHoursHistory = 20
+	defaultUnusualHoursWindow  = 90 * 24 * time.Hour
+	defaultUnidentifiedDevice  = Allow
+	ruleDisabled               = "off"
+)
+
+var defaultRuleActions = map[string]Action{
+	RuleVelocity:     Challenge,
+	RuleNewPayee:     Challenge,
+	RuleUnusualHours: Challenge,
+	RuleNewDevice:    Challenge,
+}
+
+// New builds the engine from the environment. RISK_RULES sets the action of
+// each rule as a comma separated list of rule=action, where action is allow,
+// challenge, deny or off; rules left out challenge. The RISK_* thresholds
+// fall back to their defaults when empty or invalid.
+// RISK_UNIDENTIFIED_DEVICE is the new_device action for clients that send no
+// device ID, allow unless set.
+func New() (IEngine, error) {
+	actions, err := parseRuleActions(os.Getenv("RISK_RULES"))
+	if err != nil {
+		return nil, err
+	}
+
+	unidentifiedDevice := defaultUnidentifiedDevice
+	if value := strings.ToLower(strings.TrimSpace(os.Getenv("RISK_UNIDENTIFIED_DEVICE"))); value != "" {
+		unidentifiedDevice, err = parseAction(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	velocityMaxCount := envInt("RISK_VELOCITY_MAX_COUNT", defaultVelocityMaxCount)
+	velocityWindow := envDuration("RISK_VELOCITY_WINDOW", defaultVelocityWindow)
+	unusualHoursHistory := envInt("RISK_UNUSUAL_HOURS_MIN_HISTORY", defaultUnusualHoursHistory)
+	unusualHoursWindow := envDuration("RISK_UNUSUAL_HOURS_WINDOW", defaultUnusualHoursWindow)
+
+	newPayeeThreshold, err := money.Parse(os.Getenv("RISK_NEW_PAYEE_THRESHOLD"))
+	if err != nil || newPayeeThreshold <= 0 {
+		newPayeeThreshold = defaultNewPayeeThreshold
+	}
+
+	var rules []Rule
+	for _, name := range []string{RuleVelocity, RuleNewPayee, RuleUnusualHours, RuleNewDevice} {
+		action, ok := actions[name]
+		if !ok {
+			action = defaultRuleActions[name]
+		}
+		if action == Allow {
+			continue
+		}
+
+		switch name {
+		case RuleVelocity:
+			rules = append(rules, NewVelocityRule(action, velocityMaxCount, velocityWindow))
+		case RuleNewPayee:
+			rules = append(rules, NewNewPayeeRule(action, newPayeeThreshold))
+		case RuleUnusualHours:
+			rules = append(rules, NewUnusualHoursRule(action, unusualHoursHistory, unusualHoursWindow))
+		case RuleNewDevice:
+			rules = append(rules, NewNewDeviceRule(action, unidentifiedDevice))
+		}
+	}
+
+	return NewEngine(rules...), nil
+}
+
+// parseRuleActions parses a comma separated list of rule=action. Off is
+// returned as Allow, which never flags an operation.
+func parseRuleActions(value string) (map[string]Action, error) {
+	actions := make(map[string]Action)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, actionValue, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		actionValue = strings.ToLower(strings.TrimSpace(actionValue))
+		if !ok || name == "" || actionValue == "" {
+			return nil, fmt.Errorf("invalid risk rule setting %q", entry)
+		}
+
+		if _, known := defaultRuleActions[name]; !known {
+			return nil, fmt.Errorf("unknown risk rule %q", name)
+		}
+
+		if actionValue == ruleDisabled {
+			actions[name] = Allow
+			continue
+		}
+
+		action, err := parseAction(actionValue)
+		if err != nil {
+			return nil, err
+		}
+		actions[name] = action
+	}
+
+	return actions, nil
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 1 {
+		return fallback
+	}
+	return value
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
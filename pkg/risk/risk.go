@@ -0,0 +1,119 @@
+package risk
+
+import (
+	"ProjectGolang/pkg/money"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Action is a rule's verdict on an operation. A challenged operation goes
+// ahead only once the user passes a second factor.
+type Action string
+
+const (
+	Allow     Action = "allow"
+	Challenge Action = "challenge"
+	Deny      Action = "deny"
+)
+
+func (a Action) severity() int {
+	switch a {
+	case Deny:
+		return 2
+	case Challenge:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func parseAction(value string) (Action, error) {
+	switch action := Action(value); action {
+	case Allow, Challenge, Deny:
+		return action, nil
+	default:
+		return "", fmt.Errorf("invalid risk action %q", value)
+	}
+}
+
+// Operation is a money movement about to happen. Kind is the wallet
+// transaction type it will be written as. Payee identifies the merchant or
+// recipient and is empty for operations without one.
+type Operation struct {
+	UserID   string
+	Kind     string
+	Amount   money.Amount
+	Payee    string
+	DeviceID string
+	At       time.Time
+}
+
+// History is the user's past activity that rules judge an operation against.
+// A movement is a debit or a top-up the user started.
+type History interface {
+	CountMovementsSince(ctx context.Context, userID string, since time.Time) (int, error)
+	MovementTimesSince(ctx context.Context, userID string, since time.Time) ([]time.Time, error)
+	HasPaidPayee(ctx context.Context, userID, kind, payee string) (bool, error)
+	KnownDevices(ctx context.Context, userID string) ([]string, error)
+}
+
+// Result is one rule's verdict. Reason says why the rule fired and is empty
+// when the rule allowed the operation.
+type Result struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Decision is the strictest action of all rules, with the results of the
+// rules that did not allow the operation.
+type Decision struct {
+	Action  Action   `json:"action"`
+	Results []Result `json:"results"`
+}
+
+// Rule is one check. Rules are independent of each other and of the order
+// they run in.
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, op Operation, history History) (Result, error)
+}
+
+// IEngine screens an operation with every configured rule.
+type IEngine interface {
+	Evaluate(ctx context.Context, op Operation, history History) (Decision, error)
+}
+
+type engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) IEngine {
+	return &engine{rules: rules}
+}
+
+func (e *engine) Evaluate(ctx context.Context, op Operation, history History) (Decision, error) {
+	decision := Decision{
+		Action:  Allow,
+		Results: []Result{},
+	}
+
+	for _, rule := range e.rules {
+		result, err := rule.Evaluate(ctx, op, history)
+		if err != nil {
+			return Decision{}, fmt.Errorf("risk rule %s: %w", rule.Name(), err)
+		}
+
+		if result.Action == Allow {
+			continue
+		}
+
+		decision.Results = append(decision.Results, result)
+		if result.Action.severity() > decision.Action.severity() {
+			decision.Action = result.Action
+		}
+	}
+
+	return decision, nil
+}
@@ -0,0 +1,184 @@
+package risk
+
+import (
+	"ProjectGolang/pkg/money"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Rule names, as used in RISK_RULES and in logged decisions.
+const (
+	RuleVelocity     = "velocity"
+	RuleNewPayee     = "new_payee"
+	RuleUnusualHours = "unusual_hours"
+	RuleNewDevice    = "new_device"
+)
+
+// velocityRule fires when the user has already started maxCount movements
+// within window.
+type velocityRule struct {
+	action   Action
+	maxCount int
+	window   time.Duration
+}
+
+func NewVelocityRule(action Action, maxCount int, window time.Duration) Rule {
+	return &velocityRule{action: action, maxCount: maxCount, window: window}
+}
+
+func (r *velocityRule) Name() string { return RuleVelocity }
+
+func (r *velocityRule) Evaluate(ctx context.Context, op Operation, history History) (Result, error) {
+	count, err := history.CountMovementsSince(ctx, op.UserID, op.At.Add(-r.window))
+	if err != nil {
+		return Result{}, err
+	}
+
+	if count < r.maxCount {
+		return Result{Rule: RuleVelocity, Action: Allow}, nil
+	}
+
+	return Result{
+		Rule:   RuleVelocity,
+		Action: r.action,
+		Reason: fmt.Sprintf("%d payments in the last %s", count, r.window),
+	}, nil
+}
+
+// newPayeeRule fires on the first payment of at least threshold to a payee
+// the user has never paid successfully.
+type newPayeeRule struct {
+	action    Action
+	threshold money.Amount
+}
+
+func NewNewPayeeRule(action Action, threshold money.Amount) Rule {
+	return &newPayeeRule{action: action, threshold: threshold}
+}
+
+func (r *newPayeeRule) Name() string { return RuleNewPayee }
+
+func (r *newPayeeRule) Evaluate(ctx context.Context, op Operation, history History) (Result, error) {
+	if op.Payee == "" || op.Amount < r.threshold {
+		return Result{Rule: RuleNewPayee, Action: Allow}, nil
+	}
+
+	paid, err := history.HasPaidPayee(ctx, op.UserID, op.Kind, op.Payee)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if paid {
+		return Result{Rule: RuleNewPayee, Action: Allow}, nil
+	}
+
+	return Result{
+		Rule:   RuleNewPayee,
+		Action: r.action,
+		Reason: fmt.Sprintf("first payment of %s to %s", op.Amount.Format(), op.Payee),
+	}, nil
+}
+
+// unusualHoursRule fires when none of the user's movements within window
+// happened within an hour of the time of day of this one. Users with fewer
+// than minHistory movements have no pattern yet and are never flagged. Hours
+// are compared in the zone the history is stored in.
+type unusualHoursRule struct {
+	action     Action
+	minHistory int
+	window     time.Duration
+}
+
+func NewUnusualHoursRule(action Action, minHistory int, window time.Duration) Rule {
+	return &unusualHoursRule{action: action, minHistory: minHistory, window: window}
+}
+
+func (r *unusualHoursRule) Name() string { return RuleUnusualHours }
+
+func (r *unusualHoursRule) Evaluate(ctx context.Context, op Operation, history History) (Result, error) {
+	times, err := history.MovementTimesSince(ctx, op.UserID, op.At.Add(-r.window))
+	if err != nil {
+		return Result{}, err
+	}
+
+	if len(times) < r.minHistory {
+		return Result{Rule: RuleUnusualHours, Action: Allow}, nil
+	}
+
+	hour := op.At.Hour()
+	for _, t := range times {
+		if hourDistance(t.Hour(), hour) <= 1 {
+			return Result{Rule: RuleUnusualHours, Action: Allow}, nil
+		}
+	}
+
+	return Result{
+		Rule:   RuleUnusualHours,
+		Action: r.action,
+		Reason: fmt.Sprintf("no activity around %02d:00 in %d earlier movements", hour, len(times)),
+	}, nil
+}
+
+// hourDistance is the distance between two hours of the day, across
+// midnight where that is shorter.
+func hourDistance(a, b int) int {
+	distance := a - b
+	if distance < 0 {
+		distance = -distance
+	}
+	if distance > 12 {
+		distance = 24 - distance
+	}
+	return distance
+}
+
+// newDeviceRule fires when the operation comes from a device the user has
+// not used for a movement before. The first device a user moves money from
+// is trusted. Operations from clients that do not identify their device get
+// unidentifiedAction instead, since current app versions and browsers do not
+// send one yet.
+type newDeviceRule struct {
+	action             Action
+	unidentifiedAction Action
+}
+
+func NewNewDeviceRule(action, unidentifiedAction Action) Rule {
+	return &newDeviceRule{action: action, unidentifiedAction: unidentifiedAction}
+}
+
+func (r *newDeviceRule) Name() string { return RuleNewDevice }
+
+func (r *newDeviceRule) Evaluate(ctx context.Context, op Operation, history History) (Result, error) {
+	if op.DeviceID == "" {
+		if r.unidentifiedAction == Allow {
+			return Result{Rule: RuleNewDevice, Action: Allow}, nil
+		}
+		return Result{
+			Rule:   RuleNewDevice,
+			Action: r.unidentifiedAction,
+			Reason: "device not identified",
+		}, nil
+	}
+
+	devices, err := history.KnownDevices(ctx, op.UserID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if len(devices) == 0 {
+		return Result{Rule: RuleNewDevice, Action: Allow}, nil
+	}
+
+	for _, device := range devices {
+		if device == op.DeviceID {
+			return Result{Rule: RuleNewDevice, Action: Allow}, nil
+		}
+	}
+
+	return Result{
+		Rule:   RuleNewDevice,
+		Action: r.action,
+		Reason: "payment from a new device",
+	}, nil
+}
@@ -0,0 +1,348 @@
+package risk
+
+import (
+	"ProjectGolang/pkg/money"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeHistory answers rules from fixed data and records the window start it
+// was asked for.
+type fakeHistory struct {
+	movements []time.Time
+	paid      map[string]bool
+	devices   []string
+	err       error
+
+	since time.Time
+}
+
+func (h *fakeHistory) CountMovementsSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	h.since = since
+	count := 0
+	for _, at := range h.movements {
+		if !at.Before(since) {
+			count++
+		}
+	}
+	return count, h.err
+}
+
+func (h *fakeHistory) MovementTimesSince(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	h.since = since
+	var times []time.Time
+	for _, at := range h.movements {
+		if !at.Before(since) {
+			times = append(times, at)
+		}
+	}
+	return times, h.err
+}
+
+func (h *fakeHistory) HasPaidPayee(ctx context.Context, userID, kind, payee string) (bool, error) {
+	return h.paid[kind+":"+payee], h.err
+}
+
+func (h *fakeHistory) KnownDevices(ctx context.Context, userID string) ([]string, error) {
+	return h.devices, h.err
+}
+
+var testNow = time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+
+// movementsAt returns count movements spread over the minutes before at.
+func movementsAt(at time.Time, count int) []time.Time {
+	times := make([]time.Time, count)
+	for i := range times {
+		times[i] = at.Add(-time.Duration(i+1) * time.Minute)
+	}
+	return times
+}
+
+func TestVelocityRule(t *testing.T) {
+	rule := NewVelocityRule(Deny, 5, 10*time.Minute)
+
+	tests := []struct {
+		name      string
+		movements []time.Time
+		want      Action
+	}{
+		{name: "no movements", want: Allow},
+		{name: "one below the limit", movements: movementsAt(testNow, 4), want: Allow},
+		{name: "at the limit", movements: movementsAt(testNow, 5), want: Deny},
+		{name: "above the limit", movements: movementsAt(testNow, 8), want: Deny},
+		{
+			name:      "movements outside the window",
+			movements: append(movementsAt(testNow, 4), testNow.Add(-11*time.Minute), testNow.Add(-time.Hour)),
+			want:      Allow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &fakeHistory{movements: tt.movements}
+			result, err := rule.Evaluate(context.Background(), Operation{UserID: "user-1", At: testNow}, history)
+			if err != nil {
+				t.Fatalf("Evaluate unexpected error: %v", err)
+			}
+			if result.Action != tt.want {
+				t.Fatalf("Action = %s (%s), want %s", result.Action, result.Reason, tt.want)
+			}
+			if !history.since.Equal(testNow.Add(-10 * time.Minute)) {
+				t.Fatalf("window starts at %s, want %s", history.since, testNow.Add(-10*time.Minute))
+			}
+		})
+	}
+}
+
+func TestNewPayeeRule(t *testing.T) {
+	threshold := money.FromRupiah(1_000_000)
+	rule := NewNewPayeeRule(Challenge, threshold)
+
+	tests := []struct {
+		name   string
+		payee  string
+		amount money.Amount
+		paid   bool
+		want   Action
+	}{
+		{name: "new payee below the threshold", payee: "ID1020012345678", amount: threshold - money.Sen, want: Allow},
+		{name: "new payee at the threshold", payee: "ID1020012345678", amount: threshold, want: Challenge},
+		{name: "new payee above the threshold", payee: "ID1020012345678", amount: threshold * 3, want: Challenge},
+		{name: "known payee above the threshold", payee: "ID1020012345678", amount: threshold * 3, paid: true, want: Allow},
+		{name: "operation without a payee", amount: threshold * 3, want: Allow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &fakeHistory{paid: map[string]bool{"qris_payment:" + tt.payee: tt.paid}}
+			op := Operation{UserID: "user-1", Kind: "qris_payment", Payee: tt.payee, Amount: tt.amount, At: testNow}
+
+			result, err := rule.Evaluate(context.Background(), op, history)
+			if err != nil {
+				t.Fatalf("Evaluate unexpected error: %v", err)
+			}
+			if result.Action != tt.want {
+				t.Fatalf("Action = %s (%s), want %s", result.Action, result.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnusualHoursRule(t *testing.T) {
+	rule := NewUnusualHoursRule(Challenge, 3, 90*24*time.Hour)
+
+	// dailyAt returns count movements on the days before testNow at hour.
+	dailyAt := func(hour, count int) []time.Time {
+		times := make([]time.Time, count)
+		for i := range times {
+			day := testNow.AddDate(0, 0, -(i + 1))
+			times[i] = time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.UTC)
+		}
+		return times
+	}
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		movements []time.Time
+		want      Action
+	}{
+		{name: "history below the minimum", at: testNow, movements: dailyAt(3, 2), want: Allow},
+		{name: "history at the minimum, unusual hour", at: testNow, movements: dailyAt(3, 3), want: Challenge},
+		{name: "same hour as before", at: testNow, movements: dailyAt(14, 3), want: Allow},
+		{name: "one hour from the usual time", at: testNow, movements: dailyAt(13, 3), want: Allow},
+		{name: "two hours from the usual time", at: testNow, movements: dailyAt(12, 3), want: Challenge},
+		{
+			name:      "one hour across midnight",
+			at:        time.Date(2026, 3, 10, 0, 15, 0, 0, time.UTC),
+			movements: dailyAt(23, 3),
+			want:      Allow,
+		},
+		{
+			name:      "old history outside the window",
+			at:        testNow,
+			movements: []time.Time{testNow.AddDate(0, 0, -100), testNow.AddDate(0, 0, -120), testNow.AddDate(0, 0, -150)},
+			want:      Allow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &fakeHistory{movements: tt.movements}
+			result, err := rule.Evaluate(context.Background(), Operation{UserID: "user-1", At: tt.at}, history)
+			if err != nil {
+				t.Fatalf("Evaluate unexpected error: %v", err)
+			}
+			if result.Action != tt.want {
+				t.Fatalf("Action = %s (%s), want %s", result.Action, result.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDeviceRule(t *testing.T) {
+	tests := []struct {
+		name         string
+		unidentified Action
+		deviceID     string
+		devices      []string
+		want         Action
+	}{
+		{name: "first device", unidentified: Allow, deviceID: "device-a", want: Allow},
+		{name: "known device", unidentified: Allow, deviceID: "device-b", devices: []string{"device-a", "device-b"}, want: Allow},
+		{name: "new device", unidentified: Allow, deviceID: "device-c", devices: []string{"device-a", "device-b"}, want: Challenge},
+		{name: "device not identified", unidentified: Allow, devices: []string{"device-a"}, want: Allow},
+		{name: "device not identified on first use", unidentified: Allow, want: Allow},
+		{name: "device not identified, challenged", unidentified: Challenge, devices: []string{"device-a"}, want: Challenge},
+		{name: "device not identified, denied", unidentified: Deny, want: Deny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := NewNewDeviceRule(Challenge, tt.unidentified)
+			history := &fakeHistory{devices: tt.devices}
+			result, err := rule.Evaluate(context.Background(), Operation{UserID: "user-1", DeviceID: tt.deviceID, At: testNow}, history)
+			if err != nil {
+				t.Fatalf("Evaluate unexpected error: %v", err)
+			}
+			if result.Action != tt.want {
+				t.Fatalf("Action = %s (%s), want %s", result.Action, result.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewUnidentifiedDeviceAction(t *testing.T) {
+	unidentified := Operation{UserID: "user-1", At: testNow}
+	history := &fakeHistory{devices: []string{"device-a"}}
+
+	tests := []struct {
+		value   string
+		want    Action
+		wantErr bool
+	}{
+		{value: "", want: Allow},
+		{value: "challenge", want: Challenge},
+		{value: " Deny ", want: Deny},
+		{value: "block", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("RISK_RULES", "velocity=off,new_payee=off,unusual_hours=off")
+			t.Setenv("RISK_UNIDENTIFIED_DEVICE", tt.value)
+
+			engine, err := New()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("New() with RISK_UNIDENTIFIED_DEVICE=%q: want an error", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+
+			decision, err := engine.Evaluate(context.Background(), unidentified, history)
+			if err != nil {
+				t.Fatalf("Evaluate unexpected error: %v", err)
+			}
+			if decision.Action != tt.want {
+				t.Fatalf("Action = %s, want %s", decision.Action, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineTakesStrictestAction(t *testing.T) {
+	engine := NewEngine(
+		NewVelocityRule(Deny, 2, 10*time.Minute),
+		NewNewDeviceRule(Challenge, Allow),
+		NewNewPayeeRule(Challenge, money.FromRupiah(100)),
+	)
+
+	tests := []struct {
+		name      string
+		movements []time.Time
+		deviceID  string
+		want      Action
+		wantRules []string
+	}{
+		{name: "nothing fires", deviceID: "device-a", want: Allow, wantRules: []string{}},
+		{name: "challenge only", deviceID: "device-b", want: Challenge, wantRules: []string{RuleNewDevice}},
+		{name: "deny wins over challenge", movements: movementsAt(testNow, 2), deviceID: "device-b", want: Deny, wantRules: []string{RuleVelocity, RuleNewDevice}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &fakeHistory{movements: tt.movements, devices: []string{"device-a"}}
+			op := Operation{UserID: "user-1", DeviceID: tt.deviceID, At: testNow}
+
+			decision, err := engine.Evaluate(context.Background(), op, history)
+			if err != nil {
+				t.Fatalf("Evaluate unexpected error: %v", err)
+			}
+			if decision.Action != tt.want {
+				t.Fatalf("Action = %s, want %s", decision.Action, tt.want)
+			}
+			if len(decision.Results) != len(tt.wantRules) {
+				t.Fatalf("Results = %+v, want rules %v", decision.Results, tt.wantRules)
+			}
+			for i, rule := range tt.wantRules {
+				if decision.Results[i].Rule != rule {
+					t.Fatalf("Results[%d].Rule = %s, want %s", i, decision.Results[i].Rule, rule)
+				}
+			}
+		})
+	}
+}
+
+func TestEngineReportsHistoryErrors(t *testing.T) {
+	failure := errors.New("database unavailable")
+	engine := NewEngine(NewVelocityRule(Deny, 5, 10*time.Minute))
+
+	_, err := engine.Evaluate(context.Background(), Operation{UserID: "user-1", At: testNow}, &fakeHistory{err: failure})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Evaluate error = %v, want %v", err, failure)
+	}
+}
+
+func TestParseRuleActions(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]Action
+		wantErr bool
+	}{
+		{value: "", want: map[string]Action{}},
+		{value: "velocity=deny, new_device=off", want: map[string]Action{RuleVelocity: Deny, RuleNewDevice: Allow}},
+		{value: "NEW_PAYEE=Challenge", want: map[string]Action{RuleNewPayee: Challenge}},
+		{value: "velocity", wantErr: true},
+		{value: "velocity=block", wantErr: true},
+		{value: "geo=deny", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseRuleActions(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRuleActions(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRuleActions(%q) unexpected error: %v", tt.value, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseRuleActions(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			for name, action := range tt.want {
+				if got[name] != action {
+					t.Fatalf("parseRuleActions(%q)[%s] = %s, want %s", tt.value, name, got[name], action)
+				}
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 type ItfS3 interface {
 	UploadFile(file *multipart.FileHeader) (string, error)
 	UploadFileFromBytes(filename string, data []byte) (string, error)  
+	UploadBytes(filename string, data []byte, contentType string) (string, error)
 	PresignUrl(fileName string) (string, error)
 	DeleteFile(fileName string) error
 }
@@ -150,6 +151,10 @@ func generateUniqueFileName(fileName string) (string, error) {
 
 
 func (s *s3Client) UploadFileFromBytes(filename string, data []byte) (string, error) {
+	return s.UploadBytes(filename, data, "audio/mpeg")
+}
+
+func (s *s3Client) UploadBytes(filename string, data []byte, contentType string) (string, error) {
 	uploader := s3manager.NewUploader(s.session)
 
 	uniqueFileName, err := generateUniqueFileName(filename)
@@ -162,7 +167,7 @@ func (s *s3Client) UploadFileFromBytes(filename string, data []byte) (string, er
 		Bucket:      aws.String(s.bucketName),
 		Key:         aws.String(uniqueFileName),
 		Body:        bytes.NewReader(data),
-		ContentType: aws.String("audio/mpeg"),
+		ContentType: aws.String(contentType),
 	})
 
 	if err != nil {
@@ -0,0 +1,185 @@
+package scheduler
+
+import (
+	contextPkg "ProjectGolang/pkg/context"
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Job is one run of a scheduled task. The context is cancelled when the
+// scheduler stops and carries a request ID for log correlation.
+type Job func(ctx context.Context) error
+
+// IScheduler runs background jobs inside the API process. Runs of the same
+// job never overlap; jobs that must not run concurrently across instances
+// have to guard themselves (for example with FOR UPDATE SKIP LOCKED).
+type IScheduler interface {
+	Every(name string, interval time.Duration, job Job)
+	Daily(name string, hour, minute int, loc *time.Location, job Job)
+	Start()
+	Stop()
+}
+
+type entry struct {
+	name     string
+	schedule string
+	timeout  time.Duration
+	next     func(now time.Time) time.Time
+	job      Job
+}
+
+type scheduler struct {
+	log     *logrus.Logger
+	mu      sync.Mutex
+	entries []entry
+	started bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func New(log *logrus.Logger) IScheduler {
+	return &scheduler{
+		log: log,
+	}
+}
+
+// Every registers job to run every interval. Jobs registered after Start are
+// started immediately.
+func (s *scheduler) Every(name string, interval time.Duration, job Job) {
+	s.add(entry{
+		name:     name,
+		schedule: "every " + interval.String(),
+		timeout:  interval,
+		next: func(now time.Time) time.Time {
+			return now.Add(interval)
+		},
+		job: job,
+	})
+}
+
+// Daily registers job to run once a day at hour:minute in loc. A run may take
+// up to 24 hours before its context is cancelled.
+func (s *scheduler) Daily(name string, hour, minute int, loc *time.Location, job Job) {
+	s.add(entry{
+		name:     name,
+		schedule: fmt.Sprintf("daily at %02d:%02d %s", hour, minute, loc.String()),
+		timeout:  24 * time.Hour,
+		next: func(now time.Time) time.Time {
+			return nextDailyRun(now, hour, minute, loc)
+		},
+		job: job,
+	})
+}
+
+func nextDailyRun(now time.Time, hour, minute int, loc *time.Location) time.Time {
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func (s *scheduler) add(e entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, e)
+
+	if s.started {
+		s.launch(e)
+	}
+}
+
+func (s *scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.started = true
+
+	for _, e := range s.entries {
+		s.launch(e)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return.
+func (s *scheduler) Stop() {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	s.started = false
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *scheduler) launch(e entry) {
+	ctx := s.ctx
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		s.log.WithFields(logrus.Fields{
+			"job":      e.name,
+			"schedule": e.schedule,
+		}).Info("Scheduled job started")
+
+		for {
+			timer := time.NewTimer(time.Until(e.next(time.Now())))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				s.run(ctx, e)
+			}
+		}
+	}()
+}
+
+func (s *scheduler) run(ctx context.Context, e entry) {
+	startedAt := time.Now()
+	requestID := fmt.Sprintf("job-%s-%d", e.name, startedAt.Unix())
+
+	runCtx, cancel := context.WithTimeout(contextPkg.WithRequestID(ctx, requestID), e.timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"job":        e.name,
+				"panic":      r,
+			}).Error("Scheduled job panicked")
+		}
+	}()
+
+	if err := e.job(runCtx); err != nil {
+		s.log.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"job":        e.name,
+			"error":      err.Error(),
+		}).Error("Scheduled job failed")
+		return
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"request_id": requestID,
+		"job":        e.name,
+		"duration":   time.Since(startedAt).String(),
+	}).Debug("Scheduled job finished")
+}